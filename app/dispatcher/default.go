@@ -17,6 +17,8 @@ import (
 	"github.com/v2fly/v2ray-core/v4/common/log"
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/common/protocol"
+	"github.com/v2fly/v2ray-core/v4/common/protocol/http"
+	"github.com/v2fly/v2ray-core/v4/common/protocol/tls"
 	"github.com/v2fly/v2ray-core/v4/common/session"
 	"github.com/v2fly/v2ray-core/v4/features/outbound"
 	"github.com/v2fly/v2ray-core/v4/features/policy"
@@ -176,6 +178,45 @@ func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *tran
 	return inboundLink, outboundLink
 }
 
+// getRuleStatsLink counts the connection for the rule the route is decided by,
+// and wraps the link to count its traffic if required.
+func (d *DefaultDispatcher) getRuleStatsLink(route routing.RuleRoute, link *transport.Link) *transport.Link {
+	tag := route.GetRuleTag()
+	if len(tag) == 0 || d.stats == nil {
+		return link
+	}
+	if _, noop := d.stats.(stats.NoopManager); noop {
+		return link
+	}
+
+	prefix := "routing>>>rule>>>" + tag
+	if c, _ := stats.GetOrRegisterCounter(d.stats, prefix+">>>conn"); c != nil {
+		c.Add(1)
+	}
+
+	if !route.GetRuleTrafficStats() {
+		return link
+	}
+
+	statsLink := &transport.Link{
+		Reader: link.Reader,
+		Writer: link.Writer,
+	}
+	if c, _ := stats.GetOrRegisterCounter(d.stats, prefix+">>>traffic>>>uplink"); c != nil {
+		statsLink.Reader = &SizeStatReader{
+			Counter: c,
+			Reader:  statsLink.Reader,
+		}
+	}
+	if c, _ := stats.GetOrRegisterCounter(d.stats, prefix+">>>traffic>>>downlink"); c != nil {
+		statsLink.Writer = &SizeStatWriter{
+			Counter: c,
+			Writer:  statsLink.Writer,
+		}
+	}
+	return statsLink
+}
+
 func shouldOverride(result SniffResult, domainOverride []string) bool {
 	protocolString := result.Protocol()
 	if resComp, ok := result.(SnifferResultComposite); ok {
@@ -194,6 +235,35 @@ func shouldOverride(result SniffResult, domainOverride []string) bool {
 	return false
 }
 
+// recordSniffedNames sets the TLS server name and JA3 fingerprint, or the HTTP
+// host sniffed from the connection to attributes of the content, for routing.
+func recordSniffedNames(content *session.Content, result SniffResult) {
+	if composite, ok := result.(*compositeResult); ok {
+		result = composite.protocolResult
+	}
+	switch header := result.(type) {
+	case *tls.SniffHeader:
+		content.SetAttribute(session.AttributeSniffedSNI, header.Domain())
+		if ja3 := header.JA3(); len(ja3) > 0 {
+			content.SetAttribute(session.AttributeSniffedJA3, ja3)
+		}
+	case *http.SniffHeader:
+		content.SetAttribute(session.AttributeSniffedHost, header.Domain())
+	}
+}
+
+// applySniffedDomain makes the outbound connect to the sniffed domain, or
+// only use it for routing if routeOnly is set.
+func applySniffedDomain(ctx context.Context, ob *session.Outbound, domain string, routeOnly bool) {
+	if routeOnly {
+		newError("sniffed domain for routing: ", domain).WriteToLog(session.ExportIDToError(ctx))
+		ob.RouteDomain = domain
+		return
+	}
+	newError("sniffed domain: ", domain).WriteToLog(session.ExportIDToError(ctx))
+	ob.Target.Address = net.ParseAddress(domain)
+}
+
 // Dispatch implements routing.Dispatcher.
 func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destination) (*transport.Link, error) {
 	if !destination.IsValid() {
@@ -214,47 +284,62 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 	switch {
 	case !sniffingRequest.Enabled:
 		go d.routedDispatch(ctx, outbound, destination)
-	case destination.Network != net.Network_TCP:
-		// Only metadata sniff will be used for non tcp connection
-		result, err := sniffer(ctx, nil, true)
+	case destination.Network != net.Network_TCP && !sniffsQUIC(destination, sniffingRequest):
+		// Only metadata sniff will be used for non tcp connection, except QUIC
+		// sniff for UDP connection if its domain may override the destination.
+		result, err := sniffer(ctx, NewSniffer(ctx), nil, true)
 		if err == nil {
 			content.Protocol = result.Protocol()
+			recordSniffedNames(content, result)
 			if shouldOverride(result, sniffingRequest.OverrideDestinationForProtocol) {
-				domain := result.Domain()
-				newError("sniffed domain: ", domain).WriteToLog(session.ExportIDToError(ctx))
-				destination.Address = net.ParseAddress(domain)
-				ob.Target = destination
+				applySniffedDomain(ctx, ob, result.Domain(), sniffingRequest.RouteOnly)
 			}
 		}
-		go d.routedDispatch(ctx, outbound, destination)
+		go d.routedDispatch(ctx, outbound, ob.Target)
 	default:
 		go func() {
 			cReader := &cachedReader{
 				reader: outbound.Reader.(*pipe.Reader),
 			}
 			outbound.Reader = cReader
-			result, err := sniffer(ctx, cReader, sniffingRequest.MetadataOnly)
+			var s *Sniffer
+			if destination.Network == net.Network_UDP {
+				s = NewQUICSniffer(ctx)
+			} else {
+				s = NewSniffer(ctx)
+			}
+			result, err := sniffer(ctx, s, cReader, sniffingRequest.MetadataOnly)
 			if err == nil {
 				content.Protocol = result.Protocol()
+				recordSniffedNames(content, result)
 			}
 			if err == nil && shouldOverride(result, sniffingRequest.OverrideDestinationForProtocol) {
-				domain := result.Domain()
-				newError("sniffed domain: ", domain).WriteToLog(session.ExportIDToError(ctx))
-				destination.Address = net.ParseAddress(domain)
-				ob.Target = destination
+				applySniffedDomain(ctx, ob, result.Domain(), sniffingRequest.RouteOnly)
 			}
-			d.routedDispatch(ctx, outbound, destination)
+			d.routedDispatch(ctx, outbound, ob.Target)
 		}()
 	}
 	return inbound, nil
 }
 
-func sniffer(ctx context.Context, cReader *cachedReader, metadataOnly bool) (SniffResult, error) {
+// sniffsQUIC returns whether the content of the UDP connection is sniffed,
+// which is only done if "quic" is in destOverride, with the QUIC sniffer.
+func sniffsQUIC(destination net.Destination, request session.SniffingRequest) bool {
+	if destination.Network != net.Network_UDP {
+		return false
+	}
+	for _, p := range request.OverrideDestinationForProtocol {
+		if p == "quic" {
+			return true
+		}
+	}
+	return false
+}
+
+func sniffer(ctx context.Context, sniffer *Sniffer, cReader *cachedReader, metadataOnly bool) (SniffResult, error) {
 	payload := buf.New()
 	defer payload.Release()
 
-	sniffer := NewSniffer(ctx)
-
 	metaresult, metadataErr := sniffer.SniffMetadata(ctx)
 
 	if metadataOnly {
@@ -297,6 +382,8 @@ func sniffer(ctx context.Context, cReader *cachedReader, metadataOnly bool) (Sni
 
 func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.Link, destination net.Destination) {
 	var handler outbound.Handler
+	var mirrorTag string
+	logged := true
 
 	if forcedOutboundTag := session.GetForcedOutboundTagFromContext(ctx); forcedOutboundTag != "" {
 		ctx = session.SetForcedOutboundTagToContext(ctx, "")
@@ -311,11 +398,42 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 		}
 	} else if d.router != nil {
 		if route, err := d.router.PickRoute(routing_session.AsRoutingContext(ctx)); err == nil {
-			tag := route.GetOutboundTag()
-			if h := d.ohm.GetHandler(tag); h != nil {
-				newError("taking detour [", tag, "] for [", destination, "]").WriteToLog(session.ExportIDToError(ctx))
-				handler = h
-			} else {
+			if ruleRoute, ok := route.(routing.RuleRoute); ok {
+				link = d.getRuleStatsLink(ruleRoute, link)
+				if bucket := ruleRoute.GetRuleRateLimit(); bucket != nil {
+					link = getRateLimitedLink(ctx, bucket, link)
+				}
+				if tos := ruleRoute.GetRuleTOS(); tos != 0 {
+					ctx = session.ContextWithTOS(ctx, tos)
+				}
+				if timeout := ruleRoute.GetRuleDialTimeout(); timeout != 0 {
+					ctx = session.ContextWithDialTimeout(ctx, timeout)
+				}
+				if tag := ruleRoute.GetRuleResolverTag(); len(tag) > 0 {
+					ctx = session.ContextWithResolverTag(ctx, tag)
+				}
+				if attributes := ruleRoute.GetRuleAttributes(); len(attributes) > 0 {
+					ctx = withAttributes(ctx, attributes)
+				}
+				mirrorTag = ruleRoute.GetRuleMirrorTag()
+				logged = ruleRoute.GetRuleLogged()
+				if target := ruleRoute.GetRuleRedirectTarget(); target.IsValid() {
+					ctx = redirectTarget(ctx, destination, target)
+					destination = target
+				}
+			}
+			tags := []string{route.GetOutboundTag()}
+			if fallbackRoute, ok := route.(routing.HandlerFallbackRoute); ok {
+				tags = append(tags, fallbackRoute.GetHandlerFallbackTags()...)
+			}
+			for _, tag := range tags {
+				if h := d.ohm.GetHandler(tag); h != nil {
+					if logged {
+						newError("taking detour [", tag, "] for [", destination, "]").WriteToLog(session.ExportIDToError(ctx))
+					}
+					handler = h
+					break
+				}
 				newError("non existing tag: ", tag).AtWarning().WriteToLog(session.ExportIDToError(ctx))
 			}
 		} else {
@@ -341,5 +459,32 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 		log.Record(accessMessage)
 	}
 
+	if mirrorTag != "" {
+		link = d.getMirrorLink(ctx, link, mirrorTag)
+	}
+
 	handler.Dispatch(ctx, link)
 }
+
+// redirectTarget makes the outbound connect to target instead of the original
+// destination, which is kept in attributes of the content.
+func redirectTarget(ctx context.Context, original net.Destination, target net.Destination) context.Context {
+	newError("redirecting [", original, "] to [", target, "]").WriteToLog(session.ExportIDToError(ctx))
+	if ob := session.OutboundFromContext(ctx); ob != nil {
+		ob.Target = target
+	}
+	return withAttributes(ctx, map[string]string{session.AttributeOriginalTarget: original.String()})
+}
+
+// withAttributes sets attributes to the content of the connection.
+func withAttributes(ctx context.Context, attributes map[string]string) context.Context {
+	content := session.ContentFromContext(ctx)
+	if content == nil {
+		content = new(session.Content)
+		ctx = session.ContextWithContent(ctx, content)
+	}
+	for key, value := range attributes {
+		content.SetAttribute(key, value)
+	}
+	return ctx
+}
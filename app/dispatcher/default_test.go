@@ -0,0 +1,530 @@
+package dispatcher_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	core "github.com/v2fly/v2ray-core/v4"
+	"github.com/v2fly/v2ray-core/v4/app/dispatcher"
+	"github.com/v2fly/v2ray-core/v4/app/proxyman"
+	_ "github.com/v2fly/v2ray-core/v4/app/proxyman/inbound"
+	_ "github.com/v2fly/v2ray-core/v4/app/proxyman/outbound"
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/buf"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/serial"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	"github.com/v2fly/v2ray-core/v4/features/outbound"
+	"github.com/v2fly/v2ray-core/v4/transport"
+)
+
+// A ClientHello with SNI "c.s-microsoft.com".
+var clientHello = []byte{
+	0x16, 0x03, 0x01, 0x00, 0xc8, 0x01, 0x00, 0x00,
+	0xc4, 0x03, 0x03, 0x1a, 0xac, 0xb2, 0xa8, 0xfe,
+	0xb4, 0x96, 0x04, 0x5b, 0xca, 0xf7, 0xc1, 0xf4,
+	0x2e, 0x53, 0x24, 0x6e, 0x34, 0x0c, 0x58, 0x36,
+	0x71, 0x97, 0x59, 0xe9, 0x41, 0x66, 0xe2, 0x43,
+	0xa0, 0x13, 0xb6, 0x00, 0x00, 0x20, 0x1a, 0x1a,
+	0xc0, 0x2b, 0xc0, 0x2f, 0xc0, 0x2c, 0xc0, 0x30,
+	0xcc, 0xa9, 0xcc, 0xa8, 0xcc, 0x14, 0xcc, 0x13,
+	0xc0, 0x13, 0xc0, 0x14, 0x00, 0x9c, 0x00, 0x9d,
+	0x00, 0x2f, 0x00, 0x35, 0x00, 0x0a, 0x01, 0x00,
+	0x00, 0x7b, 0xba, 0xba, 0x00, 0x00, 0xff, 0x01,
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x16, 0x00,
+	0x14, 0x00, 0x00, 0x11, 0x63, 0x2e, 0x73, 0x2d,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x6f, 0x66,
+	0x74, 0x2e, 0x63, 0x6f, 0x6d, 0x00, 0x17, 0x00,
+	0x00, 0x00, 0x23, 0x00, 0x00, 0x00, 0x0d, 0x00,
+	0x14, 0x00, 0x12, 0x04, 0x03, 0x08, 0x04, 0x04,
+	0x01, 0x05, 0x03, 0x08, 0x05, 0x05, 0x01, 0x08,
+	0x06, 0x06, 0x01, 0x02, 0x01, 0x00, 0x05, 0x00,
+	0x05, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x12,
+	0x00, 0x00, 0x00, 0x10, 0x00, 0x0e, 0x00, 0x0c,
+	0x02, 0x68, 0x32, 0x08, 0x68, 0x74, 0x74, 0x70,
+	0x2f, 0x31, 0x2e, 0x31, 0x00, 0x0b, 0x00, 0x02,
+	0x01, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x08,
+	0xaa, 0xaa, 0x00, 0x1d, 0x00, 0x17, 0x00, 0x18,
+	0xaa, 0xaa, 0x00, 0x01, 0x00,
+}
+
+type dispatchRecord struct {
+	tag        string
+	target     net.Destination
+	attributes map[string]string
+}
+
+// recordHandler is an outbound handler that records the target of each connection.
+type recordHandler struct {
+	tag     string
+	records chan<- dispatchRecord
+}
+
+func (h *recordHandler) Start() error { return nil }
+func (h *recordHandler) Close() error { return nil }
+func (h *recordHandler) Tag() string  { return h.tag }
+
+func (h *recordHandler) Dispatch(ctx context.Context, link *transport.Link) {
+	record := dispatchRecord{
+		tag:    h.tag,
+		target: session.OutboundFromContext(ctx).Target,
+	}
+	if content := session.ContentFromContext(ctx); content != nil {
+		record.attributes = content.Attributes
+	}
+	h.records <- record
+	common.Close(link.Writer)
+	common.Interrupt(link.Reader)
+}
+
+func TestDispatchSniffedDomain(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						Domain:    []*router.Domain{{Type: router.Domain_Full, Value: "c.s-microsoft.com"}},
+						TargetTag: &router.RoutingRule_Tag{Tag: "sniffed"},
+					},
+				},
+			}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	records := make(chan dispatchRecord, 1)
+	ohm := v.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	common.Must(ohm.AddHandler(context.Background(), &recordHandler{tag: "default", records: records}))
+	common.Must(ohm.AddHandler(context.Background(), &recordHandler{tag: "sniffed", records: records}))
+
+	dest := net.TCPDestination(net.ParseAddress("13.107.246.10"), 443)
+	testCases := []struct {
+		request session.SniffingRequest
+		payload []byte
+		tag     string
+		target  net.Destination
+	}{
+		{
+			request: session.SniffingRequest{
+				Enabled:                        true,
+				OverrideDestinationForProtocol: []string{"tls"},
+			},
+			payload: clientHello,
+			tag:     "sniffed",
+			target:  net.TCPDestination(net.DomainAddress("c.s-microsoft.com"), 443),
+		},
+		{
+			request: session.SniffingRequest{
+				Enabled:                        true,
+				OverrideDestinationForProtocol: []string{"tls"},
+				RouteOnly:                      true,
+			},
+			payload: clientHello,
+			tag:     "sniffed",
+			target:  dest,
+		},
+		{
+			request: session.SniffingRequest{
+				Enabled:                        true,
+				OverrideDestinationForProtocol: []string{"http", "tls"},
+				RouteOnly:                      true,
+			},
+			payload: []byte("GET / HTTP/1.1\r\nHost: c.s-microsoft.com\r\n\r\n"),
+			tag:     "sniffed",
+			target:  dest,
+		},
+		{
+			request: session.SniffingRequest{
+				Enabled:                        true,
+				OverrideDestinationForProtocol: []string{"tls"},
+				RouteOnly:                      true,
+			},
+			payload: []byte("not a known protocol"),
+			tag:     "default",
+			target:  dest,
+		},
+	}
+
+	for _, tc := range testCases {
+		ctx := session.ContextWithContent(context.Background(), &session.Content{
+			SniffingRequest: tc.request,
+		})
+		conn, err := core.Dial(ctx, v, dest)
+		common.Must(err)
+		common.Must2(conn.Write(tc.payload))
+
+		select {
+		case record := <-records:
+			if record.tag != tc.tag {
+				t.Error("expected outbound ", tc.tag, " but got ", record.tag)
+			}
+			if record.target != tc.target {
+				t.Error("expected target ", tc.target, " but got ", record.target)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for dispatch")
+		}
+		conn.Close()
+	}
+}
+
+func TestDispatchSniffUDP(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						Domain:    []*router.Domain{{Type: router.Domain_Full, Value: "c.s-microsoft.com"}},
+						TargetTag: &router.RoutingRule_Tag{Tag: "sniffed"},
+					},
+				},
+			}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	records := make(chan dispatchRecord, 1)
+	ohm := v.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	common.Must(ohm.AddHandler(context.Background(), &recordHandler{tag: "default", records: records}))
+	common.Must(ohm.AddHandler(context.Background(), &recordHandler{tag: "sniffed", records: records}))
+
+	// Only QUIC is sniffed from UDP packets, so a TLS ClientHello is not.
+	dest := net.UDPDestination(net.ParseAddress("13.107.246.10"), 443)
+	for _, override := range [][]string{{"tls"}, {"quic", "tls"}} {
+		ctx := session.ContextWithContent(context.Background(), &session.Content{
+			SniffingRequest: session.SniffingRequest{
+				Enabled:                        true,
+				OverrideDestinationForProtocol: override,
+			},
+		})
+		conn, err := core.Dial(ctx, v, dest)
+		common.Must(err)
+		common.Must2(conn.Write(clientHello))
+
+		select {
+		case record := <-records:
+			if record.tag != "default" || record.target != dest {
+				t.Error(override, ": expected ", dest, " via default, but got ", record.target, " via ", record.tag)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for dispatch")
+		}
+		conn.Close()
+	}
+}
+
+func TestDispatchDomainFronting(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						DomainFronting: router.DomainFronting_Mismatching,
+						TargetTag:      &router.RoutingRule_Tag{Tag: "fronted"},
+					},
+				},
+			}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	records := make(chan dispatchRecord, 1)
+	ohm := v.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	common.Must(ohm.AddHandler(context.Background(), &recordHandler{tag: "default", records: records}))
+	common.Must(ohm.AddHandler(context.Background(), &recordHandler{tag: "fronted", records: records}))
+
+	dest := net.TCPDestination(net.ParseAddress("13.107.246.10"), 443)
+	testCases := []struct {
+		host string
+		tag  string
+	}{
+		{host: "hidden.example.org", tag: "fronted"},
+		{host: "c.s-microsoft.com", tag: "default"},
+	}
+
+	for _, tc := range testCases {
+		content := &session.Content{
+			SniffingRequest: session.SniffingRequest{Enabled: true},
+		}
+		content.SetAttribute(session.AttributeSniffedHost, tc.host)
+		conn, err := core.Dial(session.ContextWithContent(context.Background(), content), v, dest)
+		common.Must(err)
+		common.Must2(conn.Write(clientHello))
+
+		select {
+		case record := <-records:
+			if record.tag != tc.tag {
+				t.Error("expected outbound ", tc.tag, " but got ", record.tag)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for dispatch")
+		}
+		conn.Close()
+	}
+}
+
+func TestDispatchRuleAttributes(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						Domain:        []*router.Domain{{Type: router.Domain_Full, Value: "v2fly.org"}},
+						TargetTag:     &router.RoutingRule_Tag{Tag: "premium"},
+						SetAttributes: map[string]string{"tier": "premium"},
+					},
+				},
+			}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	records := make(chan dispatchRecord, 1)
+	ohm := v.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	common.Must(ohm.AddHandler(context.Background(), &recordHandler{tag: "default", records: records}))
+	common.Must(ohm.AddHandler(context.Background(), &recordHandler{tag: "premium", records: records}))
+
+	testCases := []struct {
+		dest net.Destination
+		tag  string
+		tier string
+	}{
+		{dest: net.TCPDestination(net.DomainAddress("v2fly.org"), 443), tag: "premium", tier: "premium"},
+		{dest: net.TCPDestination(net.DomainAddress("example.com"), 443), tag: "default", tier: ""},
+	}
+	for _, tc := range testCases {
+		conn, err := core.Dial(context.Background(), v, tc.dest)
+		common.Must(err)
+
+		select {
+		case record := <-records:
+			if record.tag != tc.tag {
+				t.Error("expected outbound ", tc.tag, " but got ", record.tag)
+			}
+			if tier := record.attributes["tier"]; tier != tc.tier {
+				t.Error("expected tier attribute ", tc.tier, " but got ", tier)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for dispatch")
+		}
+		conn.Close()
+	}
+}
+
+func TestDispatchRedirectTarget(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						PortList:  &net.PortList{Range: []*net.PortRange{net.SinglePortRange(80)}},
+						TargetTag: &router.RoutingRule_Tag{Tag: "cache"},
+						RedirectTarget: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.ParseAddress("10.0.0.2")),
+							Port:    3128,
+						},
+					},
+				},
+			}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	records := make(chan dispatchRecord, 1)
+	ohm := v.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	common.Must(ohm.AddHandler(context.Background(), &recordHandler{tag: "default", records: records}))
+	common.Must(ohm.AddHandler(context.Background(), &recordHandler{tag: "cache", records: records}))
+
+	testCases := []struct {
+		dest     net.Destination
+		tag      string
+		target   net.Destination
+		original string
+	}{
+		{
+			dest:     net.TCPDestination(net.DomainAddress("v2fly.org"), 80),
+			tag:      "cache",
+			target:   net.TCPDestination(net.ParseAddress("10.0.0.2"), 3128),
+			original: "tcp:v2fly.org:80",
+		},
+		{
+			dest:   net.TCPDestination(net.DomainAddress("v2fly.org"), 443),
+			tag:    "default",
+			target: net.TCPDestination(net.DomainAddress("v2fly.org"), 443),
+		},
+	}
+	for _, tc := range testCases {
+		conn, err := core.Dial(context.Background(), v, tc.dest)
+		common.Must(err)
+
+		select {
+		case record := <-records:
+			if record.tag != tc.tag {
+				t.Error("expected outbound ", tc.tag, " but got ", record.tag)
+			}
+			if record.target != tc.target {
+				t.Error("expected target ", tc.target, " but got ", record.target)
+			}
+			if original := record.attributes[session.AttributeOriginalTarget]; original != tc.original {
+				t.Error("expected original target ", tc.original, " but got ", original)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for dispatch")
+		}
+		conn.Close()
+	}
+}
+
+// readHandler is an outbound handler that reads the whole uplink.
+type readHandler struct {
+	tag  string
+	data chan<- []byte
+}
+
+func (h *readHandler) Start() error { return nil }
+func (h *readHandler) Close() error { return nil }
+func (h *readHandler) Tag() string  { return h.tag }
+
+func (h *readHandler) Dispatch(ctx context.Context, link *transport.Link) {
+	var data []byte
+	for {
+		mb, err := link.Reader.ReadMultiBuffer()
+		for _, b := range mb {
+			data = append(data, b.Bytes()...)
+		}
+		buf.ReleaseMulti(mb)
+		if err != nil {
+			break
+		}
+	}
+	common.Close(link.Writer)
+	h.data <- data
+}
+
+// stallHandler is an outbound handler that never reads the uplink.
+type stallHandler struct {
+	tag        string
+	dispatched chan<- struct{}
+	done       <-chan struct{}
+}
+
+func (h *stallHandler) Start() error { return nil }
+func (h *stallHandler) Close() error { return nil }
+func (h *stallHandler) Tag() string  { return h.tag }
+
+func (h *stallHandler) Dispatch(ctx context.Context, link *transport.Link) {
+	h.dispatched <- struct{}{}
+	<-h.done
+	common.Interrupt(link.Reader)
+}
+
+func TestDispatchMirror(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						Domain:    []*router.Domain{{Type: router.Domain_Full, Value: "copy.v2fly.org"}},
+						TargetTag: &router.RoutingRule_Tag{Tag: "primary"},
+						MirrorTag: "copy",
+					},
+					{
+						Domain:    []*router.Domain{{Type: router.Domain_Full, Value: "stall.v2fly.org"}},
+						TargetTag: &router.RoutingRule_Tag{Tag: "primary"},
+						MirrorTag: "stall",
+					},
+				},
+			}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	primaryData := make(chan []byte, 1)
+	copyData := make(chan []byte, 1)
+	dispatched := make(chan struct{}, 1)
+	done := make(chan struct{})
+	defer close(done)
+	ohm := v.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	common.Must(ohm.AddHandler(context.Background(), &readHandler{tag: "primary", data: primaryData}))
+	common.Must(ohm.AddHandler(context.Background(), &readHandler{tag: "copy", data: copyData}))
+	common.Must(ohm.AddHandler(context.Background(), &stallHandler{tag: "stall", dispatched: dispatched, done: done}))
+
+	receive := func(ch <-chan []byte) []byte {
+		select {
+		case data := <-ch:
+			return data
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for data")
+			return nil
+		}
+	}
+
+	// The mirror receives a copy of the uplink.
+	payload := []byte("GET / HTTP/1.1\r\nHost: copy.v2fly.org\r\n\r\n")
+	conn, err := core.Dial(context.Background(), v, net.TCPDestination(net.DomainAddress("copy.v2fly.org"), 80))
+	common.Must(err)
+	common.Must2(conn.Write(payload))
+	common.Must(conn.Close())
+	if data := receive(primaryData); string(data) != string(payload) {
+		t.Error("unexpected data of primary: ", string(data))
+	}
+	if data := receive(copyData); string(data) != string(payload) {
+		t.Error("unexpected data of mirror: ", string(data))
+	}
+
+	// A stalled mirror doesn't hold back the primary.
+	payload = make([]byte, 4*1024*1024)
+	common.Must2(rand.Read(payload))
+	conn, err = core.Dial(context.Background(), v, net.TCPDestination(net.DomainAddress("stall.v2fly.org"), 80))
+	common.Must(err)
+	go func() {
+		for b := payload; len(b) > 0; b = b[1024:] {
+			if _, err := conn.Write(b[:1024]); err != nil {
+				return
+			}
+		}
+		conn.Close()
+	}()
+	select {
+	case <-dispatched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for mirror")
+	}
+	if data := receive(primaryData); !bytes.Equal(data, payload) {
+		t.Error("expect primary to receive all data, but got ", len(data), " bytes")
+	}
+}
@@ -0,0 +1,103 @@
+//go:build !confonly
+// +build !confonly
+
+package dispatcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/buf"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	"github.com/v2fly/v2ray-core/v4/transport"
+	"github.com/v2fly/v2ray-core/v4/transport/pipe"
+)
+
+// mirrorBufferSize is the maximum size of uplink data buffered for a mirror
+// outbound. Data exceeding it is dropped.
+const mirrorBufferSize = 512 * 1024
+
+// getMirrorLink dispatches a copy of the uplink to the outbound with the tag,
+// and returns the link for the chosen outbound to read the uplink from.
+func (d *DefaultDispatcher) getMirrorLink(ctx context.Context, link *transport.Link, tag string) *transport.Link {
+	handler := d.ohm.GetHandler(tag)
+	if handler == nil {
+		newError("non existing mirror tag: ", tag).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+		return link
+	}
+
+	mirrorCtx := ctx
+	if ob := session.OutboundFromContext(ctx); ob != nil {
+		mirrorOutbound := *ob
+		mirrorCtx = session.ContextWithOutbound(ctx, &mirrorOutbound)
+	}
+	reader, writer := pipe.New(pipe.WithSizeLimit(mirrorBufferSize), pipe.DiscardOverflow())
+	newError("mirroring to [", tag, "]").WriteToLog(session.ExportIDToError(ctx))
+	go handler.Dispatch(mirrorCtx, &transport.Link{
+		Reader: reader,
+		Writer: buf.Discard,
+	})
+
+	return &transport.Link{
+		Reader: &mirrorReader{
+			Reader: link.Reader,
+			Mirror: writer,
+		},
+		Writer: link.Writer,
+	}
+}
+
+// mirrorReader writes a copy of the data read to Mirror. Mirror is expected
+// not to block.
+type mirrorReader struct {
+	Reader buf.Reader
+	Mirror buf.Writer
+}
+
+func (r *mirrorReader) mirror(mb buf.MultiBuffer, err error) {
+	if !mb.IsEmpty() {
+		if err := r.Mirror.WriteMultiBuffer(copyMultiBuffer(mb)); err != nil {
+			newError("failed to write to mirror").Base(err).AtDebug().WriteToLog()
+		}
+	}
+	if err != nil && err != buf.ErrReadTimeout {
+		common.Close(r.Mirror)
+	}
+}
+
+func (r *mirrorReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb, err := r.Reader.ReadMultiBuffer()
+	r.mirror(mb, err)
+	return mb, err
+}
+
+func (r *mirrorReader) ReadMultiBufferTimeout(timeout time.Duration) (buf.MultiBuffer, error) {
+	timeoutReader, ok := r.Reader.(buf.TimeoutReader)
+	if !ok {
+		return nil, buf.ErrNotTimeoutReader
+	}
+	mb, err := timeoutReader.ReadMultiBufferTimeout(timeout)
+	r.mirror(mb, err)
+	return mb, err
+}
+
+func (r *mirrorReader) Interrupt() {
+	common.Interrupt(r.Reader)
+	common.Interrupt(r.Mirror)
+}
+
+// copyMultiBuffer returns a copy of mb, keeping boundaries of buffers.
+func copyMultiBuffer(mb buf.MultiBuffer) buf.MultiBuffer {
+	c := make(buf.MultiBuffer, 0, len(mb))
+	for _, b := range mb {
+		if b.Len() > buf.Size {
+			c = append(c, buf.MergeBytes(nil, b.Bytes())...)
+			continue
+		}
+		nb := buf.New()
+		common.Must2(nb.Write(b.Bytes()))
+		c = append(c, nb)
+	}
+	return c
+}
@@ -0,0 +1,114 @@
+//go:build !confonly
+// +build !confonly
+
+package dispatcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/buf"
+	"github.com/v2fly/v2ray-core/v4/common/ratelimit"
+	"github.com/v2fly/v2ray-core/v4/transport"
+)
+
+// RateLimitedWriter waits for tokens of the bucket before writing.
+type RateLimitedWriter struct {
+	Bucket *ratelimit.Bucket
+	Writer buf.Writer
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewRateLimitedWriter creates a new RateLimitedWriter. Waiting is aborted
+// when ctx is done or the writer is interrupted.
+func NewRateLimitedWriter(ctx context.Context, bucket *ratelimit.Bucket, writer buf.Writer) *RateLimitedWriter {
+	ctx, cancel := context.WithCancel(ctx)
+	return &RateLimitedWriter{
+		Bucket: bucket,
+		Writer: writer,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (w *RateLimitedWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if err := w.Bucket.Wait(w.ctx, int(mb.Len())); err != nil {
+		buf.ReleaseMulti(mb)
+		return err
+	}
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func (w *RateLimitedWriter) Close() error {
+	w.cancel()
+	return common.Close(w.Writer)
+}
+
+func (w *RateLimitedWriter) Interrupt() {
+	w.cancel()
+	common.Interrupt(w.Writer)
+}
+
+// RateLimitedReader waits for tokens of the bucket before returning what it
+// reads.
+type RateLimitedReader struct {
+	Bucket *ratelimit.Bucket
+	Reader buf.Reader
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewRateLimitedReader creates a new RateLimitedReader. Waiting is aborted
+// when ctx is done or the reader is interrupted.
+func NewRateLimitedReader(ctx context.Context, bucket *ratelimit.Bucket, reader buf.Reader) *RateLimitedReader {
+	ctx, cancel := context.WithCancel(ctx)
+	return &RateLimitedReader{
+		Bucket: bucket,
+		Reader: reader,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (r *RateLimitedReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb, err := r.Reader.ReadMultiBuffer()
+	return r.wait(mb, err)
+}
+
+func (r *RateLimitedReader) ReadMultiBufferTimeout(timeout time.Duration) (buf.MultiBuffer, error) {
+	timeoutReader, ok := r.Reader.(buf.TimeoutReader)
+	if !ok {
+		return nil, buf.ErrNotTimeoutReader
+	}
+	mb, err := timeoutReader.ReadMultiBufferTimeout(timeout)
+	return r.wait(mb, err)
+}
+
+func (r *RateLimitedReader) wait(mb buf.MultiBuffer, err error) (buf.MultiBuffer, error) {
+	if mb.IsEmpty() {
+		return mb, err
+	}
+	if waitErr := r.Bucket.Wait(r.ctx, int(mb.Len())); waitErr != nil {
+		buf.ReleaseMulti(mb)
+		return nil, waitErr
+	}
+	return mb, err
+}
+
+func (r *RateLimitedReader) Interrupt() {
+	r.cancel()
+	common.Interrupt(r.Reader)
+}
+
+// getRateLimitedLink wraps the link so that traffic in both directions takes
+// tokens of the bucket.
+func getRateLimitedLink(ctx context.Context, bucket *ratelimit.Bucket, link *transport.Link) *transport.Link {
+	return &transport.Link{
+		Reader: NewRateLimitedReader(ctx, bucket, link.Reader),
+		Writer: NewRateLimitedWriter(ctx, bucket, link.Writer),
+	}
+}
@@ -0,0 +1,59 @@
+package dispatcher_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/v2fly/v2ray-core/v4/app/dispatcher"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/buf"
+	"github.com/v2fly/v2ray-core/v4/common/ratelimit"
+)
+
+func TestRateLimitedWriter(t *testing.T) {
+	// 200KB at 400KB/s after a burst of 40KB takes 0.4s.
+	bucket := ratelimit.NewBucket(400*1024, 40*1024, nil)
+	writer := NewRateLimitedWriter(context.Background(), bucket, buf.Discard)
+
+	start := time.Now()
+	for i := 0; i < 200; i++ {
+		b := buf.New()
+		b.Extend(1024)
+		common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+	}
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond || elapsed > 800*time.Millisecond {
+		t.Error("expect throughput capped near 400KB/s, but writing 200KB took ", elapsed)
+	}
+
+	writer.Interrupt()
+	b := buf.New()
+	b.Extend(buf.Size)
+	if err := writer.WriteMultiBuffer(buf.MultiBuffer{b}); err == nil {
+		t.Error("expect error writing to interrupted writer while out of tokens")
+	}
+}
+
+func TestRateLimitedReader(t *testing.T) {
+	// Both directions share the bucket.
+	bucket := ratelimit.NewBucket(400*1024, 40*1024, nil)
+	reader := NewRateLimitedReader(context.Background(), bucket, buf.NewReader(bytes.NewReader(make([]byte, 100*1024))))
+	writer := NewRateLimitedWriter(context.Background(), bucket, buf.Discard)
+
+	start := time.Now()
+	for {
+		mb, err := reader.ReadMultiBuffer()
+		if err != nil {
+			break
+		}
+		common.Must(writer.WriteMultiBuffer(mb))
+	}
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond || elapsed > 800*time.Millisecond {
+		t.Error("expect throughput capped near 400KB/s, but relaying 100KB took ", elapsed)
+	}
+
+	if _, err := reader.ReadMultiBufferTimeout(time.Second); err != buf.ErrNotTimeoutReader {
+		t.Fatal("expect ErrNotTimeoutReader, but got ", err)
+	}
+}
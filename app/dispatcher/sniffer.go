@@ -9,6 +9,7 @@ import (
 	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/common/protocol/bittorrent"
 	"github.com/v2fly/v2ray-core/v4/common/protocol/http"
+	"github.com/v2fly/v2ray-core/v4/common/protocol/quic"
 	"github.com/v2fly/v2ray-core/v4/common/protocol/tls"
 )
 
@@ -32,12 +33,24 @@ type Sniffer struct {
 }
 
 func NewSniffer(ctx context.Context) *Sniffer {
+	return newSniffer(ctx, []protocolSnifferWithMetadata{
+		{func(c context.Context, b []byte) (SniffResult, error) { return http.SniffHTTP(b) }, false},
+		{func(c context.Context, b []byte) (SniffResult, error) { return tls.SniffTLS(b) }, false},
+		{func(c context.Context, b []byte) (SniffResult, error) { return bittorrent.SniffBittorrent(b) }, false},
+	})
+}
+
+// NewQUICSniffer creates a Sniffer for UDP connections, which sniffs QUIC
+// only, in addition to metadata.
+func NewQUICSniffer(ctx context.Context) *Sniffer {
+	return newSniffer(ctx, []protocolSnifferWithMetadata{
+		{func(c context.Context, b []byte) (SniffResult, error) { return quic.SniffQUIC(b) }, false},
+	})
+}
+
+func newSniffer(ctx context.Context, sniffers []protocolSnifferWithMetadata) *Sniffer {
 	ret := &Sniffer{
-		sniffer: []protocolSnifferWithMetadata{
-			{func(c context.Context, b []byte) (SniffResult, error) { return http.SniffHTTP(b) }, false},
-			{func(c context.Context, b []byte) (SniffResult, error) { return tls.SniffTLS(b) }, false},
-			{func(c context.Context, b []byte) (SniffResult, error) { return bittorrent.SniffBittorrent(b) }, false},
-		},
+		sniffer: sniffers,
 	}
 	if sniffer, err := newFakeDNSSniffer(ctx); err == nil {
 		others := ret.sniffer
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/v2fly/v2ray-core/v4/app/router"
 	"github.com/v2fly/v2ray-core/v4/common"
@@ -31,6 +32,7 @@ type DNS struct {
 	ipOption        *dns.IPOption
 	hosts           *StaticHosts
 	clients         []*Client
+	taggedClients   map[string]*Client
 	ctx             context.Context
 	domainMatcher   strmatcher.IndexMatcher
 	matcherInfos    []*DomainMatcherInfo
@@ -87,6 +89,7 @@ func New(ctx context.Context, config *Config) (*DNS, error) {
 	}
 
 	clients := []*Client{}
+	var taggedClients map[string]*Client
 	domainRuleCount := 0
 	for _, ns := range config.NameServer {
 		domainRuleCount += len(ns.PrioritizedDomain)
@@ -95,7 +98,7 @@ func New(ctx context.Context, config *Config) (*DNS, error) {
 	// MatcherInfos is ensured to cover the maximum index domainMatcher could return, where matcher's index starts from 1
 	matcherInfos := make([]*DomainMatcherInfo, domainRuleCount+1)
 	domainMatcher := &strmatcher.MatcherGroup{}
-	geoipContainer := router.GeoIPMatcherContainer{}
+	geoipContainer := new(router.GeoIPMatcherContainer)
 
 	for _, endpoint := range config.NameServers {
 		features.PrintDeprecatedFeatureWarning("simple DNS server")
@@ -127,6 +130,16 @@ func New(ctx context.Context, config *Config) (*DNS, error) {
 			return nil, newError("failed to create client").Base(err)
 		}
 		clients = append(clients, client)
+
+		if len(ns.Tag) > 0 {
+			if _, found := taggedClients[ns.Tag]; found {
+				return nil, newError("duplicated name server tag: ", ns.Tag)
+			}
+			if taggedClients == nil {
+				taggedClients = make(map[string]*Client)
+			}
+			taggedClients[ns.Tag] = client
+		}
 	}
 
 	// If there is no DNS client in config, add a `localhost` DNS client
@@ -139,6 +152,7 @@ func New(ctx context.Context, config *Config) (*DNS, error) {
 		hosts:           hosts,
 		ipOption:        ipOption,
 		clients:         clients,
+		taggedClients:   taggedClients,
 		ctx:             ctx,
 		domainMatcher:   domainMatcher,
 		matcherInfos:    matcherInfos,
@@ -162,6 +176,19 @@ func (s *DNS) Close() error {
 	return nil
 }
 
+// LookupIPWithTag implements dns.TaggedLookup, for outbounds selecting the
+// name server by tag.
+func (s *DNS) LookupIPWithTag(tag string, domain string) ([]net.IP, error) {
+	client, found := s.taggedClients[tag]
+	if !found {
+		return nil, newError("name server ", tag, " not found")
+	}
+	option := *s.ipOption
+	option.FakeEnable = false
+	ctx := session.ContextWithInbound(s.ctx, &session.Inbound{Tag: s.tag})
+	return client.QueryIP(ctx, strings.TrimSuffix(domain, "."), option, s.disableCache)
+}
+
 // IsOwnLink implements proxy.dns.ownLinkVerifier
 func (s *DNS) IsOwnLink(ctx context.Context) bool {
 	inbound := session.InboundFromContext(ctx)
@@ -170,34 +197,43 @@ func (s *DNS) IsOwnLink(ctx context.Context) bool {
 
 // LookupIP implements dns.Client.
 func (s *DNS) LookupIP(domain string) ([]net.IP, error) {
-	return s.lookupIPInternal(domain, dns.IPOption{
+	ips, _, err := s.lookupIPInternal(domain, dns.IPOption{
 		IPv4Enable: true,
 		IPv6Enable: true,
 		FakeEnable: s.ipOption.FakeEnable,
 	})
+	return ips, err
 }
 
 // LookupIPv4 implements dns.IPv4Lookup.
 func (s *DNS) LookupIPv4(domain string) ([]net.IP, error) {
-	return s.lookupIPInternal(domain, dns.IPOption{
+	ips, _, err := s.lookupIPInternal(domain, dns.IPOption{
 		IPv4Enable: true,
 		IPv6Enable: false,
 		FakeEnable: s.ipOption.FakeEnable,
 	})
+	return ips, err
 }
 
 // LookupIPv6 implements dns.IPv6Lookup.
 func (s *DNS) LookupIPv6(domain string) ([]net.IP, error) {
-	return s.lookupIPInternal(domain, dns.IPOption{
+	ips, _, err := s.lookupIPInternal(domain, dns.IPOption{
 		IPv4Enable: false,
 		IPv6Enable: true,
 		FakeEnable: s.ipOption.FakeEnable,
 	})
+	return ips, err
+}
+
+// LookupIPWithTTL implements dns.TTLLookup. The TTL is unknown for static
+// hosts and name servers not caching records, e.g. localhost.
+func (s *DNS) LookupIPWithTTL(domain string, option dns.IPOption) ([]net.IP, time.Duration, error) {
+	return s.lookupIPInternal(domain, option)
 }
 
-func (s *DNS) lookupIPInternal(domain string, option dns.IPOption) ([]net.IP, error) {
+func (s *DNS) lookupIPInternal(domain string, option dns.IPOption) ([]net.IP, time.Duration, error) {
 	if domain == "" {
-		return nil, newError("empty domain name")
+		return nil, 0, newError("empty domain name")
 	}
 
 	// Normalize the FQDN form query
@@ -208,13 +244,14 @@ func (s *DNS) lookupIPInternal(domain string, option dns.IPOption) ([]net.IP, er
 	case addrs == nil: // Domain not recorded in static host
 		break
 	case len(addrs) == 0: // Domain recorded, but no valid IP returned (e.g. IPv4 address with only IPv6 enabled)
-		return nil, dns.ErrEmptyResponse
+		return nil, 0, dns.ErrEmptyResponse
 	case len(addrs) == 1 && addrs[0].Family().IsDomain(): // Domain replacement
 		newError("domain replaced: ", domain, " -> ", addrs[0].Domain()).WriteToLog()
 		domain = addrs[0].Domain()
 	default: // Successfully found ip records in static host
 		newError("returning ", len(addrs), " IP(s) for domain ", domain, " -> ", addrs).WriteToLog()
-		return toNetIP(addrs)
+		ips, err := toNetIP(addrs)
+		return ips, 0, err
 	}
 
 	// Name servers lookup
@@ -227,18 +264,18 @@ func (s *DNS) lookupIPInternal(domain string, option dns.IPOption) ([]net.IP, er
 		}
 		ips, err := client.QueryIP(ctx, domain, option, s.disableCache)
 		if len(ips) > 0 {
-			return ips, nil
+			return ips, client.QueryTTL(domain, option), nil
 		}
 		if err != nil {
 			newError("failed to lookup ip for domain ", domain, " at server ", client.Name()).Base(err).WriteToLog()
 			errs = append(errs, err)
 		}
 		if err != context.Canceled && err != context.DeadlineExceeded && err != errExpectedIPNonMatch {
-			return nil, err
+			return nil, 0, err
 		}
 	}
 
-	return nil, newError("returning nil for domain ", domain).Base(errors.Combine(errs...))
+	return nil, 0, newError("returning nil for domain ", domain).Base(errors.Combine(errs...))
 }
 
 // GetIPOption implements ClientWithIPOption.
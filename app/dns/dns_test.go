@@ -1,6 +1,7 @@
 package dns_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -18,8 +19,11 @@ import (
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/common/serial"
 	feature_dns "github.com/v2fly/v2ray-core/v4/features/dns"
+	"github.com/v2fly/v2ray-core/v4/features/outbound"
 	"github.com/v2fly/v2ray-core/v4/proxy/freedom"
 	"github.com/v2fly/v2ray-core/v4/testing/servers/udp"
+	"github.com/v2fly/v2ray-core/v4/transport/internet"
+	_ "github.com/v2fly/v2ray-core/v4/transport/internet/tcp"
 )
 
 type staticHandler struct{}
@@ -968,3 +972,95 @@ func TestMultiMatchPrioritizedDomain(t *testing.T) {
 		t.Error("DNS query doesn't finish in 2 seconds.")
 	}
 }
+
+// fixedHandler answers all A queries with the IP.
+type fixedHandler struct {
+	ip string
+}
+
+func (h *fixedHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	ans := new(dns.Msg)
+	ans.SetReply(r)
+	for _, q := range r.Question {
+		if q.Qtype == dns.TypeA {
+			rr, err := dns.NewRR(q.Name + " IN A " + h.ip)
+			common.Must(err)
+			ans.Answer = append(ans.Answer, rr)
+		}
+	}
+	w.WriteMsg(ans)
+}
+
+func TestTaggedNameServerResolver(t *testing.T) {
+	// Instances with the same name server tag resolve with their own servers.
+	expected := []string{"127.0.0.3", "127.0.0.4"}
+	port := udp.PickPort()
+	for _, ip := range expected {
+		listener, err := net.Listen("tcp", ip+":"+port.String())
+		common.Must(err)
+		defer listener.Close()
+	}
+
+	handlers := make([]outbound.Handler, 0, len(expected))
+	for _, ip := range expected {
+		dnsPort := udp.PickPort()
+		dnsServer := dns.Server{
+			Addr:    "127.0.0.1:" + dnsPort.String(),
+			Net:     "udp",
+			Handler: &fixedHandler{ip: ip},
+			UDPSize: 1200,
+		}
+		go dnsServer.ListenAndServe()
+		defer dnsServer.Shutdown()
+
+		config := &core.Config{
+			App: []*serial.TypedMessage{
+				serial.ToTypedMessage(&Config{
+					NameServer: []*NameServer{
+						{
+							Address: &net.Endpoint{
+								Network: net.Network_UDP,
+								Address: net.NewIPOrDomain(net.LocalHostIP),
+								Port:    uint32(dnsPort),
+							},
+							Tag: "exit",
+						},
+					},
+				}),
+				serial.ToTypedMessage(&dispatcher.Config{}),
+				serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+				serial.ToTypedMessage(&policy.Config{}),
+			},
+			Outbound: []*core.OutboundHandlerConfig{
+				{
+					Tag:           "out",
+					ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+					SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+						StreamSettings: &internet.StreamConfig{
+							SocketSettings: &internet.SocketConfig{ResolverTag: "exit"},
+						},
+					}),
+				},
+			},
+		}
+
+		v, err := core.New(config)
+		common.Must(err)
+		common.Must(v.Start())
+		defer v.Close()
+		handlers = append(handlers, v.GetFeature(outbound.ManagerType()).(outbound.Manager).GetHandler("out"))
+	}
+	time.Sleep(time.Second)
+
+	dest := net.TCPDestination(net.DomainAddress("exit.example.com"), port)
+	for i, handler := range handlers {
+		conn, err := handler.(internet.Dialer).Dial(context.Background(), dest)
+		if err != nil {
+			t.Fatal("failed to dial with resolver of instance ", i, ": ", err)
+		}
+		if ip := conn.RemoteAddr().(*net.TCPAddr).IP.String(); ip != expected[i] {
+			t.Error("expect ", expected[i], " resolved by instance ", i, ", but got ", ip)
+		}
+		conn.Close()
+	}
+}
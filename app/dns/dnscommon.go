@@ -47,6 +47,31 @@ func (r *IPRecord) getIPs() ([]net.Address, error) {
 	return r.IP, nil
 }
 
+// ttl returns the remaining TTL of the unexpired records of the families
+// enabled in option, the smaller one if both have addresses, or zero if none.
+func (r *record) ttl(option dns_feature.IPOption, now time.Time) time.Duration {
+	if r == nil {
+		return 0
+	}
+	var records []*IPRecord
+	if option.IPv4Enable {
+		records = append(records, r.A)
+	}
+	if option.IPv6Enable {
+		records = append(records, r.AAAA)
+	}
+	var ttl time.Duration
+	for _, rec := range records {
+		if rec == nil || len(rec.IP) == 0 || !rec.Expire.After(now) {
+			continue
+		}
+		if remaining := rec.Expire.Sub(now); ttl == 0 || remaining < ttl {
+			ttl = remaining
+		}
+	}
+	return ttl
+}
+
 func isNewer(baseRec *IPRecord, newRec *IPRecord) bool {
 	if newRec == nil {
 		return false
@@ -185,3 +185,42 @@ func TestFqdn(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordTTL(t *testing.T) {
+	now := time.Now()
+	rec := &record{
+		A: &IPRecord{
+			IP:     []net.Address{net.ParseAddress("1.1.1.1")},
+			Expire: now.Add(60 * time.Second),
+		},
+		AAAA: &IPRecord{
+			IP:     []net.Address{net.ParseAddress("2606:4700:4700::1111")},
+			Expire: now.Add(30 * time.Second),
+		},
+	}
+	expired := &record{
+		A: &IPRecord{
+			IP:     []net.Address{net.ParseAddress("1.1.1.1")},
+			Expire: now.Add(-time.Second),
+		},
+	}
+	tests := []struct {
+		name   string
+		rec    *record
+		option dns_feature.IPOption
+		want   time.Duration
+	}{
+		{"both families", rec, dns_feature.IPOption{IPv4Enable: true, IPv6Enable: true}, 30 * time.Second},
+		{"ipv4 only", rec, dns_feature.IPOption{IPv4Enable: true}, 60 * time.Second},
+		{"ipv6 only", rec, dns_feature.IPOption{IPv6Enable: true}, 30 * time.Second},
+		{"expired", expired, dns_feature.IPOption{IPv4Enable: true, IPv6Enable: true}, 0},
+		{"not cached", nil, dns_feature.IPOption{IPv4Enable: true, IPv6Enable: true}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rec.ttl(tt.option, now); got != tt.want {
+				t.Errorf("ttl() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -26,6 +26,13 @@ type Server interface {
 	QueryIP(ctx context.Context, domain string, clientIP net.IP, option dns.IPOption, disableCache bool) ([]net.IP, error)
 }
 
+// recordTTLGetter is implemented by Servers caching records.
+type recordTTLGetter interface {
+	// getTTL returns the remaining TTL of cached records of the domain, or
+	// zero if not cached.
+	getTTL(domain string, option dns.IPOption) time.Duration
+}
+
 // Client is the interface for DNS client.
 type Client struct {
 	server       Server
@@ -71,7 +78,7 @@ func NewServer(dest net.Destination, dispatcher routing.Dispatcher) (Server, err
 }
 
 // NewClient creates a DNS client managing a name server with client IP, domain rules and expected IPs.
-func NewClient(ctx context.Context, ns *NameServer, clientIP net.IP, container router.GeoIPMatcherContainer, matcherInfos *[]*DomainMatcherInfo, updateDomainRule func(strmatcher.Matcher, int, []*DomainMatcherInfo) error) (*Client, error) {
+func NewClient(ctx context.Context, ns *NameServer, clientIP net.IP, container *router.GeoIPMatcherContainer, matcherInfos *[]*DomainMatcherInfo, updateDomainRule func(strmatcher.Matcher, int, []*DomainMatcherInfo) error) (*Client, error) {
 	client := &Client{}
 
 	err := core.RequireFeatures(ctx, func(dispatcher routing.Dispatcher) error {
@@ -201,6 +208,15 @@ func (c *Client) QueryIP(ctx context.Context, domain string, option dns.IPOption
 	return c.MatchExpectedIPs(domain, ips)
 }
 
+// QueryTTL returns the remaining TTL of records of the domain cached by the
+// name server, or zero if unknown.
+func (c *Client) QueryTTL(domain string, option dns.IPOption) time.Duration {
+	if getter, ok := c.server.(recordTTLGetter); ok {
+		return getter.getTTL(domain, option)
+	}
+	return 0
+}
+
 // MatchExpectedIPs matches queried domain IPs with expected IPs and returns matched ones.
 func (c *Client) MatchExpectedIPs(domain string, ips []net.IP) ([]net.IP, error) {
 	if len(c.expectIPs) == 0 {
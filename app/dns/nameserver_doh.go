@@ -290,6 +290,13 @@ func (s *DoHNameServer) dohHTTPSContext(ctx context.Context, b []byte) ([]byte,
 	return ioutil.ReadAll(resp.Body)
 }
 
+// getTTL implements recordTTLGetter.
+func (s *DoHNameServer) getTTL(domain string, option dns_feature.IPOption) time.Duration {
+	s.RLock()
+	defer s.RUnlock()
+	return s.ips[Fqdn(domain)].ttl(option, time.Now())
+}
+
 func (s *DoHNameServer) findIPsForDomain(domain string, option dns_feature.IPOption) ([]net.IP, error) {
 	s.RLock()
 	record, found := s.ips[domain]
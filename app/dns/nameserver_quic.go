@@ -227,6 +227,13 @@ func (s *QUICNameServer) sendQuery(ctx context.Context, domain string, clientIP
 	}
 }
 
+// getTTL implements recordTTLGetter.
+func (s *QUICNameServer) getTTL(domain string, option dns_feature.IPOption) time.Duration {
+	s.RLock()
+	defer s.RUnlock()
+	return s.ips[Fqdn(domain)].ttl(option, time.Now())
+}
+
 func (s *QUICNameServer) findIPsForDomain(domain string, option dns_feature.IPOption) ([]net.IP, error) {
 	s.RLock()
 	record, found := s.ips[domain]
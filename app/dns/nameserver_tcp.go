@@ -269,6 +269,13 @@ func (s *TCPNameServer) sendQuery(ctx context.Context, domain string, clientIP n
 	}
 }
 
+// getTTL implements recordTTLGetter.
+func (s *TCPNameServer) getTTL(domain string, option dns_feature.IPOption) time.Duration {
+	s.RLock()
+	defer s.RUnlock()
+	return s.ips[Fqdn(domain)].ttl(option, time.Now())
+}
+
 func (s *TCPNameServer) findIPsForDomain(domain string, option dns_feature.IPOption) ([]net.IP, error) {
 	s.RLock()
 	record, found := s.ips[domain]
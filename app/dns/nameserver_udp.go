@@ -209,6 +209,13 @@ func (s *ClassicNameServer) sendQuery(ctx context.Context, domain string, client
 	}
 }
 
+// getTTL implements recordTTLGetter.
+func (s *ClassicNameServer) getTTL(domain string, option dns_feature.IPOption) time.Duration {
+	s.RLock()
+	defer s.RUnlock()
+	return s.ips[Fqdn(domain)].ttl(option, time.Now())
+}
+
 func (s *ClassicNameServer) findIPsForDomain(domain string, option dns_feature.IPOption) ([]net.IP, error) {
 	s.RLock()
 	record, found := s.ips[domain]
@@ -0,0 +1,133 @@
+//go:build !confonly
+// +build !confonly
+
+package observatory
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultAvailabilityWindow is the window uptime is computed over, if not
+// configured.
+const defaultAvailabilityWindow = 24 * time.Hour
+
+type availabilityTransition struct {
+	time  time.Time
+	alive bool
+}
+
+type availabilityHistory struct {
+	// observed is the time the outbound is first observed.
+	observed time.Time
+	// transitions are in time order. The first one is the state of the
+	// outbound at the start of the window, or when it is first observed.
+	transitions []availabilityTransition
+}
+
+// AvailabilityTracker records up and down transitions of outbounds, and
+// computes their uptime in a rolling window. An outbound is deemed to stay in
+// the state of its last observation until the next one. It is safe for
+// concurrent use.
+type AvailabilityTracker struct {
+	window time.Duration
+
+	access    sync.Mutex
+	histories map[string]*availabilityHistory
+}
+
+// NewAvailabilityTracker creates a new AvailabilityTracker with the window.
+func NewAvailabilityTracker(window time.Duration) *AvailabilityTracker {
+	return &AvailabilityTracker{
+		window:    window,
+		histories: make(map[string]*availabilityHistory),
+	}
+}
+
+// Record records the state of the outbound observed at now.
+func (t *AvailabilityTracker) Record(outbound string, alive bool, now time.Time) {
+	t.access.Lock()
+	defer t.access.Unlock()
+
+	history, found := t.histories[outbound]
+	if !found {
+		history = &availabilityHistory{observed: now}
+		t.histories[outbound] = history
+	}
+	if n := len(history.transitions); n == 0 || history.transitions[n-1].alive != alive {
+		history.transitions = append(history.transitions, availabilityTransition{time: now, alive: alive})
+	}
+
+	// Transitions before the last one preceding the window are irrelevant.
+	start := now.Add(-t.window)
+	expired := 0
+	for expired+1 < len(history.transitions) && !history.transitions[expired+1].time.After(start) {
+		expired++
+	}
+	history.transitions = history.transitions[:copy(history.transitions, history.transitions[expired:])]
+}
+
+// Report returns the availability of the outbounds at now, in the order of
+// outbound tags. All recorded outbounds are reported if outbounds is nil.
+func (t *AvailabilityTracker) Report(outbounds []string, now time.Time) *AvailabilityReport {
+	t.access.Lock()
+	defer t.access.Unlock()
+
+	var tags []string
+	if outbounds == nil {
+		tags = make([]string, 0, len(t.histories))
+		for outbound := range t.histories {
+			tags = append(tags, outbound)
+		}
+	} else {
+		tags = append(make([]string, 0, len(outbounds)), outbounds...)
+	}
+	sort.Strings(tags)
+
+	report := &AvailabilityReport{Window: int64(t.window)}
+	for _, outbound := range tags {
+		if history, found := t.histories[outbound]; found {
+			report.Status = append(report.Status, history.status(outbound, now.Add(-t.window), now))
+		}
+	}
+	return report
+}
+
+func (h *availabilityHistory) status(outbound string, start, now time.Time) *AvailabilityStatus {
+	if h.observed.After(start) {
+		start = h.observed
+	}
+
+	var up time.Duration
+	var transitions uint32
+	for i, transition := range h.transitions {
+		from := transition.time
+		if from.Before(start) {
+			from = start
+		} else if i > 0 {
+			transitions++
+		}
+		to := now
+		if i+1 < len(h.transitions) {
+			to = h.transitions[i+1].time
+		}
+		if transition.alive && to.After(from) {
+			up += to.Sub(from)
+		}
+	}
+
+	last := h.transitions[len(h.transitions)-1]
+	status := &AvailabilityStatus{
+		OutboundTag:        outbound,
+		Alive:              last.alive,
+		Transitions:        transitions,
+		LastTransitionTime: last.time.Unix(),
+	}
+	if observed := now.Sub(start); observed > 0 {
+		status.Uptime = float64(up) / float64(observed) * 100
+	} else if last.alive {
+		status.Uptime = 100
+	}
+	return status
+}
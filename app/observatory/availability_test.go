@@ -0,0 +1,140 @@
+package observatory
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v2net "github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/tagged"
+)
+
+func TestAvailabilityTracker(t *testing.T) {
+	tracker := NewAvailabilityTracker(10 * time.Minute)
+	start := time.Unix(1000000, 0)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	tracker.Record("a", true, at(0))
+	tracker.Record("a", false, at(2))
+	tracker.Record("a", false, at(3))
+	tracker.Record("a", true, at(5))
+	tracker.Record("b", false, at(8))
+
+	testCases := []struct {
+		record      func()
+		now         int
+		outbound    string
+		alive       bool
+		uptime      float64
+		transitions uint32
+	}{
+		{
+			// Up for 2 + 5 minutes.
+			now:         10,
+			outbound:    "a",
+			alive:       true,
+			uptime:      70,
+			transitions: 2,
+		},
+		{
+			// Observed for 2 minutes only.
+			now:      10,
+			outbound: "b",
+			alive:    false,
+			uptime:   0,
+		},
+		{
+			// Down from 4 to 5 minutes in the window.
+			record:      func() { tracker.Record("a", true, at(14)) },
+			now:         14,
+			outbound:    "a",
+			alive:       true,
+			uptime:      90,
+			transitions: 1,
+		},
+		{
+			record:      func() { tracker.Record("a", false, at(20)) },
+			now:         25,
+			outbound:    "a",
+			alive:       false,
+			uptime:      50,
+			transitions: 1,
+		},
+		{
+			record:      func() { tracker.Record("b", true, at(25)) },
+			now:         25,
+			outbound:    "b",
+			alive:       true,
+			uptime:      0,
+			transitions: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		if tc.record != nil {
+			tc.record()
+		}
+		report := tracker.Report([]string{tc.outbound}, at(tc.now))
+		if len(report.Status) != 1 {
+			t.Fatal("expect status of ", tc.outbound, " in case ", i, ", but got ", report.Status)
+		}
+		status := report.Status[0]
+		if status.Alive != tc.alive || status.Uptime != tc.uptime || status.Transitions != tc.transitions {
+			t.Error("unexpected status in case ", i, ": ", status)
+		}
+	}
+
+	if report := tracker.Report(nil, at(25)); len(report.Status) != 2 || report.Status[0].OutboundTag != "a" || report.Status[1].OutboundTag != "b" {
+		t.Error("expect all outbounds to be reported, but got ", report.Status)
+	}
+	if report := tracker.Report([]string{"c"}, at(25)); len(report.Status) != 0 {
+		t.Error("expect unobserved outbound not to be reported, but got ", report.Status)
+	}
+}
+
+func TestObserverAvailability(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	up := true
+	dialer := tagged.Dialer
+	tagged.Dialer = func(ctx context.Context, dest v2net.Destination, tag string) (v2net.Conn, error) {
+		if !up {
+			return nil, newError("outbound is down")
+		}
+		return net.Dial("tcp", dest.NetAddr())
+	}
+	defer func() { tagged.Dialer = dialer }()
+
+	o := &Observer{
+		config:       &Config{ProbeUrl: server.URL},
+		ctx:          context.Background(),
+		availability: NewAvailabilityTracker(time.Hour),
+	}
+	for _, alive := range []bool{true, false, false, true} {
+		up = alive
+		result := o.probe("a")
+		o.updateStatusForResult("a", &result)
+	}
+
+	report, err := o.GetAvailability(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Status) != 1 {
+		t.Fatal("expect status of one outbound, but got ", report.Status)
+	}
+	if status := report.Status[0]; !status.Alive || status.Transitions != 2 || status.Uptime <= 0 || status.Uptime >= 100 {
+		t.Error("unexpected availability: ", status)
+	}
+	if report.Window != int64(time.Hour) {
+		t.Error("unexpected window: ", report.Window)
+	}
+}
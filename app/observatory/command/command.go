@@ -3,6 +3,8 @@
 
 package command
 
+//go:generate go run github.com/v2fly/v2ray-core/v4/common/errors/errorgen
+
 import (
 	"context"
 
@@ -32,6 +34,26 @@ func (s *service) GetOutboundStatus(ctx context.Context, request *GetOutboundSta
 	}, nil
 }
 
+// availabilityReporter is implemented by observatories reporting availability
+// of outbounds.
+type availabilityReporter interface {
+	GetAvailability(ctx context.Context, selectors []string) (*observatory.AvailabilityReport, error)
+}
+
+func (s *service) GetOutboundAvailability(ctx context.Context, request *GetOutboundAvailabilityRequest) (*GetOutboundAvailabilityResponse, error) {
+	reporter, ok := s.observatory.(availabilityReporter)
+	if !ok {
+		return nil, newError("observatory doesn't report availability")
+	}
+	report, err := reporter.GetAvailability(ctx, request.Selector)
+	if err != nil {
+		return nil, err
+	}
+	return &GetOutboundAvailabilityResponse{
+		Report: report,
+	}, nil
+}
+
 func (s *service) Register(server *grpc.Server) {
 	RegisterObservatoryServiceServer(server, s)
 }
@@ -19,6 +19,7 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ObservatoryServiceClient interface {
 	GetOutboundStatus(ctx context.Context, in *GetOutboundStatusRequest, opts ...grpc.CallOption) (*GetOutboundStatusResponse, error)
+	GetOutboundAvailability(ctx context.Context, in *GetOutboundAvailabilityRequest, opts ...grpc.CallOption) (*GetOutboundAvailabilityResponse, error)
 }
 
 type observatoryServiceClient struct {
@@ -38,11 +39,21 @@ func (c *observatoryServiceClient) GetOutboundStatus(ctx context.Context, in *Ge
 	return out, nil
 }
 
+func (c *observatoryServiceClient) GetOutboundAvailability(ctx context.Context, in *GetOutboundAvailabilityRequest, opts ...grpc.CallOption) (*GetOutboundAvailabilityResponse, error) {
+	out := new(GetOutboundAvailabilityResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.observatory.command.ObservatoryService/GetOutboundAvailability", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ObservatoryServiceServer is the server API for ObservatoryService service.
 // All implementations must embed UnimplementedObservatoryServiceServer
 // for forward compatibility
 type ObservatoryServiceServer interface {
 	GetOutboundStatus(context.Context, *GetOutboundStatusRequest) (*GetOutboundStatusResponse, error)
+	GetOutboundAvailability(context.Context, *GetOutboundAvailabilityRequest) (*GetOutboundAvailabilityResponse, error)
 	mustEmbedUnimplementedObservatoryServiceServer()
 }
 
@@ -53,6 +64,9 @@ type UnimplementedObservatoryServiceServer struct {
 func (UnimplementedObservatoryServiceServer) GetOutboundStatus(context.Context, *GetOutboundStatusRequest) (*GetOutboundStatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetOutboundStatus not implemented")
 }
+func (UnimplementedObservatoryServiceServer) GetOutboundAvailability(context.Context, *GetOutboundAvailabilityRequest) (*GetOutboundAvailabilityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOutboundAvailability not implemented")
+}
 func (UnimplementedObservatoryServiceServer) mustEmbedUnimplementedObservatoryServiceServer() {}
 
 // UnsafeObservatoryServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -84,6 +98,24 @@ func _ObservatoryService_GetOutboundStatus_Handler(srv interface{}, ctx context.
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ObservatoryService_GetOutboundAvailability_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOutboundAvailabilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObservatoryServiceServer).GetOutboundAvailability(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.observatory.command.ObservatoryService/GetOutboundAvailability",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObservatoryServiceServer).GetOutboundAvailability(ctx, req.(*GetOutboundAvailabilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ObservatoryService_ServiceDesc is the grpc.ServiceDesc for ObservatoryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -95,6 +127,10 @@ var ObservatoryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetOutboundStatus",
 			Handler:    _ObservatoryService_GetOutboundStatus_Handler,
 		},
+		{
+			MethodName: "GetOutboundAvailability",
+			Handler:    _ObservatoryService_GetOutboundAvailability_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "app/observatory/command/command.proto",
@@ -31,22 +31,56 @@ type Observer struct {
 
 	statusLock sync.Mutex
 	status     []*OutboundStatus
+	backoff    map[string]int
+	version    uint64
 
 	finished *done.Instance
 
-	ohm outbound.Manager
+	ohm          outbound.Manager
+	store        StatusStore
+	availability *AvailabilityTracker
 }
 
 func (o *Observer) GetObservation(ctx context.Context) (proto.Message, error) {
 	return &ObservationResult{Status: o.status}, nil
 }
 
+// ObservationVersion implements extension.ObservationVersioner. It changes
+// after each probe.
+func (o *Observer) ObservationVersion() uint64 {
+	o.statusLock.Lock()
+	defer o.statusLock.Unlock()
+	return o.version
+}
+
+// GetAvailability returns the availability of observed outbounds matching the
+// selectors, e.g. those of a balancer, or of all observed outbounds if
+// selectors is empty.
+func (o *Observer) GetAvailability(ctx context.Context, selectors []string) (*AvailabilityReport, error) {
+	var outbounds []string
+	if len(selectors) > 0 {
+		hs, ok := o.ohm.(outbound.HandlerSelector)
+		if !ok {
+			return nil, newError("outbound.Manager is not a HandlerSelector")
+		}
+		outbounds = append([]string{}, hs.Select(selectors)...)
+	}
+	return o.availability.Report(outbounds, time.Now()), nil
+}
+
 func (o *Observer) Type() interface{} {
 	return extension.ObservatoryType()
 }
 
+// SetStatusStore sets the store outbound status is persisted to. It overrides
+// persistent_file in config, and must be called before the observer starts.
+func (o *Observer) SetStatusStore(store StatusStore) {
+	o.store = store
+}
+
 func (o *Observer) Start() error {
 	if o.config != nil && len(o.config.SubjectSelector) != 0 {
+		o.restoreStatus()
 		o.finished = done.New()
 		go o.background()
 	}
@@ -73,21 +107,64 @@ func (o *Observer) background() {
 
 		o.updateStatus(outbounds)
 
+		probed := false
 		for _, v := range outbounds {
+			if o.skipProbe(v) {
+				continue
+			}
+			probed = true
 			result := o.probe(v)
 			o.updateStatusForResult(v, &result)
+			o.persistStatus()
 			if o.finished.Done() {
 				return
 			}
-			sleepTime := time.Second * 10
-			if o.config.ProbeInterval != 0 {
-				sleepTime = time.Duration(o.config.ProbeInterval)
-			}
-			time.Sleep(sleepTime)
+			time.Sleep(o.probeInterval())
+		}
+		if !probed {
+			time.Sleep(o.probeInterval())
 		}
 	}
 }
 
+func (o *Observer) probeInterval() time.Duration {
+	if o.config.ProbeInterval != 0 {
+		return time.Duration(o.config.ProbeInterval)
+	}
+	return time.Second * 10
+}
+
+// maxProbeBackoffShift caps the backoff of probing dead outbounds at 2^5
+// times the usual interval.
+const maxProbeBackoffShift = 5
+
+// probeBackoffRounds returns the number of probing rounds an outbound is
+// skipped after the given number of consecutive failures. Once it is dead,
+// i.e. failed maxFailures times in a row, the interval between its probes
+// doubles after each failure. There is no backoff if maxFailures is zero.
+func probeBackoffRounds(failures uint32, maxFailures uint32) int {
+	if maxFailures == 0 || failures < maxFailures {
+		return 0
+	}
+	shift := failures - maxFailures + 1
+	if shift > maxProbeBackoffShift {
+		shift = maxProbeBackoffShift
+	}
+	return 1<<shift - 1
+}
+
+// skipProbe returns whether the outbound is skipped in this probing round,
+// as it is dead and backing off.
+func (o *Observer) skipProbe(outbound string) bool {
+	o.statusLock.Lock()
+	defer o.statusLock.Unlock()
+	if o.backoff[outbound] > 0 {
+		o.backoff[outbound]--
+		return true
+	}
+	return false
+}
+
 func (o *Observer) updateStatus(outbounds []string) {
 	o.statusLock.Lock()
 	defer o.statusLock.Unlock()
@@ -175,16 +252,69 @@ func (o *Observer) updateStatusForResult(outbound string, result *ProbeResult) {
 		o.status = append(o.status, status)
 	}
 
-	status.LastTryTime = time.Now().Unix()
+	now := time.Now()
+	if o.availability != nil {
+		o.availability.Record(outbound, result.Alive, now)
+	}
+
+	o.version++
+	status.LastTryTime = now.Unix()
 	status.OutboundTag = outbound
 	status.Alive = result.Alive
 	if result.Alive {
 		status.Delay = result.Delay
 		status.LastSeenTime = status.LastTryTime
 		status.LastErrorReason = ""
+		status.ConsecutiveFailures = 0
+		delete(o.backoff, outbound)
 	} else {
 		status.LastErrorReason = result.LastErrorReason
 		status.Delay = 99999999
+		status.ConsecutiveFailures++
+		if rounds := probeBackoffRounds(status.ConsecutiveFailures, o.config.BackoffMaxFailures); rounds > 0 {
+			if o.backoff == nil {
+				o.backoff = make(map[string]int)
+			}
+			o.backoff[outbound] = rounds
+		}
+	}
+}
+
+// restoreStatus seeds outbound status with fresh entries from the store.
+func (o *Observer) restoreStatus() {
+	if o.store == nil {
+		return
+	}
+	status, err := o.store.Load()
+	if err != nil {
+		newError("failed to restore outbound status").Base(err).AtWarning().WriteToLog()
+		return
+	}
+	maxAge := defaultPersistentMaxAge
+	if o.config.PersistentMaxAge > 0 {
+		maxAge = time.Duration(o.config.PersistentMaxAge)
+	}
+
+	o.statusLock.Lock()
+	defer o.statusLock.Unlock()
+	o.status = freshStatus(status, time.Now(), maxAge)
+	o.version++
+}
+
+// persistStatus saves current outbound status to the store.
+func (o *Observer) persistStatus() {
+	if o.store == nil {
+		return
+	}
+	o.statusLock.Lock()
+	status := make([]*OutboundStatus, len(o.status))
+	for i, s := range o.status {
+		status[i] = proto.Clone(s).(*OutboundStatus)
+	}
+	o.statusLock.Unlock()
+
+	if err := o.store.Save(status); err != nil {
+		newError("failed to persist outbound status").Base(err).AtWarning().WriteToLog()
 	}
 }
 
@@ -205,11 +335,20 @@ func New(ctx context.Context, config *Config) (*Observer, error) {
 	if err != nil {
 		return nil, newError("Cannot get depended features").Base(err)
 	}
-	return &Observer{
-		config: config,
-		ctx:    ctx,
-		ohm:    outboundManager,
-	}, nil
+	window := defaultAvailabilityWindow
+	if config.AvailabilityWindow > 0 {
+		window = time.Duration(config.AvailabilityWindow)
+	}
+	o := &Observer{
+		config:       config,
+		ctx:          ctx,
+		ohm:          outboundManager,
+		availability: NewAvailabilityTracker(window),
+	}
+	if config.PersistentFile != "" {
+		o.store = NewFileStatusStore(config.PersistentFile)
+	}
+	return o, nil
 }
 
 func init() {
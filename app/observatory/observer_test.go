@@ -0,0 +1,114 @@
+package observatory
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v2net "github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/tagged"
+)
+
+func TestProbeBackoffRounds(t *testing.T) {
+	testCases := []struct {
+		maxFailures uint32
+		expected    []int
+	}{
+		{0, []int{0, 0, 0, 0, 0, 0}},
+		{1, []int{0, 1, 3, 7, 15, 31, 31, 31}},
+		{3, []int{0, 0, 0, 1, 3, 7, 15, 31, 31, 31}},
+	}
+	for _, tc := range testCases {
+		for failures, rounds := range tc.expected {
+			if r := probeBackoffRounds(uint32(failures), tc.maxFailures); r != rounds {
+				t.Error("expect ", rounds, " rounds skipped after ", failures, " failures with max ", tc.maxFailures, ", but got ", r)
+			}
+		}
+	}
+}
+
+func TestObserverNoProbeBackoff(t *testing.T) {
+	o := &Observer{config: &Config{}}
+	for i := 0; i < 10; i++ {
+		if o.skipProbe("a") {
+			t.Fatal("expect no backoff by default, but skipped round ", i)
+		}
+		o.updateStatusForResult("a", &ProbeResult{Alive: false})
+	}
+}
+
+func TestObserverProbeBackoff(t *testing.T) {
+	o := &Observer{config: &Config{BackoffMaxFailures: 2}}
+
+	// probes returns whether the outbound is probed in each of the rounds,
+	// with all probes failing.
+	probes := func(rounds int) []bool {
+		probed := make([]bool, rounds)
+		for i := range probed {
+			if !o.skipProbe("a") {
+				probed[i] = true
+				o.updateStatusForResult("a", &ProbeResult{Alive: false})
+			}
+		}
+		return probed
+	}
+
+	// Intervals between probes are 1, 1, 2, 4 and 8 rounds, doubling once
+	// the outbound is dead after 2 failures.
+	expected := []bool{true, true, false, true, false, false, false, true, false, false, false, false, false, false, false, true}
+	for i, probed := range probes(len(expected)) {
+		if probed != expected[i] {
+			t.Fatal("unexpected probe in round ", i, ": ", probed)
+		}
+	}
+	if failures := o.status[0].ConsecutiveFailures; failures != 5 {
+		t.Error("expect 5 consecutive failures, but got ", failures)
+	}
+
+	for o.skipProbe("a") {
+	}
+	o.updateStatusForResult("a", &ProbeResult{Alive: true, Delay: 100})
+	if status := o.status[0]; !status.Alive || status.ConsecutiveFailures != 0 {
+		t.Error("expect outbound to recover, but got ", status)
+	}
+	if o.skipProbe("a") {
+		t.Error("expect no backoff after recovery")
+	}
+}
+
+func TestObserverProbeThroughOutbound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// The probe dials through the outbound handler with the tag, which is
+	// mocked here. The "broken" outbound fails its proxy handshake, closing
+	// the connection, even though the probe target is reachable.
+	var dialedTags []string
+	dialer := tagged.Dialer
+	tagged.Dialer = func(ctx context.Context, dest v2net.Destination, tag string) (v2net.Conn, error) {
+		dialedTags = append(dialedTags, tag)
+		if tag == "broken" {
+			client, remote := net.Pipe()
+			remote.Close()
+			return client, nil
+		}
+		return net.Dial("tcp", dest.NetAddr())
+	}
+	defer func() { tagged.Dialer = dialer }()
+
+	o := &Observer{config: &Config{ProbeUrl: server.URL}, ctx: context.Background()}
+
+	if result := o.probe("working"); !result.Alive {
+		t.Error("expect outbound to be alive, but got ", result.LastErrorReason)
+	}
+	if result := o.probe("broken"); result.Alive || len(result.LastErrorReason) == 0 {
+		t.Error("expect outbound failing handshake to be dead, but got ", result.Alive)
+	}
+	if len(dialedTags) != 2 || dialedTags[0] != "working" || dialedTags[1] != "broken" {
+		t.Error("expect probes to dial through outbounds, but got ", dialedTags)
+	}
+}
@@ -0,0 +1,91 @@
+//go:build !confonly
+// +build !confonly
+
+package observatory
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const defaultPersistentMaxAge = time.Hour
+
+// StatusStore persists outbound status across restarts.
+type StatusStore interface {
+	// Load returns the status saved last time. It returns no status and no
+	// error if nothing has been saved.
+	Load() ([]*OutboundStatus, error)
+	// Save replaces the saved status.
+	Save([]*OutboundStatus) error
+}
+
+// FileStatusStore is a StatusStore saving status to a file.
+type FileStatusStore struct {
+	path string
+}
+
+// NewFileStatusStore creates a new FileStatusStore saving status to the file at path.
+func NewFileStatusStore(path string) *FileStatusStore {
+	return &FileStatusStore{path: path}
+}
+
+// Load implements StatusStore.
+func (s *FileStatusStore) Load() ([]*OutboundStatus, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newError("failed to read ", s.path).Base(err)
+	}
+	result := new(ObservationResult)
+	if err := proto.Unmarshal(data, result); err != nil {
+		return nil, newError("failed to parse ", s.path).Base(err)
+	}
+	return result.Status, nil
+}
+
+// Save implements StatusStore. The file is replaced atomically by renaming a
+// temporary file written in the same directory.
+func (s *FileStatusStore) Save(status []*OutboundStatus) error {
+	data, err := proto.Marshal(&ObservationResult{Status: status})
+	if err != nil {
+		return newError("failed to encode status").Base(err)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return newError("failed to create temporary file").Base(err)
+	}
+	tmpPath := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return newError("failed to write ", tmpPath).Base(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return newError("failed to write ", tmpPath).Base(err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return newError("failed to replace ", s.path).Base(err)
+	}
+	return nil
+}
+
+// freshStatus returns status last tried no earlier than maxAge before now.
+func freshStatus(status []*OutboundStatus, now time.Time, maxAge time.Duration) []*OutboundStatus {
+	threshold := now.Add(-maxAge).Unix()
+	fresh := make([]*OutboundStatus, 0, len(status))
+	for _, s := range status {
+		if s.OutboundTag != "" && s.LastTryTime >= threshold {
+			fresh = append(fresh, s)
+		}
+	}
+	return fresh
+}
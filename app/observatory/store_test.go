@@ -0,0 +1,72 @@
+package observatory
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+)
+
+func TestFileStatusStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "v2ray-observatory")
+	common.Must(err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStatusStore(filepath.Join(dir, "status"))
+	status, err := store.Load()
+	common.Must(err)
+	if len(status) != 0 {
+		t.Error("expect no status before saving, but got ", status)
+	}
+
+	expected := []*OutboundStatus{
+		{OutboundTag: "a", Alive: true, Delay: 120, LastSeenTime: 1000, LastTryTime: 1000},
+		{OutboundTag: "b", Alive: false, Delay: 99999999, LastSeenTime: 900, LastTryTime: 1000},
+	}
+	common.Must(store.Save(expected[:1]))
+	common.Must(store.Save(expected))
+
+	status, err = store.Load()
+	common.Must(err)
+	if r := cmp.Diff(status, expected, protocmp.Transform()); r != "" {
+		t.Error(r)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	common.Must(err)
+	if len(files) != 1 {
+		t.Error("expect temporary files to be removed, but got ", len(files), " files")
+	}
+}
+
+func TestFileStatusStoreCorrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "v2ray-observatory")
+	common.Must(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "status")
+	common.Must(ioutil.WriteFile(path, []byte("not a status"), 0o600))
+	if _, err := NewFileStatusStore(path).Load(); err == nil {
+		t.Error("expect error loading corrupted file")
+	}
+}
+
+func TestFreshStatus(t *testing.T) {
+	now := time.Unix(10000, 0)
+	status := []*OutboundStatus{
+		{OutboundTag: "fresh", LastTryTime: 9000},
+		{OutboundTag: "stale", LastTryTime: 5000},
+		{OutboundTag: "", LastTryTime: 9500},
+	}
+
+	fresh := freshStatus(status, now, time.Hour)
+	if len(fresh) != 1 || fresh[0].OutboundTag != "fresh" {
+		t.Error("expect only fresh status, but got ", fresh)
+	}
+}
@@ -2,6 +2,9 @@ package outbound
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	core "github.com/v2fly/v2ray-core/v4"
 	"github.com/v2fly/v2ray-core/v4/app/proxyman"
@@ -9,12 +12,15 @@ import (
 	"github.com/v2fly/v2ray-core/v4/common/mux"
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/common/session"
+	"github.com/v2fly/v2ray-core/v4/features/dns"
 	"github.com/v2fly/v2ray-core/v4/features/outbound"
 	"github.com/v2fly/v2ray-core/v4/features/policy"
 	"github.com/v2fly/v2ray-core/v4/features/stats"
 	"github.com/v2fly/v2ray-core/v4/proxy"
 	"github.com/v2fly/v2ray-core/v4/transport"
 	"github.com/v2fly/v2ray-core/v4/transport/internet"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/headers/noop"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/tcp"
 	"github.com/v2fly/v2ray-core/v4/transport/internet/tls"
 	"github.com/v2fly/v2ray-core/v4/transport/pipe"
 )
@@ -46,14 +52,22 @@ func getStatCounter(v *core.Instance, tag string) (stats.Counter, stats.Counter)
 
 // Handler is an implements of outbound.Handler.
 type Handler struct {
+	activeConns     int64 // 64-bit aligned for atomic access on 32-bit platforms
 	tag             string
 	senderSettings  *proxyman.SenderConfig
 	streamSettings  *internet.MemoryStreamConfig
 	proxy           proxy.Outbound
 	outboundManager outbound.Manager
+	dns             dns.Client
 	mux             *mux.ClientManager
 	uplinkCounter   stats.Counter
 	downlinkCounter stats.Counter
+	ctx             context.Context
+
+	warmAccess  sync.Mutex
+	warmPool    *WarmPool
+	warmSize    int
+	warmMaxIdle time.Duration
 }
 
 // NewHandler create a new Handler based on the given configuration.
@@ -65,6 +79,12 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 		outboundManager: v.GetFeature(outbound.ManagerType()).(outbound.Manager),
 		uplinkCounter:   uplinkCounter,
 		downlinkCounter: downlinkCounter,
+		ctx:             ctx,
+	}
+	if err := core.RequireFeatures(ctx, func(d dns.Client) {
+		h.dns = d
+	}); err != nil {
+		return nil, err
 	}
 
 	if config.SenderSettings != nil {
@@ -105,18 +125,20 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 		if config.Concurrency < 1 || config.Concurrency > 1024 {
 			return nil, newError("invalid mux concurrency: ", config.Concurrency).AtWarning()
 		}
+		factory := mux.NewDialingWorkerFactory(
+			ctx,
+			proxyHandler,
+			h,
+			mux.ClientStrategy{
+				MaxConcurrency: config.Concurrency,
+				MaxConnection:  128,
+			},
+		)
+		factory.Stats = mux.NewClientStats(v.GetFeature(stats.ManagerType()).(stats.Manager), h.tag)
 		h.mux = &mux.ClientManager{
 			Enabled: h.senderSettings.MultiplexSettings.Enabled,
 			Picker: &mux.IncrementalWorkerPicker{
-				Factory: mux.NewDialingWorkerFactory(
-					ctx,
-					proxyHandler,
-					h,
-					mux.ClientStrategy{
-						MaxConcurrency: config.Concurrency,
-						MaxConnection:  128,
-					},
-				),
+				Factory: factory,
 			},
 		}
 	}
@@ -130,8 +152,18 @@ func (h *Handler) Tag() string {
 	return h.tag
 }
 
+// ActiveConnections implements outbound.ConnectionCounter. Connections
+// dispatched through mux are counted until they are handed over to a mux
+// worker.
+func (h *Handler) ActiveConnections() int64 {
+	return atomic.LoadInt64(&h.activeConns)
+}
+
 // Dispatch implements proxy.Outbound.Dispatch.
 func (h *Handler) Dispatch(ctx context.Context, link *transport.Link) {
+	atomic.AddInt64(&h.activeConns, 1)
+	defer atomic.AddInt64(&h.activeConns, -1)
+
 	if h.mux != nil && (h.mux.Enabled || session.MuxPreferedFromContext(ctx)) {
 		if err := h.mux.Dispatch(ctx, link); err != nil {
 			err := newError("failed to process mux outbound traffic").Base(err)
@@ -190,25 +222,146 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (internet.Conn
 
 			newError("failed to get outbound handler with tag: ", tag).AtWarning().WriteToLog(session.ExportIDToError(ctx))
 		}
+	}
 
-		if h.senderSettings.Via != nil {
-			outbound := session.OutboundFromContext(ctx)
-			if outbound == nil {
-				outbound = new(session.Outbound)
-				ctx = session.ContextWithOutbound(ctx, outbound)
-			}
-			outbound.Gateway = h.senderSettings.Via.AsAddress()
+	ctx = h.dialContext(ctx, dest)
+	if pool := h.getWarmPool(ctx); pool != nil {
+		if conn := pool.Get(dest); conn != nil {
+			newError("using warm connection to ", dest).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+			return h.getStatCouterConnection(conn), nil
 		}
 	}
 
-	if h.senderSettings != nil && h.senderSettings.ProxySettings != nil && h.senderSettings.ProxySettings.HasTag() && h.senderSettings.ProxySettings.TransportLayerProxy {
+	conn, err := internet.Dial(ctx, dest, h.streamSettings)
+	return h.getStatCouterConnection(conn), err
+}
+
+// dialContext returns ctx with the DNS client of the instance, and the gateway
+// and the transport layer proxy of the sender settings, for dialing directly.
+func (h *Handler) dialContext(ctx context.Context, dest net.Destination) context.Context {
+	if h.dns != nil {
+		ctx = internet.ContextWithDNSClient(ctx, h.dns)
+	}
+	if h.senderSettings == nil {
+		return ctx
+	}
+
+	if h.senderSettings.Via != nil {
+		outbound := session.OutboundFromContext(ctx)
+		if outbound == nil {
+			outbound = new(session.Outbound)
+			ctx = session.ContextWithOutbound(ctx, outbound)
+		}
+		outbound.Gateway = h.senderSettings.Via.AsAddress()
+	}
+
+	if h.senderSettings.ProxySettings != nil && h.senderSettings.ProxySettings.HasTag() && h.senderSettings.ProxySettings.TransportLayerProxy {
 		tag := h.senderSettings.ProxySettings.Tag
 		newError("transport layer proxying to ", tag, " for dest ", dest).AtDebug().WriteToLog(session.ExportIDToError(ctx))
 		ctx = session.SetTransportLayerProxyTagToContext(ctx, tag)
 	}
+	return ctx
+}
 
-	conn, err := internet.Dial(ctx, dest, h.streamSettings)
-	return h.getStatCouterConnection(conn), err
+// getWarmPool returns the warm pool to take a connection from, or nil if the
+// pool is disabled or the connection has dialing settings of its own, i.e. the
+// TOS, the dial timeout or the resolver tag of the routing rule, which warm
+// connections don't follow.
+func (h *Handler) getWarmPool(ctx context.Context) *WarmPool {
+	if session.TOSFromContext(ctx) != 0 || session.DialTimeoutFromContext(ctx) != 0 || len(session.ResolverTagFromContext(ctx)) > 0 {
+		return nil
+	}
+	h.warmAccess.Lock()
+	defer h.warmAccess.Unlock()
+	return h.warmPool
+}
+
+// SetWarmPool implements outbound.Warmer. The pool is only enabled for
+// proxies connecting to fixed servers over raw TCP, see isWarmable, and keeps
+// connections to each TCP server. Connections of the pool are dialed with the context of the handler
+// rather than that of each connection, and the TLS handshake, if any, is done
+// in advance. Source addresses, if configured, are still rotated per
+// connection as they are dialed. Only direct dials use the pool, not those
+// proxied through another outbound.
+func (h *Handler) SetWarmPool(size int, maxIdle time.Duration) {
+	h.warmAccess.Lock()
+	defer h.warmAccess.Unlock()
+	if size <= h.warmSize && maxIdle <= h.warmMaxIdle {
+		return
+	}
+	if size > h.warmSize {
+		h.warmSize = size
+	}
+	if maxIdle > h.warmMaxIdle {
+		h.warmMaxIdle = maxIdle
+	}
+	if h.warmSize <= 0 || h.warmMaxIdle <= 0 {
+		return
+	}
+
+	servers, ok := h.proxy.(proxy.ServerOutbound)
+	if !ok || !isWarmable(h.streamSettings) {
+		newError("warm pool is not supported by outbound ", h.tag).AtDebug().WriteToLog()
+		return
+	}
+	var dests []net.Destination
+	for _, dest := range servers.Servers() {
+		if dest.Network == net.Network_TCP {
+			dests = append(dests, dest)
+		}
+	}
+	if len(dests) == 0 {
+		return
+	}
+
+	if h.warmPool != nil {
+		h.warmPool.Close()
+	}
+	ctx := h.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	h.warmPool = NewWarmPool(ctx, h.warmSize, h.warmMaxIdle, dests, h.dialWarm, nil)
+}
+
+// isWarmable returns whether connections dialed with the stream settings can
+// be kept warm. They must be raw TCP connections, optionally with TLS, since
+// other transports, e.g. WebSocket, are broken by the read timeout of the
+// health check. They must not send a PROXY protocol header either, which
+// would be written without the inbound of the client the connection is handed
+// over to.
+func isWarmable(settings *internet.MemoryStreamConfig) bool {
+	if settings == nil {
+		return true
+	}
+	if settings.ProtocolName != "tcp" {
+		return false
+	}
+	if config, ok := settings.ProtocolSettings.(*tcp.Config); ok && config.HeaderSettings != nil {
+		header, err := config.HeaderSettings.GetInstance()
+		if _, noop := header.(*noop.ConnectionConfig); err != nil || !noop {
+			return false
+		}
+	}
+	if settings.SecuritySettings != nil && tls.ConfigFromStreamSettings(settings) == nil {
+		return false
+	}
+	return settings.SocketSettings == nil || settings.SocketSettings.SendProxyProtocol == 0
+}
+
+// dialWarm dials a connection for the warm pool.
+func (h *Handler) dialWarm(ctx context.Context, dest net.Destination) (internet.Connection, error) {
+	conn, err := internet.Dial(h.dialContext(ctx, dest), dest, h.streamSettings)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, newError("failed to handshake warm connection").Base(err)
+		}
+	}
+	return conn, nil
 }
 
 func (h *Handler) getStatCouterConnection(conn internet.Connection) internet.Connection {
@@ -235,5 +388,11 @@ func (h *Handler) Start() error {
 // Close implements common.Closable.
 func (h *Handler) Close() error {
 	common.Close(h.mux)
+	h.warmAccess.Lock()
+	if h.warmPool != nil {
+		h.warmPool.Close()
+		h.warmPool = nil
+	}
+	h.warmAccess.Unlock()
 	return nil
 }
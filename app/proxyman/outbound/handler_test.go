@@ -3,22 +3,32 @@ package outbound_test
 import (
 	"context"
 	"testing"
+	"time"
 	_ "unsafe"
 
 	core "github.com/v2fly/v2ray-core/v4"
 	"github.com/v2fly/v2ray-core/v4/app/policy"
+	"github.com/v2fly/v2ray-core/v4/app/proxyman"
 	. "github.com/v2fly/v2ray-core/v4/app/proxyman/outbound"
 	"github.com/v2fly/v2ray-core/v4/app/stats"
+	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/protocol"
 	"github.com/v2fly/v2ray-core/v4/common/serial"
+	"github.com/v2fly/v2ray-core/v4/common/session"
 	"github.com/v2fly/v2ray-core/v4/features/outbound"
 	"github.com/v2fly/v2ray-core/v4/proxy/freedom"
+	"github.com/v2fly/v2ray-core/v4/proxy/socks"
 	"github.com/v2fly/v2ray-core/v4/transport/internet"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/headers/http"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/tcp"
+	_ "github.com/v2fly/v2ray-core/v4/transport/internet/websocket"
 )
 
 func TestInterfaces(t *testing.T) {
 	_ = (outbound.Handler)(new(Handler))
 	_ = (outbound.Manager)(new(Manager))
+	_ = (outbound.Warmer)(new(Handler))
 }
 
 //go:linkname toContext github.com/v2fly/v2ray-core/v4.toContext
@@ -80,3 +90,110 @@ func TestOutboundWithStatCounter(t *testing.T) {
 		t.Errorf("Expected conn to be StatCouterConnection")
 	}
 }
+
+func TestOutboundWarmPool(t *testing.T) {
+	server := newWarmServer(t)
+	dest := server.dest()
+
+	v, _ := core.New(&core.Config{})
+	v.AddFeature((outbound.Manager)(new(Manager)))
+	ctx := toContext(context.Background(), v)
+	h, err := NewHandler(ctx, &core.OutboundHandlerConfig{
+		Tag: "tag",
+		ProxySettings: serial.ToTypedMessage(&socks.ClientConfig{
+			Server: []*protocol.ServerEndpoint{
+				{
+					Address: net.NewIPOrDomain(dest.Address),
+					Port:    uint32(dest.Port),
+				},
+			},
+		}),
+	})
+	common.Must(err)
+	defer h.Close()
+
+	handler := h.(*Handler)
+	handler.SetWarmPool(1, time.Minute)
+	waitFor(t, func() bool { return server.conn(0) != nil })
+
+	conn, err := handler.Dial(ctx, dest)
+	common.Must(err)
+	defer conn.Close()
+	if !isConnOf(conn, server.conn(0)) {
+		t.Error("expected the warm connection to be handed over")
+	}
+
+	// Connections with a TOS of their own are dialed anew.
+	waitFor(t, func() bool { return server.conn(1) != nil })
+	conn, err = handler.Dial(session.ContextWithTOS(ctx, 0x10), dest)
+	common.Must(err)
+	defer conn.Close()
+	if isConnOf(conn, server.conn(1)) {
+		t.Error("expected a new connection with its own TOS")
+	}
+}
+
+func TestOutboundWarmPoolUnsupported(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings *internet.StreamConfig
+	}{
+		{
+			name: "websocket",
+			settings: &internet.StreamConfig{
+				ProtocolName: "websocket",
+			},
+		},
+		{
+			name: "http header",
+			settings: &internet.StreamConfig{
+				TransportSettings: []*internet.TransportConfig{
+					{
+						ProtocolName: "tcp",
+						Settings: serial.ToTypedMessage(&tcp.Config{
+							HeaderSettings: serial.ToTypedMessage(&http.Config{}),
+						}),
+					},
+				},
+			},
+		},
+		{
+			name: "PROXY protocol",
+			settings: &internet.StreamConfig{
+				SocketSettings: &internet.SocketConfig{SendProxyProtocol: 2},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := newWarmServer(t)
+			dest := server.dest()
+
+			v, _ := core.New(&core.Config{})
+			v.AddFeature((outbound.Manager)(new(Manager)))
+			ctx := toContext(context.Background(), v)
+			h, err := NewHandler(ctx, &core.OutboundHandlerConfig{
+				Tag: "tag",
+				SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+					StreamSettings: c.settings,
+				}),
+				ProxySettings: serial.ToTypedMessage(&socks.ClientConfig{
+					Server: []*protocol.ServerEndpoint{
+						{
+							Address: net.NewIPOrDomain(dest.Address),
+							Port:    uint32(dest.Port),
+						},
+					},
+				}),
+			})
+			common.Must(err)
+			defer h.Close()
+
+			h.(*Handler).SetWarmPool(1, time.Minute)
+			time.Sleep(100 * time.Millisecond)
+			if server.conn(0) != nil {
+				t.Error("expected no warm connection")
+			}
+		})
+	}
+}
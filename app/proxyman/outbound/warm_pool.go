@@ -0,0 +1,232 @@
+package outbound
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/transport/internet"
+)
+
+// healthCheckTimeout is how long an idle connection is read before handover
+// to see whether it is still alive.
+const healthCheckTimeout = time.Millisecond
+
+// maxWarmDestinations is the maximum number of destinations of a WarmPool.
+const maxWarmDestinations = 8
+
+// WarmPool keeps connections dialed in advance to a fixed set of destinations,
+// usually the servers of a proxy, so that they are handed over without paying
+// the handshake cost. Connections idle for longer than the max idle age are
+// closed. It is safe for concurrent use.
+type WarmPool struct {
+	size    int
+	maxIdle time.Duration
+	dests   map[net.Destination]bool
+	dial    func(ctx context.Context, dest net.Destination) (internet.Connection, error)
+	now     func() time.Time
+
+	access  sync.Mutex
+	idle    map[net.Destination][]*warmConn
+	filling map[net.Destination]bool
+	closed  bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+type warmConn struct {
+	conn   internet.Connection
+	dialed time.Time
+	timer  *time.Timer
+}
+
+// NewWarmPool creates a new WarmPool keeping size idle connections for each
+// of the destinations, at most maxWarmDestinations of them. Connections are
+// dialed with dial under a context derived from ctx, which is canceled when
+// the pool is closed. The pool starts filling at once. If now is nil,
+// time.Now is used.
+func NewWarmPool(ctx context.Context, size int, maxIdle time.Duration, dests []net.Destination, dial func(ctx context.Context, dest net.Destination) (internet.Connection, error), now func() time.Time) *WarmPool {
+	if now == nil {
+		now = time.Now
+	}
+	if len(dests) > maxWarmDestinations {
+		newError("warming only the first ", maxWarmDestinations, " of ", len(dests), " destinations").AtWarning().WriteToLog()
+		dests = dests[:maxWarmDestinations]
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p := &WarmPool{
+		size:    size,
+		maxIdle: maxIdle,
+		dests:   make(map[net.Destination]bool, len(dests)),
+		dial:    dial,
+		now:     now,
+		idle:    make(map[net.Destination][]*warmConn),
+		filling: make(map[net.Destination]bool),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	for _, dest := range dests {
+		p.dests[dest] = true
+	}
+	for dest := range p.dests {
+		p.replenish(dest)
+	}
+	return p
+}
+
+// Get returns an idle connection to the destination, or nil if there is none
+// alive or the destination is not one of the pool. Connections handed over
+// are replenished in the background.
+func (p *WarmPool) Get(dest net.Destination) internet.Connection {
+	if !p.dests[dest] {
+		return nil
+	}
+	defer p.replenish(dest)
+
+	for {
+		p.access.Lock()
+		conns := p.idle[dest]
+		if len(conns) == 0 {
+			p.access.Unlock()
+			return nil
+		}
+		c := conns[len(conns)-1]
+		p.setIdle(dest, conns[:len(conns)-1])
+		p.access.Unlock()
+
+		c.timer.Stop()
+		if p.now().Sub(c.dialed) < p.maxIdle && isAlive(c.conn) {
+			return c.conn
+		}
+		newError("discarding stale warm connection to ", dest).AtDebug().WriteToLog()
+		c.conn.Close()
+	}
+}
+
+// Len returns the number of idle connections to the destination.
+func (p *WarmPool) Len(dest net.Destination) int {
+	p.access.Lock()
+	defer p.access.Unlock()
+	return len(p.idle[dest])
+}
+
+// Close closes all idle connections, and stops replenishing the pool.
+func (p *WarmPool) Close() error {
+	p.access.Lock()
+	idle := p.idle
+	p.idle = make(map[net.Destination][]*warmConn)
+	p.closed = true
+	p.access.Unlock()
+
+	p.cancel()
+	for _, conns := range idle {
+		for _, c := range conns {
+			c.timer.Stop()
+			c.conn.Close()
+		}
+	}
+	return nil
+}
+
+// replenish dials connections to the destination in the background, until
+// there are size idle ones. Only one goroutine fills each destination.
+func (p *WarmPool) replenish(dest net.Destination) {
+	p.access.Lock()
+	defer p.access.Unlock()
+	if p.closed || p.filling[dest] || len(p.idle[dest]) >= p.size {
+		return
+	}
+	p.filling[dest] = true
+	go p.fill(dest)
+}
+
+func (p *WarmPool) fill(dest net.Destination) {
+	defer func() {
+		p.access.Lock()
+		delete(p.filling, dest)
+		p.access.Unlock()
+	}()
+
+	for {
+		p.access.Lock()
+		full := p.closed || len(p.idle[dest]) >= p.size
+		p.access.Unlock()
+		if full {
+			return
+		}
+
+		conn, err := p.dial(p.ctx, dest)
+		if err != nil {
+			newError("failed to dial warm connection to ", dest).Base(err).AtDebug().WriteToLog()
+			return
+		}
+		if !p.put(dest, conn) {
+			conn.Close()
+			return
+		}
+	}
+}
+
+// put adds the connection to the idle ones of the destination, and returns
+// false if the pool is closed or full.
+func (p *WarmPool) put(dest net.Destination, conn internet.Connection) bool {
+	p.access.Lock()
+	defer p.access.Unlock()
+	if p.closed || len(p.idle[dest]) >= p.size {
+		return false
+	}
+	c := &warmConn{conn: conn, dialed: p.now()}
+	c.timer = time.AfterFunc(p.maxIdle, func() {
+		if p.remove(dest, c) {
+			conn.Close()
+		}
+	})
+	p.idle[dest] = append(p.idle[dest], c)
+	return true
+}
+
+// remove removes the connection from the idle ones of the destination, and
+// returns whether it was idle.
+func (p *WarmPool) remove(dest net.Destination, c *warmConn) bool {
+	p.access.Lock()
+	defer p.access.Unlock()
+	conns := p.idle[dest]
+	for i, idle := range conns {
+		if idle == c {
+			p.setIdle(dest, append(conns[:i:i], conns[i+1:]...))
+			return true
+		}
+	}
+	return false
+}
+
+// setIdle replaces the idle connections of the destination. Callers must hold
+// the access lock.
+func (p *WarmPool) setIdle(dest net.Destination, conns []*warmConn) {
+	if len(conns) == 0 {
+		delete(p.idle, dest)
+	} else {
+		p.idle[dest] = conns
+	}
+}
+
+// isAlive checks whether the idle connection is still usable, i.e. the peer
+// has neither closed it nor sent anything, since outbound protocols speak
+// first. It reads with a short deadline, since reads with an expired deadline
+// fail without checking the connection.
+func isAlive(conn internet.Connection) bool {
+	if err := conn.SetReadDeadline(time.Now().Add(healthCheckTimeout)); err != nil {
+		return false
+	}
+	var b [1]byte
+	_, err := conn.Read(b[:])
+	if err == nil || err == io.EOF {
+		return false
+	}
+	if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		return false
+	}
+	return conn.SetReadDeadline(time.Time{}) == nil
+}
@@ -0,0 +1,186 @@
+package outbound_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/v2fly/v2ray-core/v4/app/proxyman/outbound"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/transport/internet"
+)
+
+// warmServer accepts TCP connections and keeps them open.
+type warmServer struct {
+	listener net.Listener
+
+	access sync.Mutex
+	conns  []net.Conn
+}
+
+func newWarmServer(t *testing.T) *warmServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	s := &warmServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.access.Lock()
+			s.conns = append(s.conns, conn)
+			s.access.Unlock()
+		}
+	}()
+	t.Cleanup(func() {
+		listener.Close()
+		s.access.Lock()
+		defer s.access.Unlock()
+		for _, conn := range s.conns {
+			conn.Close()
+		}
+	})
+	return s
+}
+
+func (s *warmServer) dest() net.Destination {
+	return net.DestinationFromAddr(s.listener.Addr())
+}
+
+func (s *warmServer) conn(i int) net.Conn {
+	s.access.Lock()
+	defer s.access.Unlock()
+	if i >= len(s.conns) {
+		return nil
+	}
+	return s.conns[i]
+}
+
+func dialTCP(ctx context.Context, dest net.Destination) (internet.Connection, error) {
+	return net.Dial("tcp", dest.NetAddr())
+}
+
+// isConnOf returns whether conn is the client side of the server connection.
+// The pool may have replaced a discarded connection with a new one already.
+func isConnOf(conn internet.Connection, serverConn net.Conn) bool {
+	return conn != nil && conn.LocalAddr().String() == serverConn.RemoteAddr().String()
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWarmPoolReuse(t *testing.T) {
+	server := newWarmServer(t)
+	dest := server.dest()
+	pool := NewWarmPool(context.Background(), 1, time.Minute, []net.Destination{dest}, dialTCP, nil)
+	defer pool.Close()
+
+	// The pool fills at once.
+	waitFor(t, func() bool { return pool.Len(dest) == 1 })
+
+	conn := pool.Get(dest)
+	if conn == nil {
+		t.Fatal("expected a warm connection")
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	var b [4]byte
+	if _, err := io.ReadFull(server.conn(0), b[:]); err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:]) != "ping" {
+		t.Error("unexpected payload: ", string(b[:]))
+	}
+
+	// The pool is replenished after the handover.
+	waitFor(t, func() bool { return pool.Len(dest) == 1 })
+}
+
+func TestWarmPoolDiscardStale(t *testing.T) {
+	server := newWarmServer(t)
+	dest := server.dest()
+	now := time.Now()
+	var access sync.Mutex
+	clock := func() time.Time {
+		access.Lock()
+		defer access.Unlock()
+		return now
+	}
+	pool := NewWarmPool(context.Background(), 1, time.Minute, []net.Destination{dest}, dialTCP, clock)
+	defer pool.Close()
+
+	waitFor(t, func() bool { return pool.Len(dest) == 1 && server.conn(0) != nil })
+
+	access.Lock()
+	now = now.Add(2 * time.Minute)
+	access.Unlock()
+	if conn := pool.Get(dest); isConnOf(conn, server.conn(0)) {
+		t.Fatal("expected the stale connection to be discarded")
+	}
+	var b [1]byte
+	if _, err := server.conn(0).Read(b[:]); err != io.EOF {
+		t.Error("expected the stale connection to be closed, but got ", err)
+	}
+}
+
+func TestWarmPoolDiscardDead(t *testing.T) {
+	server := newWarmServer(t)
+	dest := server.dest()
+	pool := NewWarmPool(context.Background(), 1, time.Minute, []net.Destination{dest}, dialTCP, nil)
+	defer pool.Close()
+
+	waitFor(t, func() bool { return pool.Len(dest) == 1 && server.conn(0) != nil })
+
+	server.conn(0).Close()
+	// Wait for the FIN to arrive.
+	time.Sleep(100 * time.Millisecond)
+	if conn := pool.Get(dest); isConnOf(conn, server.conn(0)) {
+		t.Fatal("expected the connection closed by the peer to be discarded")
+	}
+}
+
+func TestWarmPoolClose(t *testing.T) {
+	server := newWarmServer(t)
+	dest := server.dest()
+	pool := NewWarmPool(context.Background(), 2, time.Minute, []net.Destination{dest}, dialTCP, nil)
+
+	waitFor(t, func() bool { return pool.Len(dest) == 2 })
+
+	common.Must(pool.Close())
+	if pool.Len(dest) != 0 {
+		t.Error("expected no idle connection after close")
+	}
+	if conn := pool.Get(dest); conn != nil {
+		t.Error("expected no warm connection after close")
+	}
+}
+
+func TestWarmPoolOtherDestination(t *testing.T) {
+	server := newWarmServer(t)
+	other := newWarmServer(t)
+	pool := NewWarmPool(context.Background(), 1, time.Minute, []net.Destination{server.dest()}, dialTCP, nil)
+	defer pool.Close()
+
+	waitFor(t, func() bool { return pool.Len(server.dest()) == 1 })
+	if conn := pool.Get(other.dest()); conn != nil {
+		t.Fatal("expected no warm connection to a destination not of the pool")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if pool.Len(other.dest()) != 0 || other.conn(0) != nil {
+		t.Error("expected no connection dialed to a destination not of the pool")
+	}
+}
@@ -5,10 +5,17 @@ package router
 
 import (
 	"context"
+	"regexp"
+	"sync"
+	"time"
 
+	core "github.com/v2fly/v2ray-core/v4"
+	"github.com/v2fly/v2ray-core/v4/app/observatory"
 	"github.com/v2fly/v2ray-core/v4/common/dice"
 	"github.com/v2fly/v2ray-core/v4/features/extension"
 	"github.com/v2fly/v2ray-core/v4/features/outbound"
+	"github.com/v2fly/v2ray-core/v4/features/routing"
+	"github.com/v2fly/v2ray-core/v4/features/stats"
 )
 
 type BalancingStrategy interface {
@@ -27,28 +34,537 @@ func (s *RandomStrategy) PickOutbound(tags []string) string {
 }
 
 type Balancer struct {
-	selectors []string
-	strategy  BalancingStrategy
-	ohm       outbound.Manager
+	tag      string
+	strategy BalancingStrategy
+	ohm      outbound.Manager
+	ctx      context.Context
+
+	access        sync.RWMutex
+	selectors     []string
+	selectorMatch BalancingRule_SelectorMatch
+	patterns      []*regexp.Regexp
+	drainPeriod   time.Duration
+	draining      map[string]*time.Timer
+	onDrained     func(tag string)
+	onPick        func(candidates []string, chosen string)
+
+	affinity    *AffinityTable
+	maxFailures uint32
+
+	warmPoolSize    int
+	warmPoolMaxIdle time.Duration
+	warmAccess      sync.Mutex
+	warmed          map[string]outbound.Handler
+
+	observatory lazyObservatory
+
+	// Dead outbounds cached for a version of the observation.
+	deadAccess  sync.Mutex
+	deadCached  bool
+	deadVersion uint64
+	dead        map[string]bool
+
+	metricsOnce sync.Once
+	metrics     *BalancerStats
 }
 
+// PickOutbound returns the tag of the outbound the connection should be sent to.
 func (b *Balancer) PickOutbound() (string, error) {
+	tags, err := b.PickOutbounds()
+	if err != nil {
+		return "", err
+	}
+	return tags[0], nil
+}
+
+// PickOutboundExcluding is like PickOutbound, but never picks any outbound in
+// exclude, e.g. those already tried for the connection. The balancing strategy
+// runs over the remaining outbounds only.
+func (b *Balancer) PickOutboundExcluding(exclude []string) (string, error) {
+	tags, err := b.pickOutbounds("", exclude)
+	if err != nil {
+		return "", err
+	}
+	return tags[0], nil
+}
+
+// PickOutbounds returns tags of all selected outbounds as an ordered list of
+// candidates. The outbound chosen by the balancing strategy comes first,
+// followed by the other healthy outbounds. Outbounds reported dead by the
+// observatory are put last.
+func (b *Balancer) PickOutbounds() ([]string, error) {
+	return b.pickOutbounds("", nil)
+}
+
+// PickOutboundsFor is like PickOutbounds, but with session affinity enabled,
+// connections from the same source IP are sent to the same outbound as long
+// as it is healthy, until the affinity expires.
+func (b *Balancer) PickOutboundsFor(ctx routing.Context) ([]string, error) {
+	var key string
+	if b.affinity != nil {
+		if ips := ctx.GetSourceIPs(); len(ips) > 0 {
+			key = ips[0].String()
+		}
+	}
+	return b.pickOutbounds(key, nil)
+}
+
+func (b *Balancer) pickOutbounds(affinityKey string, exclude []string) ([]string, error) {
 	hs, ok := b.ohm.(outbound.HandlerSelector)
 	if !ok {
-		return "", newError("outbound.Manager is not a HandlerSelector")
+		return nil, newError("outbound.Manager is not a HandlerSelector")
 	}
-	tags := hs.Select(b.selectors)
+	metrics := b.getMetrics()
+	b.access.RLock()
+	tags := b.selectOutbounds(hs)
+	onPick := b.onPick
+	b.access.RUnlock()
 	if len(tags) == 0 {
-		return "", newError("no available outbounds selected")
+		return nil, newError("no available outbounds selected")
 	}
-	tag := b.strategy.PickOutbound(tags)
+	if len(exclude) > 0 {
+		tags = excludeTags(tags, exclude)
+		if len(tags) == 0 {
+			return nil, newError("all selected outbounds are excluded: ", exclude)
+		}
+	}
+
+	dead := b.getDeadOutbounds()
+	alive := make([]string, 0, len(tags))
+	var unhealthy []string
+	for _, tag := range tags {
+		if dead[tag] {
+			unhealthy = append(unhealthy, tag)
+		} else {
+			alive = append(alive, tag)
+		}
+	}
+	if len(alive) == 0 {
+		alive, unhealthy = unhealthy, nil
+	}
+
+	tag := b.pickAffinity(affinityKey, alive)
 	if tag == "" {
-		return "", newError("balancing strategy returns empty tag")
+		tag = b.strategy.PickOutbound(alive)
+		if tag == "" {
+			return nil, newError("balancing strategy returns empty tag")
+		}
+		if b.affinity != nil && affinityKey != "" {
+			b.affinity.Set(affinityKey, tag)
+		}
+	}
+
+	metrics.RecordPick(tag)
+	notifyPick(onPick, alive, tag)
+	b.warm(tag)
+
+	candidates := make([]string, 0, len(tags))
+	candidates = append(candidates, tag)
+	for _, t := range alive {
+		if t != tag {
+			candidates = append(candidates, t)
+		}
+	}
+	candidates = append(candidates, unhealthy...)
+	return candidates, nil
+}
+
+// warm enables the warm pool of the outbound, if configured and supported.
+// Each handler is configured once, as SetWarmPool merges settings of all
+// balancers sharing the handler.
+func (b *Balancer) warm(tag string) {
+	if b.warmPoolSize <= 0 {
+		return
+	}
+	handler := b.ohm.GetHandler(tag)
+	if handler == nil {
+		return
+	}
+
+	b.warmAccess.Lock()
+	if b.warmed[tag] == handler {
+		b.warmAccess.Unlock()
+		return
+	}
+	if b.warmed == nil {
+		b.warmed = make(map[string]outbound.Handler)
+	}
+	b.warmed[tag] = handler
+	b.warmAccess.Unlock()
+
+	if w, ok := handler.(outbound.Warmer); ok {
+		w.SetWarmPool(b.warmPoolSize, b.warmPoolMaxIdle)
+	}
+}
+
+// excludeTags returns tags not in exclude.
+func excludeTags(tags []string, exclude []string) []string {
+	excluded := make(map[string]bool, len(exclude))
+	for _, tag := range exclude {
+		excluded[tag] = true
+	}
+	remaining := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !excluded[tag] {
+			remaining = append(remaining, tag)
+		}
+	}
+	return remaining
+}
+
+// SetOnPick sets a callback invoked on every balancing decision, with the
+// healthy outbounds the decision is made among and the chosen one. It is
+// called synchronously without any lock held, so it should return quickly.
+// Nil disables the callback.
+func (b *Balancer) SetOnPick(onPick func(candidates []string, chosen string)) {
+	b.access.Lock()
+	defer b.access.Unlock()
+	b.onPick = onPick
+}
+
+// notifyPick calls onPick, if not nil, with a copy of the candidates.
+func notifyPick(onPick func(candidates []string, chosen string), candidates []string, chosen string) {
+	if onPick == nil {
+		return
+	}
+	onPick(append([]string(nil), candidates...), chosen)
+}
+
+// pickAffinity returns the outbound the key is pinned to, if it is one of
+// the candidates.
+func (b *Balancer) pickAffinity(key string, candidates []string) string {
+	if b.affinity == nil || key == "" {
+		return ""
+	}
+	tag, found := b.affinity.Get(key)
+	if !found {
+		return ""
+	}
+	for _, candidate := range candidates {
+		if candidate == tag {
+			return tag
+		}
+	}
+	return ""
+}
+
+// SetAffinityTable sets the table for session affinity. It must be called
+// before the balancer is in use.
+func (b *Balancer) SetAffinityTable(t *AffinityTable) {
+	b.affinity = t
+}
+
+// selectOutbounds returns tags of outbounds matching the selectors. Callers
+// must hold the access lock.
+func (b *Balancer) selectOutbounds(hs outbound.HandlerSelector) []string {
+	if b.patterns == nil {
+		return hs.Select(b.selectors)
+	}
+
+	// An empty prefix selects all outbounds, which are then filtered by patterns.
+	all := hs.Select([]string{""})
+	tags := make([]string, 0, len(all))
+	for _, tag := range all {
+		for _, pattern := range b.patterns {
+			if pattern.MatchString(tag) {
+				tags = append(tags, tag)
+				break
+			}
+		}
+	}
+	return tags
+}
+
+// UpdateSelectors replaces the selectors of the balancer. Outbounds no longer
+// selected are drained: the balancer picks them no more, and reports them to
+// the OnDrained callback after the drain grace period, unless selected again
+// in the meantime. Nothing is reported if the grace period is zero.
+func (b *Balancer) UpdateSelectors(selectors []string) error {
+	hs, ok := b.ohm.(outbound.HandlerSelector)
+	if !ok {
+		return newError("outbound.Manager is not a HandlerSelector")
+	}
+
+	b.access.Lock()
+	defer b.access.Unlock()
+
+	patterns, err := compileSelectors(b.selectorMatch, selectors)
+	if err != nil {
+		return err
+	}
+	oldTags := b.selectOutbounds(hs)
+	b.selectors = selectors
+	b.patterns = patterns
+	newTags := b.selectOutbounds(hs)
+
+	selected := make(map[string]bool, len(newTags))
+	for _, tag := range newTags {
+		selected[tag] = true
+		if timer, found := b.draining[tag]; found {
+			timer.Stop()
+			delete(b.draining, tag)
+		}
 	}
-	return tag, nil
+	if b.drainPeriod <= 0 {
+		return nil
+	}
+	for _, tag := range oldTags {
+		if selected[tag] || b.draining[tag] != nil {
+			continue
+		}
+		if b.draining == nil {
+			b.draining = make(map[string]*time.Timer)
+		}
+		b.draining[tag] = b.startDrain(tag)
+	}
+	return nil
+}
+
+func (b *Balancer) startDrain(tag string) *time.Timer {
+	newError("draining outbound ", tag, " in ", b.drainPeriod).AtInfo().WriteToLog()
+	var timer *time.Timer
+	timer = time.AfterFunc(b.drainPeriod, func() {
+		b.access.Lock()
+		if b.draining[tag] != timer {
+			b.access.Unlock()
+			return
+		}
+		delete(b.draining, tag)
+		onDrained := b.onDrained
+		b.access.Unlock()
+
+		newError("drained outbound ", tag).AtInfo().WriteToLog()
+		if onDrained != nil {
+			onDrained(tag)
+		}
+	})
+	return timer
+}
+
+// SetOnDrained sets a callback invoked with the tag of each outbound whose
+// drain grace period has elapsed. The outbound may still be in use elsewhere,
+// e.g. by other balancers. Nil disables the callback.
+func (b *Balancer) SetOnDrained(onDrained func(tag string)) {
+	b.access.Lock()
+	defer b.access.Unlock()
+	b.onDrained = onDrained
+}
+
+// references returns whether the outbound is selected or being drained by
+// the balancer.
+func (b *Balancer) references(hs outbound.HandlerSelector, tag string) bool {
+	b.access.RLock()
+	defer b.access.RUnlock()
+	if b.draining[tag] != nil {
+		return true
+	}
+	for _, selected := range b.selectOutbounds(hs) {
+		if selected == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// DrainingOutbounds returns tags of outbounds being drained.
+func (b *Balancer) DrainingOutbounds() []string {
+	b.access.RLock()
+	defer b.access.RUnlock()
+
+	tags := make([]string, 0, len(b.draining))
+	for tag := range b.draining {
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// lazyObservatory is the observatory set explicitly, or else that of the V2Ray
+// instance in the context, looked up on first use.
+type lazyObservatory struct {
+	once        sync.Once
+	observatory extension.Observatory
+}
+
+// set sets the observatory. It must be called before get.
+func (l *lazyObservatory) set(o extension.Observatory) {
+	l.observatory = o
+}
+
+// get returns the observatory, or nil if none is available.
+func (l *lazyObservatory) get(ctx context.Context) extension.Observatory {
+	l.once.Do(func() {
+		if l.observatory != nil || ctx == nil {
+			return
+		}
+		if v := core.FromContext(ctx); v != nil {
+			if o, ok := v.GetFeature(extension.ObservatoryType()).(extension.Observatory); ok {
+				l.observatory = o
+			}
+		}
+	})
+	return l.observatory
+}
+
+// observeStatus returns status of outbounds in the observation of o, or nil if
+// it is not available.
+func observeStatus(ctx context.Context, o extension.Observatory) []*observatory.OutboundStatus {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	observeReport, err := o.GetObservation(ctx)
+	if err != nil {
+		newError("cannot get observe report").Base(err).WriteToLog()
+		return nil
+	}
+	result, ok := observeReport.(*observatory.ObservationResult)
+	if !ok {
+		return nil
+	}
+	return result.Status
+}
+
+// activeConnections returns the number of active connections of the outbound,
+// or zero if it doesn't count them.
+func activeConnections(ohm outbound.Manager, tag string) int64 {
+	if ohm == nil {
+		return 0
+	}
+	if counter, ok := ohm.GetHandler(tag).(outbound.ConnectionCounter); ok {
+		return counter.ActiveConnections()
+	}
+	return 0
+}
+
+// pickWeighted returns one of tags randomly, each with a chance proportional
+// to its weight. If all weights are zero, each has the same chance.
+func pickWeighted(tags []string, weights []float64) string {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		return tags[dice.Roll(len(tags))]
+	}
+
+	r := float64(dice.Roll(1<<30)) / (1 << 30) * total
+	for i, w := range weights {
+		if r < w {
+			return tags[i]
+		}
+		r -= w
+	}
+	return tags[len(tags)-1]
+}
+
+// getDeadOutbounds returns the set of outbounds known to be dead by the
+// observatory, see outboundDead. It is empty if no observatory is available.
+// If the observatory implements extension.ObservationVersioner, the set is
+// computed once per version of the observation, i.e. once per probe. The
+// returned map must not be modified.
+func (b *Balancer) getDeadOutbounds() map[string]bool {
+	o := b.observatory.get(b.ctx)
+	if o == nil {
+		return nil
+	}
+
+	versioner, versioned := o.(extension.ObservationVersioner)
+	if !versioned {
+		return b.observeDeadOutbounds(o)
+	}
+	version := versioner.ObservationVersion()
+	b.deadAccess.Lock()
+	if b.deadCached && b.deadVersion == version {
+		dead := b.dead
+		b.deadAccess.Unlock()
+		return dead
+	}
+	b.deadAccess.Unlock()
+
+	dead := b.observeDeadOutbounds(o)
+	b.deadAccess.Lock()
+	b.deadCached = true
+	b.deadVersion = version
+	b.dead = dead
+	b.deadAccess.Unlock()
+	return dead
+}
+
+// observeDeadOutbounds gets the observation of o and returns the set of
+// outbounds dead in it.
+func (b *Balancer) observeDeadOutbounds(o extension.Observatory) map[string]bool {
+	dead := make(map[string]bool)
+	for _, status := range observeStatus(b.ctx, o) {
+		if outboundDead(status, b.maxFailures) {
+			dead[status.OutboundTag] = true
+		}
+	}
+	return dead
+}
+
+// outboundDead returns whether the outbound is considered dead, that is, its
+// last maxFailures probes failed, or its last probe failed if maxFailures is
+// zero.
+func outboundDead(status *observatory.OutboundStatus, maxFailures uint32) bool {
+	if maxFailures == 0 {
+		return !status.Alive
+	}
+	return status.ConsecutiveFailures >= maxFailures
+}
+
+// SetObservatory sets the observatory consulted for outbound health. If not
+// set, the observatory of the V2Ray instance in the injected context is used.
+// It must be called before the balancer is in use.
+func (b *Balancer) SetObservatory(o extension.Observatory) {
+	b.observatory.set(o)
+	if receiver, ok := b.strategy.(observatoryReceiver); ok {
+		receiver.SetObservatory(o)
+	}
+}
+
+// getMetrics returns the metrics of the balancer, or nil if no stats manager
+// is configured. If not set, the stats manager of the V2Ray instance in the
+// injected context is used.
+func (b *Balancer) getMetrics() *BalancerStats {
+	b.metricsOnce.Do(func() {
+		if b.metrics != nil || b.ctx == nil {
+			return
+		}
+		if v := core.FromContext(b.ctx); v != nil {
+			if m, ok := v.GetFeature(stats.ManagerType()).(stats.Manager); ok {
+				b.setMetrics(NewBalancerStats(m, b.tag))
+			}
+		}
+	})
+	return b.metrics
+}
+
+// SetStatsManager sets the stats manager to record metrics of the balancer
+// in, see BalancerStats. If not set, the stats manager of the V2Ray instance
+// in the injected context is used. It must be called before the balancer is
+// in use.
+func (b *Balancer) SetStatsManager(m stats.Manager) {
+	b.setMetrics(NewBalancerStats(m, b.tag))
+}
+
+func (b *Balancer) setMetrics(metrics *BalancerStats) {
+	b.metrics = metrics
+	if receiver, ok := b.strategy.(metricsReceiver); ok {
+		receiver.SetMetrics(metrics)
+	}
+}
+
+// metricsReceiver is implemented by strategies recording metrics.
+type metricsReceiver interface {
+	SetMetrics(*BalancerStats)
+}
+
+// observatoryReceiver is implemented by strategies that consult the observatory.
+type observatoryReceiver interface {
+	SetObservatory(extension.Observatory)
 }
 
 func (b *Balancer) InjectContext(ctx context.Context) {
+	b.ctx = ctx
 	if contextReceiver, ok := b.strategy.(extension.ContextReceiver); ok {
 		contextReceiver.InjectContext(ctx)
 	}
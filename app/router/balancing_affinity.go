@@ -0,0 +1,88 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"sync"
+	"time"
+)
+
+// AffinityTable pins keys, e.g. source IPs, to outbound tags for a period of
+// time. It is safe for concurrent use.
+type AffinityTable struct {
+	ttl time.Duration
+	now func() time.Time
+
+	access    sync.Mutex
+	entries   map[string]affinityEntry
+	nextSweep time.Time
+}
+
+type affinityEntry struct {
+	tag    string
+	expire time.Time
+}
+
+// NewAffinityTable creates a new AffinityTable whose entries expire after ttl.
+// If now is nil, time.Now is used.
+func NewAffinityTable(ttl time.Duration, now func() time.Time) *AffinityTable {
+	if now == nil {
+		now = time.Now
+	}
+	return &AffinityTable{
+		ttl:     ttl,
+		now:     now,
+		entries: make(map[string]affinityEntry),
+	}
+}
+
+// Get returns the tag the key is pinned to, if not expired.
+func (t *AffinityTable) Get(key string) (string, bool) {
+	t.access.Lock()
+	defer t.access.Unlock()
+
+	entry, found := t.entries[key]
+	if !found {
+		return "", false
+	}
+	if !t.now().Before(entry.expire) {
+		delete(t.entries, key)
+		return "", false
+	}
+	return entry.tag, true
+}
+
+// Set pins the key to the tag for the TTL of the table.
+func (t *AffinityTable) Set(key string, tag string) {
+	t.access.Lock()
+	defer t.access.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+	t.entries[key] = affinityEntry{
+		tag:    tag,
+		expire: now.Add(t.ttl),
+	}
+}
+
+// Len returns the number of entries, including expired ones not evicted yet.
+func (t *AffinityTable) Len() int {
+	t.access.Lock()
+	defer t.access.Unlock()
+	return len(t.entries)
+}
+
+// sweep evicts expired entries, at most once per TTL. Callers must hold the
+// access lock.
+func (t *AffinityTable) sweep(now time.Time) {
+	if now.Before(t.nextSweep) {
+		return
+	}
+	t.nextSweep = now.Add(t.ttl)
+	for key, entry := range t.entries {
+		if !now.Before(entry.expire) {
+			delete(t.entries, key)
+		}
+	}
+}
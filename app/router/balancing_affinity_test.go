@@ -0,0 +1,44 @@
+package router_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/v2fly/v2ray-core/v4/app/router"
+)
+
+func TestAffinityTableEviction(t *testing.T) {
+	now := time.Unix(10000, 0)
+	table := NewAffinityTable(time.Minute, func() time.Time { return now })
+
+	table.Set("k1", "a")
+	table.Set("k2", "b")
+	if tag, found := table.Get("k1"); !found || tag != "a" {
+		t.Error("expect k1 pinned to a, but got ", tag, found)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, found := table.Get("k1"); found {
+		t.Error("expect k1 expired")
+	}
+	table.Set("k3", "c")
+	if n := table.Len(); n != 1 {
+		t.Error("expect expired entries evicted, but got ", n, " entries")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprint(i, "-", j)
+				table.Set(key, "a")
+				table.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,88 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/features/stats"
+)
+
+// rttBuckets are the upper bounds of buckets of the RTT histogram.
+var rttBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+}
+
+// BalancerStats records metrics of a balancer as counters of the stats
+// manager, named with the prefix "routing>>>balancer>>>{tag}>>>". The counter
+// "pick>>>{outbound}" is the number of times the outbound is picked. The RTT
+// of successful probes of the outbound is recorded in a histogram, where the
+// counter "rtt>>>{outbound}>>>le_{bound}ms" is the number of probes with RTT
+// not exceeding the bound, cumulative like Prometheus histograms,
+// "rtt>>>{outbound}>>>le_inf" is the number of all probes, and
+// "rtt>>>{outbound}>>>sum_ms" is their sum of RTT in milliseconds.
+//
+// A nil BalancerStats records nothing.
+type BalancerStats struct {
+	manager stats.Manager
+	prefix  string
+}
+
+// NewBalancerStats creates a new BalancerStats of the balancer with the tag.
+// It returns nil if the stats manager is not configured.
+func NewBalancerStats(manager stats.Manager, tag string) *BalancerStats {
+	if manager == nil {
+		return nil
+	}
+	if _, noop := manager.(stats.NoopManager); noop {
+		return nil
+	}
+	return &BalancerStats{
+		manager: manager,
+		prefix:  "routing>>>balancer>>>" + tag + ">>>",
+	}
+}
+
+// RecordPick counts a pick of the outbound.
+func (s *BalancerStats) RecordPick(outbound string) {
+	if s == nil {
+		return
+	}
+	s.add("pick>>>"+outbound, 1)
+}
+
+// RecordRTT records the RTT of a successful probe of the outbound into its
+// histogram.
+func (s *BalancerStats) RecordRTT(outbound string, rtt time.Duration) {
+	if s == nil {
+		return
+	}
+	prefix := "rtt>>>" + outbound + ">>>"
+	for _, bound := range rttBuckets {
+		if rtt <= bound {
+			s.add(prefix+"le_"+strconv.FormatInt(bound.Milliseconds(), 10)+"ms", 1)
+		}
+	}
+	s.add(prefix+"le_inf", 1)
+	s.add(prefix+"sum_ms", rtt.Milliseconds())
+}
+
+func (s *BalancerStats) add(name string, delta int64) {
+	name = s.prefix + name
+	c, err := stats.GetOrRegisterCounter(s.manager, name)
+	if err != nil {
+		// Registered concurrently by another pick.
+		c = s.manager.GetCounter(name)
+	}
+	if c != nil {
+		c.Add(delta)
+	}
+}
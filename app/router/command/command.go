@@ -10,8 +10,10 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 
 	core "github.com/v2fly/v2ray-core/v4"
+	"github.com/v2fly/v2ray-core/v4/app/router"
 	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/features/routing"
 	"github.com/v2fly/v2ray-core/v4/features/stats"
@@ -35,6 +37,9 @@ func (s *routingServer) TestRoute(ctx context.Context, request *TestRouteRequest
 	if request.RoutingContext == nil {
 		return nil, newError("Invalid routing request.")
 	}
+	if request.Explain {
+		return s.explainRoute(request.RoutingContext)
+	}
 	route, err := s.router.PickRoute(AsRoutingContext(request.RoutingContext))
 	if err != nil {
 		return nil, err
@@ -46,6 +51,72 @@ func (s *routingServer) TestRoute(ctx context.Context, request *TestRouteRequest
 	return AsProtobufMessage(request.FieldSelectors)(route), nil
 }
 
+// routeExplainer is implemented by routers that can explain routing decisions.
+type routeExplainer interface {
+	ExplainRoute(ctx routing.Context) []*router.RuleTrace
+}
+
+func (s *routingServer) explainRoute(msg *RoutingContext) (*RoutingContext, error) {
+	explainer, ok := s.router.(routeExplainer)
+	if !ok {
+		return nil, newError("router doesn't support explaining routes")
+	}
+	traces := explainer.ExplainRoute(AsRoutingContext(msg))
+
+	result := proto.Clone(msg).(*RoutingContext)
+	result.OutboundTag = ""
+	result.OutboundGroupTags = nil
+	result.RuleTraces = make([]*RuleTrace, 0, len(traces))
+	for _, trace := range traces {
+		result.RuleTraces = append(result.RuleTraces, &RuleTrace{
+			Index:       int32(trace.Index),
+			RuleTag:     trace.RuleTag,
+			OutboundTag: trace.OutboundTag,
+			BalancerTag: trace.BalancerTag,
+			ResolvedIP:  trace.ResolvedIP,
+			Matched:     trace.Matched,
+			Reason:      trace.Reason,
+		})
+		if trace.Matched {
+			result.OutboundTag = trace.OutboundTag
+		}
+	}
+	return result, nil
+}
+
+// geoDataReloader is implemented by routers that can reload geo data.
+type geoDataReloader interface {
+	ReloadGeoData() error
+}
+
+func (s *routingServer) ReloadGeoData(ctx context.Context, request *ReloadGeoDataRequest) (*ReloadGeoDataResponse, error) {
+	reloader, ok := s.router.(geoDataReloader)
+	if !ok {
+		return nil, newError("router doesn't support reloading geo data")
+	}
+	if err := reloader.ReloadGeoData(); err != nil {
+		return nil, err
+	}
+	return &ReloadGeoDataResponse{}, nil
+}
+
+// balancerSelectorsUpdater is implemented by routers that can update outbound
+// selectors of balancers.
+type balancerSelectorsUpdater interface {
+	UpdateBalancerSelectors(tag string, selectors []string) error
+}
+
+func (s *routingServer) UpdateBalancerSelectors(ctx context.Context, request *UpdateBalancerSelectorsRequest) (*UpdateBalancerSelectorsResponse, error) {
+	updater, ok := s.router.(balancerSelectorsUpdater)
+	if !ok {
+		return nil, newError("router doesn't support updating balancers")
+	}
+	if err := updater.UpdateBalancerSelectors(request.BalancerTag, request.Selectors); err != nil {
+		return nil, err
+	}
+	return &UpdateBalancerSelectorsResponse{}, nil
+}
+
 func (s *routingServer) SubscribeRoutingStats(request *SubscribeRoutingStatsRequest, stream RoutingService_SubscribeRoutingStatsServer) error {
 	if s.routingStats == nil {
 		return newError("Routing statistics not enabled.")
@@ -20,6 +20,8 @@ const _ = grpc.SupportPackageIsVersion7
 type RoutingServiceClient interface {
 	SubscribeRoutingStats(ctx context.Context, in *SubscribeRoutingStatsRequest, opts ...grpc.CallOption) (RoutingService_SubscribeRoutingStatsClient, error)
 	TestRoute(ctx context.Context, in *TestRouteRequest, opts ...grpc.CallOption) (*RoutingContext, error)
+	ReloadGeoData(ctx context.Context, in *ReloadGeoDataRequest, opts ...grpc.CallOption) (*ReloadGeoDataResponse, error)
+	UpdateBalancerSelectors(ctx context.Context, in *UpdateBalancerSelectorsRequest, opts ...grpc.CallOption) (*UpdateBalancerSelectorsResponse, error)
 }
 
 type routingServiceClient struct {
@@ -71,12 +73,32 @@ func (c *routingServiceClient) TestRoute(ctx context.Context, in *TestRouteReque
 	return out, nil
 }
 
+func (c *routingServiceClient) ReloadGeoData(ctx context.Context, in *ReloadGeoDataRequest, opts ...grpc.CallOption) (*ReloadGeoDataResponse, error) {
+	out := new(ReloadGeoDataResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.router.command.RoutingService/ReloadGeoData", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingServiceClient) UpdateBalancerSelectors(ctx context.Context, in *UpdateBalancerSelectorsRequest, opts ...grpc.CallOption) (*UpdateBalancerSelectorsResponse, error) {
+	out := new(UpdateBalancerSelectorsResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.router.command.RoutingService/UpdateBalancerSelectors", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RoutingServiceServer is the server API for RoutingService service.
 // All implementations must embed UnimplementedRoutingServiceServer
 // for forward compatibility
 type RoutingServiceServer interface {
 	SubscribeRoutingStats(*SubscribeRoutingStatsRequest, RoutingService_SubscribeRoutingStatsServer) error
 	TestRoute(context.Context, *TestRouteRequest) (*RoutingContext, error)
+	ReloadGeoData(context.Context, *ReloadGeoDataRequest) (*ReloadGeoDataResponse, error)
+	UpdateBalancerSelectors(context.Context, *UpdateBalancerSelectorsRequest) (*UpdateBalancerSelectorsResponse, error)
 	mustEmbedUnimplementedRoutingServiceServer()
 }
 
@@ -90,6 +112,12 @@ func (UnimplementedRoutingServiceServer) SubscribeRoutingStats(*SubscribeRouting
 func (UnimplementedRoutingServiceServer) TestRoute(context.Context, *TestRouteRequest) (*RoutingContext, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method TestRoute not implemented")
 }
+func (UnimplementedRoutingServiceServer) ReloadGeoData(context.Context, *ReloadGeoDataRequest) (*ReloadGeoDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadGeoData not implemented")
+}
+func (UnimplementedRoutingServiceServer) UpdateBalancerSelectors(context.Context, *UpdateBalancerSelectorsRequest) (*UpdateBalancerSelectorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateBalancerSelectors not implemented")
+}
 func (UnimplementedRoutingServiceServer) mustEmbedUnimplementedRoutingServiceServer() {}
 
 // UnsafeRoutingServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -142,6 +170,42 @@ func _RoutingService_TestRoute_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RoutingService_ReloadGeoData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadGeoDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).ReloadGeoData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.router.command.RoutingService/ReloadGeoData",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).ReloadGeoData(ctx, req.(*ReloadGeoDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoutingService_UpdateBalancerSelectors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateBalancerSelectorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).UpdateBalancerSelectors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.router.command.RoutingService/UpdateBalancerSelectors",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).UpdateBalancerSelectors(ctx, req.(*UpdateBalancerSelectorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RoutingService_ServiceDesc is the grpc.ServiceDesc for RoutingService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +217,14 @@ var RoutingService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "TestRoute",
 			Handler:    _RoutingService_TestRoute_Handler,
 		},
+		{
+			MethodName: "ReloadGeoData",
+			Handler:    _RoutingService_ReloadGeoData_Handler,
+		},
+		{
+			MethodName: "UpdateBalancerSelectors",
+			Handler:    _RoutingService_UpdateBalancerSelectors_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
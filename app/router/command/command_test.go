@@ -11,6 +11,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/test/bufconn"
 
+	outbound_manager "github.com/v2fly/v2ray-core/v4/app/proxyman/outbound"
 	"github.com/v2fly/v2ray-core/v4/app/router"
 	. "github.com/v2fly/v2ray-core/v4/app/router/command"
 	"github.com/v2fly/v2ray-core/v4/app/stats"
@@ -360,3 +361,92 @@ func TestSerivceTestRoute(t *testing.T) {
 		}
 	}
 }
+
+func TestServiceTestRouteExplain(t *testing.T) {
+	c := stats.NewChannel(&stats.ChannelConfig{
+		SubscriberLimit: 1,
+		BufferSize:      16,
+	})
+	common.Must(c.Start())
+	defer c.Close()
+
+	r := new(router.Router)
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	common.Must(r.Init(context.TODO(), &router.Config{
+		Rule: []*router.RoutingRule{
+			{
+				RuleTag:   "bt",
+				Protocol:  []string{"bittorrent"},
+				TargetTag: &router.RoutingRule_Tag{Tag: "blocked"},
+			},
+			{
+				RuleTag:   "web",
+				PortList:  &net.PortList{Range: []*net.PortRange{{From: 443, To: 443}}},
+				TargetTag: &router.RoutingRule_Tag{Tag: "out"},
+			},
+		},
+	}, mocks.NewDNSClient(mockCtl), mocks.NewOutboundManager(mockCtl)))
+
+	sub, err := c.Subscribe()
+	common.Must(err)
+
+	server := NewRoutingServer(r, c)
+	request := &RoutingContext{TargetDomain: "v2fly.org", TargetPort: 443}
+	result, err := server.TestRoute(context.Background(), &TestRouteRequest{
+		RoutingContext: request,
+		PublishResult:  true,
+		Explain:        true,
+	})
+	common.Must(err)
+
+	expected := &RoutingContext{
+		TargetDomain: "v2fly.org",
+		TargetPort:   443,
+		OutboundTag:  "out",
+		RuleTraces: []*RuleTrace{
+			{Index: 0, RuleTag: "bt", OutboundTag: "blocked", Reason: "protocol not matched"},
+			{Index: 1, RuleTag: "web", OutboundTag: "out", Matched: true},
+		},
+	}
+	if r := cmp.Diff(result, expected, cmpopts.IgnoreUnexported(RoutingContext{}, RuleTrace{})); r != "" {
+		t.Error(r)
+	}
+	if request.OutboundTag != "" || len(request.RuleTraces) != 0 {
+		t.Error("expect request to be unchanged, but got ", request)
+	}
+
+	select {
+	case msg := <-sub:
+		t.Error("expect nothing to be published in explain mode, but got ", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestServiceUpdateBalancerSelectors(t *testing.T) {
+	ohm, err := outbound_manager.New(context.Background(), nil)
+	common.Must(err)
+
+	r := new(router.Router)
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	common.Must(r.Init(context.TODO(), &router.Config{
+		BalancingRule: []*router.BalancingRule{
+			{Tag: "balance", OutboundSelector: []string{"a"}},
+		},
+	}, mocks.NewDNSClient(mockCtl), ohm))
+
+	server := NewRoutingServer(r, nil)
+	_, err = server.UpdateBalancerSelectors(context.Background(), &UpdateBalancerSelectorsRequest{
+		BalancerTag: "balance",
+		Selectors:   []string{"b"},
+	})
+	common.Must(err)
+	_, err = server.UpdateBalancerSelectors(context.Background(), &UpdateBalancerSelectorsRequest{
+		BalancerTag: "missing",
+		Selectors:   []string{"b"},
+	})
+	if err == nil {
+		t.Error("expect error for unknown balancer")
+	}
+}
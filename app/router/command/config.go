@@ -46,7 +46,12 @@ func AsRoutingRoute(r *RoutingContext) routing.Route {
 }
 
 var fieldMap = map[string]func(*RoutingContext, routing.Route){
-	"inbound":        func(s *RoutingContext, r routing.Route) { s.InboundTag = r.GetInboundTag() },
+	"inbound": func(s *RoutingContext, r routing.Route) { s.InboundTag = r.GetInboundTag() },
+	"inbound_transport": func(s *RoutingContext, r routing.Route) {
+		if getter, ok := r.(routing.InboundTransportGetter); ok {
+			s.InboundTransport = getter.GetInboundTransport()
+		}
+	},
 	"network":        func(s *RoutingContext, r routing.Route) { s.Network = r.GetNetwork() },
 	"ip_source":      func(s *RoutingContext, r routing.Route) { s.SourceIPs = mapIPsToBytes(r.GetSourceIPs()) },
 	"ip_target":      func(s *RoutingContext, r routing.Route) { s.TargetIPs = mapIPsToBytes(r.GetTargetIPs()) },
@@ -4,12 +4,16 @@
 package router
 
 import (
+	"math"
+	"strconv"
 	"strings"
+	"sync"
 
 	"go.starlark.net/starlark"
 	"go.starlark.net/syntax"
 
 	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
 	"github.com/v2fly/v2ray-core/v4/common/strmatcher"
 	"github.com/v2fly/v2ray-core/v4/features/routing"
 )
@@ -44,6 +48,84 @@ func (v *ConditionChan) Len() int {
 	return len(*v)
 }
 
+type ConditionOr []Condition
+
+func NewConditionOr() *ConditionOr {
+	var condOr ConditionOr = make([]Condition, 0, 8)
+	return &condOr
+}
+
+func (v *ConditionOr) Add(cond Condition) *ConditionOr {
+	*v = append(*v, cond)
+	return v
+}
+
+// Apply returns true if any of the conditions registered in this group matches.
+func (v *ConditionOr) Apply(ctx routing.Context) bool {
+	for _, cond := range *v {
+		if cond.Apply(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *ConditionOr) Len() int {
+	return len(*v)
+}
+
+// NegateMatcher inverts the result of the condition it wraps.
+type NegateMatcher struct {
+	cond Condition
+}
+
+func NewNegateMatcher(cond Condition) *NegateMatcher {
+	return &NegateMatcher{
+		cond: cond,
+	}
+}
+
+// Apply implements Condition.
+func (m *NegateMatcher) Apply(ctx routing.Context) bool {
+	return !m.cond.Apply(ctx)
+}
+
+// LazyMatcher builds the condition it wraps on first use. It is safe for
+// concurrent use.
+type LazyMatcher struct {
+	once  sync.Once
+	build func() (Condition, error)
+	cond  Condition
+}
+
+// NewLazyMatcher creates a new LazyMatcher of the condition built by build.
+func NewLazyMatcher(build func() (Condition, error)) *LazyMatcher {
+	return &LazyMatcher{
+		build: build,
+	}
+}
+
+// Condition builds the condition if not yet, and returns it. It returns nil if
+// the condition fails to build.
+func (m *LazyMatcher) Condition() Condition {
+	m.once.Do(func() {
+		cond, err := m.build()
+		if err != nil {
+			newError("failed to build condition on first use").Base(err).AtError().WriteToLog()
+		} else {
+			m.cond = cond
+		}
+		m.build = nil
+	})
+	return m.cond
+}
+
+// Apply implements Condition. A condition failing to build never matches.
+func (m *LazyMatcher) Apply(ctx routing.Context) bool {
+	cond := m.Condition()
+	return cond != nil && cond.Apply(ctx)
+}
+
 var matcherTypeMap = map[Domain_Type]strmatcher.Type{
 	Domain_Plain:  strmatcher.Substr,
 	Domain_Regex:  strmatcher.Regex,
@@ -52,6 +134,13 @@ var matcherTypeMap = map[Domain_Type]strmatcher.Type{
 }
 
 func domainToMatcher(domain *Domain) (strmatcher.Matcher, error) {
+	if domain.Type == Domain_Regex {
+		return globalDomainPatternContainer.Add(domain)
+	}
+	return newDomainPatternMatcher(domain)
+}
+
+func newDomainPatternMatcher(domain *Domain) (strmatcher.Matcher, error) {
 	matcherType, f := matcherTypeMap[domain.Type]
 	if !f {
 		return nil, newError("unsupported domain type", domain.Type)
@@ -65,8 +154,97 @@ func domainToMatcher(domain *Domain) (strmatcher.Matcher, error) {
 	return matcher, nil
 }
 
+type domainPatternKey struct {
+	domainType Domain_Type
+	value      string
+}
+
+// DomainPatternContainer shares matchers of domain patterns among rules, so
+// that identical regular expressions are compiled once. It is safe for
+// concurrent use.
+type DomainPatternContainer struct {
+	access   sync.Mutex
+	matchers map[domainPatternKey]strmatcher.Matcher
+}
+
+// Add returns the matcher of the domain pattern, creating it if not found.
+// Labels and attributes of the domain are ignored.
+func (c *DomainPatternContainer) Add(domain *Domain) (strmatcher.Matcher, error) {
+	key := domainPatternKey{
+		domainType: domain.Type,
+		value:      domain.Value,
+	}
+
+	c.access.Lock()
+	defer c.access.Unlock()
+	if m, found := c.matchers[key]; found {
+		return m, nil
+	}
+	m, err := newDomainPatternMatcher(domain)
+	if err != nil {
+		return nil, err
+	}
+	if c.matchers == nil {
+		c.matchers = make(map[domainPatternKey]strmatcher.Matcher)
+	}
+	c.matchers[key] = m
+	return m, nil
+}
+
+// Len returns the number of shared matchers.
+func (c *DomainPatternContainer) Len() int {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return len(c.matchers)
+}
+
+// Reset removes all shared matchers. Matchers in use are kept by their rules.
+func (c *DomainPatternContainer) Reset() {
+	c.access.Lock()
+	defer c.access.Unlock()
+	c.matchers = nil
+}
+
+// globalDomainPatternContainer shares regular expressions of domains among
+// rules of the router. Other types of patterns are cheap to create and not
+// shared.
+var globalDomainPatternContainer DomainPatternContainer
+
+// SharedDomainPatterns returns the number of domain patterns shared among
+// rules.
+func SharedDomainPatterns() int {
+	return globalDomainPatternContainer.Len()
+}
+
+// anchorRegexDomains returns a copy of domains with regular expressions
+// anchored to match whole domain names.
+func anchorRegexDomains(domains []*Domain) []*Domain {
+	anchored := make([]*Domain, len(domains))
+	for i, d := range domains {
+		if d.Type == Domain_Regex {
+			d = &Domain{
+				Type:      Domain_Regex,
+				Value:     "^(?:" + d.Value + ")$",
+				Attribute: d.Attribute,
+				Label:     d.Label,
+			}
+		}
+		anchored[i] = d
+	}
+	return anchored
+}
+
 type DomainMatcher struct {
 	matchers strmatcher.IndexMatcher
+	cache    *DomainMatchCache
+	cacheID  uint32
+
+	// labels maps indices of labeled domains to their labels. It is nil if no
+	// domain is labeled.
+	labels map[uint32]string
+	// Geosite entries searched in place after the domains above, if geodata
+	// is mapped.
+	mapped mappedGeoSiteMatchers
 }
 
 func NewMphMatcherGroup(domains []*Domain) (*DomainMatcher, error) {
@@ -87,23 +265,124 @@ func NewMphMatcherGroup(domains []*Domain) (*DomainMatcher, error) {
 	}, nil
 }
 
+// NewDomainMatcher creates a DomainMatcher. Labels of domains, if any, are
+// recorded in the attributes of matching connections.
 func NewDomainMatcher(domains []*Domain) (*DomainMatcher, error) {
 	g := new(strmatcher.MatcherGroup)
+	var labels map[uint32]string
 	for _, d := range domains {
 		m, err := domainToMatcher(d)
 		if err != nil {
 			return nil, err
 		}
-		g.Add(m)
+		index := g.Add(m)
+		if len(d.Label) > 0 {
+			if labels == nil {
+				labels = make(map[uint32]string)
+			}
+			labels[index] = d.Label
+		}
 	}
 
 	return &DomainMatcher{
 		matchers: g,
+		labels:   labels,
 	}, nil
 }
 
+// withGeoSiteDomains returns domains followed by those of the geosite entries.
+func withGeoSiteDomains(domains []*Domain, sites []*GeoSite) []*Domain {
+	if len(sites) == 0 {
+		return domains
+	}
+	merged := append([]*Domain(nil), domains...)
+	for _, site := range sites {
+		merged = append(merged, site.Domain...)
+	}
+	return merged
+}
+
+// hasLabel returns whether any domain is labeled.
+func hasLabel(domains []*Domain) bool {
+	for _, d := range domains {
+		if len(d.Label) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCache makes the matcher memoize match results in the cache, which is
+// disabled if nil. It must be called before the matcher is in use.
+func (m *DomainMatcher) SetCache(c *DomainMatchCache) {
+	m.cache = c
+	if c != nil {
+		m.cacheID = c.newMatcherID()
+	}
+}
+
+// labeled returns whether any domain is labeled.
+func (m *DomainMatcher) labeled() bool {
+	return m.labels != nil || m.mapped.labeled()
+}
+
 func (m *DomainMatcher) ApplyDomain(domain string) bool {
-	return len(m.matchers.Match(strings.ToLower(domain))) > 0
+	if m.labeled() {
+		matched, _ := m.matchLabel(domain)
+		return matched
+	}
+	domain = strings.ToLower(domain)
+	if m.cache == nil {
+		return m.match(domain)
+	}
+	if matched, found := m.cache.get(m.cacheID, domain); found {
+		return matched.(bool)
+	}
+	matched := m.match(domain)
+	m.cache.put(m.cacheID, domain, matched)
+	return matched
+}
+
+func (m *DomainMatcher) match(domain string) bool {
+	if len(m.matchers.Match(domain)) > 0 {
+		return true
+	}
+	matched, _ := m.mapped.matchLabel(domain, false)
+	return matched
+}
+
+// labelMatch is the cached match result of a matcher with labels.
+type labelMatch struct {
+	matched bool
+	label   string
+}
+
+// matchLabel returns whether the domain matches, and the label of the first
+// labeled domain it matches.
+func (m *DomainMatcher) matchLabel(domain string) (bool, string) {
+	domain = strings.ToLower(domain)
+	if m.cache != nil {
+		if result, found := m.cache.get(m.cacheID, domain); found {
+			result := result.(labelMatch)
+			return result.matched, result.label
+		}
+	}
+	indices := m.matchers.Match(domain)
+	result := labelMatch{matched: len(indices) > 0}
+	first := uint32(0)
+	for _, index := range indices {
+		if label, found := m.labels[index]; found && (first == 0 || index < first) {
+			first = index
+			result.label = label
+		}
+	}
+	if len(result.label) == 0 && len(m.mapped) > 0 {
+		result.matched, result.label = m.mapped.matchLabel(domain, result.matched)
+	}
+	if m.cache != nil {
+		m.cache.put(m.cacheID, domain, result)
+	}
+	return result.matched, result.label
 }
 
 // Apply implements Condition.
@@ -112,18 +391,33 @@ func (m *DomainMatcher) Apply(ctx routing.Context) bool {
 	if len(domain) == 0 {
 		return false
 	}
-	return m.ApplyDomain(domain)
+	if !m.labeled() {
+		return m.ApplyDomain(domain)
+	}
+	matched, label := m.matchLabel(domain)
+	if len(label) > 0 {
+		if setter, ok := ctx.(routing.AttributeSetter); ok {
+			setter.SetAttribute(session.AttributeDomainLabel, label)
+		}
+	}
+	return matched
 }
 
 type MultiGeoIPMatcher struct {
 	matchers []*GeoIPMatcher
+	// GeoIPs searched in place, if geodata is mapped.
+	mapped   []*mappedGeoIPMatcher
 	onSource bool
 }
 
 func NewMultiGeoIPMatcher(geoips []*GeoIP, onSource bool) (*MultiGeoIPMatcher, error) {
+	return newMultiGeoIPMatcher(&globalGeoIPContainer, geoips, onSource)
+}
+
+func newMultiGeoIPMatcher(container *GeoIPMatcherContainer, geoips []*GeoIP, onSource bool) (*MultiGeoIPMatcher, error) {
 	var matchers []*GeoIPMatcher
 	for _, geoip := range geoips {
-		matcher, err := globalGeoIPContainer.Add(geoip)
+		matcher, err := container.Add(geoip)
 		if err != nil {
 			return nil, err
 		}
@@ -152,6 +446,11 @@ func (m *MultiGeoIPMatcher) Apply(ctx routing.Context) bool {
 				return true
 			}
 		}
+		for _, matcher := range m.mapped {
+			if matcher.Match(ip) {
+				return true
+			}
+		}
 	}
 	return false
 }
@@ -181,17 +480,21 @@ type NetworkMatcher struct {
 	list [8]bool
 }
 
+// NewNetworkMatcher creates a new NetworkMatcher matching any of the networks.
 func NewNetworkMatcher(network []net.Network) NetworkMatcher {
 	var matcher NetworkMatcher
 	for _, n := range network {
-		matcher.list[int(n)] = true
+		if n >= 0 && int(n) < len(matcher.list) {
+			matcher.list[int(n)] = true
+		}
 	}
 	return matcher
 }
 
 // Apply implements Condition.
 func (v NetworkMatcher) Apply(ctx routing.Context) bool {
-	return v.list[int(ctx.GetNetwork())]
+	n := ctx.GetNetwork()
+	return n >= 0 && int(n) < len(v.list) && v.list[int(n)]
 }
 
 type UserMatcher struct {
@@ -254,6 +557,40 @@ func (v *InboundTagMatcher) Apply(ctx routing.Context) bool {
 	return false
 }
 
+type TransportProtocolMatcher struct {
+	protocols []string
+}
+
+func NewTransportProtocolMatcher(protocols []string) *TransportProtocolMatcher {
+	pCopy := make([]string, 0, len(protocols))
+	for _, p := range protocols {
+		if len(p) > 0 {
+			pCopy = append(pCopy, p)
+		}
+	}
+	return &TransportProtocolMatcher{
+		protocols: pCopy,
+	}
+}
+
+// Apply implements Condition.
+func (m *TransportProtocolMatcher) Apply(ctx routing.Context) bool {
+	getter, ok := ctx.(routing.InboundTransportGetter)
+	if !ok {
+		return false
+	}
+	transport := getter.GetInboundTransport()
+	if len(transport) == 0 {
+		return false
+	}
+	for _, p := range m.protocols {
+		if p == transport {
+			return true
+		}
+	}
+	return false
+}
+
 type ProtocolMatcher struct {
 	protocols []string
 }
@@ -286,17 +623,99 @@ func (m *ProtocolMatcher) Apply(ctx routing.Context) bool {
 	return false
 }
 
+// DomainFrontingMatcher matches connections by whether their sniffed TLS
+// server name and HTTP host are the same.
+type DomainFrontingMatcher struct {
+	mismatching bool
+}
+
+func NewDomainFrontingMatcher(mode DomainFronting) *DomainFrontingMatcher {
+	return &DomainFrontingMatcher{
+		mismatching: mode == DomainFronting_Mismatching,
+	}
+}
+
+// Apply implements Condition.
+func (m *DomainFrontingMatcher) Apply(ctx routing.Context) bool {
+	attributes := ctx.GetAttributes()
+	sni := normalizeSniffedName(attributes[session.AttributeSniffedSNI])
+	host := normalizeSniffedName(attributes[session.AttributeSniffedHost])
+	if len(sni) == 0 || len(host) == 0 {
+		return false
+	}
+	return (sni != host) == m.mismatching
+}
+
+// Ja3Matcher matches connections by the JA3 fingerprint of their sniffed TLS
+// client hello.
+type Ja3Matcher struct {
+	hashes map[string]bool
+}
+
+func NewJa3Matcher(hashes []string) *Ja3Matcher {
+	m := &Ja3Matcher{
+		hashes: make(map[string]bool, len(hashes)),
+	}
+	for _, hash := range hashes {
+		m.hashes[strings.ToLower(hash)] = true
+	}
+	return m
+}
+
+// Apply implements Condition.
+func (m *Ja3Matcher) Apply(ctx routing.Context) bool {
+	ja3 := ctx.GetAttributes()[session.AttributeSniffedJA3]
+	return len(ja3) > 0 && m.hashes[ja3]
+}
+
+// normalizeSniffedName returns the name in lower case, without port and
+// trailing dot.
+func normalizeSniffedName(name string) string {
+	if h, _, err := net.SplitHostPort(name); err == nil {
+		name = h
+	}
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// IPQueryMatcher matches connections by whether the target is an IP address
+// or a domain.
+type IPQueryMatcher struct {
+	domain bool
+}
+
+func NewIPQueryMatcher(kind RoutingRule_IsIpQuery) *IPQueryMatcher {
+	return &IPQueryMatcher{
+		domain: kind == RoutingRule_DomainOnly,
+	}
+}
+
+// Apply implements Condition.
+func (m *IPQueryMatcher) Apply(ctx routing.Context) bool {
+	if len(ctx.GetTargetDomain()) > 0 {
+		return m.domain
+	}
+	return !m.domain && len(ctx.GetTargetIPs()) > 0
+}
+
 type AttributeMatcher struct {
 	program *starlark.Program
 }
 
+// NewAttributeMatcher creates a new AttributeMatcher from a Starlark
+// expression. Besides attrs, the dict of all attributes as strings, the
+// expression may use nums, the dict of attributes with numeric values, and
+// between(value, low, high), which checks low <= value <= high. For example,
+// "between(nums['content-length'], 1024, 65536)".
 func NewAttributeMatcher(code string) (*AttributeMatcher, error) {
 	starFile, err := syntax.Parse("attr.star", "satisfied=("+code+")", 0)
 	if err != nil {
 		return nil, newError("attr rule").Base(err)
 	}
+	if err := checkAttributeExpr(starFile); err != nil {
+		return nil, newError("attr rule").Base(err)
+	}
 	p, err := starlark.FileProgram(starFile, func(name string) bool {
-		return name == "attrs"
+		return name == "attrs" || name == "nums" || name == "between"
 	})
 	if err != nil {
 		return nil, err
@@ -306,15 +725,100 @@ func NewAttributeMatcher(code string) (*AttributeMatcher, error) {
 	}, nil
 }
 
+// checkAttributeExpr rejects numeric comparisons that would always fail when
+// evaluated.
+func checkAttributeExpr(f *syntax.File) error {
+	var err error
+	syntax.Walk(f, func(n syntax.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *syntax.CallExpr:
+			if fn, ok := n.Fn.(*syntax.Ident); ok && fn.Name == "between" {
+				if len(n.Args) != 3 {
+					err = newError("between() takes exactly 3 arguments, but got ", len(n.Args))
+					return false
+				}
+				for _, arg := range n.Args {
+					if isStringLiteral(arg) {
+						err = newError("between() takes numbers, but got a string")
+						return false
+					}
+				}
+			}
+		case *syntax.BinaryExpr:
+			switch n.Op {
+			case syntax.LT, syntax.GT, syntax.LE, syntax.GE, syntax.EQL, syntax.NEQ:
+				if (isNumsIndex(n.X) && isStringLiteral(n.Y)) || (isStringLiteral(n.X) && isNumsIndex(n.Y)) {
+					err = newError("cannot compare numeric attribute with a string")
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return err
+}
+
+func isNumsIndex(e syntax.Expr) bool {
+	index, ok := e.(*syntax.IndexExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := index.X.(*syntax.Ident)
+	return ok && ident.Name == "nums"
+}
+
+func isStringLiteral(e syntax.Expr) bool {
+	literal, ok := e.(*syntax.Literal)
+	return ok && literal.Token == syntax.STRING
+}
+
+// numericValue returns the attribute value as a Starlark number, or nil if it
+// is not a finite number.
+func numericValue(value string) starlark.Value {
+	value = strings.TrimSpace(value)
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return starlark.MakeInt64(i)
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return starlark.Float(f)
+	}
+	return nil
+}
+
+var attrBetween = starlark.NewBuiltin("between", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var value, low, high starlark.Value
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 3, &value, &low, &high); err != nil {
+		return nil, err
+	}
+	geLow, err := starlark.Compare(syntax.GE, value, low)
+	if err != nil {
+		return nil, err
+	}
+	leHigh, err := starlark.Compare(syntax.LE, value, high)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Bool(geLow && leHigh), nil
+})
+
 // Match implements attributes matching.
 func (m *AttributeMatcher) Match(attrs map[string]string) bool {
 	attrsDict := new(starlark.Dict)
+	numsDict := new(starlark.Dict)
 	for key, value := range attrs {
 		attrsDict.SetKey(starlark.String(key), starlark.String(value))
+		if num := numericValue(value); num != nil {
+			numsDict.SetKey(starlark.String(key), num)
+		}
 	}
 
 	predefined := make(starlark.StringDict)
 	predefined["attrs"] = attrsDict
+	predefined["nums"] = numsDict
+	predefined["between"] = attrBetween
 
 	thread := &starlark.Thread{
 		Name: "matcher",
@@ -0,0 +1,51 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/features/routing"
+)
+
+// DNSResultMatcher matches target domains whose resolved records cross any of
+// the thresholds on the number of addresses and the TTL.
+type DNSResultMatcher struct {
+	minRecordCount int
+	maxTTL         time.Duration
+}
+
+// NewDNSResultMatcher creates a new DNSResultMatcher from the config.
+func NewDNSResultMatcher(result *DnsResult) (*DNSResultMatcher, error) {
+	if result.MinRecordCount == 0 && result.MaxTtl == 0 {
+		return nil, newError("neither record count nor TTL threshold of DNS result is set")
+	}
+	return &DNSResultMatcher{
+		minRecordCount: int(result.MinRecordCount),
+		maxTTL:         time.Duration(result.MaxTtl) * time.Second,
+	}, nil
+}
+
+// Apply implements Condition.
+func (m *DNSResultMatcher) Apply(ctx routing.Context) bool {
+	if len(ctx.GetTargetDomain()) == 0 {
+		return false
+	}
+	ips := ctx.GetTargetIPs()
+	if len(ips) == 0 {
+		return false
+	}
+	if m.minRecordCount > 0 && len(ips) >= m.minRecordCount {
+		return true
+	}
+	if m.maxTTL > 0 {
+		if getter, ok := ctx.(routing.ResolvedTTLGetter); ok {
+			// Zero TTL is unknown rather than expiring.
+			if ttl := getter.GetResolvedTTL(); ttl > 0 && ttl <= m.maxTTL {
+				return true
+			}
+		}
+	}
+	return false
+}
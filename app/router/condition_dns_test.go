@@ -0,0 +1,126 @@
+package router_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	"github.com/v2fly/v2ray-core/v4/features/dns"
+	routing_dns "github.com/v2fly/v2ray-core/v4/features/routing/dns"
+)
+
+// ttlDNSClient resolves domains to a number of addresses of the same TTL.
+type ttlDNSClient struct {
+	records map[string]int
+	ttl     time.Duration
+}
+
+func (*ttlDNSClient) Type() interface{} { return dns.ClientType() }
+func (*ttlDNSClient) Start() error      { return nil }
+func (*ttlDNSClient) Close() error      { return nil }
+
+func (c *ttlDNSClient) LookupIP(domain string) ([]net.IP, error) {
+	ips, _, err := c.LookupIPWithTTL(domain, dns.IPOption{IPv4Enable: true, IPv6Enable: true})
+	return ips, err
+}
+
+func (c *ttlDNSClient) LookupIPWithTTL(domain string, _ dns.IPOption) ([]net.IP, time.Duration, error) {
+	n := c.records[domain]
+	if n == 0 {
+		return nil, 0, dns.ErrEmptyResponse
+	}
+	ips := make([]net.IP, n)
+	for i := range ips {
+		ips[i] = net.IP{10, 0, 0, byte(i + 1)}
+	}
+	return ips, c.ttl, nil
+}
+
+func TestDNSResultMatcher(t *testing.T) {
+	client := &ttlDNSClient{
+		records: map[string]int{
+			"cdn.example.com":    8,
+			"single.example.com": 1,
+		},
+		ttl: 300 * time.Second,
+	}
+
+	testCases := []struct {
+		result *router.DnsResult
+		ttl    time.Duration
+		target net.Destination
+		match  bool
+	}{
+		{
+			result: &router.DnsResult{MinRecordCount: 4},
+			target: net.TCPDestination(net.DomainAddress("cdn.example.com"), 443),
+			match:  true,
+		},
+		{
+			result: &router.DnsResult{MinRecordCount: 4},
+			target: net.TCPDestination(net.DomainAddress("single.example.com"), 443),
+			match:  false,
+		},
+		{
+			result: &router.DnsResult{MaxTtl: 60},
+			ttl:    30 * time.Second,
+			target: net.TCPDestination(net.DomainAddress("single.example.com"), 443),
+			match:  true,
+		},
+		{
+			result: &router.DnsResult{MaxTtl: 60},
+			ttl:    300 * time.Second,
+			target: net.TCPDestination(net.DomainAddress("single.example.com"), 443),
+			match:  false,
+		},
+		{
+			// Unknown TTL doesn't match.
+			result: &router.DnsResult{MaxTtl: 60},
+			target: net.TCPDestination(net.DomainAddress("single.example.com"), 443),
+			match:  false,
+		},
+		{
+			result: &router.DnsResult{MinRecordCount: 4, MaxTtl: 60},
+			ttl:    300 * time.Second,
+			target: net.TCPDestination(net.DomainAddress("cdn.example.com"), 443),
+			match:  true,
+		},
+		{
+			// Unresolved domains don't match.
+			result: &router.DnsResult{MinRecordCount: 1, MaxTtl: 60},
+			ttl:    30 * time.Second,
+			target: net.TCPDestination(net.DomainAddress("unknown.example.com"), 443),
+			match:  false,
+		},
+		{
+			// Neither do IP targets.
+			result: &router.DnsResult{MinRecordCount: 1},
+			target: net.TCPDestination(net.ParseAddress("10.0.0.1"), 443),
+			match:  false,
+		},
+	}
+
+	for _, test := range testCases {
+		matcher, err := router.NewDNSResultMatcher(test.result)
+		common.Must(err)
+		client.ttl = test.ttl
+		ctx := routing_dns.ContextWithDNSClient(withOutbound(&session.Outbound{Target: test.target}), client)
+		if actual := matcher.Apply(ctx); actual != test.match {
+			t.Error("matcher ", test.result, " for ", test.target, " with TTL ", test.ttl, ": expect ", test.match, ", but got ", actual)
+		}
+	}
+
+	// Without resolving, the target domain has no records.
+	matcher, err := router.NewDNSResultMatcher(&router.DnsResult{MinRecordCount: 1})
+	common.Must(err)
+	if matcher.Apply(withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("cdn.example.com"), 443)})) {
+		t.Error("unresolved target matched")
+	}
+
+	if _, err := router.NewDNSResultMatcher(&router.DnsResult{}); err == nil {
+		t.Error("expect error for empty DNS result")
+	}
+}
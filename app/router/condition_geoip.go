@@ -6,6 +6,8 @@ package router
 import (
 	"encoding/binary"
 	"sort"
+	"strings"
+	"sync"
 
 	"github.com/v2fly/v2ray-core/v4/common/net"
 )
@@ -153,55 +155,81 @@ func (m *GeoIPMatcher) match6(ip ipv6) bool {
 
 // Match returns true if the given ip is included by the GeoIP.
 func (m *GeoIPMatcher) Match(ip net.IP) bool {
-	switch len(ip) {
-	case 4:
-		if m.reverseMatch {
-			return !m.match4(binary.BigEndian.Uint32(ip))
-		}
-		return m.match4(binary.BigEndian.Uint32(ip))
-	case 16:
-		if m.reverseMatch {
-			return !m.match6(ipv6{
-				a: binary.BigEndian.Uint64(ip[0:8]),
-				b: binary.BigEndian.Uint64(ip[8:16]),
-			})
-		}
-		return m.match6(ipv6{
-			a: binary.BigEndian.Uint64(ip[0:8]),
-			b: binary.BigEndian.Uint64(ip[8:16]),
-		})
-	default:
+	if len(ip) != 4 && len(ip) != 16 {
 		return false
 	}
+	return m.match(ip) != m.reverseMatch
+}
+
+func (m *GeoIPMatcher) match(ip net.IP) bool {
+	if len(ip) == 4 {
+		return m.match4(binary.BigEndian.Uint32(ip))
+	}
+	// IPv4-mapped IPv6 addresses (::ffff:a.b.c.d) match IPv4 CIDRs as well.
+	if ip4 := ip.To4(); ip4 != nil && m.match4(binary.BigEndian.Uint32(ip4)) {
+		return true
+	}
+	return m.match6(ipv6{
+		a: binary.BigEndian.Uint64(ip[0:8]),
+		b: binary.BigEndian.Uint64(ip[8:16]),
+	})
+}
+
+// geoIPMatcherKey identifies GeoIPMatchers that can be shared.
+type geoIPMatcherKey struct {
+	countryCode  string
+	reverseMatch bool
 }
 
-// GeoIPMatcherContainer is a container for GeoIPMatchers. It keeps unique copies of GeoIPMatcher by country code.
+// GeoIPMatcherContainer is a container for GeoIPMatchers. It keeps unique copies of GeoIPMatcher by country code,
+// so that rules referencing the same country share one immutable CIDR table.
 type GeoIPMatcherContainer struct {
-	matchers []*GeoIPMatcher
+	access   sync.Mutex
+	matchers map[geoIPMatcherKey]*GeoIPMatcher
 }
 
 // Add adds a new GeoIP set into the container.
 // If the country code of GeoIP is not empty, GeoIPMatcherContainer will try to find an existing one, instead of adding a new one.
 func (c *GeoIPMatcherContainer) Add(geoip *GeoIP) (*GeoIPMatcher, error) {
-	if len(geoip.CountryCode) > 0 {
-		for _, m := range c.matchers {
-			if m.countryCode == geoip.CountryCode && m.reverseMatch == geoip.ReverseMatch {
-				return m, nil
-			}
+	c.access.Lock()
+	defer c.access.Unlock()
+
+	countryCode := geoip.CountryCode
+	key := geoIPMatcherKey{
+		countryCode:  strings.ToUpper(countryCode),
+		reverseMatch: geoip.ReverseMatch,
+	}
+	if len(countryCode) > 0 {
+		if m, found := c.matchers[key]; found {
+			return m, nil
 		}
 	}
 
 	m := &GeoIPMatcher{
-		countryCode:  geoip.CountryCode,
+		countryCode:  countryCode,
 		reverseMatch: geoip.ReverseMatch,
 	}
 	if err := m.Init(geoip.Cidr); err != nil {
 		return nil, err
 	}
-	if len(geoip.CountryCode) > 0 {
-		c.matchers = append(c.matchers, m)
+	if len(countryCode) > 0 {
+		if c.matchers == nil {
+			c.matchers = make(map[geoIPMatcherKey]*GeoIPMatcher)
+		}
+		c.matchers[key] = m
 	}
 	return m, nil
 }
 
+// replace replaces matchers in the container with those in other.
+func (c *GeoIPMatcherContainer) replace(other *GeoIPMatcherContainer) {
+	other.access.Lock()
+	matchers := other.matchers
+	other.access.Unlock()
+
+	c.access.Lock()
+	defer c.access.Unlock()
+	c.matchers = matchers
+}
+
 var globalGeoIPContainer GeoIPMatcherContainer
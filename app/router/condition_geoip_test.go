@@ -14,6 +14,7 @@ import (
 	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/common/platform/filesystem"
+	"github.com/v2fly/v2ray-core/v4/common/session"
 )
 
 func init() {
@@ -62,6 +63,33 @@ func TestGeoIPMatcherContainer(t *testing.T) {
 	}
 }
 
+func TestGeoIPMatcherContainerShared(t *testing.T) {
+	container := &router.GeoIPMatcherContainer{}
+	cidrs := []*router.CIDR{{Ip: []byte{10, 0, 0, 0}, Prefix: 8}}
+
+	m, err := container.Add(&router.GeoIP{CountryCode: "CN", Cidr: cidrs})
+	common.Must(err)
+	for i := 0; i < 50; i++ {
+		shared, err := container.Add(&router.GeoIP{CountryCode: "CN", Cidr: cidrs})
+		common.Must(err)
+		if shared != m {
+			t.Fatal("expect matcher to be shared by rules of the same country")
+		}
+	}
+	if lower, _ := container.Add(&router.GeoIP{CountryCode: "cn", Cidr: cidrs}); lower != m {
+		t.Error("expect country codes to be case insensitive")
+	}
+	if reversed, _ := container.Add(&router.GeoIP{CountryCode: "CN", Cidr: cidrs, ReverseMatch: true}); reversed == m {
+		t.Error("expect different matcher for reverse match")
+	}
+
+	c1, _ := container.Add(&router.GeoIP{Cidr: cidrs})
+	c2, _ := container.Add(&router.GeoIP{Cidr: cidrs})
+	if c1 == c2 {
+		t.Error("expect custom geoips without country code not to be shared")
+	}
+}
+
 func TestGeoIPMatcher(t *testing.T) {
 	cidrList := router.CIDRList{
 		{Ip: []byte{0, 0, 0, 0}, Prefix: 8},
@@ -170,6 +198,47 @@ func TestGeoIPReverseMatcher(t *testing.T) {
 	}
 }
 
+func TestGeoIPMatcherIPv4Mapped(t *testing.T) {
+	cidrList := router.CIDRList{
+		{Ip: []byte{192, 168, 0, 0}, Prefix: 16},
+		{Ip: net.ParseAddress("64:ff9b::").IP(), Prefix: 96},
+	}
+	matcher := &router.GeoIPMatcher{}
+	common.Must(matcher.Init(cidrList))
+	reverseMatcher := &router.GeoIPMatcher{}
+	reverseMatcher.SetReverseMatch(true)
+	common.Must(reverseMatcher.Init(cidrList))
+
+	testCases := []struct {
+		Input  string
+		Output bool
+	}{
+		{
+			Input:  "::ffff:192.168.1.1",
+			Output: true,
+		},
+		{
+			Input:  "::ffff:10.0.0.1",
+			Output: false,
+		},
+		{
+			Input:  "64:ff9b::c0a8:101",
+			Output: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		// net.ParseIP returns IPv4 addresses in 16-byte form, as resolvers do.
+		ip := net.ParseIP(testCase.Input)
+		if actual := matcher.Match(ip); actual != testCase.Output {
+			t.Error("expect input", testCase.Input, "to be", testCase.Output, ", but actually", actual)
+		}
+		if actual := reverseMatcher.Match(ip); actual == testCase.Output {
+			t.Error("expect input", testCase.Input, "to be", !testCase.Output, "in reverse match, but actually", actual)
+		}
+	}
+}
+
 func TestGeoIPMatcher4CN(t *testing.T) {
 	ips, err := loadGeoIP("CN")
 	common.Must(err)
@@ -240,3 +309,50 @@ func BenchmarkGeoIPMatcher6US(b *testing.B) {
 		_ = matcher.Match(net.ParseAddress("2001:4860:4860::8888").IP())
 	}
 }
+
+func TestMultiGeoIPMatcherReverseMatch(t *testing.T) {
+	cidrs := []*router.CIDR{
+		{Ip: []byte{10, 0, 0, 0}, Prefix: 8},
+		{Ip: []byte{192, 168, 0, 0}, Prefix: 16},
+	}
+
+	testCases := []struct {
+		Input   string
+		Output  bool
+		Reverse bool
+	}{
+		{
+			Input:  "10.1.2.3",
+			Output: true,
+		},
+		{
+			Input:  "8.8.8.8",
+			Output: false,
+		},
+		{
+			Input:   "10.1.2.3",
+			Output:  false,
+			Reverse: true,
+		},
+		{
+			Input:   "192.168.1.1",
+			Output:  false,
+			Reverse: true,
+		},
+		{
+			Input:   "8.8.8.8",
+			Output:  true,
+			Reverse: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		matcher, err := router.NewMultiGeoIPMatcher([]*router.GeoIP{{Cidr: cidrs, ReverseMatch: testCase.Reverse}}, false)
+		common.Must(err)
+		ctx := withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress(testCase.Input), 80)})
+		actual := matcher.Apply(ctx)
+		if actual != testCase.Output {
+			t.Error("expect input", testCase.Input, "with reverse match", testCase.Reverse, "to be", testCase.Output, ", but actually", actual)
+		}
+	}
+}
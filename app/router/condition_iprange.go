@@ -0,0 +1,68 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+// IPRangeToCIDRs returns the minimal list of CIDRs that covers exactly the
+// IP addresses in range [from, to]. Both ends must be of the same family.
+func IPRangeToCIDRs(from, to net.IP) ([]*CIDR, error) {
+	if from4, to4 := from.To4(), to.To4(); from4 != nil && to4 != nil {
+		from, to = from4, to4
+	} else if len(from) != net.IPv6len || len(to) != net.IPv6len || from4 != nil || to4 != nil {
+		return nil, newError("invalid IP range ", from, "-", to, ": mismatched address families")
+	}
+	if bytes.Compare(from, to) > 0 {
+		return nil, newError("invalid IP range ", from, "-", to, ": start is greater than end")
+	}
+
+	bits := len(from) * 8
+	start := new(big.Int).SetBytes(from)
+	end := new(big.Int).SetBytes(to)
+	one := big.NewInt(1)
+
+	var cidrs []*CIDR
+	for start.Cmp(end) <= 0 {
+		// Size of the largest block aligned at start that doesn't exceed end.
+		size := int(start.TrailingZeroBits())
+		if start.Sign() == 0 {
+			size = bits
+		}
+		for size > 0 {
+			last := new(big.Int).Lsh(one, uint(size))
+			last.Add(last, start).Sub(last, one)
+			if last.Cmp(end) <= 0 {
+				break
+			}
+			size--
+		}
+
+		ip := make([]byte, len(from))
+		start.FillBytes(ip)
+		cidrs = append(cidrs, &CIDR{
+			Ip:     ip,
+			Prefix: uint32(bits - size),
+		})
+
+		start.Add(start, new(big.Int).Lsh(one, uint(size)))
+	}
+	return cidrs, nil
+}
+
+func ipRangesToCIDRs(ranges []*IPRange) ([]*CIDR, error) {
+	var cidrs []*CIDR
+	for _, r := range ranges {
+		rangeCidrs, err := IPRangeToCIDRs(r.From, r.To)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, rangeCidrs...)
+	}
+	return cidrs, nil
+}
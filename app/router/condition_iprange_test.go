@@ -0,0 +1,170 @@
+package router_test
+
+import (
+	gonet "net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+)
+
+func parseCIDRs(cidrs ...string) []*router.CIDR {
+	list := make([]*router.CIDR, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := gonet.ParseCIDR(cidr)
+		common.Must(err)
+		ip := ipNet.IP
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		prefix, _ := ipNet.Mask.Size()
+		list = append(list, &router.CIDR{
+			Ip:     ip,
+			Prefix: uint32(prefix),
+		})
+	}
+	return list
+}
+
+func TestIPRangeToCIDRs(t *testing.T) {
+	testCases := []struct {
+		from   string
+		to     string
+		output []*router.CIDR
+	}{
+		{
+			from: "10.0.0.5",
+			to:   "10.0.0.200",
+			output: parseCIDRs(
+				"10.0.0.5/32",
+				"10.0.0.6/31",
+				"10.0.0.8/29",
+				"10.0.0.16/28",
+				"10.0.0.32/27",
+				"10.0.0.64/26",
+				"10.0.0.128/26",
+				"10.0.0.192/29",
+				"10.0.0.200/32",
+			),
+		},
+		{
+			from:   "192.168.1.1",
+			to:     "192.168.1.1",
+			output: parseCIDRs("192.168.1.1/32"),
+		},
+		{
+			from:   "0.0.0.0",
+			to:     "255.255.255.255",
+			output: parseCIDRs("0.0.0.0/0"),
+		},
+		{
+			from:   "172.16.255.0",
+			to:     "172.17.0.255",
+			output: parseCIDRs("172.16.255.0/24", "172.17.0.0/24"),
+		},
+		{
+			from:   "2001:db8::",
+			to:     "2001:db8::ff",
+			output: parseCIDRs("2001:db8::/120"),
+		},
+		{
+			from:   "2001:db8::ffff",
+			to:     "2001:db8::1:1",
+			output: parseCIDRs("2001:db8::ffff/128", "2001:db8::1:0/127"),
+		},
+		{
+			from:   "::",
+			to:     "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+			output: parseCIDRs("::/0"),
+		},
+	}
+
+	for _, tc := range testCases {
+		cidrs, err := router.IPRangeToCIDRs(net.ParseIP(tc.from), net.ParseIP(tc.to))
+		common.Must(err)
+		if r := cmp.Diff(cidrs, tc.output, protocmp.Transform()); r != "" {
+			t.Error(tc.from, "-", tc.to, ": ", r)
+		}
+	}
+}
+
+func TestIPRangeToCIDRsInvalid(t *testing.T) {
+	testCases := []struct {
+		from string
+		to   string
+	}{
+		{
+			from: "10.0.0.200",
+			to:   "10.0.0.5",
+		},
+		{
+			from: "2001:db8::2",
+			to:   "2001:db8::1",
+		},
+		{
+			from: "10.0.0.1",
+			to:   "2001:db8::1",
+		},
+	}
+
+	for _, tc := range testCases {
+		if _, err := router.IPRangeToCIDRs(net.ParseIP(tc.from), net.ParseIP(tc.to)); err == nil {
+			t.Error("expected error for IP range ", tc.from, "-", tc.to)
+		}
+	}
+}
+
+func TestRoutingRuleIPRange(t *testing.T) {
+	rule := &router.RoutingRule{
+		IpRange: []*router.IPRange{
+			{
+				From: []byte{10, 0, 0, 5},
+				To:   []byte{10, 0, 0, 200},
+			},
+		},
+	}
+	cond, err := rule.BuildCondition()
+	common.Must(err)
+
+	testCases := []struct {
+		input  string
+		output bool
+	}{
+		{
+			input:  "10.0.0.4",
+			output: false,
+		},
+		{
+			input:  "10.0.0.5",
+			output: true,
+		},
+		{
+			input:  "10.0.0.100",
+			output: true,
+		},
+		{
+			input:  "10.0.0.200",
+			output: true,
+		},
+		{
+			input:  "10.0.0.201",
+			output: false,
+		},
+	}
+	for _, tc := range testCases {
+		ctx := withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress(tc.input), 80)})
+		if actual := cond.Apply(ctx); actual != tc.output {
+			t.Error("IP ", tc.input, " expected ", tc.output, " but got ", actual)
+		}
+	}
+
+	rule.IpRange[0].From, rule.IpRange[0].To = rule.IpRange[0].To, rule.IpRange[0].From
+	if _, err := rule.BuildCondition(); err == nil {
+		t.Error("expected error for reversed IP range")
+	}
+}
@@ -0,0 +1,70 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"strings"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/features/routing"
+)
+
+// ProcessResolveFunc returns the executable path of the local process that
+// owns the socket of the given network, address and port.
+type ProcessResolveFunc func(network net.Network, ip net.IP, port net.Port) (string, error)
+
+// ProcessPathMatcher matches the executable path of the process that opened
+// the connection. Connections whose process can't be resolved don't match.
+type ProcessPathMatcher struct {
+	paths   []string
+	resolve ProcessResolveFunc
+}
+
+// NewProcessPathMatcher creates a new ProcessPathMatcher. A path ending with
+// "/" matches all executables under that directory, and other paths match
+// exactly. If resolve is nil, SystemProcessResolve is used.
+func NewProcessPathMatcher(paths []string, resolve ProcessResolveFunc) *ProcessPathMatcher {
+	pathsCopy := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if len(path) > 0 {
+			pathsCopy = append(pathsCopy, path)
+		}
+	}
+	if resolve == nil {
+		resolve = SystemProcessResolve
+	}
+	return &ProcessPathMatcher{
+		paths:   pathsCopy,
+		resolve: resolve,
+	}
+}
+
+func (m *ProcessPathMatcher) matchPath(path string) bool {
+	for _, p := range m.paths {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply implements Condition.
+func (m *ProcessPathMatcher) Apply(ctx routing.Context) bool {
+	network := ctx.GetNetwork()
+	if network != net.Network_TCP && network != net.Network_UDP {
+		return false
+	}
+	port := ctx.GetSourcePort()
+	for _, ip := range ctx.GetSourceIPs() {
+		path, err := m.resolve(network, ip, port)
+		if err != nil {
+			newError("failed to resolve process of ", ip, ":", port).Base(err).AtDebug().WriteToLog()
+			continue
+		}
+		if m.matchPath(path) {
+			return true
+		}
+	}
+	return false
+}
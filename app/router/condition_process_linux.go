@@ -0,0 +1,171 @@
+//go:build !confonly && linux
+// +build !confonly,linux
+
+package router
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+var nativeEndian binary.ByteOrder = func() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+// SystemProcessResolve finds the socket bound to the given address and port in
+// procfs, and returns the executable path of the process owning it. Sockets
+// of processes of other users are only visible to root.
+func SystemProcessResolve(network net.Network, ip net.IP, port net.Port) (string, error) {
+	var tables []string
+	switch network {
+	case net.Network_TCP:
+		tables = []string{"/proc/net/tcp", "/proc/net/tcp6"}
+	case net.Network_UDP:
+		tables = []string{"/proc/net/udp", "/proc/net/udp6"}
+	default:
+		return "", newError("unsupported network: ", network)
+	}
+
+	for _, table := range tables {
+		inode, err := findSocketInode(table, ip, port, network == net.Network_UDP)
+		if err != nil {
+			return "", err
+		}
+		if len(inode) > 0 {
+			return findSocketProcess(inode)
+		}
+	}
+	return "", newError("no socket found for ", ip, ":", port)
+}
+
+// parseProcAddress parses an address of form "0100007F:1F90" in procfs
+// socket tables, where each 32-bit word of the IP is in native byte order.
+func parseProcAddress(s string) (net.IP, net.Port, bool) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return nil, 0, false
+	}
+	ip, err := hex.DecodeString(s[:i])
+	if err != nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
+		return nil, 0, false
+	}
+	for j := 0; j < len(ip); j += 4 {
+		nativeEndian.PutUint32(ip[j:], binary.BigEndian.Uint32(ip[j:]))
+	}
+	port, err := strconv.ParseUint(s[i+1:], 16, 16)
+	if err != nil {
+		return nil, 0, false
+	}
+	return ip, net.Port(port), true
+}
+
+// findSocketInode returns the inode of the socket bound to ip and port in the
+// given socket table, or an empty string if not found. If anyIP is true,
+// sockets bound to the unspecified address also match, as UDP sockets usually
+// are.
+func findSocketInode(table string, ip net.IP, port net.Port, anyIP bool) (string, error) {
+	f, err := os.Open(table)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", newError("failed to open ", table).Base(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		localIP, localPort, ok := parseProcAddress(fields[1])
+		if ok && localPort == port && (localIP.Equal(ip) || (anyIP && localIP.IsUnspecified())) && fields[9] != "0" {
+			return fields[9], nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// socketProcessTTL is how long an index of socket owners is used for sockets
+// found in it.
+const socketProcessTTL = 2 * time.Second
+
+// socketProcessIndex maps inodes of sockets to executable paths of processes
+// holding them, as of the last walk of procfs. Walks are serialized, and
+// shared by lookups started before them.
+type socketProcessIndex struct {
+	access  sync.Mutex
+	scanned time.Time
+	exes    map[string]string
+}
+
+var globalSocketProcessIndex socketProcessIndex
+
+// findSocketProcess returns the executable path of the process holding the
+// socket with the given inode.
+func findSocketProcess(inode string) (string, error) {
+	return globalSocketProcessIndex.find(inode)
+}
+
+func (x *socketProcessIndex) find(inode string) (string, error) {
+	start := time.Now()
+
+	x.access.Lock()
+	defer x.access.Unlock()
+	if exe, found := x.exes[inode]; found && start.Sub(x.scanned) < socketProcessTTL {
+		return exe, nil
+	}
+	// Sockets existing when the lookup started are in a walk started since.
+	if x.scanned.Before(start) {
+		x.scanned = time.Now()
+		x.exes = scanSocketProcesses()
+	}
+	if exe, found := x.exes[inode]; found {
+		return exe, nil
+	}
+	return "", newError("no process found for socket ", inode)
+}
+
+// scanSocketProcesses walks file descriptors of all processes, and returns
+// executable paths of processes by inodes of sockets they hold.
+func scanSocketProcesses() map[string]string {
+	exes := make(map[string]string)
+	fds, err := filepath.Glob("/proc/[0-9]*/fd/[0-9]*")
+	if err != nil {
+		return exes
+	}
+	pidExes := make(map[string]string)
+	for _, fd := range fds {
+		link, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		pid := strings.SplitN(strings.TrimPrefix(fd, "/proc/"), "/", 2)[0]
+		exe, found := pidExes[pid]
+		if !found {
+			exe, _ = os.Readlink(filepath.Join("/proc", pid, "exe"))
+			pidExes[pid] = exe
+		}
+		inode := strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")
+		if _, found := exes[inode]; !found && len(exe) > 0 {
+			exes[inode] = exe
+		}
+	}
+	return exes
+}
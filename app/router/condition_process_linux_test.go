@@ -0,0 +1,62 @@
+package router_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+func TestSystemProcessResolve(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	common.Must(err)
+	defer conn.Close()
+
+	executable, err := os.Executable()
+	common.Must(err)
+
+	addr := conn.LocalAddr().(*net.TCPAddr)
+	path, err := router.SystemProcessResolve(net.Network_TCP, addr.IP, net.Port(addr.Port))
+	common.Must(err)
+	if path != executable {
+		t.Error("expected process ", executable, " but got ", path)
+	}
+
+	if _, err := router.SystemProcessResolve(net.Network_TCP, addr.IP, 1); err == nil {
+		t.Error("expected error resolving unused port")
+	}
+
+	// Sockets created after the last lookup are found too.
+	conn2, err := net.Dial("tcp", listener.Addr().String())
+	common.Must(err)
+	defer conn2.Close()
+
+	addr = conn2.LocalAddr().(*net.TCPAddr)
+	path, err = router.SystemProcessResolve(net.Network_TCP, addr.IP, net.Port(addr.Port))
+	common.Must(err)
+	if path != executable {
+		t.Error("expected process ", executable, " but got ", path)
+	}
+}
+
+func BenchmarkSystemProcessResolve(b *testing.B) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	common.Must(err)
+	defer conn.Close()
+
+	addr := conn.LocalAddr().(*net.TCPAddr)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		common.Must2(router.SystemProcessResolve(net.Network_TCP, addr.IP, net.Port(addr.Port)))
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !confonly && !linux
+// +build !confonly,!linux
+
+package router
+
+import (
+	"runtime"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+// SystemProcessResolve is not supported on this platform, and always returns an error.
+func SystemProcessResolve(network net.Network, ip net.IP, port net.Port) (string, error) {
+	return "", newError("resolving process is not supported on ", runtime.GOOS)
+}
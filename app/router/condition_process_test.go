@@ -0,0 +1,67 @@
+package router_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	routing_session "github.com/v2fly/v2ray-core/v4/features/routing/session"
+)
+
+func TestProcessPathMatcher(t *testing.T) {
+	processes := map[string]string{
+		"tcp:127.0.0.1:1001": "/usr/lib/firefox/firefox",
+		"tcp:127.0.0.1:1002": "/usr/lib/firefox-esr/firefox-esr",
+		"tcp:127.0.0.1:1003": "/usr/bin/curl",
+		"udp:127.0.0.1:1003": "/usr/bin/dig",
+	}
+	resolve := func(network net.Network, ip net.IP, port net.Port) (string, error) {
+		if path, found := processes[net.Destination{Network: network, Address: net.IPAddress(ip), Port: port}.String()]; found {
+			return path, nil
+		}
+		return "", errors.New("not found")
+	}
+	matcher := router.NewProcessPathMatcher([]string{"/usr/lib/firefox/", "/usr/bin/curl"}, resolve)
+
+	testCases := []struct {
+		source net.Destination
+		output bool
+	}{
+		{
+			source: net.TCPDestination(net.LocalHostIP, 1001),
+			output: true,
+		},
+		{
+			source: net.TCPDestination(net.LocalHostIP, 1002),
+			output: false,
+		},
+		{
+			source: net.TCPDestination(net.LocalHostIP, 1003),
+			output: true,
+		},
+		{
+			source: net.UDPDestination(net.LocalHostIP, 1003),
+			output: false,
+		},
+		{
+			source: net.TCPDestination(net.LocalHostIP, 1004),
+			output: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		ctx := &routing_session.Context{
+			Inbound:  &session.Inbound{Source: tc.source},
+			Outbound: &session.Outbound{Target: net.Destination{Network: tc.source.Network, Address: net.DomainAddress("example.com"), Port: 443}},
+		}
+		if actual := matcher.Apply(ctx); actual != tc.output {
+			t.Error("source ", tc.source, " expected ", tc.output, " but got ", actual)
+		}
+	}
+
+	if matcher.Apply(withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("example.com"), 443)})) {
+		t.Error("expected no match without source")
+	}
+}
@@ -0,0 +1,83 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/v2fly/v2ray-core/v4/features/routing"
+)
+
+// PublicSuffixMatcher matches target domains by their registrable domains or
+// public suffixes, as defined by the public suffix list.
+type PublicSuffixMatcher struct {
+	domains  map[string]bool
+	suffixes []string
+}
+
+// normalizePSLDomain returns the lower case ASCII form of an internationalized domain.
+func normalizePSLDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	return idna.ToASCII(domain)
+}
+
+// NewPublicSuffixMatcher creates a new PublicSuffixMatcher. Entries starting
+// with "." are public suffixes, and others are registrable domains.
+func NewPublicSuffixMatcher(entries []string) (*PublicSuffixMatcher, error) {
+	m := &PublicSuffixMatcher{
+		domains: make(map[string]bool),
+	}
+	for _, entry := range entries {
+		isSuffix := strings.HasPrefix(entry, ".")
+		domain, err := normalizePSLDomain(strings.TrimPrefix(entry, "."))
+		if err != nil {
+			return nil, newError("invalid domain: ", entry).Base(err)
+		}
+		if len(domain) == 0 {
+			continue
+		}
+		if isSuffix {
+			m.suffixes = append(m.suffixes, domain)
+		} else {
+			m.domains[domain] = true
+		}
+	}
+	return m, nil
+}
+
+// ApplyDomain returns whether the domain matches any of the entries.
+func (m *PublicSuffixMatcher) ApplyDomain(domain string) bool {
+	domain, err := normalizePSLDomain(domain)
+	if err != nil || len(domain) == 0 {
+		return false
+	}
+
+	if len(m.domains) > 0 {
+		if etldPlusOne, err := publicsuffix.EffectiveTLDPlusOne(domain); err == nil && m.domains[etldPlusOne] {
+			return true
+		}
+	}
+
+	if len(m.suffixes) > 0 {
+		suffix, _ := publicsuffix.PublicSuffix(domain)
+		for _, s := range m.suffixes {
+			if suffix == s || strings.HasSuffix(suffix, "."+s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Apply implements Condition.
+func (m *PublicSuffixMatcher) Apply(ctx routing.Context) bool {
+	domain := ctx.GetTargetDomain()
+	if len(domain) == 0 {
+		return false
+	}
+	return m.ApplyDomain(domain)
+}
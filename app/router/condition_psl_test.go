@@ -0,0 +1,48 @@
+package router_test
+
+import (
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+)
+
+func TestPublicSuffixMatcher(t *testing.T) {
+	testCases := []struct {
+		entries []string
+		domain  string
+		output  bool
+	}{
+		{entries: []string{"example.co.uk"}, domain: "www.example.co.uk", output: true},
+		{entries: []string{"example.co.uk"}, domain: "example.co.uk", output: true},
+		{entries: []string{"example.co.uk"}, domain: "example.uk", output: false},
+		{entries: []string{"co.uk"}, domain: "example.co.uk", output: false},
+		{entries: []string{"example.com"}, domain: "a.b.EXAMPLE.com.", output: true},
+		{entries: []string{".uk"}, domain: "example.co.uk", output: true},
+		{entries: []string{".uk"}, domain: "example.uk", output: true},
+		{entries: []string{".co.uk"}, domain: "example.co.uk", output: true},
+		{entries: []string{".co.uk"}, domain: "example.uk", output: false},
+		{entries: []string{".cn"}, domain: "www.baidu.com.cn", output: true},
+		{entries: []string{".cn"}, domain: "example.com", output: false},
+		{entries: []string{"例子.中国"}, domain: "www.例子.中国", output: true},
+		{entries: []string{"例子.中国"}, domain: "www.xn--fsqu00a.xn--fiqs8s", output: true},
+		{entries: []string{".中国"}, domain: "www.xn--fsqu00a.xn--fiqs8s", output: true},
+	}
+
+	for _, tc := range testCases {
+		matcher, err := router.NewPublicSuffixMatcher(tc.entries)
+		common.Must(err)
+		ctx := withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress(tc.domain), 443)})
+		if actual := matcher.Apply(ctx); actual != tc.output {
+			t.Error("expect ", tc.domain, " against ", tc.entries, " to be ", tc.output, ", but actually ", actual)
+		}
+	}
+
+	matcher, err := router.NewPublicSuffixMatcher([]string{".uk"})
+	common.Must(err)
+	if matcher.Apply(withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("1.2.3.4"), 443)})) {
+		t.Error("expect IP target not to match")
+	}
+}
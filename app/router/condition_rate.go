@@ -0,0 +1,142 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"sync"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/features/routing"
+)
+
+// ConnectionRateCounter counts connections by key, e.g. source IP, in a
+// sliding window. It is safe for concurrent use.
+type ConnectionRateCounter struct {
+	window time.Duration
+	limit  int
+	now    func() time.Time
+
+	access    sync.Mutex
+	entries   map[string][]time.Time
+	nextSweep time.Time
+}
+
+// NewConnectionRateCounter creates a new ConnectionRateCounter, which keeps
+// times of at most limit latest connections of each key. If now is nil,
+// time.Now is used.
+func NewConnectionRateCounter(window time.Duration, limit int, now func() time.Time) *ConnectionRateCounter {
+	if now == nil {
+		now = time.Now
+	}
+	return &ConnectionRateCounter{
+		window:  window,
+		limit:   limit,
+		now:     now,
+		entries: make(map[string][]time.Time),
+	}
+}
+
+// Add records a connection of the key, and returns the number of its
+// connections in the window, including this one, capped at the limit.
+func (c *ConnectionRateCounter) Add(key string) int {
+	c.access.Lock()
+	defer c.access.Unlock()
+
+	now := c.now()
+	c.sweep(now)
+
+	times := c.entries[key]
+	start := now.Add(-c.window)
+	expired := 0
+	for expired < len(times) && !times[expired].After(start) {
+		expired++
+	}
+	times = append(times[:copy(times, times[expired:])], now)
+	if len(times) > c.limit {
+		times = times[:copy(times, times[len(times)-c.limit:])]
+	}
+	c.entries[key] = times
+	return len(times)
+}
+
+// Peek returns the number of connections of the key in the window, counting
+// a new one as Add does, without recording it.
+func (c *ConnectionRateCounter) Peek(key string) int {
+	c.access.Lock()
+	defer c.access.Unlock()
+
+	start := c.now().Add(-c.window)
+	n := 1
+	for _, t := range c.entries[key] {
+		if t.After(start) {
+			n++
+		}
+	}
+	if n > c.limit {
+		n = c.limit
+	}
+	return n
+}
+
+// Len returns the number of keys, including expired ones not evicted yet.
+func (c *ConnectionRateCounter) Len() int {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return len(c.entries)
+}
+
+// sweep evicts keys without connections in the window, at most once per
+// window. Callers must hold the access lock.
+func (c *ConnectionRateCounter) sweep(now time.Time) {
+	if now.Before(c.nextSweep) {
+		return
+	}
+	c.nextSweep = now.Add(c.window)
+	start := now.Add(-c.window)
+	for key, times := range c.entries {
+		if !times[len(times)-1].After(start) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// ConnectionRateMatcher matches connections from source IPs that opened more
+// connections than the threshold in the window, counting the connection being
+// matched.
+type ConnectionRateMatcher struct {
+	counter   *ConnectionRateCounter
+	threshold int
+}
+
+// NewConnectionRateMatcher creates a new ConnectionRateMatcher. now is used to
+// obtain the current time and defaults to time.Now if nil.
+func NewConnectionRateMatcher(rate *ConnectionRate, now func() time.Time) (*ConnectionRateMatcher, error) {
+	if rate.Window == 0 || rate.Threshold == 0 {
+		return nil, newError("window and threshold of connection rate must be positive")
+	}
+	window := time.Duration(rate.Window) * time.Millisecond
+	return &ConnectionRateMatcher{
+		counter:   NewConnectionRateCounter(window, int(rate.Threshold)+1, now),
+		threshold: int(rate.Threshold),
+	}, nil
+}
+
+// Apply implements Condition.
+func (m *ConnectionRateMatcher) Apply(ctx routing.Context) bool {
+	ips := ctx.GetSourceIPs()
+	if len(ips) == 0 {
+		return false
+	}
+	return m.counter.Add(ips[0].String()) > m.threshold
+}
+
+// Peek implements peekCondition. It matches as Apply does, without counting
+// the connection.
+func (m *ConnectionRateMatcher) Peek(ctx routing.Context) bool {
+	ips := ctx.GetSourceIPs()
+	if len(ips) == 0 {
+		return false
+	}
+	return m.counter.Peek(ips[0].String()) > m.threshold
+}
@@ -0,0 +1,118 @@
+package router_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+)
+
+func withSource(ip string) *session.Inbound {
+	return &session.Inbound{Source: net.TCPDestination(net.ParseAddress(ip), 1234)}
+}
+
+func TestConnectionRateMatcher(t *testing.T) {
+	now := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	matcher, err := router.NewConnectionRateMatcher(&router.ConnectionRate{Window: 1000, Threshold: 3}, func() time.Time { return now })
+	common.Must(err)
+
+	a := withInbound(withSource("10.0.0.1"))
+	b := withInbound(withSource("10.0.0.2"))
+
+	for i := 0; i < 3; i++ {
+		if matcher.Apply(a) {
+			t.Error("connection ", i, " within threshold matched")
+		}
+		now = now.Add(100 * time.Millisecond)
+	}
+	if !matcher.Apply(a) {
+		t.Error("burst over threshold not matched")
+	}
+	if matcher.Apply(b) {
+		t.Error("connection from another source matched")
+	}
+	if matcher.Apply(withBackground()) {
+		t.Error("connection without source matched")
+	}
+
+	now = now.Add(time.Second)
+	if matcher.Apply(a) {
+		t.Error("connection after window matched")
+	}
+}
+
+func TestConnectionRateMatcherPeek(t *testing.T) {
+	now := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	matcher, err := router.NewConnectionRateMatcher(&router.ConnectionRate{Window: 1000, Threshold: 2}, func() time.Time { return now })
+	common.Must(err)
+
+	a := withInbound(withSource("10.0.0.1"))
+	for i := 0; i < 5; i++ {
+		if matcher.Peek(a) {
+			t.Fatal("peeking counted connections")
+		}
+	}
+	matcher.Apply(a)
+	matcher.Apply(a)
+	if !matcher.Peek(a) {
+		t.Error("expect the next connection over threshold to be matched")
+	}
+	now = now.Add(time.Second)
+	if matcher.Peek(a) {
+		t.Error("expect connections out of window not to be counted")
+	}
+}
+
+func TestConnectionRateMatcherInvalid(t *testing.T) {
+	for _, rate := range []*router.ConnectionRate{
+		{Window: 1000},
+		{Threshold: 3},
+	} {
+		if _, err := router.NewConnectionRateMatcher(rate, nil); err == nil {
+			t.Error("expect error for ", rate)
+		}
+	}
+}
+
+func TestConnectionRateCounterSweep(t *testing.T) {
+	now := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	counter := router.NewConnectionRateCounter(time.Second, 4, func() time.Time { return now })
+
+	for i := 0; i < 100; i++ {
+		counter.Add(strconv.Itoa(i))
+	}
+	if n := counter.Len(); n != 100 {
+		t.Error("expect 100 keys, but got ", n)
+	}
+
+	now = now.Add(2 * time.Second)
+	counter.Add("new")
+	if n := counter.Len(); n != 1 {
+		t.Error("expect expired keys evicted, but got ", n, " keys")
+	}
+}
+
+func TestConnectionRateCounterConcurrent(t *testing.T) {
+	counter := router.NewConnectionRateCounter(time.Minute, 1000, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				counter.Add("key")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := counter.Add("key"); n != 501 {
+		t.Error("expect 501 connections, but got ", n)
+	}
+}
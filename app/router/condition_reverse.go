@@ -0,0 +1,188 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/common/cache"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/features/dns"
+	"github.com/v2fly/v2ray-core/v4/features/dns/localdns"
+	"github.com/v2fly/v2ray-core/v4/features/routing"
+)
+
+const (
+	// DefaultReverseLookupTTL is the time a reverse lookup result is cached for.
+	DefaultReverseLookupTTL = 10 * time.Minute
+
+	// maxReverseLookups is the maximum number of concurrent reverse lookups
+	// of a ReverseDomainMatcher.
+	maxReverseLookups = 16
+	// reverseLookupCacheSize is the maximum number of IPs whose host names are
+	// cached by a ReverseDomainMatcher.
+	reverseLookupCacheSize = 4096
+)
+
+type reverseLookupRecord struct {
+	names  []string
+	expire time.Time
+}
+
+// reverseLookupCall is a pending reverse lookup. names is set before done is
+// closed.
+type reverseLookupCall struct {
+	done  chan struct{}
+	names []string
+}
+
+// ReverseDomainMatcher matches host names obtained by reverse lookup of the
+// target IPs. Lookups are done asynchronously and cached. A target IP without
+// a cached result doesn't match until its lookup is finished, and is not
+// looked up if too many lookups are pending.
+type ReverseDomainMatcher struct {
+	domains  *DomainMatcher
+	resolver dns.ReverseLookup
+	ttl      time.Duration
+	now      func() time.Time
+	cache    cache.Lru
+	slots    chan struct{}
+
+	access  sync.Mutex
+	pending map[string]*reverseLookupCall
+}
+
+// NewReverseDomainMatcher creates a new ReverseDomainMatcher looking up host
+// names with the resolver. If resolver is nil, the system resolver is used. If
+// ttl is not positive, DefaultReverseLookupTTL is used. If now is nil,
+// time.Now is used.
+func NewReverseDomainMatcher(domains []*Domain, resolver dns.ReverseLookup, ttl time.Duration, now func() time.Time) (*ReverseDomainMatcher, error) {
+	matcher, err := NewDomainMatcher(domains)
+	if err != nil {
+		return nil, err
+	}
+	return newReverseDomainMatcher(matcher, resolver, ttl, now), nil
+}
+
+func newReverseDomainMatcher(matcher *DomainMatcher, resolver dns.ReverseLookup, ttl time.Duration, now func() time.Time) *ReverseDomainMatcher {
+	if resolver == nil {
+		resolver = localdns.New()
+	}
+	if ttl <= 0 {
+		ttl = DefaultReverseLookupTTL
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &ReverseDomainMatcher{
+		domains:  matcher,
+		resolver: resolver,
+		ttl:      ttl,
+		now:      now,
+		cache:    cache.NewLru(reverseLookupCacheSize),
+		slots:    make(chan struct{}, maxReverseLookups),
+		pending:  make(map[string]*reverseLookupCall),
+	}
+}
+
+// Resolve returns the host names of ip. If they are not cached, it looks them
+// up and waits for the lookup to finish.
+func (m *ReverseDomainMatcher) Resolve(ip net.IP) []string {
+	key := ip.String()
+	if names, found := m.cached(key); found {
+		return names
+	}
+	call := m.lookup(key, ip, true)
+	<-call.done
+	return call.names
+}
+
+func (m *ReverseDomainMatcher) cached(key string) ([]string, bool) {
+	value, found := m.cache.Get(key)
+	if !found {
+		return nil, false
+	}
+	record := value.(*reverseLookupRecord)
+	if !m.now().Before(record.expire) {
+		return nil, false
+	}
+	return record.names, true
+}
+
+// lookup returns the pending lookup of ip, starting one if there is none. If
+// maxReverseLookups lookups are pending, it waits for one of them to finish if
+// wait is true, and returns nil otherwise.
+func (m *ReverseDomainMatcher) lookup(key string, ip net.IP, wait bool) *reverseLookupCall {
+	m.access.Lock()
+	call, found := m.pending[key]
+	m.access.Unlock()
+	if found {
+		return call
+	}
+
+	if wait {
+		m.slots <- struct{}{}
+	} else {
+		select {
+		case m.slots <- struct{}{}:
+		default:
+			return nil
+		}
+	}
+
+	m.access.Lock()
+	defer m.access.Unlock()
+	if call, found := m.pending[key]; found {
+		<-m.slots
+		return call
+	}
+	call = &reverseLookupCall{done: make(chan struct{})}
+	m.pending[key] = call
+	go m.resolve(key, ip, call)
+	return call
+}
+
+func (m *ReverseDomainMatcher) resolve(key string, ip net.IP, call *reverseLookupCall) {
+	defer func() { <-m.slots }()
+
+	names, err := m.resolver.LookupAddr(ip)
+	if err != nil {
+		newError("failed to lookup host names of ", key).Base(err).AtDebug().WriteToLog()
+	}
+	call.names = make([]string, 0, len(names))
+	for _, name := range names {
+		call.names = append(call.names, strings.TrimSuffix(name, "."))
+	}
+	m.cache.Put(key, &reverseLookupRecord{
+		names:  call.names,
+		expire: m.now().Add(m.ttl),
+	})
+
+	m.access.Lock()
+	delete(m.pending, key)
+	m.access.Unlock()
+	close(call.done)
+}
+
+// Apply implements Condition.
+func (m *ReverseDomainMatcher) Apply(ctx routing.Context) bool {
+	for _, ip := range ctx.GetTargetIPs() {
+		key := ip.String()
+		names, found := m.cached(key)
+		if !found {
+			if m.lookup(key, ip, false) == nil {
+				newError("too many pending reverse lookups, skipping ", key).AtDebug().WriteToLog()
+			}
+			continue
+		}
+		for _, name := range names {
+			if m.domains.ApplyDomain(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
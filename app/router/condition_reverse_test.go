@@ -0,0 +1,182 @@
+package router_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	"github.com/v2fly/v2ray-core/v4/features/dns"
+	"github.com/v2fly/v2ray-core/v4/features/routing"
+)
+
+// fakeReverseResolver answers reverse lookups from a table. Lookups are
+// signaled on started, and block while it is held.
+type fakeReverseResolver struct {
+	access  sync.Mutex
+	names   map[string][]string
+	lookups int
+	hold    chan struct{}
+	started chan struct{}
+}
+
+func newFakeReverseResolver() *fakeReverseResolver {
+	r := &fakeReverseResolver{
+		names:   make(map[string][]string),
+		hold:    make(chan struct{}),
+		started: make(chan struct{}, 1024),
+	}
+	close(r.hold)
+	return r
+}
+
+func (r *fakeReverseResolver) set(ip string, names ...string) {
+	r.access.Lock()
+	defer r.access.Unlock()
+	r.names[ip] = names
+}
+
+func (r *fakeReverseResolver) count() int {
+	r.access.Lock()
+	defer r.access.Unlock()
+	return r.lookups
+}
+
+// block holds lookups until the returned function is called.
+func (r *fakeReverseResolver) block() func() {
+	hold := make(chan struct{})
+	r.access.Lock()
+	r.hold = hold
+	r.access.Unlock()
+	return func() { close(hold) }
+}
+
+func (r *fakeReverseResolver) LookupAddr(ip net.IP) ([]string, error) {
+	r.access.Lock()
+	r.lookups++
+	hold := r.hold
+	names, found := r.names[ip.String()]
+	r.access.Unlock()
+
+	r.started <- struct{}{}
+	<-hold
+	if !found {
+		return nil, dns.ErrEmptyResponse
+	}
+	return append([]string(nil), names...), nil
+}
+
+func targetIP(ip string) routing.Context {
+	return withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress(ip), 443)})
+}
+
+func TestReverseDomainMatcher(t *testing.T) {
+	resolver := newFakeReverseResolver()
+	resolver.set("1.2.3.4", "server-1.cdn.v2fly.org.")
+	resolver.set("5.6.7.8", "host.example.com.")
+
+	matcher, err := router.NewReverseDomainMatcher([]*router.Domain{
+		{Type: router.Domain_Domain, Value: "v2fly.org"},
+	}, resolver, time.Hour, nil)
+	common.Must(err)
+
+	cases := []struct {
+		ip    string
+		names []string
+		match bool
+	}{
+		{"1.2.3.4", []string{"server-1.cdn.v2fly.org"}, true},
+		{"5.6.7.8", []string{"host.example.com"}, false},
+		{"9.9.9.9", []string{}, false},
+	}
+	for _, c := range cases {
+		if matcher.Apply(targetIP(c.ip)) {
+			t.Error("expect ", c.ip, " not to match before reverse lookup finishes")
+		}
+		names := matcher.Resolve(net.ParseIP(c.ip))
+		if len(names) != len(c.names) || (len(names) > 0 && names[0] != c.names[0]) {
+			t.Error("expect host names of ", c.ip, " to be ", c.names, ", but actually ", names)
+		}
+		for i := 0; i < 10; i++ {
+			if matcher.Apply(targetIP(c.ip)) != c.match {
+				t.Error("expect match of ", c.ip, " to be ", c.match)
+			}
+		}
+	}
+	if matcher.Apply(withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2fly.org"), 443)})) {
+		t.Error("expect domain target not to match")
+	}
+
+	if c := resolver.count(); c != len(cases) {
+		t.Error("expect ", len(cases), " lookups, but actually ", c)
+	}
+}
+
+func TestReverseDomainMatcherExpire(t *testing.T) {
+	resolver := newFakeReverseResolver()
+	resolver.set("1.2.3.4", "a.v2fly.org")
+
+	now := time.Now()
+	matcher, err := router.NewReverseDomainMatcher([]*router.Domain{
+		{Type: router.Domain_Full, Value: "a.v2fly.org"},
+	}, resolver, time.Minute, func() time.Time { return now })
+	common.Must(err)
+
+	target := targetIP("1.2.3.4")
+	matcher.Resolve(net.ParseIP("1.2.3.4"))
+	if !matcher.Apply(target) {
+		t.Fatal("expect 1.2.3.4 to match after reverse lookup")
+	}
+
+	resolver.set("1.2.3.4", "b.v2fly.org")
+	now = now.Add(59 * time.Second)
+	if !matcher.Apply(target) {
+		t.Error("expect cached host names of 1.2.3.4 to match before expiry")
+	}
+	now = now.Add(time.Second)
+	if matcher.Apply(target) {
+		t.Error("expect 1.2.3.4 not to match after cache expires")
+	}
+	if names := matcher.Resolve(net.ParseIP("1.2.3.4")); len(names) != 1 || names[0] != "b.v2fly.org" {
+		t.Error("expect host names of 1.2.3.4 to be looked up again, but got ", names)
+	}
+	if matcher.Apply(target) {
+		t.Error("expect 1.2.3.4 not to match with new host names")
+	}
+	if c := resolver.count(); c != 2 {
+		t.Error("expect 2 lookups, but actually ", c)
+	}
+}
+
+func TestReverseDomainMatcherPendingLimit(t *testing.T) {
+	resolver := newFakeReverseResolver()
+	release := resolver.block()
+
+	matcher, err := router.NewReverseDomainMatcher([]*router.Domain{
+		{Type: router.Domain_Domain, Value: "v2fly.org"},
+	}, resolver, time.Hour, nil)
+	common.Must(err)
+
+	for i := 0; i < 100; i++ {
+		ip := net.IPAddress([]byte{10, 0, 0, byte(i)}).String()
+		matcher.Apply(targetIP(ip))
+		// Lookups in progress are shared.
+		matcher.Apply(targetIP(ip))
+	}
+	for i := 0; i < 16; i++ {
+		<-resolver.started
+	}
+	if c := resolver.count(); c != 16 {
+		t.Error("expect 16 pending lookups, but actually ", c)
+	}
+
+	release()
+	// IPs skipped are looked up later.
+	matcher.Resolve(net.ParseIP("10.0.0.99"))
+	if c := resolver.count(); c != 17 {
+		t.Error("expect 17 lookups, but actually ", c)
+	}
+}
@@ -0,0 +1,70 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"hash/fnv"
+	"math"
+
+	"github.com/v2fly/v2ray-core/v4/common/dice"
+	"github.com/v2fly/v2ray-core/v4/features/routing"
+)
+
+// sampleScale is the number of slots connections are spread over, so that
+// percentages are applied with a precision of 0.01%.
+const sampleScale = 10000
+
+// SampleMatcher matches a percentage of connections, either at random or by
+// hashing a key of them.
+type SampleMatcher struct {
+	threshold int
+	key       RoutingRule_SampleHashKey
+}
+
+// NewSampleMatcher creates a new SampleMatcher matching percent of
+// connections, which must be in (0, 100].
+func NewSampleMatcher(percent float32, key RoutingRule_SampleHashKey) (*SampleMatcher, error) {
+	if !(percent > 0 && percent <= 100) {
+		return nil, newError("sample percent must be in (0, 100], but got ", percent)
+	}
+	if _, found := RoutingRule_SampleHashKey_name[int32(key)]; !found {
+		return nil, newError("unknown sample hash key: ", key)
+	}
+	return &SampleMatcher{
+		threshold: int(math.Round(float64(percent) * sampleScale / 100)),
+		key:       key,
+	}, nil
+}
+
+// sampleKey returns the value of the key of the connection, or an empty
+// string if it has none.
+func sampleKey(ctx routing.Context, key RoutingRule_SampleHashKey) string {
+	switch key {
+	case RoutingRule_SourceIp:
+		if ips := ctx.GetSourceIPs(); len(ips) > 0 {
+			return ips[0].String()
+		}
+	case RoutingRule_User:
+		return ctx.GetUser()
+	case RoutingRule_Target:
+		if domain := ctx.GetTargetDomain(); len(domain) > 0 {
+			return domain
+		}
+		if ips := ctx.GetTargetIPs(); len(ips) > 0 {
+			return ips[0].String()
+		}
+	}
+	return ""
+}
+
+// Apply implements Condition.
+func (m *SampleMatcher) Apply(ctx routing.Context) bool {
+	key := sampleKey(ctx, m.key)
+	if len(key) == 0 {
+		return dice.Roll(sampleScale) < m.threshold
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64()%sampleScale) < m.threshold
+}
@@ -0,0 +1,98 @@
+package router_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/protocol"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+)
+
+// ratioMatched returns the fraction of n contexts matched.
+func ratioMatched(matcher *router.SampleMatcher, n int, withIndex func(int) *session.Inbound) float64 {
+	matched := 0
+	for i := 0; i < n; i++ {
+		if matcher.Apply(withInbound(withIndex(i))) {
+			matched++
+		}
+	}
+	return float64(matched) / float64(n)
+}
+
+func TestSampleMatcherRandom(t *testing.T) {
+	matcher, err := router.NewSampleMatcher(10, router.RoutingRule_Random)
+	common.Must(err)
+
+	ratio := ratioMatched(matcher, 20000, func(int) *session.Inbound {
+		return withSource("10.0.0.1")
+	})
+	if ratio < 0.085 || ratio > 0.115 {
+		t.Error("expect about 10% matched, but got ", ratio)
+	}
+}
+
+func TestSampleMatcherHash(t *testing.T) {
+	canary, err := router.NewSampleMatcher(10, router.RoutingRule_SourceIp)
+	common.Must(err)
+	wider, err := router.NewSampleMatcher(30, router.RoutingRule_SourceIp)
+	common.Must(err)
+
+	const n = 5000
+	source := func(i int) *session.Inbound {
+		return withSource(fmt.Sprintf("10.%d.%d.1", i/256, i%256))
+	}
+	matched := 0
+	for i := 0; i < n; i++ {
+		first := canary.Apply(withInbound(source(i)))
+		for j := 0; j < 3; j++ {
+			if canary.Apply(withInbound(source(i))) != first {
+				t.Fatal("unstable sample for ", source(i).Source)
+			}
+		}
+		if first {
+			matched++
+			if !wider.Apply(withInbound(source(i))) {
+				t.Error("source ", source(i).Source, " in 10% sample but not in 30% sample")
+			}
+		}
+	}
+	if ratio := float64(matched) / n; ratio < 0.08 || ratio > 0.12 {
+		t.Error("expect about 10% of sources matched, but got ", ratio)
+	}
+
+	// Connections without a user are sampled at random.
+	byUser, err := router.NewSampleMatcher(50, router.RoutingRule_User)
+	common.Must(err)
+	ratio := ratioMatched(byUser, 2000, func(int) *session.Inbound {
+		return &session.Inbound{}
+	})
+	if ratio < 0.4 || ratio > 0.6 {
+		t.Error("expect about 50% matched without user, but got ", ratio)
+	}
+	user := &session.Inbound{User: &protocol.MemoryUser{Email: "love@v2fly.org"}}
+	first := byUser.Apply(withInbound(user))
+	for i := 0; i < 10; i++ {
+		if byUser.Apply(withInbound(user)) != first {
+			t.Fatal("unstable sample for user")
+		}
+	}
+}
+
+func TestSampleMatcherInvalid(t *testing.T) {
+	for _, percent := range []float32{-1, 100.5} {
+		if _, err := router.NewSampleMatcher(percent, router.RoutingRule_Random); err == nil {
+			t.Error("expect error for percent ", percent)
+		}
+	}
+	if _, err := router.NewSampleMatcher(10, router.RoutingRule_SampleHashKey(100)); err == nil {
+		t.Error("expect error for unknown hash key")
+	}
+
+	always, err := router.NewSampleMatcher(100, router.RoutingRule_Random)
+	common.Must(err)
+	if ratio := ratioMatched(always, 100, func(int) *session.Inbound { return &session.Inbound{} }); ratio != 1 {
+		t.Error("expect all matched at 100%, but got ", ratio)
+	}
+}
@@ -0,0 +1,114 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/features/routing"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+type scheduleWindow struct {
+	weekdayFrom time.Weekday
+	weekdayTo   time.Weekday
+	begin       int
+	end         int
+}
+
+func (w *scheduleWindow) containsWeekday(day time.Weekday) bool {
+	if w.weekdayFrom <= w.weekdayTo {
+		return day >= w.weekdayFrom && day <= w.weekdayTo
+	}
+	return day >= w.weekdayFrom || day <= w.weekdayTo
+}
+
+// contains checks whether the wall clock time given by day and seconds since
+// midnight falls inside the window.
+func (w *scheduleWindow) contains(day time.Weekday, seconds int) bool {
+	switch {
+	case w.begin == w.end:
+		return w.containsWeekday(day)
+	case w.begin < w.end:
+		return w.containsWeekday(day) && seconds >= w.begin && seconds < w.end
+	default:
+		// The window crosses midnight, so the early part of a day belongs to
+		// the window started on the previous day.
+		if seconds >= w.begin {
+			return w.containsWeekday(day)
+		}
+		return seconds < w.end && w.containsWeekday((day+6)%7)
+	}
+}
+
+// ScheduleMatcher matches when the current time falls inside any of its windows.
+type ScheduleMatcher struct {
+	location *time.Location
+	windows  []scheduleWindow
+	now      func() time.Time
+}
+
+// NewScheduleMatcher creates a new ScheduleMatcher. now is used to obtain the
+// current time and defaults to time.Now if nil.
+func NewScheduleMatcher(schedule *Schedule, now func() time.Time) (*ScheduleMatcher, error) {
+	location := time.Local
+	if len(schedule.Timezone) > 0 {
+		loc, err := time.LoadLocation(schedule.Timezone)
+		if err != nil {
+			return nil, newError("unknown timezone: ", schedule.Timezone).Base(err)
+		}
+		location = loc
+	}
+
+	windows := make([]scheduleWindow, 0, len(schedule.Window))
+	for _, w := range schedule.Window {
+		if w.WeekdayFrom > 6 || w.WeekdayTo > 6 {
+			return nil, newError("invalid weekday range: ", w.WeekdayFrom, "-", w.WeekdayTo)
+		}
+		if w.Begin >= secondsPerDay || w.End > secondsPerDay {
+			return nil, newError("invalid time of day range: ", w.Begin, "-", w.End)
+		}
+		windows = append(windows, scheduleWindow{
+			weekdayFrom: time.Weekday(w.WeekdayFrom),
+			weekdayTo:   time.Weekday(w.WeekdayTo),
+			begin:       int(w.Begin),
+			end:         int(w.End),
+		})
+	}
+	if len(windows) == 0 {
+		return nil, newError("empty schedule")
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return &ScheduleMatcher{
+		location: location,
+		windows:  windows,
+		now:      now,
+	}, nil
+}
+
+// Match checks whether t falls inside the schedule.
+func (m *ScheduleMatcher) Match(t time.Time) bool {
+	// Wall clock time is used, so that windows follow DST transitions of the
+	// configured time zone.
+	t = t.In(m.location)
+	hour, minute, sec := t.Clock()
+	seconds := hour*3600 + minute*60 + sec
+	day := t.Weekday()
+	for i := range m.windows {
+		if m.windows[i].contains(day, seconds) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply implements Condition.
+func (m *ScheduleMatcher) Apply(ctx routing.Context) bool {
+	return m.Match(m.now())
+}
@@ -0,0 +1,169 @@
+package router_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+)
+
+func TestScheduleMatcher(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	common.Must(err)
+
+	cases := []struct {
+		schedule *router.Schedule
+		input    time.Time
+		output   bool
+	}{
+		{
+			// Work hours, Monday to Friday.
+			schedule: &router.Schedule{
+				Timezone: "UTC",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 1, WeekdayTo: 5, Begin: 9 * 3600, End: 18 * 3600},
+				},
+			},
+			input:  time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC), // Wednesday
+			output: true,
+		},
+		{
+			schedule: &router.Schedule{
+				Timezone: "UTC",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 1, WeekdayTo: 5, Begin: 9 * 3600, End: 18 * 3600},
+				},
+			},
+			input:  time.Date(2021, 9, 1, 18, 0, 0, 0, time.UTC),
+			output: false,
+		},
+		{
+			schedule: &router.Schedule{
+				Timezone: "UTC",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 1, WeekdayTo: 5, Begin: 9 * 3600, End: 18 * 3600},
+				},
+			},
+			input:  time.Date(2021, 9, 4, 10, 0, 0, 0, time.UTC), // Saturday
+			output: false,
+		},
+		{
+			// Time zone of the schedule is honored.
+			schedule: &router.Schedule{
+				Timezone: "Asia/Shanghai",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 1, WeekdayTo: 5, Begin: 9 * 3600, End: 18 * 3600},
+				},
+			},
+			input:  time.Date(2021, 9, 1, 2, 0, 0, 0, time.UTC), // 10:00 in Shanghai
+			output: true,
+		},
+		{
+			// Friday night to Saturday morning, crossing midnight.
+			schedule: &router.Schedule{
+				Timezone: "UTC",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 5, WeekdayTo: 5, Begin: 22 * 3600, End: 6 * 3600},
+				},
+			},
+			input:  time.Date(2021, 9, 4, 5, 59, 59, 0, time.UTC), // Saturday
+			output: true,
+		},
+		{
+			schedule: &router.Schedule{
+				Timezone: "UTC",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 5, WeekdayTo: 5, Begin: 22 * 3600, End: 6 * 3600},
+				},
+			},
+			input:  time.Date(2021, 9, 3, 5, 0, 0, 0, time.UTC), // Friday morning
+			output: false,
+		},
+		{
+			// Weekday range wrapping around the end of the week.
+			schedule: &router.Schedule{
+				Timezone: "UTC",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 6, WeekdayTo: 0},
+				},
+			},
+			input:  time.Date(2021, 9, 5, 12, 0, 0, 0, time.UTC), // Sunday
+			output: true,
+		},
+		{
+			// 2021-03-14 02:30 does not exist in New York, 03:30 EDT is already past the window.
+			schedule: &router.Schedule{
+				Timezone: "America/New_York",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 0, WeekdayTo: 6, Begin: 1 * 3600, End: 3 * 3600},
+				},
+			},
+			input:  time.Date(2021, 3, 14, 7, 30, 0, 0, time.UTC),
+			output: false,
+		},
+		{
+			schedule: &router.Schedule{
+				Timezone: "America/New_York",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 0, WeekdayTo: 6, Begin: 1 * 3600, End: 3 * 3600},
+				},
+			},
+			input:  time.Date(2021, 3, 14, 1, 30, 0, 0, newYork),
+			output: true,
+		},
+		{
+			// 01:30 happens twice on 2021-11-07 in New York, both are matched.
+			schedule: &router.Schedule{
+				Timezone: "America/New_York",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 0, WeekdayTo: 6, Begin: 1 * 3600, End: 2 * 3600},
+				},
+			},
+			input:  time.Date(2021, 11, 7, 6, 30, 0, 0, time.UTC), // 01:30 EST
+			output: true,
+		},
+		{
+			schedule: &router.Schedule{
+				Timezone: "America/New_York",
+				Window: []*router.Schedule_Window{
+					{WeekdayFrom: 0, WeekdayTo: 6, Begin: 1 * 3600, End: 2 * 3600},
+				},
+			},
+			input:  time.Date(2021, 11, 7, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+			output: true,
+		},
+	}
+
+	for _, test := range cases {
+		input := test.input
+		matcher, err := router.NewScheduleMatcher(test.schedule, func() time.Time { return input })
+		common.Must(err)
+		if actual := matcher.Apply(withBackground()); actual != test.output {
+			t.Error("test case failed: ", test.input, " expected ", test.output, " but got ", actual)
+		}
+	}
+}
+
+func TestScheduleMatcherInvalid(t *testing.T) {
+	cases := []*router.Schedule{
+		{
+			Timezone: "Invalid/Zone",
+			Window:   []*router.Schedule_Window{{Begin: 0, End: 3600}},
+		},
+		{
+			Window: []*router.Schedule_Window{{WeekdayFrom: 7, WeekdayTo: 1}},
+		},
+		{
+			Window: []*router.Schedule_Window{{Begin: 86400, End: 3600}},
+		},
+		{},
+	}
+
+	for _, schedule := range cases {
+		if _, err := router.NewScheduleMatcher(schedule, nil); err == nil {
+			t.Error("expect error for schedule ", schedule)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"google.golang.org/protobuf/proto"
@@ -19,6 +21,7 @@ import (
 	"github.com/v2fly/v2ray-core/v4/common/protocol/http"
 	"github.com/v2fly/v2ray-core/v4/common/session"
 	"github.com/v2fly/v2ray-core/v4/features/routing"
+	routing_dns "github.com/v2fly/v2ray-core/v4/features/routing/dns"
 	routing_session "github.com/v2fly/v2ray-core/v4/features/routing/session"
 )
 
@@ -67,6 +70,17 @@ func withContent(content *session.Content) routing.Context {
 	return &routing_session.Context{Content: content}
 }
 
+func withSniffedNames(sni, host string) routing.Context {
+	content := new(session.Content)
+	if len(sni) > 0 {
+		content.SetAttribute(session.AttributeSniffedSNI, sni)
+	}
+	if len(host) > 0 {
+		content.SetAttribute(session.AttributeSniffedHost, host)
+	}
+	return withContent(content)
+}
+
 func TestRoutingRule(t *testing.T) {
 	type ruleTest struct {
 		input  routing.Context
@@ -268,6 +282,44 @@ func TestRoutingRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			rule: &router.RoutingRule{
+				TransportProtocol: []string{"websocket"},
+			},
+			test: []ruleTest{
+				{
+					input:  withInbound(&session.Inbound{Tag: "test", TransportProtocol: "websocket"}),
+					output: true,
+				},
+				{
+					input:  withInbound(&session.Inbound{Tag: "test", TransportProtocol: "mkcp"}),
+					output: false,
+				},
+				{
+					input:  withInbound(&session.Inbound{Tag: "test"}),
+					output: false,
+				},
+				{
+					input:  routing_dns.ContextWithDNSClient(withInbound(&session.Inbound{TransportProtocol: "websocket"}), nil),
+					output: true,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				TransportProtocol: []string{"websocket", "mkcp"},
+			},
+			test: []ruleTest{
+				{
+					input:  withInbound(&session.Inbound{TransportProtocol: "mkcp"}),
+					output: true,
+				},
+				{
+					input:  withInbound(&session.Inbound{TransportProtocol: "tcp"}),
+					output: false,
+				},
+			},
+		},
 		{
 			rule: &router.RoutingRule{
 				PortList: &net.PortList{
@@ -336,6 +388,362 @@ func TestRoutingRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			rule: &router.RoutingRule{
+				IsIpQuery: router.RoutingRule_IpOnly,
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("2001:db8::1"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2fly.org"), 80)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 80), RouteDomain: "v2fly.org"}),
+					output: false,
+				},
+				{
+					input:  withBackground(),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				IsIpQuery: router.RoutingRule_DomainOnly,
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2fly.org"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 80), RouteDomain: "v2fly.org"}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 80)}),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				PortClass: []router.RoutingRule_PortClass{router.RoutingRule_Privileged},
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 1)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 1023)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 1024)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 65535)}),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				PortClass: []router.RoutingRule_PortClass{router.RoutingRule_Registered},
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 1023)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 1024)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 49151)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 49152)}),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				PortClass: []router.RoutingRule_PortClass{router.RoutingRule_Ephemeral},
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 49151)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 49152)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 65535)}),
+					output: true,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				PortList: &net.PortList{
+					Range: []*net.PortRange{
+						{From: 8080, To: 8080},
+					},
+				},
+				PortClass:  []router.RoutingRule_PortClass{router.RoutingRule_Privileged},
+				NegatePort: true,
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 443)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 8080)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 8443)}),
+					output: true,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				DomainFronting: router.DomainFronting_Mismatching,
+			},
+			test: []ruleTest{
+				{
+					input:  withSniffedNames("front.example.com", "hidden.example.org"),
+					output: true,
+				},
+				{
+					input:  withSniffedNames("www.example.com", "WWW.example.com."),
+					output: false,
+				},
+				{
+					input:  withSniffedNames("front.example.com", ""),
+					output: false,
+				},
+				{
+					input:  withSniffedNames("", "hidden.example.org"),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				Ja3: []string{"B8F81673C0E1D29908346F3BAB892B9B", "a66e498c488aa0523759691248cdfb01"},
+			},
+			test: []ruleTest{
+				{
+					input:  withContent(&session.Content{Attributes: map[string]string{session.AttributeSniffedJA3: "b8f81673c0e1d29908346f3bab892b9b"}}),
+					output: true,
+				},
+				{
+					input:  withContent(&session.Content{Attributes: map[string]string{session.AttributeSniffedJA3: "0f07bc69e01592785bad20835bc85ab9"}}),
+					output: false,
+				},
+				{
+					input:  withSniffedNames("www.example.com", ""),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				DomainFronting: router.DomainFronting_Matching,
+			},
+			test: []ruleTest{
+				{
+					input:  withSniffedNames("www.example.com", "WWW.example.com:443"),
+					output: true,
+				},
+				{
+					input:  withSniffedNames("front.example.com", "hidden.example.org"),
+					output: false,
+				},
+				{
+					input:  withBackground(),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				Geoip: []*router.GeoIP{
+					{
+						Cidr: []*router.CIDR{
+							{
+								Ip:     []byte{10, 0, 0, 0},
+								Prefix: 8,
+							},
+							{
+								Ip:     []byte{192, 168, 0, 0},
+								Prefix: 16,
+							},
+						},
+					},
+				},
+				NegateIp: true,
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("10.1.2.3"), 80)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("192.168.1.1"), 80)}),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				Domain: []*router.Domain{
+					{
+						Value: "v2fly.org",
+						Type:  router.Domain_Domain,
+					},
+				},
+				PortList: &net.PortList{
+					Range: []*net.PortRange{
+						{From: 443, To: 443},
+					},
+				},
+				NegatePort: true,
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.v2fly.org"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.v2fly.org"), 443)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.v2ray.com"), 80)}),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				OrGroups: []*router.RoutingRule{
+					{
+						Domain: []*router.Domain{
+							{
+								Value: "v2fly.org",
+								Type:  router.Domain_Domain,
+							},
+						},
+						PortList: &net.PortList{
+							Range: []*net.PortRange{
+								{From: 443, To: 443},
+							},
+						},
+					},
+					{
+						Geoip: []*router.GeoIP{
+							{
+								Cidr: []*router.CIDR{
+									{
+										Ip:     []byte{8, 8, 8, 8},
+										Prefix: 32,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.v2fly.org"), 443)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.v2fly.org"), 80)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.8.8"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.ParseAddress("8.8.4.4"), 443)}),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &router.RoutingRule{
+				InboundTag: []string{"test"},
+				OrGroups: []*router.RoutingRule{
+					{
+						Networks: []net.Network{net.Network_UDP},
+					},
+					{
+						PortList: &net.PortList{
+							Range: []*net.PortRange{
+								{From: 53, To: 53},
+							},
+						},
+					},
+				},
+			},
+			test: []ruleTest{
+				{
+					input: &routing_session.Context{
+						Inbound:  &session.Inbound{Tag: "test"},
+						Outbound: &session.Outbound{Target: net.UDPDestination(net.LocalHostIP, 1000)},
+					},
+					output: true,
+				},
+				{
+					input: &routing_session.Context{
+						Inbound:  &session.Inbound{Tag: "test"},
+						Outbound: &session.Outbound{Target: net.TCPDestination(net.LocalHostIP, 53)},
+					},
+					output: true,
+				},
+				{
+					input: &routing_session.Context{
+						Inbound:  &session.Inbound{Tag: "test"},
+						Outbound: &session.Outbound{Target: net.TCPDestination(net.LocalHostIP, 80)},
+					},
+					output: false,
+				},
+				{
+					input: &routing_session.Context{
+						Inbound:  &session.Inbound{Tag: "test2"},
+						Outbound: &session.Outbound{Target: net.UDPDestination(net.LocalHostIP, 53)},
+					},
+					output: false,
+				},
+			},
+		},
 	}
 
 	for _, test := range cases {
@@ -351,6 +759,73 @@ func TestRoutingRule(t *testing.T) {
 	}
 }
 
+func TestRoutingRuleAnchorRegex(t *testing.T) {
+	domains := []*router.Domain{
+		{
+			Value: "facebook\\.com",
+			Type:  router.Domain_Regex,
+		},
+		{
+			Value: "v2fly.org",
+			Type:  router.Domain_Full,
+		},
+	}
+
+	testCases := []struct {
+		domain     string
+		unanchored bool
+		anchored   bool
+	}{
+		{
+			domain:     "facebook.com",
+			unanchored: true,
+			anchored:   true,
+		},
+		{
+			domain:     "www.facebook.com",
+			unanchored: true,
+			anchored:   false,
+		},
+		{
+			domain:     "facebook.com.cn",
+			unanchored: true,
+			anchored:   false,
+		},
+		{
+			domain:     "v2fly.org",
+			unanchored: true,
+			anchored:   true,
+		},
+	}
+
+	for _, domainMatcher := range []string{"linear", "mph"} {
+		for _, anchorRegex := range []bool{false, true} {
+			rule := &router.RoutingRule{
+				Domain:        domains,
+				DomainMatcher: domainMatcher,
+				AnchorRegex:   anchorRegex,
+			}
+			cond, err := rule.BuildCondition()
+			common.Must(err)
+
+			for _, tc := range testCases {
+				expected := tc.unanchored
+				if anchorRegex {
+					expected = tc.anchored
+				}
+				ctx := withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress(tc.domain), 80)})
+				if actual := cond.Apply(ctx); actual != expected {
+					t.Error("domain ", tc.domain, " with ", domainMatcher, " matcher and anchorRegex ", anchorRegex, " expected ", expected, " but got ", actual)
+				}
+			}
+		}
+	}
+
+	if domains[0].Value != "facebook\\.com" {
+		t.Error("domain list is modified: ", domains[0].Value)
+	}
+}
+
 func loadGeoSite(country string) ([]*router.Domain, error) {
 	geositeBytes, err := filesystem.ReadAsset("geosite.dat")
 	if err != nil {
@@ -501,6 +976,288 @@ func BenchmarkDomainMatcher(b *testing.B) {
 	}
 }
 
+func generateDomains(count int) []*router.Domain {
+	domains := make([]*router.Domain, 0, count)
+	for i := 0; i < count; i++ {
+		domain := &router.Domain{Value: "site" + strconv.Itoa(i) + ".example.com"}
+		switch i % 50 {
+		case 0:
+			domain.Type = router.Domain_Plain
+			domain.Value = "keyword" + strconv.Itoa(i)
+		case 1, 2, 3, 4, 5, 6, 7, 8, 9:
+			domain.Type = router.Domain_Full
+		default:
+			domain.Type = router.Domain_Domain
+		}
+		domains = append(domains, domain)
+	}
+	return domains
+}
+
+func TestDomainMatcherCache(t *testing.T) {
+	domains := generateDomains(5000)
+
+	matcher, err := router.NewDomainMatcher(domains)
+	common.Must(err)
+	cachedMatcher, err := router.NewDomainMatcher(domains)
+	common.Must(err)
+	cachedMatcher.SetCache(router.NewDomainMatchCache(16))
+
+	var queries []string
+	for i := 0; i < 6000; i += 7 {
+		queries = append(queries, "www.site"+strconv.Itoa(i)+".example.com", "SITE"+strconv.Itoa(i)+".example.com", "a-keyword"+strconv.Itoa(i)+".net")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 3; round++ {
+				for _, query := range queries {
+					if expected, actual := matcher.ApplyDomain(query), cachedMatcher.ApplyDomain(query); expected != actual {
+						t.Error("domain ", query, " expected ", expected, " but got ", actual)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDomainMatcherLabel(t *testing.T) {
+	domains := []*router.Domain{
+		{Type: router.Domain_Domain, Value: "ads.example", Label: "ads"},
+		{Type: router.Domain_Full, Value: "www.example.com"},
+		{Type: router.Domain_Plain, Value: "track", Label: "tracking"},
+		{Type: router.Domain_Domain, Value: "tracker.ads.example", Label: "ads-tracking"},
+	}
+
+	cases := []struct {
+		domain  string
+		matched bool
+		label   string
+	}{
+		{domain: "x.ads.example", matched: true, label: "ads"},
+		{domain: "WWW.example.com", matched: true},
+		{domain: "tracker.ads.example", matched: true, label: "ads"},
+		{domain: "track.example.com", matched: true, label: "tracking"},
+		{domain: "example.org", matched: false},
+	}
+
+	cachedMatcher, err := router.NewDomainMatcher(domains)
+	common.Must(err)
+	cachedMatcher.SetCache(router.NewDomainMatchCache(16))
+	conds := []router.Condition{cachedMatcher}
+	for _, matcherType := range []string{"linear", "mph"} {
+		cond, err := (&router.RoutingRule{
+			Domain:        domains,
+			DomainMatcher: matcherType,
+		}).BuildCondition()
+		common.Must(err)
+		conds = append(conds, cond)
+	}
+
+	for _, cond := range conds {
+		for round := 0; round < 2; round++ {
+			for _, tc := range cases {
+				ctx := &routing_session.Context{Outbound: &session.Outbound{Target: net.TCPDestination(net.ParseAddress(tc.domain), 80)}}
+				if matched := cond.Apply(ctx); matched != tc.matched {
+					t.Error("domain ", tc.domain, " expected ", tc.matched, " but got ", matched)
+				}
+				if label := ctx.GetAttributes()[session.AttributeDomainLabel]; label != tc.label {
+					t.Error("domain ", tc.domain, " expected label ", tc.label, " but got ", label)
+				}
+			}
+		}
+	}
+
+	matcher, err := router.NewDomainMatcher(domains[1:2])
+	common.Must(err)
+	ctx := &routing_session.Context{Outbound: &session.Outbound{Target: net.TCPDestination(net.ParseAddress("www.example.com"), 80)}}
+	if !matcher.Apply(ctx) {
+		t.Error("expect www.example.com to match")
+	}
+	if ctx.Content != nil {
+		t.Error("expect no attributes recorded without labels, but got ", ctx.Content.Attributes)
+	}
+}
+
+func TestDomainPatternContainer(t *testing.T) {
+	var container router.DomainPatternContainer
+	regex := &router.Domain{Type: router.Domain_Regex, Value: `^cdn\d+\.example\.com$`}
+
+	m1, err := container.Add(regex)
+	common.Must(err)
+	m2, err := container.Add(&router.Domain{Type: router.Domain_Regex, Value: regex.Value, Label: "cdn"})
+	common.Must(err)
+	if m1 != m2 {
+		t.Error("expect identical patterns to share a matcher")
+	}
+	if !m1.Match("cdn1.example.com") || m1.Match("www.example.com") {
+		t.Error("unexpected match result of shared matcher")
+	}
+
+	m3, err := container.Add(&router.Domain{Type: router.Domain_Plain, Value: regex.Value})
+	common.Must(err)
+	if m3 == m1 {
+		t.Error("expect patterns of different types not to share a matcher")
+	}
+	if n := container.Len(); n != 2 {
+		t.Error("expect 2 shared matchers, but got ", n)
+	}
+
+	if _, err := container.Add(&router.Domain{Type: router.Domain_Regex, Value: "("}); err == nil {
+		t.Error("expect error for invalid regex")
+	}
+
+	container.Reset()
+	if n := container.Len(); n != 0 {
+		t.Error("expect no shared matchers after reset, but got ", n)
+	}
+}
+
+func TestNetworkMatcher(t *testing.T) {
+	all := []net.Network{net.Network_TCP, net.Network_UDP, net.Network_UNIX, net.Network_ICMP}
+	testCases := [][]net.Network{
+		{net.Network_TCP},
+		{net.Network_UDP},
+		{net.Network_ICMP},
+		{net.Network_TCP, net.Network_UDP},
+		{net.Network_TCP, net.Network_UDP, net.Network_ICMP},
+		{net.Network_UDP, net.Network_ICMP},
+	}
+	for _, networks := range testCases {
+		rules := []*router.RoutingRule{
+			{Networks: networks},
+			{NetworkList: &net.NetworkList{Network: networks}},
+		}
+		for _, rule := range rules {
+			cond, err := rule.BuildCondition()
+			common.Must(err)
+			for _, network := range all {
+				ctx := withOutbound(&session.Outbound{Target: net.Destination{Network: network, Address: net.LocalHostIP}})
+				if expected, actual := net.HasNetwork(networks, network), cond.Apply(ctx); expected != actual {
+					t.Error(networks, " on ", network, ": expected ", expected, " but got ", actual)
+				}
+			}
+		}
+	}
+
+	// Out of range networks never match.
+	matcher := router.NewNetworkMatcher([]net.Network{net.Network(100)})
+	ctx := withOutbound(&session.Outbound{Target: net.Destination{Network: net.Network(100)}})
+	if matcher.Apply(ctx) {
+		t.Error("expect unknown network not to match")
+	}
+}
+
+func TestLazyMatcher(t *testing.T) {
+	var builds int32
+	matcher := router.NewLazyMatcher(func() (router.Condition, error) {
+		atomic.AddInt32(&builds, 1)
+		return router.NewNetworkMatcher([]net.Network{net.Network_TCP}), nil
+	})
+
+	tcp := withOutbound(&session.Outbound{Target: net.TCPDestination(net.LocalHostIP, 80)})
+	udp := withOutbound(&session.Outbound{Target: net.UDPDestination(net.LocalHostIP, 53)})
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !matcher.Apply(tcp) || matcher.Apply(udp) {
+				t.Error("unexpected result of lazy matcher")
+			}
+		}()
+	}
+	wg.Wait()
+	if builds != 1 {
+		t.Error("expect condition to be built once, but built ", builds, " times")
+	}
+
+	failed := router.NewLazyMatcher(func() (router.Condition, error) {
+		return nil, errors.New("invalid condition")
+	})
+	if failed.Apply(tcp) {
+		t.Error("expect condition failing to build not to match")
+	}
+}
+
+func TestAttributeMatcherNumeric(t *testing.T) {
+	attrs := map[string]string{
+		"content-length": "4096",
+		"ratio":          "0.5",
+		":path":          "/test",
+	}
+	testCases := []struct {
+		code   string
+		output bool
+	}{
+		{code: "nums['content-length'] > 1024", output: true},
+		{code: "nums['content-length'] < 1024", output: false},
+		{code: "nums['content-length'] >= 4096", output: true},
+		{code: "nums['content-length'] <= 4095", output: false},
+		{code: "nums['content-length'] == 4096", output: true},
+		{code: "nums['content-length'] != 4096", output: false},
+		{code: "nums['ratio'] < 1", output: true},
+		{code: "between(nums['content-length'], 1024, 65536)", output: true},
+		{code: "between(nums['content-length'], 0, 4095)", output: false},
+		{code: "between(nums['ratio'], 0.1, 0.5)", output: true},
+		{code: "'content-length' in nums and nums['content-length'] > 0", output: true},
+		{code: "':path' in nums", output: false},
+		{code: "nums['missing'] > 0", output: false},
+		{code: "attrs[':path'] == '/test' and nums['content-length'] > 1024", output: true},
+	}
+	for _, tc := range testCases {
+		matcher, err := router.NewAttributeMatcher(tc.code)
+		common.Must(err)
+		if actual := matcher.Match(attrs); actual != tc.output {
+			t.Error(tc.code, ": expected ", tc.output, " but got ", actual)
+		}
+	}
+}
+
+func TestAttributeMatcherMalformed(t *testing.T) {
+	for _, code := range []string{
+		"nums['content-length'] >",
+		"nums['content-length'] > '1024'",
+		"between(nums['content-length'], 1024)",
+		"between(nums['content-length'], '0', 1024)",
+		"size > 1024",
+	} {
+		if _, err := router.NewAttributeMatcher(code); err == nil {
+			t.Error("expect error for ", code)
+		}
+	}
+}
+
+func benchmarkLargeDomainMatcher(b *testing.B, cacheSize int) {
+	matcher, err := router.NewDomainMatcher(generateDomains(50000))
+	common.Must(err)
+	matcher.SetCache(router.NewDomainMatchCache(cacheSize))
+
+	queries := make([]string, 0, 1024)
+	for i := 0; i < 1024; i++ {
+		queries = append(queries, "www.site"+strconv.Itoa(i*97)+".example.com")
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			_ = matcher.ApplyDomain(queries[i%len(queries)])
+		}
+	})
+}
+
+func BenchmarkLargeDomainMatcher(b *testing.B) {
+	benchmarkLargeDomainMatcher(b, 0)
+}
+
+func BenchmarkLargeDomainMatcherWithCache(b *testing.B) {
+	benchmarkLargeDomainMatcher(b, 4096)
+}
+
 func BenchmarkMultiGeoIPMatcher(b *testing.B) {
 	var geoips []*router.GeoIP
 
@@ -4,7 +4,16 @@
 package router
 
 import (
+	"bytes"
+	"math/bits"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/ratelimit"
+	"github.com/v2fly/v2ray-core/v4/features/dns"
 	"github.com/v2fly/v2ray-core/v4/features/outbound"
 	"github.com/v2fly/v2ray-core/v4/features/routing"
 )
@@ -48,10 +57,129 @@ func (l *CIDRList) Swap(i int, j int) {
 	(*l)[i], (*l)[j] = (*l)[j], (*l)[i]
 }
 
+// compareCIDR compares cidr with the CIDR of ip and prefix, in the same order as Less.
+func compareCIDR(cidr *CIDR, ip []byte, prefix uint32) int {
+	if len(cidr.Ip) != len(ip) {
+		return len(cidr.Ip) - len(ip)
+	}
+	if c := bytes.Compare(cidr.Ip, ip); c != 0 {
+		return c
+	}
+	switch {
+	case cidr.Prefix < prefix:
+		return -1
+	case cidr.Prefix > prefix:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// commonPrefixLength returns the number of leading bits shared by a and b.
+func commonPrefixLength(a []byte, b []byte) uint32 {
+	for i := range a {
+		if x := a[i] ^ b[i]; x != 0 {
+			return uint32(i*8 + bits.LeadingZeros8(x))
+		}
+	}
+	return uint32(len(a) * 8)
+}
+
+func maskIP(ip []byte, prefix uint32) []byte {
+	masked := make([]byte, len(ip))
+	for i := range ip {
+		switch {
+		case uint32(i*8+8) <= prefix:
+			masked[i] = ip[i]
+		case uint32(i*8) < prefix:
+			masked[i] = ip[i] & (0xff << (8 - (prefix - uint32(i*8))))
+		}
+	}
+	return masked
+}
+
+// Contains returns true if ip is included by any CIDR in the list, in O(log n) time.
+// The list must be sorted, and each CIDR must be normalized to its network address.
+func (l *CIDRList) Contains(ip net.IP) bool {
+	list := *l
+	key := []byte(ip)
+	prefix := uint32(len(key) * 8)
+
+	for {
+		// Find the last CIDR not greater than the key. A CIDR containing ip must
+		// be in form of (ip masked by p, p), so all CIDRs after this one can be
+		// skipped.
+		i := sort.Search(len(list), func(i int) bool {
+			return compareCIDR(list[i], key, prefix) > 0
+		}) - 1
+		if i < 0 || len(list[i].Ip) != len(ip) {
+			return false
+		}
+
+		common := commonPrefixLength(list[i].Ip, ip)
+		if list[i].Prefix <= common {
+			return true
+		}
+
+		// Any other CIDR containing ip can't be longer than the common prefix.
+		// The new key is strictly less than the current CIDR, so the search
+		// always makes progress.
+		list = list[:i]
+		key = maskIP(ip, common)
+		prefix = common
+	}
+}
+
+// SortedCIDRList is a list of CIDRs sorted in the order of CIDRList, ready for
+// lookups.
+type SortedCIDRList struct {
+	list CIDRList
+}
+
+// NewSortedCIDRList returns a SortedCIDRList of copies of cidrs, each
+// normalized to its network address. cidrs is left unchanged.
+func NewSortedCIDRList(cidrs []*CIDR) (*SortedCIDRList, error) {
+	list := make(CIDRList, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if len(cidr.Ip) != net.IPv4len && len(cidr.Ip) != net.IPv6len {
+			return nil, newError("invalid IP length: ", len(cidr.Ip))
+		}
+		if cidr.Prefix > uint32(len(cidr.Ip)*8) {
+			return nil, newError("invalid prefix length ", cidr.Prefix, " for IP of length ", len(cidr.Ip))
+		}
+		list = append(list, &CIDR{Ip: maskIP(cidr.Ip, cidr.Prefix), Prefix: cidr.Prefix})
+	}
+	sort.Sort(&list)
+	return &SortedCIDRList{list: list}, nil
+}
+
+// Contains returns true if ip is included by any CIDR in the list. IPv4
+// addresses in 16-byte form are matched against IPv4 CIDRs.
+func (l *SortedCIDRList) Contains(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return l.list.Contains(ip)
+}
+
 type Rule struct {
-	Tag       string
-	Balancer  *Balancer
-	Condition Condition
+	Tag            string
+	Balancer       *Balancer
+	BalancerTag    string
+	Condition      Condition
+	RuleTag        string
+	TrafficStats   bool
+	TOS            uint32
+	DialTimeout    time.Duration
+	ResolverTag    string
+	SetAttributes  map[string]string
+	MirrorTag      string
+	LogSampler     *LogSampler
+	RedirectTarget *net.Endpoint
+	RateLimiter    *ratelimit.Limiter
+	// Final is whether this is the final rule, which only applies to
+	// connections matching no other rule. It has no condition.
+	Final bool
 }
 
 func (r *Rule) GetTag() (string, error) {
@@ -61,80 +189,337 @@ func (r *Rule) GetTag() (string, error) {
 	return r.Tag, nil
 }
 
-// Apply checks rule matching of current routing context.
+// GetTags returns the outbound tags of this rule for the routing context, in
+// the order they should be tried if handlers of former ones are missing.
+func (r *Rule) GetTags(ctx routing.Context) ([]string, error) {
+	if r.Balancer != nil {
+		return r.Balancer.PickOutboundsFor(ctx)
+	}
+	return []string{r.Tag}, nil
+}
+
+// Apply checks rule matching of current routing context. The final rule
+// never matches.
 func (r *Rule) Apply(ctx routing.Context) bool {
-	return r.Condition.Apply(ctx)
+	return !r.Final && r.Condition.Apply(ctx)
+}
+
+func negateIf(cond Condition, negate bool) Condition {
+	if negate {
+		return NewNegateMatcher(cond)
+	}
+	return cond
+}
+
+// PortRange returns the range of ports in the class, or nil if the class is
+// unknown.
+func (c RoutingRule_PortClass) PortRange() *net.PortRange {
+	switch c {
+	case RoutingRule_Privileged:
+		return &net.PortRange{From: 0, To: 1023}
+	case RoutingRule_Registered:
+		return &net.PortRange{From: 1024, To: 49151}
+	case RoutingRule_Ephemeral:
+		return &net.PortRange{From: 49152, To: 65535}
+	default:
+		return nil
+	}
 }
 
+// resolvePortList returns ports in list and the named port sets together, or
+// nil if there are none.
+func resolvePortList(list *net.PortList, names []string, portSets map[string]*net.PortList) (*net.PortList, error) {
+	if len(names) == 0 {
+		return list, nil
+	}
+	resolved := new(net.PortList)
+	if list != nil {
+		resolved.Range = append(resolved.Range, list.Range...)
+	}
+	for _, name := range names {
+		set, found := portSets[name]
+		if !found {
+			return nil, newError("port set not found: ", name)
+		}
+		resolved.Range = append(resolved.Range, set.GetRange()...)
+	}
+	return resolved, nil
+}
+
+// BuildCondition builds the condition of this rule. Rules referring to port
+// sets must be built with BuildConditionWithPortSets instead.
 func (rr *RoutingRule) BuildCondition() (Condition, error) {
+	return rr.buildCondition(&conditionEnv{geoIPs: &globalGeoIPContainer})
+}
+
+// BuildConditionWithPortSets builds the condition of this rule, resolving
+// names of port sets in portSets.
+func (rr *RoutingRule) BuildConditionWithPortSets(portSets map[string]*net.PortList) (Condition, error) {
+	return rr.buildCondition(&conditionEnv{geoIPs: &globalGeoIPContainer, portSets: portSets})
+}
+
+// buildMatcher builds the condition with build, or defers it to the first use
+// of the condition if lazy.
+func buildMatcher(lazy bool, build func() (Condition, error)) (Condition, error) {
+	if lazy {
+		return NewLazyMatcher(build), nil
+	}
+	return build()
+}
+
+// conditionEnv is what conditions of rules are built with.
+type conditionEnv struct {
+	geoIPs   *GeoIPMatcherContainer
+	portSets map[string]*net.PortList
+	// Whether domain and IP conditions are built on first use.
+	lazy bool
+	// Cache shared by domain matchers, if not nil.
+	domainCache *DomainMatchCache
+	// DNS client for reverse lookups, if not nil.
+	dns dns.Client
+	// Mapper of geodata files that GeoIPs and geosite entries with sources
+	// are searched in place in, if not nil.
+	geoData *geoDataMapper
+}
+
+// splitGeoSites returns geosite entries of which the domains are matched, and
+// those searched in place as geodata is mapped.
+func (env *conditionEnv) splitGeoSites(sites []*GeoSite) ([]*GeoSite, []*GeoSite) {
+	if env.geoData == nil {
+		return sites, nil
+	}
+	var loaded, mapped []*GeoSite
+	for _, site := range sites {
+		if len(site.Source) > 0 {
+			mapped = append(mapped, site)
+		} else {
+			loaded = append(loaded, site)
+		}
+	}
+	return loaded, mapped
+}
+
+// mapGeoSites makes the matcher search the geosite entries in place after its
+// domains.
+func (env *conditionEnv) mapGeoSites(matcher *DomainMatcher, sites []*GeoSite, anchorRegex bool) error {
+	for _, site := range sites {
+		matchers, err := env.geoData.newGeoSiteMatchers(site, anchorRegex)
+		if err != nil {
+			return newError("failed to map geosite").Base(err)
+		}
+		matcher.mapped = append(matcher.mapped, matchers...)
+	}
+	return nil
+}
+
+// newGeoIPMatcher builds the matcher of the GeoIPs. Those with sources are
+// searched in place if geodata is mapped.
+func (env *conditionEnv) newGeoIPMatcher(geoips []*GeoIP, onSource bool) (*MultiGeoIPMatcher, error) {
+	var loaded []*GeoIP
+	var mapped []*mappedGeoIPMatcher
+	for _, geoip := range geoips {
+		if env.geoData == nil || len(geoip.Source) == 0 {
+			loaded = append(loaded, geoip)
+			continue
+		}
+		matcher, err := env.geoData.newGeoIPMatcher(geoip)
+		if err != nil {
+			return nil, newError("failed to map geoip").Base(err)
+		}
+		mapped = append(mapped, matcher)
+	}
+	matcher, err := newMultiGeoIPMatcher(env.geoIPs, loaded, onSource)
+	if err != nil {
+		return nil, err
+	}
+	matcher.mapped = mapped
+	return matcher, nil
+}
+
+// buildCondition builds the condition of this rule.
+func (rr *RoutingRule) buildCondition(env *conditionEnv) (Condition, error) {
 	conds := NewConditionChan()
 
-	if len(rr.Domain) > 0 {
-		switch rr.DomainMatcher {
-		case "mph", "hybrid":
-			matcher, err := NewMphMatcherGroup(rr.Domain)
-			if err != nil {
-				return nil, newError("failed to build domain condition with MphDomainMatcher").Base(err)
-			}
-			newError("MphDomainMatcher is enabled for ", len(rr.Domain), " domain rule(s)").AtDebug().WriteToLog()
-			conds.Add(matcher)
-		case "linear":
-			fallthrough
-		default:
-			matcher, err := NewDomainMatcher(rr.Domain)
-			if err != nil {
-				return nil, newError("failed to build domain condition").Base(err)
+	// Connection rate is checked first, so that all connections evaluated
+	// against the rule are counted.
+	if rr.ConnectionRate != nil {
+		cond, err := NewConnectionRateMatcher(rr.ConnectionRate, nil)
+		if err != nil {
+			return nil, newError("failed to build connection rate condition").Base(err)
+		}
+		conds.Add(cond)
+	}
+
+	sites, mappedSites := env.splitGeoSites(rr.Geosite)
+	reverseSites, mappedReverseSites := env.splitGeoSites(rr.ReverseGeosite)
+	domains := withGeoSiteDomains(rr.Domain, sites)
+	reverseDomains := withGeoSiteDomains(rr.ReverseDomain, reverseSites)
+	if rr.AnchorRegex {
+		domains = anchorRegexDomains(domains)
+		reverseDomains = anchorRegexDomains(reverseDomains)
+	}
+
+	if len(domains) > 0 || len(mappedSites) > 0 {
+		matcherType := rr.DomainMatcher
+		if hasLabel(domains) || len(domains) == 0 {
+			// Labels are only supported by the linear matcher. It is also used
+			// if only geosite entries searched in place are matched.
+			matcherType = "linear"
+		}
+		cond, err := buildMatcher(env.lazy, func() (Condition, error) {
+			switch matcherType {
+			case "mph", "hybrid":
+				matcher, err := NewMphMatcherGroup(domains)
+				if err != nil {
+					return nil, newError("failed to build domain condition with MphDomainMatcher").Base(err)
+				}
+				newError("MphDomainMatcher is enabled for ", len(domains), " domain rule(s)").AtDebug().WriteToLog()
+				if err := env.mapGeoSites(matcher, mappedSites, rr.AnchorRegex); err != nil {
+					return nil, err
+				}
+				matcher.SetCache(env.domainCache)
+				return negateIf(matcher, rr.NegateDomain), nil
+			case "linear":
+				fallthrough
+			default:
+				matcher, err := NewDomainMatcher(domains)
+				if err != nil {
+					return nil, newError("failed to build domain condition").Base(err)
+				}
+				if err := env.mapGeoSites(matcher, mappedSites, rr.AnchorRegex); err != nil {
+					return nil, err
+				}
+				matcher.SetCache(env.domainCache)
+				return negateIf(matcher, rr.NegateDomain), nil
 			}
-			conds.Add(matcher)
+		})
+		if err != nil {
+			return nil, err
+		}
+		conds.Add(cond)
+	}
+
+	if len(rr.DomainSuffixPsl) > 0 {
+		matcher, err := NewPublicSuffixMatcher(rr.DomainSuffixPsl)
+		if err != nil {
+			return nil, newError("failed to build public suffix condition").Base(err)
+		}
+		conds.Add(matcher)
+	}
+
+	if len(reverseDomains) > 0 || len(mappedReverseSites) > 0 {
+		// Falls back to the system resolver if the DNS client can't do reverse lookups.
+		resolver, _ := env.dns.(dns.ReverseLookup)
+		ttl := time.Duration(rr.ReverseLookupTtl) * time.Second
+		matcher, err := NewDomainMatcher(reverseDomains)
+		if err != nil {
+			return nil, newError("failed to build reverse domain condition").Base(err)
+		}
+		if err := env.mapGeoSites(matcher, mappedReverseSites, rr.AnchorRegex); err != nil {
+			return nil, err
 		}
+		conds.Add(newReverseDomainMatcher(matcher, resolver, ttl, nil))
 	}
 
 	if len(rr.UserEmail) > 0 {
-		conds.Add(NewUserMatcher(rr.UserEmail))
+		conds.Add(negateIf(NewUserMatcher(rr.UserEmail), rr.NegateUserEmail))
+	}
+
+	if len(rr.ProcessPath) > 0 {
+		conds.Add(NewProcessPathMatcher(rr.ProcessPath, nil))
 	}
 
 	if len(rr.InboundTag) > 0 {
-		conds.Add(NewInboundTagMatcher(rr.InboundTag))
+		conds.Add(negateIf(NewInboundTagMatcher(rr.InboundTag), rr.NegateInboundTag))
 	}
 
-	if rr.PortList != nil {
-		conds.Add(NewPortMatcher(rr.PortList, false))
-	} else if rr.PortRange != nil {
-		conds.Add(NewPortMatcher(&net.PortList{Range: []*net.PortRange{rr.PortRange}}, false))
+	if len(rr.TransportProtocol) > 0 {
+		conds.Add(NewTransportProtocolMatcher(rr.TransportProtocol))
+	}
+
+	portList := rr.PortList
+	if portList == nil && rr.PortRange != nil {
+		portList = &net.PortList{Range: []*net.PortRange{rr.PortRange}}
+	}
+	portList, err := resolvePortList(portList, rr.PortSetName, env.portSets)
+	if err != nil {
+		return nil, newError("failed to build port condition").Base(err)
+	}
+	if len(rr.PortClass) > 0 {
+		if portList == nil {
+			portList = new(net.PortList)
+		} else {
+			portList = &net.PortList{Range: append([]*net.PortRange(nil), portList.Range...)}
+		}
+		for _, class := range rr.PortClass {
+			portRange := class.PortRange()
+			if portRange == nil {
+				return nil, newError("unknown port class: ", class)
+			}
+			portList.Range = append(portList.Range, portRange)
+		}
+	}
+	if portList != nil {
+		conds.Add(negateIf(NewPortMatcher(portList, false), rr.NegatePort))
 	}
 
-	if rr.SourcePortList != nil {
-		conds.Add(NewPortMatcher(rr.SourcePortList, true))
+	sourcePortList, err := resolvePortList(rr.SourcePortList, rr.SourcePortSetName, env.portSets)
+	if err != nil {
+		return nil, newError("failed to build source port condition").Base(err)
+	}
+	if sourcePortList != nil {
+		conds.Add(negateIf(NewPortMatcher(sourcePortList, true), rr.NegateSourcePort))
 	}
 
 	if len(rr.Networks) > 0 {
-		conds.Add(NewNetworkMatcher(rr.Networks))
+		conds.Add(negateIf(NewNetworkMatcher(rr.Networks), rr.NegateNetwork))
 	} else if rr.NetworkList != nil {
-		conds.Add(NewNetworkMatcher(rr.NetworkList.Network))
+		conds.Add(negateIf(NewNetworkMatcher(rr.NetworkList.Network), rr.NegateNetwork))
 	}
 
-	if len(rr.Geoip) > 0 {
-		cond, err := NewMultiGeoIPMatcher(rr.Geoip, false)
+	geoips := rr.Geoip
+	if len(rr.IpRange) > 0 {
+		cidrs, err := ipRangesToCIDRs(rr.IpRange)
 		if err != nil {
 			return nil, err
 		}
-		conds.Add(cond)
+		geoips = append(geoips[:len(geoips):len(geoips)], &GeoIP{Cidr: cidrs})
+	}
+
+	var cidrGeoIPs []*GeoIP
+	if len(geoips) > 0 {
+		cidrGeoIPs = geoips
 	} else if len(rr.Cidr) > 0 {
-		cond, err := NewMultiGeoIPMatcher([]*GeoIP{{Cidr: rr.Cidr}}, false)
+		cidrGeoIPs = []*GeoIP{{Cidr: rr.Cidr}}
+	}
+	if len(cidrGeoIPs) > 0 {
+		cond, err := buildMatcher(env.lazy, func() (Condition, error) {
+			cond, err := env.newGeoIPMatcher(cidrGeoIPs, false)
+			if err != nil {
+				return nil, err
+			}
+			return negateIf(cond, rr.NegateIp), nil
+		})
 		if err != nil {
 			return nil, err
 		}
 		conds.Add(cond)
 	}
 
+	var sourceGeoIPs []*GeoIP
 	if len(rr.SourceGeoip) > 0 {
-		cond, err := NewMultiGeoIPMatcher(rr.SourceGeoip, true)
-		if err != nil {
-			return nil, err
-		}
-		conds.Add(cond)
+		sourceGeoIPs = rr.SourceGeoip
 	} else if len(rr.SourceCidr) > 0 {
-		cond, err := NewMultiGeoIPMatcher([]*GeoIP{{Cidr: rr.SourceCidr}}, true)
+		sourceGeoIPs = []*GeoIP{{Cidr: rr.SourceCidr}}
+	}
+	if len(sourceGeoIPs) > 0 {
+		cond, err := buildMatcher(env.lazy, func() (Condition, error) {
+			cond, err := env.newGeoIPMatcher(sourceGeoIPs, true)
+			if err != nil {
+				return nil, err
+			}
+			return negateIf(cond, rr.NegateSourceIp), nil
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -142,7 +527,7 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 	}
 
 	if len(rr.Protocol) > 0 {
-		conds.Add(NewProtocolMatcher(rr.Protocol))
+		conds.Add(negateIf(NewProtocolMatcher(rr.Protocol), rr.NegateProtocol))
 	}
 
 	if len(rr.Attributes) > 0 {
@@ -150,9 +535,57 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 		if err != nil {
 			return nil, err
 		}
+		conds.Add(negateIf(cond, rr.NegateAttributes))
+	}
+
+	if rr.IsIpQuery != RoutingRule_Any {
+		conds.Add(NewIPQueryMatcher(rr.IsIpQuery))
+	}
+
+	if rr.DomainFronting != DomainFronting_Any {
+		conds.Add(NewDomainFrontingMatcher(rr.DomainFronting))
+	}
+
+	if len(rr.Ja3) > 0 {
+		conds.Add(NewJa3Matcher(rr.Ja3))
+	}
+
+	if rr.Schedule != nil {
+		cond, err := NewScheduleMatcher(rr.Schedule, nil)
+		if err != nil {
+			return nil, newError("failed to build schedule condition").Base(err)
+		}
+		conds.Add(cond)
+	}
+
+	if rr.DnsResult != nil {
+		cond, err := NewDNSResultMatcher(rr.DnsResult)
+		if err != nil {
+			return nil, newError("failed to build DNS result condition").Base(err)
+		}
+		conds.Add(cond)
+	}
+
+	if rr.SamplePercent != 0 {
+		cond, err := NewSampleMatcher(rr.SamplePercent, rr.SampleHashKey)
+		if err != nil {
+			return nil, newError("failed to build sample condition").Base(err)
+		}
 		conds.Add(cond)
 	}
 
+	if len(rr.OrGroups) > 0 {
+		groups := NewConditionOr()
+		for _, group := range rr.OrGroups {
+			cond, err := group.buildCondition(env)
+			if err != nil {
+				return nil, newError("failed to build condition group").Base(err)
+			}
+			groups.Add(cond)
+		}
+		conds.Add(groups)
+	}
+
 	if conds.Len() == 0 {
 		return nil, newError("this rule has no effective fields").AtWarning()
 	}
@@ -161,20 +594,92 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 }
 
 func (br *BalancingRule) Build(ohm outbound.Manager) (*Balancer, error) {
+	patterns, err := compileSelectors(br.SelectorMatch, br.OutboundSelector)
+	if err != nil {
+		return nil, err
+	}
+	balancer := &Balancer{
+		tag:           br.Tag,
+		selectors:     br.OutboundSelector,
+		selectorMatch: br.SelectorMatch,
+		patterns:      patterns,
+		drainPeriod:   time.Duration(br.DrainGracePeriod) * time.Millisecond,
+		maxFailures:   br.MaxFailures,
+		ohm:           ohm,
+	}
+	if br.WarmPoolSize > 0 {
+		balancer.warmPoolSize = int(br.WarmPoolSize)
+		balancer.warmPoolMaxIdle = time.Duration(br.WarmPoolMaxIdle) * time.Millisecond
+		if balancer.warmPoolMaxIdle == 0 {
+			balancer.warmPoolMaxIdle = time.Minute
+		}
+	}
+	if br.AffinityTtl > 0 {
+		balancer.affinity = NewAffinityTable(time.Duration(br.AffinityTtl)*time.Millisecond, nil)
+	}
+
 	switch br.Strategy {
 	case "leastPing":
-		return &Balancer{
-			selectors: br.OutboundSelector,
-			strategy:  &LeastPingStrategy{},
-			ohm:       ohm,
-		}, nil
-	case "random":
-		fallthrough
+		balancer.strategy = &LeastPingStrategy{
+			ohm:            ohm,
+			maxFailures:    br.MaxFailures,
+			maxConnPerNode: br.MaxConnPerNode,
+		}
+	case "composite":
+		balancer.strategy = NewCompositeStrategy(ohm, float64(br.LatencyWeight), float64(br.LoadWeight))
+	case "weightedHealthy":
+		balancer.strategy = NewWeightedHealthyStrategy(ohm, br.OutboundWeight, br.MaxFailures)
+	case "random", "":
+		balancer.strategy = &RandomStrategy{}
 	default:
-		return &Balancer{
-			selectors: br.OutboundSelector,
-			strategy:  &RandomStrategy{},
-			ohm:       ohm,
-		}, nil
+		strategy, err := createBalancingStrategy(br, ohm)
+		if err != nil {
+			return nil, err
+		}
+		balancer.strategy = strategy
+	}
+	return balancer, nil
+}
+
+// compileSelectors returns regular expressions of selectors, or nil if they
+// are matched as prefixes.
+func compileSelectors(match BalancingRule_SelectorMatch, selectors []string) ([]*regexp.Regexp, error) {
+	switch match {
+	case BalancingRule_Prefix:
+		return nil, nil
+	case BalancingRule_Glob, BalancingRule_Regex:
+		patterns := make([]*regexp.Regexp, 0, len(selectors))
+		for _, selector := range selectors {
+			expr := selector
+			if match == BalancingRule_Glob {
+				expr = globToRegex(selector)
+			}
+			pattern, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, newError("invalid outbound selector: ", selector).Base(err)
+			}
+			patterns = append(patterns, pattern)
+		}
+		return patterns, nil
+	default:
+		return nil, newError("unknown selector match type: ", match)
+	}
+}
+
+// globToRegex converts a glob pattern to a regular expression matching whole strings.
+func globToRegex(glob string) string {
+	var sb strings.Builder
+	sb.WriteByte('^')
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteByte('.')
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
 	}
+	sb.WriteByte('$')
+	return sb.String()
 }
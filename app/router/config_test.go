@@ -0,0 +1,127 @@
+package router_test
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+func randomCIDR(r *rand.Rand, length int) *router.CIDR {
+	ip := make([]byte, length)
+	r.Read(ip)
+	// Keep addresses in a narrow space so that CIDRs overlap with each other.
+	ip[0] = byte(r.Intn(4))
+	prefix := uint32(r.Intn(length*8 + 1))
+	for i := range ip {
+		switch {
+		case uint32(i*8+8) <= prefix:
+		case uint32(i*8) < prefix:
+			ip[i] &= 0xff << (8 - (prefix - uint32(i*8)))
+		default:
+			ip[i] = 0
+		}
+	}
+	return &router.CIDR{Ip: ip, Prefix: prefix}
+}
+
+func bruteForceContains(cidrs []*router.CIDR, ip net.IP) bool {
+	for _, cidr := range cidrs {
+		if len(cidr.Ip) != len(ip) {
+			continue
+		}
+		ipNet := &net.IPNet{IP: net.IP(cidr.Ip), Mask: net.CIDRMask(int(cidr.Prefix), len(cidr.Ip)*8)}
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCIDRListContains(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	var cidrs []*router.CIDR
+	for i := 0; i < 20000; i++ {
+		cidrs = append(cidrs, randomCIDR(r, 4))
+	}
+	for i := 0; i < 5000; i++ {
+		cidrs = append(cidrs, randomCIDR(r, 16))
+	}
+	cidrs = append(cidrs, &router.CIDR{Ip: []byte{2, 0, 0, 0}, Prefix: 8}, &router.CIDR{Ip: []byte{2, 1, 0, 0}, Prefix: 16})
+
+	list := router.CIDRList(append([]*router.CIDR(nil), cidrs...))
+	sort.Sort(&list)
+
+	var ips []net.IP
+	for i := 0; i < 20000; i++ {
+		length := 4
+		if i%4 == 0 {
+			length = 16
+		}
+		ip := make([]byte, length)
+		r.Read(ip)
+		ip[0] = byte(r.Intn(5))
+		ips = append(ips, ip)
+	}
+	for _, cidr := range cidrs[:1000] {
+		ips = append(ips, net.IP(cidr.Ip))
+	}
+	ips = append(ips, net.IP{2, 2, 3, 4}, net.IP{2, 1, 255, 255}, net.IP{0, 0, 0, 0})
+
+	for _, ip := range ips {
+		if expected, actual := bruteForceContains(cidrs, ip), list.Contains(ip); expected != actual {
+			t.Error("expect ", ip, " to be ", expected, ", but actually ", actual)
+		}
+	}
+
+	var empty router.CIDRList
+	if empty.Contains(net.IP{1, 2, 3, 4}) {
+		t.Error("expect empty list not to contain any IP")
+	}
+}
+
+func TestSortedCIDRList(t *testing.T) {
+	cidrs := []*router.CIDR{
+		{Ip: net.ParseIP("2001:db8::1").To16(), Prefix: 32},
+		{Ip: []byte{192, 168, 1, 1}, Prefix: 24},
+		{Ip: []byte{10, 0, 0, 0}, Prefix: 8},
+		{Ip: net.ParseIP("::1").To16(), Prefix: 128},
+	}
+	list, err := router.NewSortedCIDRList(cidrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cidrs[1].Ip[3] != 1 {
+		t.Error("expect input CIDRs not to be modified")
+	}
+
+	testCases := []struct {
+		ip       string
+		expected bool
+	}{
+		{ip: "10.1.2.3", expected: true},
+		{ip: "11.0.0.0", expected: false},
+		{ip: "192.168.1.200", expected: true},
+		{ip: "192.168.2.1", expected: false},
+		{ip: "2001:db8:ffff::1", expected: true},
+		{ip: "2001:db9::1", expected: false},
+		{ip: "::1", expected: true},
+		{ip: "::2", expected: false},
+		{ip: "::ffff:10.0.0.1", expected: true},
+	}
+	for _, tc := range testCases {
+		if actual := list.Contains(net.ParseIP(tc.ip)); actual != tc.expected {
+			t.Error("expect ", tc.ip, " to be ", tc.expected, ", but actually ", actual)
+		}
+	}
+
+	if _, err := router.NewSortedCIDRList([]*router.CIDR{{Ip: []byte{1, 2, 3}, Prefix: 8}}); err == nil {
+		t.Error("expect error on invalid IP length")
+	}
+	if _, err := router.NewSortedCIDRList([]*router.CIDR{{Ip: []byte{1, 2, 3, 4}, Prefix: 33}}); err == nil {
+		t.Error("expect error on invalid prefix")
+	}
+}
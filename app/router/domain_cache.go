@@ -0,0 +1,64 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"sync/atomic"
+
+	"github.com/v2fly/v2ray-core/v4/common/cache"
+)
+
+// domainMatchCacheShards is the number of independently locked parts of a
+// DomainMatchCache, so that concurrent lookups seldom contend.
+const domainMatchCacheShards = 16
+
+// DomainMatchCache memoizes match results of the domain matchers of a router
+// by queried domain. It is shared by all rules built together, and replaced
+// when rules are rebuilt. It is safe for concurrent use.
+type DomainMatchCache struct {
+	shards [domainMatchCacheShards]cache.Lru
+	lastID uint32
+}
+
+type domainMatchKey struct {
+	matcher uint32
+	domain  string
+}
+
+// NewDomainMatchCache creates a new DomainMatchCache keeping about size most
+// recent results. It returns nil if size is not positive.
+func NewDomainMatchCache(size int) *DomainMatchCache {
+	if size <= 0 {
+		return nil
+	}
+	shardSize := (size + domainMatchCacheShards - 1) / domainMatchCacheShards
+	c := new(DomainMatchCache)
+	for i := range c.shards {
+		c.shards[i] = cache.NewLru(shardSize)
+	}
+	return c
+}
+
+// newMatcherID returns a new ID to tell results of a matcher from others.
+func (c *DomainMatchCache) newMatcherID() uint32 {
+	return atomic.AddUint32(&c.lastID, 1)
+}
+
+func (c *DomainMatchCache) shard(domain string) cache.Lru {
+	// FNV-1a
+	h := uint32(2166136261)
+	for i := 0; i < len(domain); i++ {
+		h ^= uint32(domain[i])
+		h *= 16777619
+	}
+	return c.shards[h%domainMatchCacheShards]
+}
+
+func (c *DomainMatchCache) get(matcher uint32, domain string) (interface{}, bool) {
+	return c.shard(domain).Get(domainMatchKey{matcher: matcher, domain: domain})
+}
+
+func (c *DomainMatchCache) put(matcher uint32, domain string, result interface{}) {
+	c.shard(domain).Put(domainMatchKey{matcher: matcher, domain: domain}, result)
+}
@@ -0,0 +1,205 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"fmt"
+
+	"github.com/v2fly/v2ray-core/v4/features/routing"
+	routing_dns "github.com/v2fly/v2ray-core/v4/features/routing/dns"
+)
+
+// RuleTrace is the result of evaluating a routing rule in ExplainRoute.
+type RuleTrace struct {
+	// Index of the rule in the config.
+	Index       int
+	RuleTag     string
+	OutboundTag string
+	BalancerTag string
+	// Whether target IPs were resolved from the target domain, in the second
+	// pass of rules with IpIfNonMatch domain strategy.
+	ResolvedIP bool
+	Matched    bool
+	// Condition of the rule not satisfied, if not matched.
+	Reason string
+}
+
+// ExplainRoute evaluates rules against the routing context in the same order
+// as PickRoute, and returns traces of the evaluated rules. The last trace is
+// of the matching rule, if any, or of the final rule if no rule matches.
+// Balancers are not consulted, so that routing in progress is not affected.
+func (r *Router) ExplainRoute(ctx routing.Context) []*RuleTrace {
+	skipDNSResolve := ctx.GetSkipDNSResolve()
+	if r.domainStrategy == Config_IpOnDemand && !skipDNSResolve {
+		ctx = routing_dns.ContextWithDNSClient(ctx, r.dns)
+	}
+
+	rules := r.getRules()
+	traces, matched := explainRules(rules, ctx, false)
+	if !matched && r.domainStrategy == Config_IpIfNonMatch && len(ctx.GetTargetDomain()) > 0 && !skipDNSResolve {
+		ctx = routing_dns.ContextWithDNSClient(ctx, r.dns)
+		var resolvedTraces []*RuleTrace
+		resolvedTraces, matched = explainRules(rules, ctx, true)
+		traces = append(traces, resolvedTraces...)
+	}
+	if matched {
+		return traces
+	}
+
+	for i, rule := range rules {
+		if rule.Final {
+			trace := newRuleTrace(i, rule, traces[len(traces)-1].ResolvedIP)
+			trace.Matched = true
+			return append(traces, trace)
+		}
+	}
+	return traces
+}
+
+func explainRules(rules []*Rule, ctx routing.Context, resolvedIP bool) ([]*RuleTrace, bool) {
+	traces := make([]*RuleTrace, 0, len(rules))
+	for i, rule := range rules {
+		trace := newRuleTrace(i, rule, resolvedIP)
+		if rule.Final {
+			trace.Reason = "final rule skipped"
+		} else {
+			trace.Matched, trace.Reason = explainCondition(rule.Condition, ctx)
+		}
+		traces = append(traces, trace)
+		if trace.Matched {
+			return traces, true
+		}
+	}
+	return traces, false
+}
+
+func newRuleTrace(index int, rule *Rule, resolvedIP bool) *RuleTrace {
+	trace := &RuleTrace{
+		Index:      index,
+		RuleTag:    rule.RuleTag,
+		ResolvedIP: resolvedIP,
+	}
+	if rule.Balancer != nil {
+		trace.BalancerTag = rule.BalancerTag
+	} else {
+		trace.OutboundTag = rule.Tag
+	}
+	return trace
+}
+
+// peekCondition is implemented by conditions whose Apply records the
+// connection, to be evaluated by ExplainRoute without doing so.
+type peekCondition interface {
+	Peek(ctx routing.Context) bool
+}
+
+// peek evaluates the condition as Apply does, without side effects on
+// conditions implementing peekCondition.
+func peek(cond Condition, ctx routing.Context) bool {
+	switch c := cond.(type) {
+	case peekCondition:
+		return c.Peek(ctx)
+	case *ConditionChan:
+		for _, cond := range *c {
+			if !peek(cond, ctx) {
+				return false
+			}
+		}
+		return true
+	case *ConditionOr:
+		for _, cond := range *c {
+			if peek(cond, ctx) {
+				return true
+			}
+		}
+		return false
+	case *NegateMatcher:
+		return !peek(c.cond, ctx)
+	case *LazyMatcher:
+		cond := c.Condition()
+		return cond != nil && peek(cond, ctx)
+	default:
+		return cond.Apply(ctx)
+	}
+}
+
+// explainCondition evaluates the condition, and returns the first condition
+// not satisfied among those combined by AND if it doesn't match.
+func explainCondition(cond Condition, ctx routing.Context) (bool, string) {
+	if conds, ok := cond.(*ConditionChan); ok {
+		for _, c := range *conds {
+			if !peek(c, ctx) {
+				return false, describeCondition(c) + " not matched"
+			}
+		}
+		return true, ""
+	}
+	if peek(cond, ctx) {
+		return true, ""
+	}
+	return false, describeCondition(cond) + " not matched"
+}
+
+// describeCondition returns the name of the condition as in rule configs.
+func describeCondition(cond Condition) string {
+	switch c := cond.(type) {
+	case *NegateMatcher:
+		return "negated " + describeCondition(c.cond)
+	case *LazyMatcher:
+		if cond := c.Condition(); cond != nil {
+			return describeCondition(cond)
+		}
+		return "invalid condition"
+	case *ConditionChan:
+		return "conditions"
+	case *ConditionOr:
+		return "condition groups"
+	case *DomainMatcher:
+		return "domain"
+	case *PublicSuffixMatcher:
+		return "public suffix domain"
+	case *ReverseDomainMatcher:
+		return "reverse domain"
+	case *MultiGeoIPMatcher:
+		if c.onSource {
+			return "source IP"
+		}
+		return "IP"
+	case *PortMatcher:
+		if c.onSource {
+			return "source port"
+		}
+		return "port"
+	case NetworkMatcher:
+		return "network"
+	case *UserMatcher:
+		return "user"
+	case *InboundTagMatcher:
+		return "inbound tag"
+	case *TransportProtocolMatcher:
+		return "transport protocol"
+	case *ProtocolMatcher:
+		return "protocol"
+	case *IPQueryMatcher:
+		return "IP query"
+	case *DomainFrontingMatcher:
+		return "domain fronting"
+	case *Ja3Matcher:
+		return "JA3"
+	case *AttributeMatcher:
+		return "attributes"
+	case *ProcessPathMatcher:
+		return "process path"
+	case *ScheduleMatcher:
+		return "schedule"
+	case *ConnectionRateMatcher:
+		return "connection rate"
+	case *DNSResultMatcher:
+		return "DNS result"
+	case *SampleMatcher:
+		return "sample"
+	default:
+		return fmt.Sprintf("%T", cond)
+	}
+}
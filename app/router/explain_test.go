@@ -0,0 +1,137 @@
+package router_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	. "github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	routing_session "github.com/v2fly/v2ray-core/v4/features/routing/session"
+	"github.com/v2fly/v2ray-core/v4/testing/mocks"
+)
+
+func TestRouterExplainRoute(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	// Balancers must not be consulted, so no outbound selection is expected.
+	mockOhm := &mockOutboundManager{
+		Manager:         mocks.NewOutboundManager(mockCtl),
+		HandlerSelector: mocks.NewOutboundHandlerSelector(mockCtl),
+	}
+
+	config := &Config{
+		BalancingRule: []*BalancingRule{
+			{Tag: "balance", OutboundSelector: []string{"proxy-"}},
+		},
+		Rule: []*RoutingRule{
+			{
+				RuleTag:    "ads",
+				Domain:     []*Domain{{Type: Domain_Domain, Value: "ads.example.com"}},
+				InboundTag: []string{"socks"},
+				TargetTag:  &RoutingRule_Tag{Tag: "block"},
+			},
+			{
+				RuleTag:    "no-lan",
+				Geoip:      []*GeoIP{{Cidr: []*CIDR{{Ip: []byte{192, 168, 0, 0}, Prefix: 16}}}},
+				NegateIp:   true,
+				PortList:   &net.PortList{Range: []*net.PortRange{{From: 443, To: 443}}},
+				InboundTag: []string{"http"},
+				TargetTag:  &RoutingRule_BalancingTag{BalancingTag: "balance"},
+			},
+			{
+				RuleTag: "rest",
+				OrGroups: []*RoutingRule{
+					{Networks: []net.Network{net.Network_UDP}},
+					{PortList: &net.PortList{Range: []*net.PortRange{{From: 80, To: 80}}}},
+				},
+				TargetTag: &RoutingRule_Tag{Tag: "direct"},
+			},
+		},
+	}
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mocks.NewDNSClient(mockCtl), mockOhm))
+
+	explain := func(inbound string, target net.Destination) []*RuleTrace {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{Tag: inbound})
+		ctx = session.ContextWithOutbound(ctx, &session.Outbound{Target: target})
+		return r.ExplainRoute(routing_session.AsRoutingContext(ctx))
+	}
+
+	testCases := []struct {
+		inbound  string
+		target   net.Destination
+		expected []*RuleTrace
+	}{
+		{
+			inbound: "socks",
+			target:  net.TCPDestination(net.DomainAddress("ads.example.com"), 443),
+			expected: []*RuleTrace{
+				{Index: 0, RuleTag: "ads", OutboundTag: "block", Matched: true},
+			},
+		},
+		{
+			inbound: "http",
+			target:  net.TCPDestination(net.ParseAddress("1.2.3.4"), 443),
+			expected: []*RuleTrace{
+				{Index: 0, RuleTag: "ads", OutboundTag: "block", Reason: "domain not matched"},
+				{Index: 1, RuleTag: "no-lan", BalancerTag: "balance", Matched: true},
+			},
+		},
+		{
+			inbound: "http",
+			target:  net.TCPDestination(net.ParseAddress("192.168.1.1"), 443),
+			expected: []*RuleTrace{
+				{Index: 0, RuleTag: "ads", OutboundTag: "block", Reason: "domain not matched"},
+				{Index: 1, RuleTag: "no-lan", BalancerTag: "balance", Reason: "negated IP not matched"},
+				{Index: 2, RuleTag: "rest", OutboundTag: "direct", Reason: "condition groups not matched"},
+			},
+		},
+		{
+			inbound: "http",
+			target:  net.TCPDestination(net.ParseAddress("1.2.3.4"), 80),
+			expected: []*RuleTrace{
+				{Index: 0, RuleTag: "ads", OutboundTag: "block", Reason: "domain not matched"},
+				{Index: 1, RuleTag: "no-lan", BalancerTag: "balance", Reason: "port not matched"},
+				{Index: 2, RuleTag: "rest", OutboundTag: "direct", Matched: true},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		if r := cmp.Diff(explain(tc.inbound, tc.target), tc.expected); r != "" {
+			t.Error(tc.target, ": ", r)
+		}
+	}
+}
+
+func TestRouterExplainRouteConnectionRate(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				RuleTag:        "abuse",
+				ConnectionRate: &ConnectionRate{Window: 60000, Threshold: 1},
+				TargetTag:      &RoutingRule_Tag{Tag: "throttled"},
+			},
+		},
+	}
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, nil, nil))
+
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+		Source: net.TCPDestination(net.ParseAddress("10.0.0.1"), 1234),
+	})
+	expected := []*RuleTrace{
+		{Index: 0, RuleTag: "abuse", OutboundTag: "throttled", Reason: "connection rate not matched"},
+	}
+	// Explaining must not count the connection.
+	for i := 0; i < 3; i++ {
+		if r := cmp.Diff(r.ExplainRoute(routing_session.AsRoutingContext(ctx)), expected); r != "" {
+			t.Fatal(r)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package router
+
+import "sync"
+
+// GeoDataLoader loads entries of geodata files, e.g. geoip.dat and
+// geosite.dat in the asset directory.
+type GeoDataLoader interface {
+	// LoadIP returns CIDRs of the entry with the country code in the file.
+	LoadIP(filename, country string) ([]*CIDR, error)
+	// LoadGeoSiteWithAttr returns domains of the list in the file, filtered by
+	// attributes following the list name, e.g. "google@ads".
+	LoadGeoSiteWithAttr(filename, siteWithAttr string) ([]*Domain, error)
+}
+
+var (
+	geoDataLoaderCreator     func() (GeoDataLoader, error)
+	geoDataLoaderCreatorLock sync.RWMutex
+)
+
+// RegisterGeoDataLoaderCreator registers the creator of loaders that GeoIPs and
+// geosite entries of routing rules are loaded again with from their sources,
+// when geodata is reloaded. It is registered by the config loader, so that
+// entries are reloaded the same way they were loaded.
+func RegisterGeoDataLoaderCreator(creator func() (GeoDataLoader, error)) {
+	geoDataLoaderCreatorLock.Lock()
+	defer geoDataLoaderCreatorLock.Unlock()
+	geoDataLoaderCreator = creator
+}
+
+func newGeoDataLoader() (GeoDataLoader, error) {
+	geoDataLoaderCreatorLock.RLock()
+	creator := geoDataLoaderCreator
+	geoDataLoaderCreatorLock.RUnlock()
+
+	if creator == nil {
+		return nil, newError("no geodata loader registered")
+	}
+	return creator()
+}
@@ -0,0 +1,482 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"bytes"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/platform"
+)
+
+// Field numbers of encoded geodata messages, see config.proto.
+const (
+	geoListEntryField   = 1 // GeoIPList.entry, GeoSiteList.entry
+	geoCountryCodeField = 1 // GeoIP.country_code, GeoSite.country_code
+	geoIPCIDRField      = 2 // GeoIP.cidr
+	geoSiteDomainField  = 2 // GeoSite.domain
+	cidrIPField         = 1 // CIDR.ip
+	cidrPrefixField     = 2 // CIDR.prefix
+	domainTypeField     = 1 // Domain.type
+	domainValueField    = 2 // Domain.value
+	domainAttrField     = 3 // Domain.attribute
+	attrKeyField        = 1 // Domain.Attribute.key
+)
+
+// mappedGeoData is a geodata file mapped into memory. It is unmapped once
+// nothing refers to it, so matchers searching it in place keep a reference.
+type mappedGeoData struct {
+	file string
+	data []byte
+}
+
+func mapGeoData(file string) (*mappedGeoData, error) {
+	data, unmap, err := mapFile(platform.GetAssetLocation(file))
+	if err != nil {
+		return nil, newError("failed to map ", file).Base(err)
+	}
+	m := &mappedGeoData{file: file, data: data}
+	runtime.SetFinalizer(m, func(*mappedGeoData) {
+		if err := unmap(); err != nil {
+			newError("failed to unmap ", file).Base(err).AtWarning().WriteToLog()
+		}
+	})
+	return m, nil
+}
+
+// entry returns the encoded GeoIP or GeoSite of the code in the file, without
+// decoding other entries. The result refers to the mapped memory.
+func (m *mappedGeoData) entry(code string) ([]byte, bool, error) {
+	found, err := walkFields(m.data, func(num protowire.Number, value []byte) (bool, error) {
+		if num != geoListEntryField {
+			return false, nil
+		}
+		var entryCode []byte
+		if _, err := walkFields(value, func(num protowire.Number, value []byte) (bool, error) {
+			if num == geoCountryCodeField {
+				entryCode = value
+				return true, nil
+			}
+			return false, nil
+		}); err != nil {
+			return false, err
+		}
+		return strings.EqualFold(string(entryCode), code), nil
+	})
+	if err != nil {
+		return nil, false, newError("failed to decode ", m.file).Base(err)
+	}
+	return found, found != nil, nil
+}
+
+// walkFields calls f with the number and value of each length-delimited field
+// in the encoded message, until f returns true, and returns that value. Other
+// fields are skipped.
+func walkFields(b []byte, f func(num protowire.Number, value []byte) (bool, error)) ([]byte, error) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		value, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		done, err := f(num, value)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return value, nil
+		}
+	}
+	return nil, nil
+}
+
+// geoDataMapper maps each geodata file once for the rules built with it.
+type geoDataMapper struct {
+	access sync.Mutex
+	files  map[string]*mappedGeoData
+}
+
+func newGeoDataMapper() *geoDataMapper {
+	return &geoDataMapper{files: make(map[string]*mappedGeoData)}
+}
+
+func (m *geoDataMapper) file(name string) (*mappedGeoData, error) {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	if f, found := m.files[name]; found {
+		return f, nil
+	}
+	f, err := mapGeoData(name)
+	if err != nil {
+		return nil, err
+	}
+	m.files[name] = f
+	return f, nil
+}
+
+// mappedEntry is an entry of a mapped geodata file.
+type mappedEntry struct {
+	file *mappedGeoData
+	data []byte
+}
+
+// entries returns the entries of the sources with the code before "@", if
+// any. Sources not found are skipped, as long as any other is found.
+func (m *geoDataMapper) entries(sources []*GeoDataSource) ([]mappedEntry, error) {
+	var entries []mappedEntry
+	var missing *GeoDataSource
+	for _, source := range sources {
+		f, err := m.file(source.File)
+		if err != nil {
+			return nil, err
+		}
+		code := source.Code
+		if i := strings.IndexByte(code, '@'); i >= 0 {
+			code = code[:i]
+		}
+		data, found, err := f.entry(strings.TrimSpace(code))
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			missing = source
+			continue
+		}
+		entries = append(entries, mappedEntry{file: f, data: data})
+	}
+	if len(entries) == 0 && missing != nil {
+		return nil, newError("code ", missing.Code, " not found in ", missing.File)
+	}
+	return entries, nil
+}
+
+// mappedGeoIPMatcher matches IPs against CIDRs of GeoIPs searched in place in
+// mapped geodata files. Each match scans all CIDRs, which is slower than a
+// GeoIPMatcher, but the CIDRs take no memory other than the pages mapped.
+type mappedGeoIPMatcher struct {
+	entries      []mappedEntry
+	reverseMatch bool
+}
+
+func (m *geoDataMapper) newGeoIPMatcher(geoip *GeoIP) (*mappedGeoIPMatcher, error) {
+	entries, err := m.entries(geoip.Source)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		// Malformed entries are rejected when the rule is built.
+		if _, err := walkFields(entry.data, func(num protowire.Number, value []byte) (bool, error) {
+			if num != geoIPCIDRField {
+				return false, nil
+			}
+			_, _, err := decodeCIDR(value)
+			return false, err
+		}); err != nil {
+			return nil, newError("failed to decode ", entry.file.file).Base(err)
+		}
+	}
+	return &mappedGeoIPMatcher{
+		entries:      entries,
+		reverseMatch: geoip.ReverseMatch,
+	}, nil
+}
+
+func decodeCIDR(b []byte) ([]byte, uint64, error) {
+	var ip []byte
+	var prefix uint64
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == cidrIPField && typ == protowire.BytesType:
+			ip, n = protowire.ConsumeBytes(b)
+		case num == cidrPrefixField && typ == protowire.VarintType:
+			prefix, n = protowire.ConsumeVarint(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return ip, prefix, nil
+}
+
+// containsIP returns whether the CIDR of ip and prefix contains target of the
+// same length.
+func containsIP(ip []byte, prefix uint64, target []byte) bool {
+	if len(ip) != len(target) {
+		return false
+	}
+	if bits := uint64(len(ip)) * 8; prefix > bits {
+		prefix = bits
+	}
+	full := int(prefix / 8)
+	if !bytes.Equal(ip[:full], target[:full]) {
+		return false
+	}
+	if bits := prefix % 8; bits > 0 {
+		mask := byte(0xff) << (8 - bits)
+		return ip[full]&mask == target[full]&mask
+	}
+	return true
+}
+
+// Match returns true if the given ip is included by the GeoIPs.
+func (m *mappedGeoIPMatcher) Match(ip net.IP) bool {
+	if len(ip) != 4 && len(ip) != 16 {
+		return false
+	}
+	return m.match(ip) != m.reverseMatch
+}
+
+func (m *mappedGeoIPMatcher) match(ip net.IP) bool {
+	// IPv4-mapped IPv6 addresses (::ffff:a.b.c.d) match IPv4 CIDRs as well.
+	ip4 := ip.To4()
+	for _, entry := range m.entries {
+		matched, _ := walkFields(entry.data, func(num protowire.Number, value []byte) (bool, error) {
+			if num != geoIPCIDRField {
+				return false, nil
+			}
+			cidr, prefix, _ := decodeCIDR(value)
+			if len(cidr) == 4 {
+				return ip4 != nil && containsIP(cidr, prefix, ip4), nil
+			}
+			return len(ip) == 16 && containsIP(cidr, prefix, ip), nil
+		})
+		runtime.KeepAlive(entry.file)
+		if matched != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// mappedGeoSiteMatcher matches domains against those of a geosite entry
+// searched in place in a mapped geodata file, filtered by attributes. Each
+// match scans all domains, which is slower than a DomainMatcher, but only
+// regular expressions take memory other than the pages mapped.
+type mappedGeoSiteMatcher struct {
+	entry mappedEntry
+	attrs []string
+	label string
+	// Compiled regular expressions by offset of their domain in the entry.
+	regexps map[int]*regexp.Regexp
+}
+
+// newGeoSiteMatchers returns matchers of the geosite entry, one for each of its
+// sources. Regular expressions are anchored if anchorRegex is true.
+func (m *geoDataMapper) newGeoSiteMatchers(site *GeoSite, anchorRegex bool) ([]*mappedGeoSiteMatcher, error) {
+	var matchers []*mappedGeoSiteMatcher
+	for _, source := range site.Source {
+		entries, err := m.entries([]*GeoDataSource{source})
+		if err != nil {
+			return nil, err
+		}
+		matcher := &mappedGeoSiteMatcher{
+			entry:   entries[0],
+			attrs:   parseSiteAttrs(source.Code),
+			label:   site.Label,
+			regexps: make(map[int]*regexp.Regexp),
+		}
+		if err := matcher.compileRegexps(anchorRegex); err != nil {
+			return nil, newError("failed to decode ", source.File).Base(err)
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+// parseSiteAttrs returns the attributes following "@" in the code of a geosite
+// entry, in lower case.
+func parseSiteAttrs(code string) []string {
+	parts := strings.Split(code, "@")
+	var attrs []string
+	for _, attr := range parts[1:] {
+		attr = strings.ToLower(strings.TrimSpace(attr))
+		if len(attr) > 0 {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// mappedDomain is a domain decoded in place. value refers to the mapped memory.
+type mappedDomain struct {
+	domainType Domain_Type
+	value      []byte
+	hasAttrs   bool
+}
+
+// walkDomains calls f with each domain with the attributes of the matcher and
+// its offset in the entry, until f returns true.
+func (m *mappedGeoSiteMatcher) walkDomains(f func(offset int, d mappedDomain) (bool, error)) (bool, error) {
+	data := m.entry.data
+	matched, err := walkFields(data, func(num protowire.Number, value []byte) (bool, error) {
+		if num != geoSiteDomainField {
+			return false, nil
+		}
+		d, err := m.decodeDomain(value)
+		if err != nil || !d.hasAttrs {
+			return false, err
+		}
+		return f(cap(data)-cap(value), d)
+	})
+	return matched != nil, err
+}
+
+func (m *mappedGeoSiteMatcher) decodeDomain(b []byte) (mappedDomain, error) {
+	d := mappedDomain{hasAttrs: len(m.attrs) == 0}
+	var keys [][]byte
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return d, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == domainTypeField && typ == protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			d.domainType = Domain_Type(v)
+		case num == domainValueField && typ == protowire.BytesType:
+			d.value, n = protowire.ConsumeBytes(b)
+		case num == domainAttrField && typ == protowire.BytesType && len(m.attrs) > 0:
+			var attr []byte
+			attr, n = protowire.ConsumeBytes(b)
+			if n >= 0 {
+				key, err := walkFields(attr, func(num protowire.Number, _ []byte) (bool, error) {
+					return num == attrKeyField, nil
+				})
+				if err != nil {
+					return d, err
+				}
+				keys = append(keys, key)
+			}
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return d, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	if !d.hasAttrs {
+		d.hasAttrs = hasAllAttrs(keys, m.attrs)
+	}
+	return d, nil
+}
+
+func hasAllAttrs(keys [][]byte, attrs []string) bool {
+	for _, attr := range attrs {
+		found := false
+		for _, key := range keys {
+			if strings.EqualFold(string(key), attr) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *mappedGeoSiteMatcher) compileRegexps(anchor bool) error {
+	_, err := m.walkDomains(func(offset int, d mappedDomain) (bool, error) {
+		switch d.domainType {
+		case Domain_Plain, Domain_Domain, Domain_Full:
+		case Domain_Regex:
+			pattern := string(d.value)
+			if anchor {
+				pattern = "^(?:" + pattern + ")$"
+			}
+			r, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, newError("invalid regular expression: ", string(d.value)).Base(err)
+			}
+			m.regexps[offset] = r
+		default:
+			return false, newError("unsupported domain type", d.domainType)
+		}
+		return false, nil
+	})
+	return err
+}
+
+// match returns whether the domain in lower case matches.
+func (m *mappedGeoSiteMatcher) match(domain string) bool {
+	target := []byte(domain)
+	matched, _ := m.walkDomains(func(offset int, d mappedDomain) (bool, error) {
+		switch d.domainType {
+		case Domain_Plain:
+			return bytes.Contains(target, d.value), nil
+		case Domain_Regex:
+			return m.regexps[offset].MatchString(domain), nil
+		case Domain_Domain:
+			return bytes.HasSuffix(target, d.value) &&
+				(len(target) == len(d.value) || target[len(target)-len(d.value)-1] == '.'), nil
+		case Domain_Full:
+			return bytes.Equal(target, d.value), nil
+		}
+		return false, nil
+	})
+	runtime.KeepAlive(m.entry.file)
+	return matched
+}
+
+// mappedGeoSiteMatchers matches domains against geosite entries in order.
+type mappedGeoSiteMatchers []*mappedGeoSiteMatcher
+
+func (s mappedGeoSiteMatchers) labeled() bool {
+	for _, m := range s {
+		if len(m.label) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchLabel returns whether the domain in lower case matches, and the label
+// of the first labeled entry it matches. Unlabeled entries are skipped if
+// matched is already true.
+func (s mappedGeoSiteMatchers) matchLabel(domain string, matched bool) (bool, string) {
+	for _, m := range s {
+		if matched && len(m.label) == 0 {
+			continue
+		}
+		if m.match(domain) {
+			if len(m.label) > 0 {
+				return true, m.label
+			}
+			matched = true
+		}
+	}
+	return matched, ""
+}
@@ -0,0 +1,18 @@
+//go:build !confonly && !linux && !darwin && !freebsd && !openbsd && !netbsd
+// +build !confonly,!linux,!darwin,!freebsd,!openbsd,!netbsd
+
+package router
+
+import (
+	"github.com/v2fly/v2ray-core/v4/common/platform/filesystem"
+)
+
+// mapFile reads the whole file, as memory mapping is not supported on this
+// platform.
+func mapFile(path string) ([]byte, func() error, error) {
+	data, err := filesystem.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,307 @@
+package router_test
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/platform/filesystem"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	routing_session "github.com/v2fly/v2ray-core/v4/features/routing/session"
+	"github.com/v2fly/v2ray-core/v4/infra/conf/geodata"
+)
+
+// loadGeoData returns copies of rules with CIDRs and domains of GeoIPs and
+// geosite entries loaded from their sources, as the config loader does.
+func loadGeoData(t *testing.T, rules []*router.RoutingRule) []*router.RoutingRule {
+	loader, err := geodata.GetGeoDataLoader("standard")
+	common.Must(err)
+
+	loaded := make([]*router.RoutingRule, len(rules))
+	for i, rule := range rules {
+		rule = proto.Clone(rule).(*router.RoutingRule)
+		for _, geoips := range [][]*router.GeoIP{rule.Geoip, rule.SourceGeoip} {
+			for _, geoip := range geoips {
+				for _, source := range geoip.Source {
+					// Countries of continents not found are skipped.
+					cidrs, err := loader.LoadIP(source.File, source.Code)
+					if err != nil && len(geoip.Source) == 1 {
+						t.Fatal("failed to load geoip ", source.Code, ": ", err)
+					}
+					geoip.Cidr = append(geoip.Cidr, cidrs...)
+				}
+			}
+		}
+		for _, site := range rule.Geosite {
+			for _, source := range site.Source {
+				domains, err := loader.LoadGeoSiteWithAttr(source.File, source.Code)
+				if err != nil {
+					t.Fatal("failed to load geosite ", source.Code, ": ", err)
+				}
+				for _, d := range domains {
+					site.Domain = append(site.Domain, &router.Domain{Type: d.Type, Value: d.Value, Label: site.Label})
+				}
+			}
+		}
+		loaded[i] = rule
+	}
+	return loaded
+}
+
+// compareGeoDataModes checks that connections to the targets are routed the
+// same by routers with the rules in memory and with geodata mapped.
+func compareGeoDataModes(t *testing.T, rules []*router.RoutingRule, targets []net.Destination) {
+	build := func(config *router.Config) *router.Router {
+		r := new(router.Router)
+		common.Must(r.Init(context.TODO(), config, nil, nil))
+		return r
+	}
+	inMemory := build(&router.Config{Rule: loadGeoData(t, rules)})
+	mapped := build(&router.Config{Rule: rules, MapGeodata: true})
+
+	pick := func(r *router.Router, target net.Destination) (string, string) {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{Source: target})
+		ctx = session.ContextWithOutbound(ctx, &session.Outbound{Target: target})
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		if err != nil {
+			return "", ""
+		}
+		return route.GetOutboundTag(), route.GetAttributes()[session.AttributeDomainLabel]
+	}
+	matched := 0
+	for _, target := range targets {
+		expectedTag, expectedLabel := pick(inMemory, target)
+		tag, label := pick(mapped, target)
+		if tag != expectedTag || label != expectedLabel {
+			t.Error("expect ", target, " to be routed to ", expectedTag, " with label ", expectedLabel, ", but got ", tag, " with label ", label)
+		}
+		if len(tag) > 0 {
+			matched++
+		}
+	}
+	if matched == 0 {
+		t.Error("expect some targets to be matched")
+	}
+}
+
+func writeTestGeoData(dir string) {
+	geoipBytes, err := proto.Marshal(&router.GeoIPList{
+		Entry: []*router.GeoIP{
+			{
+				CountryCode: "XA",
+				Cidr: []*router.CIDR{
+					{Ip: []byte{10, 0, 0, 0}, Prefix: 8},
+					{Ip: []byte{192, 168, 1, 128}, Prefix: 25},
+					{Ip: []byte{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, Prefix: 33},
+				},
+			},
+			{
+				CountryCode: "XB",
+				Cidr: []*router.CIDR{
+					{Ip: []byte{172, 16, 0, 0}, Prefix: 12},
+					{Ip: []byte{1, 2, 3, 4}, Prefix: 32},
+				},
+			},
+		},
+	})
+	common.Must(err)
+	common.Must(filesystem.WriteFile(filepath.Join(dir, "geoip.dat"), geoipBytes))
+
+	otherBytes, err := proto.Marshal(&router.GeoIPList{
+		Entry: []*router.GeoIP{
+			{CountryCode: "XA", Cidr: []*router.CIDR{{Ip: []byte{100, 64, 0, 0}, Prefix: 10}}},
+		},
+	})
+	common.Must(err)
+	common.Must(filesystem.WriteFile(filepath.Join(dir, "other.dat"), otherBytes))
+
+	ads := []*router.Domain_Attribute{{Key: "ads", TypedValue: &router.Domain_Attribute_BoolValue{BoolValue: true}}}
+	geositeBytes, err := proto.Marshal(&router.GeoSiteList{
+		Entry: []*router.GeoSite{
+			{
+				CountryCode: "XS",
+				Domain: []*router.Domain{
+					{Type: router.Domain_Domain, Value: "example.com"},
+					{Type: router.Domain_Full, Value: "ads.example.org", Attribute: ads},
+					{Type: router.Domain_Plain, Value: "tracker", Attribute: ads},
+					{Type: router.Domain_Regex, Value: `^cdn\d+\.example\.net$`},
+				},
+			},
+			{
+				CountryCode: "XT",
+				Domain: []*router.Domain{
+					{Type: router.Domain_Regex, Value: `test\d`},
+					{Type: router.Domain_Full, Value: "v2fly.org"},
+				},
+			},
+		},
+	})
+	common.Must(err)
+	common.Must(filesystem.WriteFile(filepath.Join(dir, "geosite.dat"), geositeBytes))
+}
+
+func TestMappedGeoData(t *testing.T) {
+	assetPath := t.TempDir()
+	writeTestGeoData(assetPath)
+	defer os.Setenv("v2ray.location.asset", os.Getenv("v2ray.location.asset"))
+	os.Setenv("v2ray.location.asset", assetPath)
+
+	rules := []*router.RoutingRule{
+		{
+			TargetTag: &router.RoutingRule_Tag{Tag: "ads"},
+			Geosite: []*router.GeoSite{{
+				Source: []*router.GeoDataSource{{File: "geosite.dat", Code: "xs@ads"}},
+				Label:  "ads",
+			}},
+		},
+		{
+			TargetTag: &router.RoutingRule_Tag{Tag: "site"},
+			Domain:    []*router.Domain{{Type: router.Domain_Full, Value: "www.v2fly.org", Label: "custom"}},
+			Geosite: []*router.GeoSite{
+				{Source: []*router.GeoDataSource{{File: "geosite.dat", Code: "XS"}}},
+				{Source: []*router.GeoDataSource{{File: "geosite.dat", Code: "xt"}}, Label: "xt"},
+			},
+			AnchorRegex: true,
+		},
+		{
+			TargetTag: &router.RoutingRule_Tag{Tag: "ip"},
+			Geoip: []*router.GeoIP{{
+				CountryCode: "XA",
+				Source:      []*router.GeoDataSource{{File: "geoip.dat", Code: "xa"}},
+			}},
+		},
+		{
+			TargetTag: &router.RoutingRule_Tag{Tag: "source"},
+			SourceGeoip: []*router.GeoIP{{
+				CountryCode: "OTHER.DAT_XA",
+				Source:      []*router.GeoDataSource{{File: "other.dat", Code: "XA"}},
+			}},
+		},
+		{
+			TargetTag: &router.RoutingRule_Tag{Tag: "notxb"},
+			Geoip: []*router.GeoIP{{
+				CountryCode:  "CONTINENT_X",
+				ReverseMatch: true,
+				// Sources not found are skipped.
+				Source: []*router.GeoDataSource{{File: "geoip.dat", Code: "XB"}, {File: "geoip.dat", Code: "XC"}},
+			}},
+			Networks: []net.Network{net.Network_UDP},
+		},
+	}
+
+	var targets []net.Destination
+	for _, domain := range []string{
+		"example.com", "www.example.com", "myexample.com", "ads.example.org", "www.ads.example.org",
+		"tracker.example.info", "cdn1.example.net", "cdn1.example.net.cn", "test1.v2fly.org", "test.v2fly.org",
+		"v2fly.org", "www.v2fly.org", "EXAMPLE.COM",
+	} {
+		targets = append(targets, net.TCPDestination(net.DomainAddress(domain), 443))
+	}
+	for _, ip := range []string{
+		"10.1.2.3", "11.0.0.1", "192.168.1.127", "192.168.1.128", "192.168.1.255", "172.16.0.1", "172.32.0.1",
+		"1.2.3.4", "1.2.3.5", "100.64.0.1", "100.128.0.1", "2001:db8::1", "2001:db8:8000::1", "::ffff:10.0.0.1",
+	} {
+		targets = append(targets, net.TCPDestination(net.ParseAddress(ip), 443), net.UDPDestination(net.ParseAddress(ip), 443))
+	}
+	compareGeoDataModes(t, rules, targets)
+
+	for _, code := range []string{"XC", "XT"} {
+		config := &router.Config{
+			Rule: []*router.RoutingRule{{
+				TargetTag: &router.RoutingRule_Tag{Tag: "ip"},
+				Geoip:     []*router.GeoIP{{Source: []*router.GeoDataSource{{File: "geoip.dat", Code: code}}}},
+			}},
+			MapGeodata: true,
+		}
+		if err := new(router.Router).Init(context.TODO(), config, nil, nil); err == nil {
+			t.Error("expect error on code ", code, " not found")
+		}
+	}
+}
+
+func TestMappedGeoDataReload(t *testing.T) {
+	assetPath := t.TempDir()
+	writeTestGeoData(assetPath)
+	defer os.Setenv("v2ray.location.asset", os.Getenv("v2ray.location.asset"))
+	os.Setenv("v2ray.location.asset", assetPath)
+
+	r := new(router.Router)
+	common.Must(r.Init(context.TODO(), &router.Config{
+		Rule: []*router.RoutingRule{{
+			TargetTag: &router.RoutingRule_Tag{Tag: "ip"},
+			Geoip:     []*router.GeoIP{{Source: []*router.GeoDataSource{{File: "geoip.dat", Code: "XB"}}}},
+		}},
+		MapGeodata: true,
+	}, nil, nil))
+	matches := func(ip string) bool {
+		ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.ParseAddress(ip), 80)})
+		_, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		return err == nil
+	}
+	if !matches("1.2.3.4") {
+		t.Fatal("expect 1.2.3.4 to match before reload")
+	}
+
+	// Files are replaced rather than modified in place.
+	geoipBytes, err := proto.Marshal(&router.GeoIPList{
+		Entry: []*router.GeoIP{{CountryCode: "XB", Cidr: []*router.CIDR{{Ip: []byte{5, 6, 7, 8}, Prefix: 32}}}},
+	})
+	common.Must(err)
+	common.Must(filesystem.WriteFile(filepath.Join(assetPath, "geoip.new"), geoipBytes))
+	common.Must(os.Rename(filepath.Join(assetPath, "geoip.new"), filepath.Join(assetPath, "geoip.dat")))
+
+	common.Must(r.ReloadGeoData())
+	if matches("1.2.3.4") || !matches("5.6.7.8") {
+		t.Error("expect rule to match IPs in the replaced file after reload")
+	}
+}
+
+func TestMappedGeoDataRealFiles(t *testing.T) {
+	rules := []*router.RoutingRule{
+		{
+			TargetTag: &router.RoutingRule_Tag{Tag: "cn"},
+			Geosite:   []*router.GeoSite{{Source: []*router.GeoDataSource{{File: "geosite.dat", Code: "cn"}}}},
+		},
+		{
+			TargetTag: &router.RoutingRule_Tag{Tag: "google"},
+			Geosite:   []*router.GeoSite{{Source: []*router.GeoDataSource{{File: "geosite.dat", Code: "google"}}}},
+		},
+		{
+			TargetTag: &router.RoutingRule_Tag{Tag: "cn"},
+			Geoip:     []*router.GeoIP{{CountryCode: "CN", Source: []*router.GeoDataSource{{File: "geoip.dat", Code: "cn"}}}},
+		},
+		{
+			TargetTag: &router.RoutingRule_Tag{Tag: "us"},
+			Geoip:     []*router.GeoIP{{CountryCode: "US", Source: []*router.GeoDataSource{{File: "geoip.dat", Code: "us"}}}},
+		},
+	}
+
+	var targets []net.Destination
+	for _, domain := range []string{
+		"google.com", "www.google.com", "mail.google.com", "google.cn", "googleapis.com", "youtube.com",
+		"baidu.com", "www.baidu.com", "qq.com", "163.com", "v2fly.org", "example.com",
+	} {
+		targets = append(targets, net.TCPDestination(net.DomainAddress(domain), 443))
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		ip := make([]byte, 4)
+		rnd.Read(ip)
+		targets = append(targets, net.TCPDestination(net.IPAddress(ip), 443))
+	}
+	for i := 0; i < 200; i++ {
+		ip := make([]byte, 16)
+		rnd.Read(ip)
+		// Mostly in 2400::/6, where many CIDRs are.
+		ip[0] = 0x24 | ip[0]&0x03
+		targets = append(targets, net.TCPDestination(net.IPAddress(ip), 443))
+	}
+	compareGeoDataModes(t, rules, targets)
+}
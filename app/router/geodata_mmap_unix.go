@@ -0,0 +1,33 @@
+//go:build !confonly && (linux || darwin || freebsd || openbsd || netbsd)
+// +build !confonly
+// +build linux darwin freebsd openbsd netbsd
+
+package router
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the file read-only into memory, so that pages of it are
+// loaded on demand and may be reclaimed by the kernel.
+func mapFile(path string) ([]byte, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,148 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"google.golang.org/protobuf/proto"
+)
+
+// geoDataReloader loads GeoIPs and geosite entries of rules again from their
+// sources. Each source is loaded once per reload, and the loader is only
+// created if there is any.
+type geoDataReloader struct {
+	loader GeoDataLoader
+	cidrs  map[geoDataSourceKey][]*CIDR
+	sites  map[geoDataSourceKey][]*Domain
+}
+
+type geoDataSourceKey struct {
+	file string
+	code string
+}
+
+func newGeoDataReloader() *geoDataReloader {
+	return &geoDataReloader{
+		cidrs: make(map[geoDataSourceKey][]*CIDR),
+		sites: make(map[geoDataSourceKey][]*Domain),
+	}
+}
+
+func (r *geoDataReloader) getLoader() (GeoDataLoader, error) {
+	if r.loader == nil {
+		loader, err := newGeoDataLoader()
+		if err != nil {
+			return nil, err
+		}
+		r.loader = loader
+	}
+	return r.loader, nil
+}
+
+func (r *geoDataReloader) loadIP(source *GeoDataSource) ([]*CIDR, error) {
+	key := geoDataSourceKey{source.File, source.Code}
+	if cidrs, found := r.cidrs[key]; found {
+		return cidrs, nil
+	}
+	loader, err := r.getLoader()
+	if err != nil {
+		return nil, err
+	}
+	cidrs, err := loader.LoadIP(source.File, source.Code)
+	if err != nil {
+		return nil, newError("failed to load geoip ", source.Code, " from ", source.File).Base(err)
+	}
+	r.cidrs[key] = cidrs
+	return cidrs, nil
+}
+
+func (r *geoDataReloader) loadSite(source *GeoDataSource) ([]*Domain, error) {
+	key := geoDataSourceKey{source.File, source.Code}
+	if domains, found := r.sites[key]; found {
+		return domains, nil
+	}
+	loader, err := r.getLoader()
+	if err != nil {
+		return nil, err
+	}
+	domains, err := loader.LoadGeoSiteWithAttr(source.File, source.Code)
+	if err != nil {
+		return nil, newError("failed to load geosite ", source.Code, " from ", source.File).Base(err)
+	}
+	r.sites[key] = domains
+	return domains, nil
+}
+
+// reloadRules returns copies of rules in which GeoIPs and geosite entries with
+// sources are loaded again from them. Those without, e.g. custom CIDRs, are
+// kept as is.
+func (r *geoDataReloader) reloadRules(rules []*RoutingRule) ([]*RoutingRule, error) {
+	reloaded := make([]*RoutingRule, len(rules))
+	for i, rule := range rules {
+		rule = proto.Clone(rule).(*RoutingRule)
+		if err := r.reloadRule(rule); err != nil {
+			return nil, err
+		}
+		reloaded[i] = rule
+	}
+	return reloaded, nil
+}
+
+func (r *geoDataReloader) reloadRule(rule *RoutingRule) error {
+	for _, geoips := range [][]*GeoIP{rule.Geoip, rule.SourceGeoip} {
+		for _, geoip := range geoips {
+			if len(geoip.Source) == 0 {
+				continue
+			}
+			var cidrs []*CIDR
+			for _, source := range geoip.Source {
+				loaded, err := r.loadIP(source)
+				if err != nil {
+					return err
+				}
+				cidrs = append(cidrs, loaded...)
+			}
+			geoip.Cidr = cidrs
+		}
+	}
+	for _, sites := range [][]*GeoSite{rule.Geosite, rule.ReverseGeosite} {
+		for _, site := range sites {
+			if len(site.Source) == 0 {
+				continue
+			}
+			var domains []*Domain
+			for _, source := range site.Source {
+				loaded, err := r.loadSite(source)
+				if err != nil {
+					return err
+				}
+				domains = append(domains, labelDomains(loaded, site.Label)...)
+			}
+			site.Domain = domains
+		}
+	}
+	for _, group := range rule.OrGroups {
+		if err := r.reloadRule(group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labelDomains returns copies of domains with the label. Loaded domains may be
+// shared, so they are not modified in place.
+func labelDomains(domains []*Domain, label string) []*Domain {
+	if len(label) == 0 {
+		return domains
+	}
+	labeled := make([]*Domain, len(domains))
+	for i, d := range domains {
+		labeled[i] = &Domain{
+			Type:      d.Type,
+			Value:     d.Value,
+			Attribute: d.Attribute,
+			Label:     label,
+		}
+	}
+	return labeled
+}
@@ -0,0 +1,35 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"sync/atomic"
+)
+
+// LogSampler decides whether to log each of a sequence of matches, so that
+// the fraction of logged ones follows its rate. It is safe for concurrent use.
+type LogSampler struct {
+	rate  float64
+	count uint64
+}
+
+// NewLogSampler creates a new LogSampler from the config.
+func NewLogSampler(config *LogSampling) (*LogSampler, error) {
+	if config.Rate < 0 || config.Rate > 1 {
+		return nil, newError("log sampling rate must be between 0 and 1, but got ", config.Rate)
+	}
+	return &LogSampler{rate: float64(config.Rate)}, nil
+}
+
+// Sample returns whether to log the next match. A nil LogSampler logs all
+// matches.
+func (s *LogSampler) Sample() bool {
+	if s == nil {
+		return true
+	}
+	// Logs the match whenever the expected number of logged ones reaches the
+	// next integer.
+	n := atomic.AddUint64(&s.count, 1)
+	return uint64(float64(n)*s.rate) != uint64(float64(n-1)*s.rate)
+}
@@ -0,0 +1,64 @@
+package router_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+)
+
+func TestLogSampler(t *testing.T) {
+	const matches = 10000
+	testCases := []struct {
+		rate   float32
+		logged int
+	}{
+		{rate: 0, logged: 0},
+		{rate: 1, logged: matches},
+		{rate: 0.5, logged: matches / 2},
+		{rate: 0.01, logged: matches / 100},
+		{rate: 0.3, logged: matches * 3 / 10},
+	}
+
+	for _, tc := range testCases {
+		sampler, err := router.NewLogSampler(&router.LogSampling{Rate: tc.rate})
+		common.Must(err)
+
+		var logged int64
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < matches/10; j++ {
+					if sampler.Sample() {
+						atomic.AddInt64(&logged, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		// The rate in float32 may be slightly off.
+		if diff := int(logged) - tc.logged; diff < -1 || diff > 1 {
+			t.Error("expect ", tc.logged, " of ", matches, " matches logged at rate ", tc.rate, ", but got ", logged)
+		}
+	}
+}
+
+func TestLogSamplerInvalidRate(t *testing.T) {
+	for _, rate := range []float32{-0.1, 1.5} {
+		if _, err := router.NewLogSampler(&router.LogSampling{Rate: rate}); err == nil {
+			t.Error("expect error on log sampling rate ", rate)
+		}
+	}
+}
+
+func TestNilLogSampler(t *testing.T) {
+	var sampler *router.LogSampler
+	if !sampler.Sample() {
+		t.Error("expect nil sampler to log all matches")
+	}
+}
@@ -7,34 +7,70 @@ package router
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	core "github.com/v2fly/v2ray-core/v4"
 	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/ratelimit"
 	"github.com/v2fly/v2ray-core/v4/features/dns"
 	"github.com/v2fly/v2ray-core/v4/features/outbound"
 	"github.com/v2fly/v2ray-core/v4/features/routing"
 	routing_dns "github.com/v2fly/v2ray-core/v4/features/routing/dns"
 )
 
+// rateLimitIdleTimeout is how long a bandwidth limit of a source IP is kept
+// after its last connection, if its bucket is full.
+const rateLimitIdleTimeout = time.Minute
+
 // Router is an implementation of routing.Router.
 type Router struct {
-	domainStrategy Config_DomainStrategy
-	rules          []*Rule
-	balancers      map[string]*Balancer
-	dns            dns.Client
+	domainStrategy         Config_DomainStrategy
+	lazyMatchers           bool
+	mapGeoData             bool
+	defaultRuleTag         string
+	domainMatcherCacheSize int
+	balancers              map[string]*Balancer
+	dns                    dns.Client
+	ohm                    outbound.Manager
+	ctx                    context.Context
+
+	access      sync.RWMutex
+	rules       []*Rule
+	ruleConfigs []*RoutingRule
+	portSets    map[string]*net.PortList
 }
 
 // Route is an implementation of routing.Route.
 type Route struct {
 	routing.Context
-	outboundGroupTags []string
-	outboundTag       string
+	outboundGroupTags   []string
+	outboundTag         string
+	handlerFallbackTags []string
+	ruleTag             string
+	ruleTrafficStats    bool
+	ruleTOS             uint32
+	ruleDialTimeout     time.Duration
+	ruleResolverTag     string
+	ruleAttributes      map[string]string
+	ruleMirrorTag       string
+	ruleLogged          bool
+	ruleRedirectTarget  net.Destination
+	ruleRateLimit       *ratelimit.Bucket
+	attributes          map[string]string
 }
 
 // Init initializes the Router.
 func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm outbound.Manager) error {
 	r.domainStrategy = config.DomainStrategy
+	r.lazyMatchers = config.LazyMatchers
+	r.mapGeoData = config.MapGeodata
+	r.defaultRuleTag = config.DefaultRuleTag
+	r.domainMatcherCacheSize = int(config.DomainMatcherCacheSize)
 	r.dns = d
+	r.ohm = ohm
+	r.ctx = ctx
 
 	r.balancers = make(map[string]*Balancer, len(config.BalancingRule))
 	for _, rule := range config.BalancingRule {
@@ -43,44 +79,240 @@ func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm out
 			return err
 		}
 		balancer.InjectContext(ctx)
+		balancer.SetOnDrained(r.releaseOutbound)
 		r.balancers[rule.Tag] = balancer
 	}
 
-	r.rules = make([]*Rule, 0, len(config.Rule))
-	for _, rule := range config.Rule {
-		cond, err := rule.BuildCondition()
-		if err != nil {
-			return err
+	r.portSets = config.PortSet
+	globalDomainPatternContainer.Reset()
+	rules, err := r.buildRules(config.Rule, &globalGeoIPContainer)
+	if err != nil {
+		return err
+	}
+	r.rules = rules
+	r.ruleConfigs = config.Rule
+	return nil
+}
+
+func (r *Router) buildRules(configs []*RoutingRule, container *GeoIPMatcherContainer) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(configs))
+	hasFinal := false
+	env := &conditionEnv{
+		geoIPs:   container,
+		portSets: r.portSets,
+		lazy:     r.lazyMatchers,
+		// Results cached for old rules are not valid for new ones.
+		domainCache: NewDomainMatchCache(r.domainMatcherCacheSize),
+		dns:         r.dns,
+	}
+	if r.mapGeoData {
+		// Files are mapped again, as they may have been replaced.
+		env.geoData = newGeoDataMapper()
+	}
+	for _, rule := range configs {
+		final := !hasFinal && len(r.defaultRuleTag) > 0 && rule.RuleTag == r.defaultRuleTag
+		var cond Condition
+		if final {
+			hasFinal = true
+		} else {
+			var err error
+			cond, err = rule.buildCondition(env)
+			if err != nil {
+				return nil, err
+			}
 		}
 		rr := &Rule{
-			Condition: cond,
-			Tag:       rule.GetTag(),
+			Condition:    cond,
+			Final:        final,
+			Tag:          rule.GetTag(),
+			RuleTag:      rule.RuleTag,
+			TrafficStats: rule.RuleTrafficStats,
+			TOS:          rule.Tos,
+			DialTimeout:  time.Duration(rule.DialTimeout) * time.Millisecond,
+			ResolverTag:  rule.ResolverTag,
+			MirrorTag:    rule.MirrorTag,
+		}
+		if len(rule.SetAttributes) > 0 {
+			rr.SetAttributes = rule.SetAttributes
+		}
+		if target := rule.RedirectTarget; target != nil {
+			if target.Address == nil {
+				return nil, newError("redirect target without address")
+			}
+			rr.RedirectTarget = target
+		}
+		if limit := rule.RateLimit; limit != nil {
+			if limit.Rate == 0 {
+				return nil, newError("rate limit without rate")
+			}
+			rr.RateLimiter = ratelimit.NewLimiter(limit.Rate, limit.Burst, rateLimitIdleTimeout, nil)
+		}
+		if rule.LogSampling != nil {
+			sampler, err := NewLogSampler(rule.LogSampling)
+			if err != nil {
+				return nil, err
+			}
+			rr.LogSampler = sampler
 		}
 		btag := rule.GetBalancingTag()
 		if len(btag) > 0 {
 			brule, found := r.balancers[btag]
 			if !found {
-				return newError("balancer ", btag, " not found")
+				return nil, newError("balancer ", btag, " not found")
 			}
 			rr.Balancer = brule
+			rr.BalancerTag = btag
 		}
-		r.rules = append(r.rules, rr)
+		rules = append(rules, rr)
+	}
+	if len(r.defaultRuleTag) > 0 && !hasFinal {
+		return nil, newError("default rule not found: ", r.defaultRuleTag)
 	}
+	return rules, nil
+}
 
+// ReloadGeoData rebuilds conditions of all rules with GeoIPs and geosite
+// entries loaded again from the entries of geodata files they were loaded
+// from, with a loader registered by RegisterGeoDataLoaderCreator, or mapped
+// again if geodata is mapped. Rules are swapped at once after all of them are
+// built, and routing in progress keeps using the old rules.
+func (r *Router) ReloadGeoData() error {
+	r.access.RLock()
+	configs := r.ruleConfigs
+	r.access.RUnlock()
+
+	if !r.mapGeoData {
+		var err error
+		configs, err = newGeoDataReloader().reloadRules(configs)
+		if err != nil {
+			return newError("failed to reload geodata").Base(err)
+		}
+	}
+
+	container := new(GeoIPMatcherContainer)
+	globalDomainPatternContainer.Reset()
+	rules, err := r.buildRules(configs, container)
+	if err != nil {
+		return newError("failed to rebuild rules").Base(err)
+	}
+
+	r.access.Lock()
+	r.rules = rules
+	r.ruleConfigs = configs
+	r.access.Unlock()
+
+	globalGeoIPContainer.replace(container)
+	newError("reloaded geodata for ", len(rules), " rule(s)").AtInfo().WriteToLog()
 	return nil
 }
 
+// UpdateBalancerSelectors replaces outbound selectors of the balancer with the
+// tag. Outbounds no longer selected are drained, see Balancer.UpdateSelectors.
+func (r *Router) UpdateBalancerSelectors(tag string, selectors []string) error {
+	balancer, found := r.balancers[tag]
+	if !found {
+		return newError("balancer not found: ", tag)
+	}
+	return balancer.UpdateSelectors(selectors)
+}
+
+// releaseOutbound removes the outbound drained by a balancer from the outbound
+// manager, unless it is still referenced by the routing configuration.
+func (r *Router) releaseOutbound(tag string) {
+	if r.outboundReferenced(tag) {
+		newError("keeping drained outbound ", tag, " still in use").AtDebug().WriteToLog()
+		return
+	}
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := r.ohm.RemoveHandler(ctx, tag); err != nil {
+		newError("failed to remove drained outbound ", tag).Base(err).AtWarning().WriteToLog()
+		return
+	}
+	newError("removed drained outbound ", tag).AtInfo().WriteToLog()
+}
+
+// outboundReferenced returns whether the outbound is the default one, the
+// target or mirror of a rule, or selected by a balancer.
+func (r *Router) outboundReferenced(tag string) bool {
+	if h := r.ohm.GetDefaultHandler(); h != nil && h.Tag() == tag {
+		return true
+	}
+	for _, rule := range r.getRules() {
+		if (rule.Balancer == nil && rule.Tag == tag) || rule.MirrorTag == tag {
+			return true
+		}
+	}
+	hs, ok := r.ohm.(outbound.HandlerSelector)
+	if !ok {
+		return true
+	}
+	for _, balancer := range r.balancers {
+		if balancer.references(hs, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Router) getRules() []*Rule {
+	r.access.RLock()
+	defer r.access.RUnlock()
+	return r.rules
+}
+
 // PickRoute implements routing.Router.
 func (r *Router) PickRoute(ctx routing.Context) (routing.Route, error) {
 	rule, ctx, err := r.pickRouteInternal(ctx)
 	if err != nil {
 		return nil, err
 	}
-	tag, err := rule.GetTag()
+	tags, err := rule.GetTags(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return &Route{Context: ctx, outboundTag: tag}, nil
+	route := &Route{
+		Context:             ctx,
+		outboundTag:         tags[0],
+		handlerFallbackTags: tags[1:],
+		ruleTag:             rule.RuleTag,
+		ruleTrafficStats:    rule.TrafficStats,
+		ruleTOS:             rule.TOS,
+		ruleDialTimeout:     rule.DialTimeout,
+		ruleResolverTag:     rule.ResolverTag,
+		ruleMirrorTag:       rule.MirrorTag,
+		ruleLogged:          rule.LogSampler.Sample(),
+	}
+	if target := rule.RedirectTarget; target != nil {
+		route.ruleRedirectTarget = net.Destination{
+			Network: ctx.GetNetwork(),
+			Address: target.Address.AsAddress(),
+			Port:    net.Port(target.Port),
+		}
+		if target.Port == 0 {
+			route.ruleRedirectTarget.Port = ctx.GetTargetPort()
+		}
+	}
+	if rule.RateLimiter != nil {
+		var key string
+		if ips := ctx.GetSourceIPs(); len(ips) > 0 {
+			key = ips[0].String()
+		}
+		route.ruleRateLimit = rule.RateLimiter.Get(key)
+	}
+	if len(rule.SetAttributes) > 0 {
+		route.ruleAttributes = rule.SetAttributes
+		route.attributes = make(map[string]string, len(rule.SetAttributes))
+		for key, value := range ctx.GetAttributes() {
+			route.attributes[key] = value
+		}
+		for key, value := range rule.SetAttributes {
+			route.attributes[key] = value
+		}
+	}
+	return route, nil
 }
 
 func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context, error) {
@@ -93,25 +325,37 @@ func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context,
 		ctx = routing_dns.ContextWithDNSClient(ctx, r.dns)
 	}
 
-	for _, rule := range r.rules {
+	rules := r.getRules()
+	for _, rule := range rules {
 		if rule.Apply(ctx) {
 			return rule, ctx, nil
 		}
 	}
 
 	if r.domainStrategy != Config_IpIfNonMatch || len(ctx.GetTargetDomain()) == 0 || skipDNSResolve {
-		return nil, ctx, common.ErrNoClue
+		return pickFinalRule(rules, ctx)
 	}
 
 	ctx = routing_dns.ContextWithDNSClient(ctx, r.dns)
 
 	// Try applying rules again if we have IPs.
-	for _, rule := range r.rules {
+	for _, rule := range rules {
 		if rule.Apply(ctx) {
 			return rule, ctx, nil
 		}
 	}
 
+	return pickFinalRule(rules, ctx)
+}
+
+// pickFinalRule returns the final rule for connections matching no rule, or
+// common.ErrNoClue if there is none.
+func pickFinalRule(rules []*Rule, ctx routing.Context) (*Rule, routing.Context, error) {
+	for _, rule := range rules {
+		if rule.Final {
+			return rule, ctx, nil
+		}
+	}
 	return nil, ctx, common.ErrNoClue
 }
 
@@ -140,6 +384,79 @@ func (r *Route) GetOutboundTag() string {
 	return r.outboundTag
 }
 
+// GetHandlerFallbackTags implements routing.HandlerFallbackRoute.
+func (r *Route) GetHandlerFallbackTags() []string {
+	return r.handlerFallbackTags
+}
+
+// GetRuleTag implements routing.RuleRoute.
+func (r *Route) GetRuleTag() string {
+	return r.ruleTag
+}
+
+// GetRuleTrafficStats implements routing.RuleRoute.
+func (r *Route) GetRuleTrafficStats() bool {
+	return r.ruleTrafficStats
+}
+
+// GetRuleTOS implements routing.RuleRoute.
+func (r *Route) GetRuleTOS() uint32 {
+	return r.ruleTOS
+}
+
+// GetRuleDialTimeout implements routing.RuleRoute.
+func (r *Route) GetRuleDialTimeout() time.Duration {
+	return r.ruleDialTimeout
+}
+
+// GetRuleResolverTag implements routing.RuleRoute.
+func (r *Route) GetRuleResolverTag() string {
+	return r.ruleResolverTag
+}
+
+// GetRuleAttributes implements routing.RuleRoute.
+func (r *Route) GetRuleAttributes() map[string]string {
+	return r.ruleAttributes
+}
+
+// GetRuleMirrorTag implements routing.RuleRoute.
+func (r *Route) GetRuleMirrorTag() string {
+	return r.ruleMirrorTag
+}
+
+// GetRuleRedirectTarget implements routing.RuleRoute.
+func (r *Route) GetRuleRedirectTarget() net.Destination {
+	return r.ruleRedirectTarget
+}
+
+// GetRuleRateLimit implements routing.RuleRoute.
+func (r *Route) GetRuleRateLimit() *ratelimit.Bucket {
+	return r.ruleRateLimit
+}
+
+// GetRuleLogged implements routing.RuleRoute.
+func (r *Route) GetRuleLogged() bool {
+	return r.ruleLogged
+}
+
+// GetAttributes implements routing.Context. Attributes set by the rule are
+// merged into those of the connection.
+func (r *Route) GetAttributes() map[string]string {
+	if r.attributes != nil {
+		return r.attributes
+	}
+	return r.Context.GetAttributes()
+}
+
+// GetInboundTransport implements routing.InboundTransportGetter, if the
+// routing.Context of the route does.
+func (r *Route) GetInboundTransport() string {
+	if getter, ok := r.Context.(routing.InboundTransportGetter); ok {
+		return getter.GetInboundTransport()
+	}
+	return ""
+}
+
 func init() {
 	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
 		r := new(Router)
@@ -2,16 +2,30 @@ package router_test
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
 
+	"github.com/v2fly/v2ray-core/v4/app/observatory"
+	outbound_manager "github.com/v2fly/v2ray-core/v4/app/proxyman/outbound"
 	. "github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/app/stats"
 	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/platform/filesystem"
+	"github.com/v2fly/v2ray-core/v4/common/ratelimit"
 	"github.com/v2fly/v2ray-core/v4/common/session"
 	"github.com/v2fly/v2ray-core/v4/features/outbound"
+	"github.com/v2fly/v2ray-core/v4/features/routing"
 	routing_session "github.com/v2fly/v2ray-core/v4/features/routing/session"
+	_ "github.com/v2fly/v2ray-core/v4/infra/conf/geodata/standard"
 	"github.com/v2fly/v2ray-core/v4/testing/mocks"
 )
 
@@ -53,6 +67,517 @@ func TestSimpleRouter(t *testing.T) {
 	}
 }
 
+func TestDefaultRule(t *testing.T) {
+	config := &Config{
+		DefaultRuleTag: "final",
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "proxy",
+				},
+				RuleTag: "final",
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "direct",
+				},
+				Domain: []*Domain{{Type: Domain_Domain, Value: "v2fly.org"}},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, nil))
+
+	testCases := []struct {
+		dest    net.Destination
+		tag     string
+		ruleTag string
+	}{
+		{
+			dest: net.TCPDestination(net.DomainAddress("www.v2fly.org"), 443),
+			tag:  "direct",
+		},
+		{
+			dest:    net.TCPDestination(net.DomainAddress("example.com"), 443),
+			tag:     "proxy",
+			ruleTag: "final",
+		},
+	}
+	for _, tc := range testCases {
+		ctx := routing_session.AsRoutingContext(session.ContextWithOutbound(context.Background(), &session.Outbound{Target: tc.dest}))
+		route, err := r.PickRoute(ctx)
+		common.Must(err)
+		if tag := route.GetOutboundTag(); tag != tc.tag {
+			t.Error("expect tag ", tc.tag, " for ", tc.dest, ", but actually ", tag)
+		}
+		if ruleTag := route.(routing.RuleRoute).GetRuleTag(); ruleTag != tc.ruleTag {
+			t.Error("expect rule tag ", tc.ruleTag, " for ", tc.dest, ", but actually ", ruleTag)
+		}
+
+		traces := r.ExplainRoute(ctx)
+		if last := traces[len(traces)-1]; !last.Matched || last.OutboundTag != tc.tag {
+			t.Error("expect the last trace to match ", tc.tag, ", but got ", *last)
+		}
+	}
+
+	config.DefaultRuleTag = "missing"
+	if err := new(Router).Init(context.TODO(), config, mockDNS, nil); err == nil {
+		t.Error("expect error on undefined default rule")
+	}
+}
+
+func TestRuleTOS(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "test",
+				},
+				Networks:    []net.Network{net.Network_TCP},
+				Tos:         0xb8,
+				DialTimeout: 30000,
+				ResolverTag: "exit",
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, nil))
+
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2fly.org"), 80)})
+	route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+	common.Must(err)
+	if tos := route.(routing.RuleRoute).GetRuleTOS(); tos != 0xb8 {
+		t.Error("expect TOS 0xb8, but actually ", tos)
+	}
+	if timeout := route.(routing.RuleRoute).GetRuleDialTimeout(); timeout != 30*time.Second {
+		t.Error("expect dial timeout 30s, but actually ", timeout)
+	}
+	if tag := route.(routing.RuleRoute).GetRuleResolverTag(); tag != "exit" {
+		t.Error("expect resolver tag exit, but actually ", tag)
+	}
+}
+
+func TestRuleLogSampling(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "sampled",
+				},
+				Networks:    []net.Network{net.Network_TCP},
+				LogSampling: &LogSampling{Rate: 0.25},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "logged",
+				},
+				Networks: []net.Network{net.Network_UDP},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, nil))
+
+	pick := func(dest net.Destination) routing.RuleRoute {
+		ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: dest})
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		common.Must(err)
+		return route.(routing.RuleRoute)
+	}
+
+	logged := 0
+	for i := 0; i < 100; i++ {
+		if pick(net.TCPDestination(net.DomainAddress("v2fly.org"), 80)).GetRuleLogged() {
+			logged++
+		}
+		if !pick(net.UDPDestination(net.DomainAddress("v2fly.org"), 53)).GetRuleLogged() {
+			t.Fatal("expect routing by rule without sampling to be logged")
+		}
+	}
+	if logged != 25 {
+		t.Error("expect 25 of 100 routings logged, but got ", logged)
+	}
+
+	config.Rule[0].LogSampling.Rate = 2
+	if err := new(Router).Init(context.TODO(), config, mockDNS, nil); err == nil {
+		t.Error("expect error on invalid log sampling rate")
+	}
+}
+
+func TestLazyMatchers(t *testing.T) {
+	config := &Config{
+		LazyMatchers: true,
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "invalid",
+				},
+				Cidr: []*CIDR{{Ip: []byte{10, 0, 0}, Prefix: 8}},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "domain",
+				},
+				Domain: []*Domain{{Type: Domain_Domain, Value: "v2fly.org"}},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "ip",
+				},
+				Cidr: []*CIDR{{Ip: []byte{10, 0, 0, 0}, Prefix: 8}},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+
+	if err := new(Router).Init(context.TODO(), &Config{Rule: config.Rule}, mockDNS, nil); err == nil {
+		t.Error("expect error on invalid CIDR")
+	}
+
+	// The invalid CIDR is only found on first use, and never matches.
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, nil))
+
+	testCases := []struct {
+		dest net.Destination
+		tag  string
+	}{
+		{
+			dest: net.TCPDestination(net.DomainAddress("www.v2fly.org"), 80),
+			tag:  "domain",
+		},
+		{
+			dest: net.TCPDestination(net.ParseAddress("10.1.2.3"), 80),
+			tag:  "ip",
+		},
+	}
+	for _, tc := range testCases {
+		ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: tc.dest})
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		common.Must(err)
+		if tag := route.GetOutboundTag(); tag != tc.tag {
+			t.Error("expect tag ", tc.tag, ", but actually ", tag)
+		}
+	}
+}
+
+func TestDomainMatcherCacheShared(t *testing.T) {
+	config := &Config{
+		DomainMatcherCacheSize: 16,
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "a"},
+				Domain:    []*Domain{{Type: Domain_Domain, Value: "a.v2fly.org"}},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "b"},
+				Domain:    []*Domain{{Type: Domain_Domain, Value: "b.v2fly.org"}},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "other"},
+				Networks:  []net.Network{net.Network_TCP},
+			},
+		},
+	}
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, nil, nil))
+
+	// Each domain is queried against both matchers sharing the cache, whose
+	// results must be kept apart.
+	for round := 0; round < 2; round++ {
+		for domain, tag := range map[string]string{
+			"www.a.v2fly.org": "a",
+			"www.b.v2fly.org": "b",
+			"www.v2fly.org":   "other",
+		} {
+			ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress(domain), 80)})
+			route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+			common.Must(err)
+			if actual := route.GetOutboundTag(); actual != tag {
+				t.Error("expect tag ", tag, " for ", domain, ", but actually ", actual)
+			}
+		}
+	}
+}
+
+func TestRuleRedirectTarget(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "cache",
+				},
+				PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(80)}},
+				RedirectTarget: &net.Endpoint{
+					Address: net.NewIPOrDomain(net.ParseAddress("10.0.0.2")),
+					Port:    3128,
+				},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "mirror",
+				},
+				Networks: []net.Network{net.Network_UDP},
+				RedirectTarget: &net.Endpoint{
+					Address: net.NewIPOrDomain(net.ParseAddress("mirror.example.com")),
+				},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, nil))
+
+	testCases := []struct {
+		dest   net.Destination
+		target net.Destination
+	}{
+		{
+			dest:   net.TCPDestination(net.DomainAddress("v2fly.org"), 80),
+			target: net.TCPDestination(net.ParseAddress("10.0.0.2"), 3128),
+		},
+		{
+			dest:   net.UDPDestination(net.DomainAddress("v2fly.org"), 443),
+			target: net.UDPDestination(net.DomainAddress("mirror.example.com"), 443),
+		},
+	}
+	for _, tc := range testCases {
+		ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: tc.dest})
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		common.Must(err)
+		if target := route.(routing.RuleRoute).GetRuleRedirectTarget(); target != tc.target {
+			t.Error("expect redirect target ", tc.target, ", but actually ", target)
+		}
+	}
+
+	config.Rule[1].RedirectTarget.Address = nil
+	if err := new(Router).Init(context.TODO(), config, mockDNS, nil); err == nil {
+		t.Error("expect error on redirect target without address")
+	}
+}
+
+func TestRuleRateLimit(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "limited",
+				},
+				Networks:  []net.Network{net.Network_TCP},
+				RateLimit: &RateLimit{Rate: 1024},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "unlimited",
+				},
+				Networks: []net.Network{net.Network_UDP},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, nil))
+
+	pickBucket := func(source string, dest net.Destination) *ratelimit.Bucket {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{Source: net.TCPDestination(net.ParseAddress(source), 10000)})
+		ctx = session.ContextWithOutbound(ctx, &session.Outbound{Target: dest})
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		common.Must(err)
+		return route.(routing.RuleRoute).GetRuleRateLimit()
+	}
+
+	tcp := net.TCPDestination(net.DomainAddress("v2fly.org"), 443)
+	a := pickBucket("10.0.0.1", tcp)
+	if a == nil {
+		t.Fatal("expect connection to be rate limited")
+	}
+	if b := pickBucket("10.0.0.1", tcp); b != a {
+		t.Error("expect connections from the same source to share the bucket")
+	}
+	if b := pickBucket("10.0.0.2", tcp); b == nil || b == a {
+		t.Error("expect connections from different sources to have buckets of their own")
+	}
+	if b := pickBucket("10.0.0.1", net.UDPDestination(net.DomainAddress("v2fly.org"), 443)); b != nil {
+		t.Error("expect connection not to be rate limited")
+	}
+
+	config.Rule[0].RateLimit.Rate = 0
+	if err := new(Router).Init(context.TODO(), config, mockDNS, nil); err == nil {
+		t.Error("expect error on rate limit without rate")
+	}
+}
+
+func TestRuleSetAttributes(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "premium",
+				},
+				Domain:        []*Domain{{Type: Domain_Full, Value: "v2fly.org"}},
+				SetAttributes: map[string]string{"tier": "premium", ":path": "/override"},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "direct",
+				},
+				Networks: []net.Network{net.Network_TCP},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mocks.NewDNSClient(mockCtl), nil))
+
+	pick := func(domain string) routing.Route {
+		ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress(domain), 80)})
+		ctx = session.ContextWithContent(ctx, &session.Content{Attributes: map[string]string{":path": "/test", "host": domain}})
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		common.Must(err)
+		return route
+	}
+
+	route := pick("v2fly.org")
+	if r := cmp.Diff(route.(routing.RuleRoute).GetRuleAttributes(), map[string]string{"tier": "premium", ":path": "/override"}); r != "" {
+		t.Error(r)
+	}
+	if r := cmp.Diff(route.GetAttributes(), map[string]string{"tier": "premium", ":path": "/override", "host": "v2fly.org"}); r != "" {
+		t.Error(r)
+	}
+
+	route = pick("example.com")
+	if route.GetOutboundTag() != "direct" {
+		t.Fatal("expect direct, but got ", route.GetOutboundTag())
+	}
+	if attributes := route.(routing.RuleRoute).GetRuleAttributes(); len(attributes) != 0 {
+		t.Error("expect no attributes from unmatched rule, but got ", attributes)
+	}
+	if r := cmp.Diff(route.GetAttributes(), map[string]string{":path": "/test", "host": "example.com"}); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestRouterPortSets(t *testing.T) {
+	config := &Config{
+		PortSet: map[string]*net.PortList{
+			"web": {Range: []*net.PortRange{{From: 80, To: 80}, {From: 443, To: 443}}},
+			"dns": {Range: []*net.PortRange{{From: 53, To: 53}}},
+		},
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "web",
+				},
+				PortList:    &net.PortList{Range: []*net.PortRange{{From: 8080, To: 8080}}},
+				PortSetName: []string{"web"},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "dns",
+				},
+				SourcePortSetName: []string{"dns"},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "direct",
+				},
+				Networks: []net.Network{net.Network_TCP},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mocks.NewDNSClient(mockCtl), nil))
+
+	cases := []struct {
+		port       net.Port
+		sourcePort net.Port
+		tag        string
+	}{
+		{port: 443, sourcePort: 1000, tag: "web"},
+		{port: 8080, sourcePort: 1000, tag: "web"},
+		{port: 22, sourcePort: 53, tag: "dns"},
+		{port: 22, sourcePort: 1000, tag: "direct"},
+	}
+	for _, c := range cases {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{Source: net.TCPDestination(net.LocalHostIP, c.sourcePort)})
+		ctx = session.ContextWithOutbound(ctx, &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2fly.org"), c.port)})
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		common.Must(err)
+		if tag := route.GetOutboundTag(); tag != c.tag {
+			t.Error("port ", c.port, " from ", c.sourcePort, ": expect tag ", c.tag, ", but got ", tag)
+		}
+	}
+}
+
+func TestRouterPortSetNotFound(t *testing.T) {
+	config := &Config{
+		PortSet: map[string]*net.PortList{
+			"web": {Range: []*net.PortRange{{From: 443, To: 443}}},
+		},
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "test",
+				},
+				Networks: []net.Network{net.Network_TCP},
+				OrGroups: []*RoutingRule{
+					{SourcePortSetName: []string{"ssh"}},
+				},
+			},
+		},
+	}
+
+	if err := new(Router).Init(context.TODO(), config, nil, nil); err == nil {
+		t.Error("expect error for undefined port set")
+	}
+	if _, err := (&RoutingRule{PortSetName: []string{"web"}}).BuildCondition(); err == nil {
+		t.Error("expect error for port set without definitions")
+	}
+	if _, err := (&RoutingRule{PortSetName: []string{"web"}}).BuildConditionWithPortSets(config.PortSet); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestSimpleBalancer(t *testing.T) {
 	config := &Config{
 		Rule: []*RoutingRule{
@@ -78,7 +603,388 @@ func TestSimpleBalancer(t *testing.T) {
 	mockOhm := mocks.NewOutboundManager(mockCtl)
 	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
 
-	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test"})
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test"})
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, &mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	}))
+
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2fly.org"), 80)})
+	route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+	common.Must(err)
+	if tag := route.GetOutboundTag(); tag != "test" {
+		t.Error("expect tag 'test', bug actually ", tag)
+	}
+}
+
+type fakeObservatory struct {
+	result *observatory.ObservationResult
+}
+
+func (*fakeObservatory) Type() interface{} { return nil }
+
+func (*fakeObservatory) Start() error { return nil }
+
+func (*fakeObservatory) Close() error { return nil }
+
+func (o *fakeObservatory) GetObservation(context.Context) (proto.Message, error) {
+	return o.result, nil
+}
+
+func TestBalancerFallback(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b", "test-c"}).AnyTimes()
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+	}).Build(&mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	})
+	common.Must(err)
+	balancer.InjectContext(context.Background())
+	balancer.SetObservatory(&fakeObservatory{
+		result: &observatory.ObservationResult{
+			Status: []*observatory.OutboundStatus{
+				{OutboundTag: "test-a", Alive: false},
+				{OutboundTag: "test-b", Alive: true},
+				{OutboundTag: "test-c", Alive: true},
+			},
+		},
+	})
+
+	for i := 0; i < 16; i++ {
+		tags, err := balancer.PickOutbounds()
+		common.Must(err)
+		if len(tags) != 3 || tags[0] == "test-a" || tags[1] == "test-a" || tags[2] != "test-a" {
+			t.Fatal("expect dead outbound to be the last candidate, but actually ", tags)
+		}
+
+		tag, err := balancer.PickOutbound()
+		common.Must(err)
+		if tag == "test-a" {
+			t.Fatal("expect a healthy outbound, but actually ", tag)
+		}
+	}
+}
+
+// versionedObservatory counts observations, and changes its version only
+// when told to.
+type versionedObservatory struct {
+	fakeObservatory
+	version      uint64
+	observations int
+}
+
+func (o *versionedObservatory) GetObservation(ctx context.Context) (proto.Message, error) {
+	o.observations++
+	return o.fakeObservatory.GetObservation(ctx)
+}
+
+func (o *versionedObservatory) ObservationVersion() uint64 {
+	return o.version
+}
+
+func TestBalancerObservationCache(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b"}).AnyTimes()
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+	}).Build(&mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	})
+	common.Must(err)
+	balancer.InjectContext(context.Background())
+	statusA := &observatory.OutboundStatus{OutboundTag: "test-a", Alive: false}
+	o := &versionedObservatory{
+		fakeObservatory: fakeObservatory{
+			result: &observatory.ObservationResult{
+				Status: []*observatory.OutboundStatus{
+					statusA,
+					{OutboundTag: "test-b", Alive: true},
+				},
+			},
+		},
+	}
+	balancer.SetObservatory(o)
+
+	for i := 0; i < 8; i++ {
+		tag, err := balancer.PickOutbound()
+		common.Must(err)
+		if tag != "test-b" {
+			t.Fatal("expect the alive outbound, but actually ", tag)
+		}
+	}
+	if o.observations != 1 {
+		t.Error("expect one observation per version, but actually ", o.observations)
+	}
+
+	statusA.Alive = true
+	statusB := o.result.Status[1]
+	statusB.Alive = false
+	o.version++
+	tag, err := balancer.PickOutbound()
+	common.Must(err)
+	if tag != "test-a" {
+		t.Error("expect the observation to be refreshed on a new version, but picked ", tag)
+	}
+	if o.observations != 2 {
+		t.Error("expect two observations, but actually ", o.observations)
+	}
+}
+
+func TestBalancerMaxFailures(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b"}).AnyTimes()
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+		Strategy:         "leastPing",
+		MaxFailures:      3,
+	}).Build(&mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	})
+	common.Must(err)
+	balancer.InjectContext(context.Background())
+	statusA := &observatory.OutboundStatus{OutboundTag: "test-a", Alive: true, Delay: 50}
+	balancer.SetObservatory(&fakeObservatory{
+		result: &observatory.ObservationResult{
+			Status: []*observatory.OutboundStatus{
+				statusA,
+				{OutboundTag: "test-b", Alive: true, Delay: 100},
+			},
+		},
+	})
+
+	expectCandidates := func(expected ...string) {
+		t.Helper()
+		tags, err := balancer.PickOutbounds()
+		common.Must(err)
+		if r := cmp.Diff(tags, expected); r != "" {
+			t.Error(r)
+		}
+	}
+
+	expectCandidates("test-a", "test-b")
+
+	// Failures below the threshold are tolerated, with the RTT of the last
+	// successful probe.
+	for failures := uint32(1); failures < 3; failures++ {
+		statusA.Alive, statusA.Delay, statusA.ConsecutiveFailures = false, 99999999, failures
+		expectCandidates("test-a", "test-b")
+	}
+
+	statusA.ConsecutiveFailures = 3
+	expectCandidates("test-b", "test-a")
+	statusA.ConsecutiveFailures = 5
+	expectCandidates("test-b", "test-a")
+
+	statusA.Alive, statusA.Delay, statusA.ConsecutiveFailures = true, 60, 0
+	expectCandidates("test-a", "test-b")
+}
+
+func TestBalancerAllDead(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b"})
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+	}).Build(&mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	})
+	common.Must(err)
+	balancer.InjectContext(context.Background())
+	balancer.SetObservatory(&fakeObservatory{
+		result: &observatory.ObservationResult{
+			Status: []*observatory.OutboundStatus{
+				{OutboundTag: "test-a", Alive: false},
+				{OutboundTag: "test-b", Alive: false},
+			},
+		},
+	})
+
+	tags, err := balancer.PickOutbounds()
+	common.Must(err)
+	if len(tags) != 2 {
+		t.Error("expect all outbounds as candidates, but actually ", tags)
+	}
+}
+
+func TestBalancerPickOutboundExcluding(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b", "test-c"}).AnyTimes()
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+		Strategy:         "leastPing",
+	}).Build(&mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	})
+	common.Must(err)
+	balancer.InjectContext(context.Background())
+	balancer.SetObservatory(&fakeObservatory{
+		result: &observatory.ObservationResult{
+			Status: []*observatory.OutboundStatus{
+				{OutboundTag: "test-a", Alive: true, Delay: 50},
+				{OutboundTag: "test-b", Alive: true, Delay: 100},
+				{OutboundTag: "test-c", Alive: true, Delay: 150},
+			},
+		},
+	})
+
+	testCases := []struct {
+		exclude []string
+		tag     string
+	}{
+		{exclude: nil, tag: "test-a"},
+		{exclude: []string{"test-a"}, tag: "test-b"},
+		{exclude: []string{"test-a", "test-b"}, tag: "test-c"},
+		{exclude: []string{"test-b", "other"}, tag: "test-a"},
+	}
+	for _, tc := range testCases {
+		tag, err := balancer.PickOutboundExcluding(tc.exclude)
+		common.Must(err)
+		if tag != tc.tag {
+			t.Error("expect ", tc.tag, " excluding ", tc.exclude, ", but actually ", tag)
+		}
+	}
+
+	if _, err := balancer.PickOutboundExcluding([]string{"test-a", "test-b", "test-c"}); err == nil {
+		t.Error("expect error when all outbounds are excluded")
+	}
+}
+
+func TestBalancerStats(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b"}).AnyTimes()
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+		Strategy:         "leastPing",
+	}).Build(&mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	})
+	common.Must(err)
+	balancer.InjectContext(context.Background())
+	result := &observatory.ObservationResult{
+		Status: []*observatory.OutboundStatus{
+			{OutboundTag: "test-a", Alive: true, Delay: 80, LastTryTime: 1},
+			{OutboundTag: "test-b", Alive: true, Delay: 300, LastTryTime: 1},
+		},
+	}
+	balancer.SetObservatory(&fakeObservatory{result: result})
+	manager, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+	balancer.SetStatsManager(manager)
+
+	value := func(name string) int64 {
+		c := manager.GetCounter("routing>>>balancer>>>balance>>>" + name)
+		if c == nil {
+			return 0
+		}
+		return c.Value()
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := balancer.PickOutbound()
+		common.Must(err)
+	}
+	// test-b gets faster in a new probe.
+	result.Status[1].Delay = 40
+	result.Status[1].LastTryTime = 2
+	_, err = balancer.PickOutbound()
+	common.Must(err)
+
+	expected := map[string]int64{
+		"pick>>>test-a":            3,
+		"pick>>>test-b":            1,
+		"rtt>>>test-a>>>le_50ms":   0,
+		"rtt>>>test-a>>>le_100ms":  1,
+		"rtt>>>test-a>>>le_inf":    1,
+		"rtt>>>test-a>>>sum_ms":    80,
+		"rtt>>>test-b>>>le_50ms":   1,
+		"rtt>>>test-b>>>le_200ms":  1,
+		"rtt>>>test-b>>>le_500ms":  2,
+		"rtt>>>test-b>>>le_5000ms": 2,
+		"rtt>>>test-b>>>le_inf":    2,
+		"rtt>>>test-b>>>sum_ms":    340,
+	}
+	for name, v := range expected {
+		if actual := value(name); actual != v {
+			t.Error("expect ", v, " for ", name, ", but actually ", actual)
+		}
+	}
+
+	// Without a stats manager, nothing is recorded.
+	if NewBalancerStats(nil, "balance") != nil {
+		t.Error("expect nil stats without stats manager")
+	}
+}
+
+func TestBalancerHandlerFallbackRoute(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_BalancingTag{
+					BalancingTag: "balance",
+				},
+				Networks: []net.Network{net.Network_TCP},
+			},
+		},
+		BalancingRule: []*BalancingRule{
+			{
+				Tag:              "balance",
+				OutboundSelector: []string{"test-"},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b"})
 
 	r := new(Router)
 	common.Must(r.Init(context.TODO(), config, mockDNS, &mockOutboundManager{
@@ -89,8 +995,13 @@ func TestSimpleBalancer(t *testing.T) {
 	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2fly.org"), 80)})
 	route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
 	common.Must(err)
-	if tag := route.GetOutboundTag(); tag != "test" {
-		t.Error("expect tag 'test', bug actually ", tag)
+	fallbackRoute, ok := route.(routing.HandlerFallbackRoute)
+	if !ok {
+		t.Fatal("expect a fallback route")
+	}
+	tags := append([]string{route.GetOutboundTag()}, fallbackRoute.GetHandlerFallbackTags()...)
+	if len(tags) != 2 || tags[0] == tags[1] {
+		t.Error("expect both outbounds as candidates, but actually ", tags)
 	}
 }
 
@@ -197,3 +1108,708 @@ func TestIPIfNonMatchIP(t *testing.T) {
 		t.Error("expect tag 'test', bug actually ", tag)
 	}
 }
+
+type countingHandler struct {
+	outbound.Handler
+	conns int64
+}
+
+func (h *countingHandler) ActiveConnections() int64 {
+	return h.conns
+}
+
+func TestCompositeBalancer(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	handlerA := &countingHandler{}
+	handlerB := &countingHandler{}
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockOhm.EXPECT().GetHandler("test-a").Return(handlerA).AnyTimes()
+	mockOhm.EXPECT().GetHandler("test-b").Return(handlerB).AnyTimes()
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b"}).AnyTimes()
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+		Strategy:         "composite",
+		LatencyWeight:    1,
+		LoadWeight:       10,
+	}).Build(&mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	})
+	common.Must(err)
+	balancer.InjectContext(context.Background())
+	balancer.SetObservatory(&fakeObservatory{
+		result: &observatory.ObservationResult{
+			Status: []*observatory.OutboundStatus{
+				{OutboundTag: "test-a", Alive: true, Delay: 10},
+				{OutboundTag: "test-b", Alive: true, Delay: 100},
+			},
+		},
+	})
+
+	count := func() int {
+		picked := 0
+		for i := 0; i < 1000; i++ {
+			tag, err := balancer.PickOutbound()
+			common.Must(err)
+			if tag == "test-a" {
+				picked++
+			}
+		}
+		return picked
+	}
+
+	// Scores are 11 and 101, so test-a is picked about 90% of the time.
+	if n := count(); n < 800 {
+		t.Error("expect test-a to be preferred for lower latency, but picked ", n, " times out of 1000")
+	}
+
+	// Scores become 1011 and 101 after test-a gets busy.
+	handlerA.conns = 100
+	if n := count(); n > 200 {
+		t.Error("expect test-b to be preferred for lower load, but test-a picked ", n, " times out of 1000")
+	}
+}
+
+func TestBalancerMaxConnPerNode(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	handlerA := &countingHandler{}
+	handlerB := &countingHandler{}
+	handlerC := &countingHandler{}
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockOhm.EXPECT().GetHandler("test-a").Return(handlerA).AnyTimes()
+	mockOhm.EXPECT().GetHandler("test-b").Return(handlerB).AnyTimes()
+	mockOhm.EXPECT().GetHandler("test-c").Return(handlerC).AnyTimes()
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b", "test-c"}).AnyTimes()
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+		Strategy:         "leastPing",
+		MaxConnPerNode:   2,
+	}).Build(&mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	})
+	common.Must(err)
+	balancer.InjectContext(context.Background())
+	balancer.SetObservatory(&fakeObservatory{
+		result: &observatory.ObservationResult{
+			Status: []*observatory.OutboundStatus{
+				{OutboundTag: "test-c", Alive: true, Delay: 300},
+				{OutboundTag: "test-a", Alive: true, Delay: 10},
+				{OutboundTag: "test-b", Alive: true, Delay: 100},
+			},
+		},
+	})
+
+	testCases := []struct {
+		connsA, connsB, connsC int64
+		expected               string
+	}{
+		{connsA: 1, connsB: 0, connsC: 0, expected: "test-a"},
+		{connsA: 2, connsB: 0, connsC: 0, expected: "test-b"},
+		{connsA: 2, connsB: 2, connsC: 1, expected: "test-c"},
+		{connsA: 5, connsB: 2, connsC: 2, expected: "test-a"},
+	}
+	for _, tc := range testCases {
+		handlerA.conns, handlerB.conns, handlerC.conns = tc.connsA, tc.connsB, tc.connsC
+		tag, err := balancer.PickOutbound()
+		common.Must(err)
+		if tag != tc.expected {
+			t.Error("expect ", tc.expected, " with ", tc.connsA, "/", tc.connsB, "/", tc.connsC, " connections, but actually ", tag)
+		}
+	}
+}
+
+type taggedHandler struct {
+	outbound.Handler
+	tag string
+}
+
+func (h *taggedHandler) Tag() string { return h.tag }
+
+func (h *taggedHandler) Start() error { return nil }
+
+func (h *taggedHandler) Close() error { return nil }
+
+func TestBalancerSelectorMatch(t *testing.T) {
+	testCases := []struct {
+		match    BalancingRule_SelectorMatch
+		selector string
+	}{
+		{match: BalancingRule_Glob, selector: "proxy-us-*"},
+		{match: BalancingRule_Regex, selector: "^proxy-us-\\d+$"},
+	}
+
+	for _, tc := range testCases {
+		ohm, err := outbound_manager.New(context.Background(), nil)
+		common.Must(err)
+		for _, tag := range []string{"proxy-us-1", "proxy-uk-1", "direct"} {
+			common.Must(ohm.AddHandler(context.Background(), &taggedHandler{tag: tag}))
+		}
+
+		balancer, err := (&BalancingRule{
+			Tag:              "balance",
+			OutboundSelector: []string{tc.selector},
+			SelectorMatch:    tc.match,
+		}).Build(ohm)
+		common.Must(err)
+
+		tags, err := balancer.PickOutbounds()
+		common.Must(err)
+		if r := cmp.Diff(tags, []string{"proxy-us-1"}); r != "" {
+			t.Error(tc.match, ": ", r)
+		}
+
+		// Outbounds added after the balancer is built join the pool.
+		common.Must(ohm.AddHandler(context.Background(), &taggedHandler{tag: "proxy-us-2"}))
+		tags, err = balancer.PickOutbounds()
+		common.Must(err)
+		sort.Strings(tags)
+		if r := cmp.Diff(tags, []string{"proxy-us-1", "proxy-us-2"}); r != "" {
+			t.Error(tc.match, ": ", r)
+		}
+	}
+}
+
+func TestBalancerInvalidSelector(t *testing.T) {
+	_, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"proxy-(us"},
+		SelectorMatch:    BalancingRule_Regex,
+	}).Build(nil)
+	if err == nil {
+		t.Error("expect error for invalid regular expression selector")
+	}
+}
+
+func TestBalancerDrain(t *testing.T) {
+	ohm, err := outbound_manager.New(context.Background(), nil)
+	common.Must(err)
+	for _, tag := range []string{"a", "b"} {
+		common.Must(ohm.AddHandler(context.Background(), &taggedHandler{tag: tag}))
+	}
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"a", "b"},
+		DrainGracePeriod: 50,
+	}).Build(ohm)
+	common.Must(err)
+	drained := make(chan string, 1)
+	balancer.SetOnDrained(func(tag string) { drained <- tag })
+
+	common.Must(balancer.UpdateSelectors([]string{"a"}))
+	if r := cmp.Diff(balancer.DrainingOutbounds(), []string{"b"}); r != "" {
+		t.Error(r)
+	}
+	for i := 0; i < 100; i++ {
+		tags, err := balancer.PickOutbounds()
+		common.Must(err)
+		if r := cmp.Diff(tags, []string{"a"}); r != "" {
+			t.Fatal(r)
+		}
+	}
+
+	select {
+	case tag := <-drained:
+		if tag != "b" {
+			t.Error("expect b to be drained, but got ", tag)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expect b to be drained after grace period")
+	}
+	if tags := balancer.DrainingOutbounds(); len(tags) != 0 {
+		t.Error("expect no draining outbounds, but got ", tags)
+	}
+	if ohm.GetHandler("b") == nil {
+		t.Error("expect b not to be removed by the balancer")
+	}
+}
+
+func TestRouterDrainOutbounds(t *testing.T) {
+	ohm, err := outbound_manager.New(context.Background(), nil)
+	common.Must(err)
+	for _, tag := range []string{"default", "shared", "direct", "mirror", "only"} {
+		common.Must(ohm.AddHandler(context.Background(), &taggedHandler{tag: tag}))
+	}
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), &Config{
+		BalancingRule: []*BalancingRule{
+			{
+				Tag:              "b1",
+				OutboundSelector: []string{"default", "shared", "direct", "mirror", "only"},
+				DrainGracePeriod: 50,
+			},
+			{
+				Tag:              "b2",
+				OutboundSelector: []string{"shared"},
+			},
+		},
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_BalancingTag{BalancingTag: "b1"},
+				Networks:  []net.Network{net.Network_TCP},
+			},
+			{
+				TargetTag: &RoutingRule_BalancingTag{BalancingTag: "b2"},
+				Networks:  []net.Network{net.Network_UDP},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "direct"},
+				Domain:    []*Domain{{Type: Domain_Full, Value: "v2fly.org"}},
+				MirrorTag: "mirror",
+			},
+		},
+	}, nil, ohm))
+
+	if err := r.UpdateBalancerSelectors("b3", nil); err == nil {
+		t.Error("expect error for unknown balancer")
+	}
+	common.Must(r.UpdateBalancerSelectors("b1", []string{"none"}))
+	time.Sleep(200 * time.Millisecond)
+
+	for _, tag := range []string{"default", "shared", "direct", "mirror"} {
+		if ohm.GetHandler(tag) == nil {
+			t.Error("expect ", tag, " still in use to be kept")
+		}
+	}
+	if ohm.GetHandler("only") != nil {
+		t.Error("expect only to be removed after grace period")
+	}
+}
+
+func TestBalancerDrainCanceled(t *testing.T) {
+	ohm, err := outbound_manager.New(context.Background(), nil)
+	common.Must(err)
+	for _, tag := range []string{"a", "b"} {
+		common.Must(ohm.AddHandler(context.Background(), &taggedHandler{tag: tag}))
+	}
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"a", "b"},
+		DrainGracePeriod: 50,
+	}).Build(ohm)
+	common.Must(err)
+
+	common.Must(balancer.UpdateSelectors([]string{"a"}))
+	common.Must(balancer.UpdateSelectors([]string{"a", "b"}))
+	if tags := balancer.DrainingOutbounds(); len(tags) != 0 {
+		t.Error("expect no draining outbounds, but got ", tags)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if ohm.GetHandler("b") == nil {
+		t.Error("expect b to be kept after selected again")
+	}
+}
+
+func TestRouterReloadGeoData(t *testing.T) {
+	writeGeoIP := func(dir, file string, cidr *CIDR) {
+		geoipBytes, err := proto.Marshal(&GeoIPList{
+			Entry: []*GeoIP{{CountryCode: "XA", Cidr: []*CIDR{cidr}}},
+		})
+		common.Must(err)
+		common.Must(filesystem.WriteFile(filepath.Join(dir, file), geoipBytes))
+	}
+
+	assetPath := t.TempDir()
+	writeGeoIP(assetPath, "geoip.dat", &CIDR{Ip: []byte{192, 168, 0, 0}, Prefix: 16})
+	writeGeoIP(assetPath, "other.dat", &CIDR{Ip: []byte{172, 16, 0, 0}, Prefix: 12})
+	geositeBytes, err := proto.Marshal(&GeoSiteList{
+		Entry: []*GeoSite{{
+			CountryCode: "XS",
+			Domain: []*Domain{
+				{Type: Domain_Full, Value: "new.example", Attribute: []*Domain_Attribute{{Key: "ads", TypedValue: &Domain_Attribute_BoolValue{BoolValue: true}}}},
+				{Type: Domain_Full, Value: "other.example"},
+			},
+		}},
+	})
+	common.Must(err)
+	common.Must(filesystem.WriteFile(filepath.Join(assetPath, "geosite.dat"), geositeBytes))
+	defer os.Setenv("v2ray.location.asset", os.Getenv("v2ray.location.asset"))
+	os.Setenv("v2ray.location.asset", assetPath)
+
+	initialGeoIP := func(code, file string) *GeoIP {
+		return &GeoIP{
+			CountryCode: code,
+			Cidr:        []*CIDR{{Ip: []byte{10, 0, 0, 0}, Prefix: 8}},
+			Source:      []*GeoDataSource{{File: file, Code: "xa"}},
+		}
+	}
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "site"},
+				Geosite: []*GeoSite{
+					{
+						Domain: []*Domain{{Type: Domain_Full, Value: "old.example", Label: "xs"}},
+						Source: []*GeoDataSource{{File: "geosite.dat", Code: "xs@ads"}},
+						Label:  "xs",
+					},
+				},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "test"},
+				Geoip:     []*GeoIP{initialGeoIP("XA", "geoip.dat")},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "ext"},
+				Geoip:     []*GeoIP{initialGeoIP("OTHER.DAT_XA", "other.dat")},
+			},
+			{
+				TargetTag:   &RoutingRule_Tag{Tag: "source"},
+				SourceGeoip: []*GeoIP{initialGeoIP("XA", "geoip.dat")},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mocks.NewDNSClient(mockCtl), &mockOutboundManager{
+		Manager:         mocks.NewOutboundManager(mockCtl),
+		HandlerSelector: mocks.NewOutboundHandlerSelector(mockCtl),
+	}))
+
+	route := func(ctx context.Context) string {
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		if err != nil {
+			return ""
+		}
+		return route.GetOutboundTag()
+	}
+	routeIP := func(ip net.IP) string {
+		return route(session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.IPAddress(ip), 80)}))
+	}
+	routeSource := func(ip net.IP) string {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{Source: net.TCPDestination(net.IPAddress(ip), 1234)})
+		return route(session.ContextWithOutbound(ctx, &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2fly.org"), 80)}))
+	}
+	routeDomain := func(domain string) (string, string) {
+		ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress(domain), 80)})
+		route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+		if err != nil {
+			return "", ""
+		}
+		return route.GetOutboundTag(), route.GetAttributes()[session.AttributeDomainLabel]
+	}
+
+	if tag := routeIP(net.IP{10, 1, 1, 1}); tag != "test" {
+		t.Fatal("expect 10.1.1.1 to be routed to test before reload, but got ", tag)
+	}
+	if tag := routeSource(net.IP{10, 1, 1, 1}); tag != "source" {
+		t.Fatal("expect source 10.1.1.1 to be routed to source before reload, but got ", tag)
+	}
+	if tag, _ := routeDomain("old.example"); tag != "site" {
+		t.Fatal("expect old.example to be routed to site before reload, but got ", tag)
+	}
+
+	common.Must(r.ReloadGeoData())
+
+	testCases := []struct {
+		ip  net.IP
+		tag string
+	}{
+		{net.IP{10, 1, 1, 1}, ""},
+		{net.IP{192, 168, 1, 1}, "test"},
+		// The entry of the same country code in another file is reloaded from that file.
+		{net.IP{172, 16, 1, 1}, "ext"},
+	}
+	for _, tc := range testCases {
+		if tag := routeIP(tc.ip); tag != tc.tag {
+			t.Error("expect ", tc.ip, " to be routed to ", tc.tag, " after reload, but got ", tag)
+		}
+	}
+	// The rule sharing the country is reloaded too.
+	if routeSource(net.IP{10, 1, 1, 1}) == "source" || routeSource(net.IP{192, 168, 1, 1}) != "source" {
+		t.Error("expect source rule to match IPs in the reloaded table")
+	}
+
+	if tag, label := routeDomain("new.example"); tag != "site" || label != "xs" {
+		t.Error("expect new.example to be routed to site with label xs after reload, but got ", tag, " and ", label)
+	}
+	for _, domain := range []string{"old.example", "other.example"} {
+		if tag, _ := routeDomain(domain); tag == "site" {
+			t.Error("expect ", domain, " not to be routed to site after reload")
+		}
+	}
+}
+
+func TestRouterSharedDomainPatterns(t *testing.T) {
+	assetPath := t.TempDir()
+	geoipBytes, err := proto.Marshal(&GeoIPList{})
+	common.Must(err)
+	common.Must(filesystem.WriteFile(filepath.Join(assetPath, "geoip.dat"), geoipBytes))
+	defer os.Setenv("v2ray.location.asset", os.Getenv("v2ray.location.asset"))
+	os.Setenv("v2ray.location.asset", assetPath)
+
+	cdn := &Domain{Type: Domain_Regex, Value: `^cdn\d+\.`}
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "tcp"},
+				Domain:    []*Domain{cdn, {Type: Domain_Regex, Value: `\.test$`}},
+				Networks:  []net.Network{net.Network_TCP},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "udp"},
+				Domain:    []*Domain{cdn},
+				Networks:  []net.Network{net.Network_UDP},
+			},
+		},
+	}
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, nil, nil))
+	if n := SharedDomainPatterns(); n != 2 {
+		t.Error("expect 2 shared domain patterns, but got ", n)
+	}
+
+	check := func() {
+		testCases := []struct {
+			dest net.Destination
+			tag  string
+		}{
+			{net.TCPDestination(net.DomainAddress("cdn1.example.com"), 443), "tcp"},
+			{net.TCPDestination(net.DomainAddress("www.test"), 443), "tcp"},
+			{net.UDPDestination(net.DomainAddress("cdn2.example.com"), 443), "udp"},
+		}
+		for _, tc := range testCases {
+			ctx := routing_session.AsRoutingContext(session.ContextWithOutbound(context.Background(), &session.Outbound{Target: tc.dest}))
+			route, err := r.PickRoute(ctx)
+			common.Must(err)
+			if tag := route.GetOutboundTag(); tag != tc.tag {
+				t.Error("expect tag ", tc.tag, " for ", tc.dest, ", but actually ", tag)
+			}
+		}
+		ctx := routing_session.AsRoutingContext(session.ContextWithOutbound(context.Background(), &session.Outbound{
+			Target: net.UDPDestination(net.DomainAddress("www.test"), 443),
+		}))
+		if _, err := r.PickRoute(ctx); err == nil {
+			t.Error("expect no route for www.test over UDP")
+		}
+	}
+	check()
+
+	common.Must(r.ReloadGeoData())
+	if n := SharedDomainPatterns(); n != 2 {
+		t.Error("expect 2 shared domain patterns after reload, but got ", n)
+	}
+	check()
+}
+
+func TestBalancerAffinity(t *testing.T) {
+	ohm, err := outbound_manager.New(context.Background(), nil)
+	common.Must(err)
+	for _, tag := range []string{"a", "b"} {
+		common.Must(ohm.AddHandler(context.Background(), &taggedHandler{tag: tag}))
+	}
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"a", "b"},
+		Strategy:         "leastPing",
+	}).Build(ohm)
+	common.Must(err)
+	now := time.Unix(10000, 0)
+	balancer.SetAffinityTable(NewAffinityTable(time.Minute, func() time.Time { return now }))
+	obs := &fakeObservatory{
+		result: &observatory.ObservationResult{
+			Status: []*observatory.OutboundStatus{
+				{OutboundTag: "a", Alive: true, Delay: 100},
+				{OutboundTag: "b", Alive: true, Delay: 200},
+			},
+		},
+	}
+	balancer.SetObservatory(obs)
+
+	pick := func(source string) string {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{Source: net.TCPDestination(net.ParseAddress(source), 1234)})
+		tags, err := balancer.PickOutboundsFor(routing_session.AsRoutingContext(ctx))
+		common.Must(err)
+		return tags[0]
+	}
+
+	if tag := pick("10.0.0.1"); tag != "a" {
+		t.Fatal("expect a, but got ", tag)
+	}
+
+	// b is faster now, but the client sticks to a until the affinity expires.
+	obs.result.Status[1].Delay = 50
+	now = now.Add(30 * time.Second)
+	if tag := pick("10.0.0.1"); tag != "a" {
+		t.Error("expect pinned a, but got ", tag)
+	}
+	if tag := pick("10.0.0.2"); tag != "b" {
+		t.Error("expect b for new client, but got ", tag)
+	}
+
+	now = now.Add(time.Minute)
+	if tag := pick("10.0.0.1"); tag != "b" {
+		t.Error("expect b after affinity expired, but got ", tag)
+	}
+
+	// Pinned outbounds that become dead are not used.
+	obs.result.Status[1].Alive = false
+	if tag := pick("10.0.0.1"); tag != "a" {
+		t.Error("expect a after b is dead, but got ", tag)
+	}
+}
+
+func TestBalancerOnPick(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b", "test-c"}).AnyTimes()
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+	}).Build(&mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	})
+	common.Must(err)
+	balancer.SetObservatory(&fakeObservatory{
+		result: &observatory.ObservationResult{
+			Status: []*observatory.OutboundStatus{
+				{OutboundTag: "test-a", Alive: false},
+			},
+		},
+	})
+
+	type pick struct {
+		candidates []string
+		chosen     string
+	}
+	var picks []pick
+	balancer.SetOnPick(func(candidates []string, chosen string) {
+		picks = append(picks, pick{candidates, chosen})
+	})
+
+	var chosen []string
+	for i := 0; i < 100; i++ {
+		tag, err := balancer.PickOutbound()
+		common.Must(err)
+		chosen = append(chosen, tag)
+	}
+
+	if len(picks) != len(chosen) {
+		t.Fatal("expect ", len(chosen), " picks, but got ", len(picks))
+	}
+	for i, p := range picks {
+		if r := cmp.Diff(p.candidates, []string{"test-b", "test-c"}); r != "" {
+			t.Error(r)
+		}
+		if p.chosen != chosen[i] {
+			t.Error("expect pick ", i, " to be ", chosen[i], ", but got ", p.chosen)
+		}
+	}
+
+	// The callback may reconfigure the balancer, as no lock is held.
+	calls := 0
+	balancer.SetOnPick(func([]string, string) {
+		calls++
+		balancer.SetOnPick(nil)
+	})
+	for i := 0; i < 3; i++ {
+		_, err := balancer.PickOutbound()
+		common.Must(err)
+	}
+	if calls != 1 {
+		t.Error("expect callback to be called once, but got ", calls)
+	}
+}
+
+// warmHandler records the warm pool settings of the outbound.
+type warmHandler struct {
+	outbound.Handler
+	calls   int
+	size    int
+	maxIdle time.Duration
+}
+
+func (h *warmHandler) SetWarmPool(size int, maxIdle time.Duration) {
+	h.calls++
+	h.size = size
+	h.maxIdle = maxIdle
+}
+
+func TestBalancerWarmPool(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	handler := new(warmHandler)
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockOhm.EXPECT().GetHandler("test-a").Return(handler).AnyTimes()
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a"}).AnyTimes()
+
+	balancer, err := (&BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+		WarmPoolSize:     2,
+	}).Build(&mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	})
+	common.Must(err)
+
+	for i := 0; i < 3; i++ {
+		tag, err := balancer.PickOutbound()
+		common.Must(err)
+		if tag != "test-a" {
+			t.Fatal("expect tag 'test-a', but got ", tag)
+		}
+	}
+	if handler.calls != 1 {
+		t.Error("expect the warm pool to be set once, but got ", handler.calls)
+	}
+	if handler.size != 2 || handler.maxIdle != time.Minute {
+		t.Error("unexpected warm pool of size ", handler.size, " and max idle time ", handler.maxIdle)
+	}
+}
+
+func benchmarkRouterInit(b *testing.B, lazy bool) {
+	// Each rule has CIDRs of its own, so that none of them is shared.
+	config := &Config{LazyMatchers: lazy}
+	for i := 0; i < 500; i++ {
+		cidrs := make([]*CIDR, 0, 1000)
+		for j := 0; j < 1000; j++ {
+			cidrs = append(cidrs, &CIDR{Ip: []byte{10, byte(i >> 8), byte(i), byte(j)}, Prefix: 32})
+		}
+		config.Rule = append(config.Rule, &RoutingRule{
+			TargetTag: &RoutingRule_Tag{Tag: "test"},
+			Domain:    []*Domain{{Type: Domain_Domain, Value: fmt.Sprint("rule", i, ".example.com")}},
+			Cidr:      cidrs,
+		})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		common.Must(new(Router).Init(context.Background(), config, nil, nil))
+	}
+}
+
+func BenchmarkRouterInit(b *testing.B) {
+	benchmarkRouterInit(b, false)
+}
+
+func BenchmarkRouterInitLazy(b *testing.B) {
+	benchmarkRouterInit(b, true)
+}
@@ -0,0 +1,100 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"context"
+
+	"github.com/v2fly/v2ray-core/v4/features/extension"
+	"github.com/v2fly/v2ray-core/v4/features/outbound"
+)
+
+const (
+	defaultLatencyWeight = 1
+	defaultLoadWeight    = 10
+)
+
+// CompositeStrategy picks outbounds randomly, weighted by both the RTT
+// measured by the observatory and the number of active connections. Each
+// outbound is scored by latencyWeight*RTT+loadWeight*connections, and has a
+// chance inversely proportional to its score.
+type CompositeStrategy struct {
+	ctx           context.Context
+	ohm           outbound.Manager
+	latencyWeight float64
+	loadWeight    float64
+
+	observatory lazyObservatory
+}
+
+// NewCompositeStrategy creates a new CompositeStrategy. If both weights are
+// zero, default weights are used.
+func NewCompositeStrategy(ohm outbound.Manager, latencyWeight, loadWeight float64) *CompositeStrategy {
+	if latencyWeight == 0 && loadWeight == 0 {
+		latencyWeight, loadWeight = defaultLatencyWeight, defaultLoadWeight
+	}
+	return &CompositeStrategy{
+		ohm:           ohm,
+		latencyWeight: latencyWeight,
+		loadWeight:    loadWeight,
+	}
+}
+
+func (s *CompositeStrategy) InjectContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// SetObservatory sets the observatory RTT is taken from. If not set, the
+// observatory of the V2Ray instance in the injected context is used.
+func (s *CompositeStrategy) SetObservatory(o extension.Observatory) {
+	s.observatory.set(o)
+}
+
+// getDelays returns RTT in milliseconds of alive outbounds reported by the observatory.
+func (s *CompositeStrategy) getDelays() map[string]int64 {
+	o := s.observatory.get(s.ctx)
+	if o == nil {
+		return nil
+	}
+	observed := observeStatus(s.ctx, o)
+	delays := make(map[string]int64, len(observed))
+	for _, status := range observed {
+		if status.Alive {
+			delays[status.OutboundTag] = status.Delay
+		}
+	}
+	return delays
+}
+
+// Score returns the score of the outbound with the given RTT and number of
+// active connections. Lower is better.
+func (s *CompositeStrategy) Score(delay int64, load int64) float64 {
+	return s.latencyWeight*float64(delay) + s.loadWeight*float64(load)
+}
+
+// PickOutbound implements BalancingStrategy. Outbounds without RTT measured
+// are scored with the highest RTT among the others.
+func (s *CompositeStrategy) PickOutbound(tags []string) string {
+	if len(tags) == 0 {
+		panic("0 tags")
+	}
+
+	delays := s.getDelays()
+	var maxDelay int64
+	for _, tag := range tags {
+		if delay, found := delays[tag]; found && delay > maxDelay {
+			maxDelay = delay
+		}
+	}
+
+	weights := make([]float64, len(tags))
+	for i, tag := range tags {
+		delay, found := delays[tag]
+		if !found {
+			delay = maxDelay
+		}
+		weights[i] = 1 / (1 + s.Score(delay, activeConnections(s.ohm, tag)))
+	}
+	return pickWeighted(tags, weights)
+}
@@ -5,22 +5,54 @@ package router
 
 import (
 	"context"
+	"sort"
+	"sync"
+	"time"
 
 	core "github.com/v2fly/v2ray-core/v4"
 	"github.com/v2fly/v2ray-core/v4/app/observatory"
 	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/features/extension"
+	"github.com/v2fly/v2ray-core/v4/features/outbound"
 )
 
 type LeastPingStrategy struct {
 	ctx         context.Context
 	observatory extension.Observatory
+	ohm         outbound.Manager
+
+	// maxConnPerNode is the number of active connections at which an outbound
+	// is skipped in favor of the next fastest one. Not capped if zero.
+	maxConnPerNode uint32
+
+	// maxFailures is the number of consecutive failed probes after which an
+	// outbound is excluded. Outbounds failing fewer probes are ranked by the
+	// RTT of their last successful probe.
+	maxFailures uint32
+	access      sync.Mutex
+	lastDelay   map[string]int64
+
+	// metrics records RTT of probes, each seen by lastProbe, the last try
+	// time of outbounds.
+	metrics   *BalancerStats
+	lastProbe map[string]int64
 }
 
 func (l *LeastPingStrategy) InjectContext(ctx context.Context) {
 	l.ctx = ctx
 }
 
+// SetObservatory sets the observatory to get RTT of outbounds from. If not
+// set, the observatory of the V2Ray instance in the injected context is used.
+func (l *LeastPingStrategy) SetObservatory(o extension.Observatory) {
+	l.observatory = o
+}
+
+// SetMetrics sets the metrics to record RTT of probes in.
+func (l *LeastPingStrategy) SetMetrics(metrics *BalancerStats) {
+	l.metrics = metrics
+}
+
 func (l *LeastPingStrategy) PickOutbound(strings []string) string {
 	if l.observatory == nil {
 		common.Must(core.RequireFeatures(l.ctx, func(observatory extension.Observatory) error {
@@ -36,22 +68,85 @@ func (l *LeastPingStrategy) PickOutbound(strings []string) string {
 	}
 	outboundsList := outboundList(strings)
 	if result, ok := observeReport.(*observatory.ObservationResult); ok {
-		status := result.Status
-		leastPing := int64(99999999)
-		selectedOutboundName := ""
-		for _, v := range status {
-			if outboundsList.contains(v.OutboundTag) && v.Alive && v.Delay < leastPing {
-				selectedOutboundName = v.OutboundTag
-				leastPing = v.Delay
+		var candidates []*observatory.OutboundStatus
+		delays := make(map[string]int64)
+		for _, v := range result.Status {
+			if !outboundsList.contains(v.OutboundTag) {
+				continue
+			}
+			l.recordProbe(v)
+			if delay, ok := l.getDelay(v); ok {
+				candidates = append(candidates, v)
+				delays[v.OutboundTag] = delay
+			}
+		}
+		if len(candidates) == 0 {
+			return ""
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return delays[candidates[i].OutboundTag] < delays[candidates[j].OutboundTag]
+		})
+		for _, v := range candidates {
+			if !l.isFull(v.OutboundTag) {
+				return v.OutboundTag
 			}
 		}
-		return selectedOutboundName
+		return candidates[0].OutboundTag
 	}
 
 	// No way to understand observeReport
 	return ""
 }
 
+// getDelay returns the RTT of the outbound to rank it by, and whether it may
+// be picked.
+func (l *LeastPingStrategy) getDelay(status *observatory.OutboundStatus) (int64, bool) {
+	if l.maxFailures == 0 {
+		return status.Delay, status.Alive
+	}
+
+	l.access.Lock()
+	defer l.access.Unlock()
+	if status.Alive {
+		if l.lastDelay == nil {
+			l.lastDelay = make(map[string]int64)
+		}
+		l.lastDelay[status.OutboundTag] = status.Delay
+		return status.Delay, true
+	}
+	if outboundDead(status, l.maxFailures) {
+		return 0, false
+	}
+	delay, found := l.lastDelay[status.OutboundTag]
+	return delay, found
+}
+
+// recordProbe records the RTT of the last probe of the outbound, if it
+// succeeded and has not been recorded.
+func (l *LeastPingStrategy) recordProbe(status *observatory.OutboundStatus) {
+	if l.metrics == nil || !status.Alive {
+		return
+	}
+
+	l.access.Lock()
+	if l.lastProbe == nil {
+		l.lastProbe = make(map[string]int64)
+	}
+	recorded := l.lastProbe[status.OutboundTag] == status.LastTryTime
+	l.lastProbe[status.OutboundTag] = status.LastTryTime
+	l.access.Unlock()
+
+	if !recorded {
+		l.metrics.RecordRTT(status.OutboundTag, time.Duration(status.Delay)*time.Millisecond)
+	}
+}
+
+// isFull returns whether the outbound has reached maxConnPerNode active
+// connections.
+func (l *LeastPingStrategy) isFull(tag string) bool {
+	return l.maxConnPerNode > 0 && activeConnections(l.ohm, tag) >= int64(l.maxConnPerNode)
+}
+
 type outboundList []string
 
 func (o outboundList) contains(name string) bool {
@@ -0,0 +1,65 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"sync"
+
+	"github.com/v2fly/v2ray-core/v4/features/outbound"
+)
+
+// BalancingStrategyFactory creates a balancing strategy for the balancing
+// rule, with outbounds managed by ohm. Strategies implementing
+// extension.ContextReceiver get the context of the V2Ray instance injected.
+type BalancingStrategyFactory func(rule *BalancingRule, ohm outbound.Manager) (BalancingStrategy, error)
+
+var (
+	strategyFactoryMap     = make(map[string]BalancingStrategyFactory)
+	strategyFactoryMapLock = &sync.RWMutex{}
+)
+
+// builtinStrategies are names of balancing strategies provided by the router.
+var builtinStrategies = map[string]bool{
+	"":                true,
+	"random":          true,
+	"leastPing":       true,
+	"composite":       true,
+	"weightedHealthy": true,
+}
+
+// RegisterBalancingStrategy registers a balancing strategy under the name,
+// which balancing rules refer to in strategy. Names of built-in strategies
+// can't be registered.
+func RegisterBalancingStrategy(name string, factory BalancingStrategyFactory) error {
+	if factory == nil {
+		return newError("nil BalancingStrategyFactory")
+	}
+	if builtinStrategies[name] {
+		return newError("balancing strategy ", name, " is built in")
+	}
+
+	strategyFactoryMapLock.Lock()
+	defer strategyFactoryMapLock.Unlock()
+
+	if _, found := strategyFactoryMap[name]; found {
+		return newError("balancing strategy ", name, " is already registered")
+	}
+	strategyFactoryMap[name] = factory
+	return nil
+}
+
+func createBalancingStrategy(rule *BalancingRule, ohm outbound.Manager) (BalancingStrategy, error) {
+	strategyFactoryMapLock.RLock()
+	factory, found := strategyFactoryMap[rule.Strategy]
+	strategyFactoryMapLock.RUnlock()
+
+	if !found {
+		return nil, newError("unknown balancing strategy: ", rule.Strategy)
+	}
+	strategy, err := factory(rule, ohm)
+	if err != nil {
+		return nil, newError("failed to create balancing strategy ", rule.Strategy).Base(err)
+	}
+	return strategy, nil
+}
@@ -0,0 +1,74 @@
+package router_test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	. "github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/features/outbound"
+	"github.com/v2fly/v2ray-core/v4/testing/mocks"
+)
+
+// lastStrategy picks the last of the outbounds.
+type lastStrategy struct {
+	rule *BalancingRule
+	ohm  outbound.Manager
+}
+
+func (s *lastStrategy) PickOutbound(tags []string) string {
+	return tags[len(tags)-1]
+}
+
+func TestRegisterBalancingStrategy(t *testing.T) {
+	var created *lastStrategy
+	common.Must(RegisterBalancingStrategy("test-last", func(rule *BalancingRule, ohm outbound.Manager) (BalancingStrategy, error) {
+		created = &lastStrategy{rule: rule, ohm: ohm}
+		return created, nil
+	}))
+
+	if err := RegisterBalancingStrategy("test-last", func(*BalancingRule, outbound.Manager) (BalancingStrategy, error) {
+		return nil, nil
+	}); err == nil {
+		t.Error("expect error registering a strategy twice")
+	}
+	if err := RegisterBalancingStrategy("leastPing", func(*BalancingRule, outbound.Manager) (BalancingStrategy, error) {
+		return nil, nil
+	}); err == nil {
+		t.Error("expect error registering a built-in strategy")
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-a", "test-b", "test-c"}).AnyTimes()
+	ohm := &mockOutboundManager{
+		Manager:         mocks.NewOutboundManager(mockCtl),
+		HandlerSelector: mockHs,
+	}
+
+	rule := &BalancingRule{
+		Tag:              "balance",
+		OutboundSelector: []string{"test-"},
+		Strategy:         "test-last",
+	}
+	balancer, err := rule.Build(ohm)
+	common.Must(err)
+	if created == nil || created.rule != rule || created.ohm != ohm {
+		t.Fatal("expect strategy to be created with the rule and outbound manager")
+	}
+
+	for i := 0; i < 4; i++ {
+		tag, err := balancer.PickOutbound()
+		common.Must(err)
+		if tag != "test-c" {
+			t.Error("expect test-c picked by the registered strategy, but got ", tag)
+		}
+	}
+
+	if _, err := (&BalancingRule{Tag: "balance", OutboundSelector: []string{"test-"}, Strategy: "test-unknown"}).Build(ohm); err == nil {
+		t.Error("expect error building unknown strategy")
+	}
+}
@@ -0,0 +1,105 @@
+//go:build !confonly
+// +build !confonly
+
+package router
+
+import (
+	"context"
+
+	"github.com/v2fly/v2ray-core/v4/app/observatory"
+	"github.com/v2fly/v2ray-core/v4/features/extension"
+	"github.com/v2fly/v2ray-core/v4/features/outbound"
+)
+
+// WeightedHealthyStrategy picks healthy outbounds randomly, each with a
+// chance proportional to its static weight divided by one plus its number of
+// active connections. Outbounds considered dead by the observatory, see
+// outboundDead, are excluded, so that the chances of the others are
+// renormalized. If all outbounds are dead, the one whose last failed probe is
+// the earliest is picked.
+type WeightedHealthyStrategy struct {
+	ctx         context.Context
+	ohm         outbound.Manager
+	weights     map[string]uint32
+	maxFailures uint32
+
+	observatory lazyObservatory
+}
+
+// NewWeightedHealthyStrategy creates a new WeightedHealthyStrategy. Outbounds
+// not in weights have a weight of 1.
+func NewWeightedHealthyStrategy(ohm outbound.Manager, weights map[string]uint32, maxFailures uint32) *WeightedHealthyStrategy {
+	return &WeightedHealthyStrategy{
+		ohm:         ohm,
+		weights:     weights,
+		maxFailures: maxFailures,
+	}
+}
+
+func (s *WeightedHealthyStrategy) InjectContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// SetObservatory sets the observatory health is taken from. If not set, the
+// observatory of the V2Ray instance in the injected context is used.
+func (s *WeightedHealthyStrategy) SetObservatory(o extension.Observatory) {
+	s.observatory.set(o)
+}
+
+// getStatus returns status of outbounds reported by the observatory.
+func (s *WeightedHealthyStrategy) getStatus() map[string]*observatory.OutboundStatus {
+	o := s.observatory.get(s.ctx)
+	if o == nil {
+		return nil
+	}
+	observed := observeStatus(s.ctx, o)
+	status := make(map[string]*observatory.OutboundStatus, len(observed))
+	for _, st := range observed {
+		status[st.OutboundTag] = st
+	}
+	return status
+}
+
+func (s *WeightedHealthyStrategy) getWeight(tag string) float64 {
+	weight, found := s.weights[tag]
+	if !found {
+		weight = 1
+	}
+	return float64(weight) / float64(1+activeConnections(s.ohm, tag))
+}
+
+// PickOutbound implements BalancingStrategy.
+func (s *WeightedHealthyStrategy) PickOutbound(tags []string) string {
+	if len(tags) == 0 {
+		panic("0 tags")
+	}
+
+	status := s.getStatus()
+	healthy := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if st, found := status[tag]; !found || !outboundDead(st, s.maxFailures) {
+			healthy = append(healthy, tag)
+		}
+	}
+	if len(healthy) == 0 {
+		return leastRecentlyFailed(tags, status)
+	}
+
+	weights := make([]float64, len(healthy))
+	for i, tag := range healthy {
+		weights[i] = s.getWeight(tag)
+	}
+	return pickWeighted(healthy, weights)
+}
+
+// leastRecentlyFailed returns the outbound whose last failed probe is the
+// earliest.
+func leastRecentlyFailed(tags []string, status map[string]*observatory.OutboundStatus) string {
+	picked := tags[0]
+	for _, tag := range tags[1:] {
+		if status[tag].GetLastTryTime() < status[picked].GetLastTryTime() {
+			picked = tag
+		}
+	}
+	return picked
+}
@@ -0,0 +1,70 @@
+package router_test
+
+import (
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v4/app/observatory"
+	. "github.com/v2fly/v2ray-core/v4/app/router"
+)
+
+func TestWeightedHealthyStrategy(t *testing.T) {
+	s := NewWeightedHealthyStrategy(nil, map[string]uint32{"a": 3, "b": 1, "c": 4}, 2)
+	statusC := &observatory.OutboundStatus{OutboundTag: "c", Alive: true, Delay: 100}
+	s.SetObservatory(&fakeObservatory{
+		result: &observatory.ObservationResult{
+			Status: []*observatory.OutboundStatus{
+				{OutboundTag: "a", Alive: true, Delay: 100},
+				{OutboundTag: "b", Alive: false, ConsecutiveFailures: 1},
+				statusC,
+			},
+		},
+	})
+	tags := []string{"a", "b", "c"}
+
+	pick := func(n int) map[string]int {
+		counts := make(map[string]int)
+		for i := 0; i < n; i++ {
+			counts[s.PickOutbound(tags)]++
+		}
+		return counts
+	}
+	expectShare := func(counts map[string]int, n int, tag string, share float64) {
+		t.Helper()
+		if actual := float64(counts[tag]) / float64(n); actual < share-0.05 || actual > share+0.05 {
+			t.Error("expect ", tag, " to be picked with probability ", share, ", but got ", actual)
+		}
+	}
+
+	const n = 8000
+	counts := pick(n)
+	expectShare(counts, n, "a", 3.0/8)
+	expectShare(counts, n, "b", 1.0/8)
+	expectShare(counts, n, "c", 4.0/8)
+
+	// Weights of the others are renormalized after c is gated out.
+	statusC.Alive, statusC.ConsecutiveFailures = false, 2
+	counts = pick(n)
+	if counts["c"] != 0 {
+		t.Error("expect dead outbound not picked, but got ", counts["c"], " picks")
+	}
+	expectShare(counts, n, "a", 3.0/4)
+	expectShare(counts, n, "b", 1.0/4)
+}
+
+func TestWeightedHealthyStrategyAllDead(t *testing.T) {
+	s := NewWeightedHealthyStrategy(nil, map[string]uint32{"a": 10}, 0)
+	s.SetObservatory(&fakeObservatory{
+		result: &observatory.ObservationResult{
+			Status: []*observatory.OutboundStatus{
+				{OutboundTag: "a", Alive: false, LastTryTime: 300},
+				{OutboundTag: "b", Alive: false, LastTryTime: 100},
+				{OutboundTag: "c", Alive: false, LastTryTime: 200},
+			},
+		},
+	})
+	for i := 0; i < 16; i++ {
+		if tag := s.PickOutbound([]string{"a", "b", "c"}); tag != "b" {
+			t.Fatal("expect least recently failed outbound b, but got ", tag)
+		}
+	}
+}
@@ -8,6 +8,8 @@ package command
 import (
 	"context"
 	"runtime"
+	"sort"
+	"strings"
 	"time"
 
 	grpc "google.golang.org/grpc"
@@ -83,6 +85,69 @@ func (s *statsServer) QueryStats(ctx context.Context, request *QueryStatsRequest
 	return response, nil
 }
 
+// parseRuleCounterName splits the name of a routing rule counter,
+// "routing>>>rule>>>{tag}>>>{kind}", into the rule tag and the kind.
+func parseRuleCounterName(name string) (string, string, bool) {
+	const prefix = "routing>>>rule>>>"
+	if !strings.HasPrefix(name, prefix) {
+		return "", "", false
+	}
+	name = strings.TrimPrefix(name, prefix)
+	for _, kind := range []string{"conn", "traffic>>>uplink", "traffic>>>downlink"} {
+		if strings.HasSuffix(name, ">>>"+kind) {
+			return strings.TrimSuffix(name, ">>>"+kind), kind, true
+		}
+	}
+	return "", "", false
+}
+
+func (s *statsServer) GetRuleStats(ctx context.Context, request *GetRuleStatsRequest) (*GetRuleStatsResponse, error) {
+	manager, ok := s.stats.(*stats.Manager)
+	if !ok {
+		return nil, newError("GetRuleStats only works its own stats.Manager.")
+	}
+
+	ruleStats := make(map[string]*RuleStat)
+	manager.VisitCounters(func(name string, c feature_stats.Counter) bool {
+		tag, kind, ok := parseRuleCounterName(name)
+		if !ok {
+			return true
+		}
+
+		stat, found := ruleStats[tag]
+		if !found {
+			stat = &RuleStat{RuleTag: tag}
+			ruleStats[tag] = stat
+		}
+		var value int64
+		if request.Reset_ {
+			value = c.Set(0)
+		} else {
+			value = c.Value()
+		}
+		switch kind {
+		case "conn":
+			stat.Connections = value
+		case "traffic>>>uplink":
+			stat.Uplink = value
+		case "traffic>>>downlink":
+			stat.Downlink = value
+		}
+		return true
+	})
+
+	response := &GetRuleStatsResponse{
+		Stat: make([]*RuleStat, 0, len(ruleStats)),
+	}
+	for _, stat := range ruleStats {
+		response.Stat = append(response.Stat, stat)
+	}
+	sort.Slice(response.Stat, func(i, j int) bool {
+		return response.Stat[i].RuleTag < response.Stat[j].RuleTag
+	})
+	return response, nil
+}
+
 func (s *statsServer) GetSysStats(ctx context.Context, request *SysStatsRequest) (*SysStatsResponse, error) {
 	var rtm runtime.MemStats
 	runtime.ReadMemStats(&rtm)
@@ -2,6 +2,7 @@ package command_test
 
 import (
 	"context"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -90,3 +91,97 @@ func TestQueryStats(t *testing.T) {
 		t.Error(r)
 	}
 }
+
+func TestGetRuleStats(t *testing.T) {
+	m, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+
+	for name, value := range map[string]int64{
+		"routing>>>rule>>>b>>>conn":               2,
+		"routing>>>rule>>>a>>>conn":               1,
+		"routing>>>rule>>>a>>>traffic>>>uplink":   10,
+		"routing>>>rule>>>a>>>traffic>>>downlink": 20,
+		"outbound>>>a>>>traffic>>>uplink":         30,
+	} {
+		c, err := m.RegisterCounter(name)
+		common.Must(err)
+		c.Set(value)
+	}
+
+	s := NewStatsServer(m)
+	resp, err := s.GetRuleStats(context.Background(), &GetRuleStatsRequest{Reset_: true})
+	common.Must(err)
+	if r := cmp.Diff(resp.Stat, []*RuleStat{
+		{RuleTag: "a", Connections: 1, Uplink: 10, Downlink: 20},
+		{RuleTag: "b", Connections: 2},
+	}, cmpopts.IgnoreUnexported(RuleStat{})); r != "" {
+		t.Error(r)
+	}
+
+	resp, err = s.GetRuleStats(context.Background(), &GetRuleStatsRequest{})
+	common.Must(err)
+	for _, stat := range resp.Stat {
+		if stat.Connections != 0 || stat.Uplink != 0 || stat.Downlink != 0 {
+			t.Error("expect stats of rule ", stat.RuleTag, " to be reset, but got ", stat)
+		}
+	}
+	if v := m.GetCounter("outbound>>>a>>>traffic>>>uplink").Value(); v != 30 {
+		t.Error("expect other counters not to be reset, but got ", v)
+	}
+}
+
+func TestGetRuleStatsConcurrentReset(t *testing.T) {
+	m, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+
+	tags := []string{"a", "b", "c"}
+	for _, tag := range tags {
+		_, err := m.RegisterCounter("routing>>>rule>>>" + tag + ">>>conn")
+		common.Must(err)
+	}
+
+	const increments = 10000
+	var wg sync.WaitGroup
+	for _, tag := range tags {
+		c := m.GetCounter("routing>>>rule>>>" + tag + ">>>conn")
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < increments; j++ {
+					c.Add(1)
+				}
+			}()
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	s := NewStatsServer(m)
+	totals := make(map[string]int64)
+	snapshot := func() {
+		resp, err := s.GetRuleStats(context.Background(), &GetRuleStatsRequest{Reset_: true})
+		common.Must(err)
+		for _, stat := range resp.Stat {
+			totals[stat.RuleTag] += stat.Connections
+		}
+	}
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			snapshot()
+		}
+	}
+	snapshot()
+
+	for _, tag := range tags {
+		if totals[tag] != 4*increments {
+			t.Error("expect ", 4*increments, " connections of rule ", tag, " in total, but got ", totals[tag])
+		}
+	}
+}
@@ -131,6 +131,8 @@ type DialingWorkerFactory struct {
 	Proxy    proxy.Outbound
 	Dialer   internet.Dialer
 	Strategy ClientStrategy
+	// Stats records statistics of the workers created, if not nil.
+	Stats *ClientStats
 
 	ctx context.Context
 }
@@ -149,13 +151,22 @@ func (f *DialingWorkerFactory) Create() (*ClientWorker, error) {
 	uplinkReader, upLinkWriter := pipe.New(opts...)
 	downlinkReader, downlinkWriter := pipe.New(opts...)
 
-	c, err := NewClientWorker(transport.Link{
+	link := transport.Link{
 		Reader: downlinkReader,
 		Writer: upLinkWriter,
-	}, f.Strategy)
+	}
+	ss := f.Stats.openSession()
+	if ss != nil {
+		link.Reader = &countingReader{Reader: link.Reader, counter: ss.downlink}
+		link.Writer = &countingWriter{Writer: link.Writer, counter: ss.uplink}
+	}
+
+	c, err := NewClientWorker(link, f.Strategy)
 	if err != nil {
+		ss.close()
 		return nil, err
 	}
+	c.stats = ss
 
 	go func(p proxy.Outbound, d internet.Dialer, c common.Closable) {
 		ctx := session.ContextWithOutbound(f.ctx, &session.Outbound{
@@ -167,6 +178,7 @@ func (f *DialingWorkerFactory) Create() (*ClientWorker, error) {
 			errors.New("failed to handler mux client connection").Base(err).WriteToLog()
 		}
 		common.Must(c.Close())
+		ss.close()
 		cancel()
 	}(f.Proxy, f.Dialer, c.done)
 
@@ -183,6 +195,7 @@ type ClientWorker struct {
 	link           transport.Link
 	done           *done.Instance
 	strategy       ClientStrategy
+	stats          *sessionStats
 }
 
 var (
@@ -310,6 +323,8 @@ func (m *ClientWorker) Dispatch(ctx context.Context, link *transport.Link) bool
 	}
 	s.input = link.Reader
 	s.output = link.Writer
+	s.stats = m.stats
+	m.stats.addStream(1)
 	go fetchInput(ctx, s, m.link.Writer)
 	return true
 }
@@ -72,19 +72,24 @@ func (m *SessionManager) Add(s *Session) {
 	m.sessions[s.ID] = s
 }
 
-func (m *SessionManager) Remove(id uint16) {
+// Remove removes the session of the id, and returns whether it was present.
+func (m *SessionManager) Remove(id uint16) bool {
 	m.Lock()
 	defer m.Unlock()
 
 	if m.closed {
-		return
+		return false
 	}
 
+	if _, found := m.sessions[id]; !found {
+		return false
+	}
 	delete(m.sessions, id)
 
 	if len(m.sessions) == 0 {
 		m.sessions = make(map[uint16]*Session, 16)
 	}
+	return true
 }
 
 func (m *SessionManager) Get(id uint16) (*Session, bool) {
@@ -141,13 +146,16 @@ type Session struct {
 	parent       *SessionManager
 	ID           uint16
 	transferType protocol.TransferType
+	stats        *sessionStats
 }
 
 // Close closes all resources associated with this session.
 func (s *Session) Close() error {
 	common.Close(s.output)
 	common.Close(s.input)
-	s.parent.Remove(s.ID)
+	if s.parent.Remove(s.ID) {
+		s.stats.addStream(-1)
+	}
 	return nil
 }
 
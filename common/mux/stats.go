@@ -0,0 +1,140 @@
+package mux
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/buf"
+	"github.com/v2fly/v2ray-core/v4/features/stats"
+)
+
+// ClientStats records statistics of the mux sessions of an outbound as
+// counters of the stats manager, named with the prefix
+// "outbound>>>{tag}>>>mux>>>". The counter "sessions" is the number of open
+// mux sessions, i.e. underlying connections. For each open session, the
+// counter "session>>>{id}>>>streams" is the number of its active sub-streams,
+// and "session>>>{id}>>>traffic>>>uplink" and
+// "session>>>{id}>>>traffic>>>downlink" are the bytes it carries, including
+// frame headers. Counters of a session are unregistered when it is closed.
+//
+// A nil ClientStats records nothing.
+type ClientStats struct {
+	manager  stats.Manager
+	prefix   string
+	sessions stats.Counter
+	lastID   uint32
+}
+
+// NewClientStats creates a new ClientStats of the outbound with the tag. It
+// returns nil if the stats manager is not configured.
+func NewClientStats(manager stats.Manager, tag string) *ClientStats {
+	if manager == nil || len(tag) == 0 {
+		return nil
+	}
+	if _, noop := manager.(stats.NoopManager); noop {
+		return nil
+	}
+	prefix := "outbound>>>" + tag + ">>>mux>>>"
+	sessions, err := stats.GetOrRegisterCounter(manager, prefix+"sessions")
+	if err != nil {
+		newError("failed to register mux stats").Base(err).AtWarning().WriteToLog()
+		return nil
+	}
+	return &ClientStats{
+		manager:  manager,
+		prefix:   prefix,
+		sessions: sessions,
+	}
+}
+
+// sessionStats holds the counters of an open mux session.
+type sessionStats struct {
+	parent   *ClientStats
+	names    []string
+	streams  stats.Counter
+	uplink   stats.Counter
+	downlink stats.Counter
+}
+
+// openSession registers counters of a new mux session. It returns nil if
+// they can't be registered.
+func (s *ClientStats) openSession() *sessionStats {
+	if s == nil {
+		return nil
+	}
+	prefix := s.prefix + "session>>>" + strconv.FormatUint(uint64(atomic.AddUint32(&s.lastID, 1)), 10) + ">>>"
+	ss := &sessionStats{parent: s}
+	for _, c := range []struct {
+		counter *stats.Counter
+		name    string
+	}{
+		{&ss.streams, "streams"},
+		{&ss.uplink, "traffic>>>uplink"},
+		{&ss.downlink, "traffic>>>downlink"},
+	} {
+		counter, err := stats.GetOrRegisterCounter(s.manager, prefix+c.name)
+		if err != nil {
+			newError("failed to register mux stats counter ", prefix+c.name).Base(err).AtWarning().WriteToLog()
+			ss.unregister()
+			return nil
+		}
+		*c.counter = counter
+		ss.names = append(ss.names, prefix+c.name)
+	}
+	s.sessions.Add(1)
+	return ss
+}
+
+func (ss *sessionStats) unregister() {
+	for _, name := range ss.names {
+		ss.parent.manager.UnregisterCounter(name)
+	}
+}
+
+// close unregisters counters of the session.
+func (ss *sessionStats) close() {
+	if ss == nil {
+		return
+	}
+	ss.unregister()
+	ss.parent.sessions.Add(-1)
+}
+
+// addStream adds delta to the number of active sub-streams of the session.
+func (ss *sessionStats) addStream(delta int64) {
+	if ss != nil {
+		ss.streams.Add(delta)
+	}
+}
+
+// countingWriter counts bytes written through it.
+type countingWriter struct {
+	buf.Writer
+	counter stats.Counter
+}
+
+func (w *countingWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	w.counter.Add(int64(mb.Len()))
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func (w *countingWriter) Close() error {
+	return common.Close(w.Writer)
+}
+
+// countingReader counts bytes read through it.
+type countingReader struct {
+	buf.Reader
+	counter stats.Counter
+}
+
+func (r *countingReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb, err := r.Reader.ReadMultiBuffer()
+	r.counter.Add(int64(mb.Len()))
+	return mb, err
+}
+
+func (r *countingReader) Interrupt() {
+	common.Interrupt(r.Reader)
+}
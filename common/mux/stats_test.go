@@ -0,0 +1,92 @@
+package mux_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/app/stats"
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/buf"
+	"github.com/v2fly/v2ray-core/v4/common/mux"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	feature_stats "github.com/v2fly/v2ray-core/v4/features/stats"
+	"github.com/v2fly/v2ray-core/v4/transport"
+	"github.com/v2fly/v2ray-core/v4/transport/internet"
+	"github.com/v2fly/v2ray-core/v4/transport/pipe"
+)
+
+// discardOutbound discards uplink traffic until it is closed.
+type discardOutbound struct {
+	done chan struct{}
+}
+
+func (o *discardOutbound) Process(ctx context.Context, link *transport.Link, dialer internet.Dialer) error {
+	go buf.Copy(link.Reader, buf.Discard)
+	<-o.done
+	return nil
+}
+
+func TestClientStatsNoop(t *testing.T) {
+	if s := mux.NewClientStats(feature_stats.NoopManager{}, "out"); s != nil {
+		t.Error("expected no stats without stats manager")
+	}
+}
+
+func TestClientStats(t *testing.T) {
+	manager, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+	value := func(name string) int64 {
+		c := manager.GetCounter("outbound>>>out>>>mux>>>" + name)
+		if c == nil {
+			return -1
+		}
+		return c.Value()
+	}
+	waitFor := func(name string, expected int64) {
+		t.Helper()
+		for i := 0; value(name) != expected; i++ {
+			if i == 500 {
+				t.Fatal("expected ", name, " to be ", expected, ", but got ", value(name))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	proxy := &discardOutbound{done: make(chan struct{})}
+	factory := mux.NewDialingWorkerFactory(context.Background(), proxy, nil, mux.ClientStrategy{
+		MaxConcurrency: 4,
+		MaxConnection:  4,
+	})
+	factory.Stats = mux.NewClientStats(manager, "out")
+	worker, err := factory.Create()
+	common.Must(err)
+	waitFor("sessions", 1)
+	waitFor("session>>>1>>>streams", 0)
+
+	reader, writer := pipe.New(pipe.WithoutSizeLimit())
+	_, output := pipe.New(pipe.WithoutSizeLimit())
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("www.v2fly.org"), 80),
+	})
+	if !worker.Dispatch(ctx, &transport.Link{Reader: reader, Writer: output}) {
+		t.Fatal("failed to dispatch")
+	}
+	waitFor("session>>>1>>>streams", 1)
+
+	b := buf.New()
+	b.WriteString("hello")
+	common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+	common.Must(writer.Close())
+	waitFor("session>>>1>>>streams", 0)
+	if uplink := value("session>>>1>>>traffic>>>uplink"); uplink <= 5 {
+		t.Error("expected uplink traffic with frame headers, but got ", uplink)
+	}
+
+	close(proxy.done)
+	waitFor("sessions", 0)
+	if value("session>>>1>>>streams") != -1 {
+		t.Error("expected counters of the closed session to be unregistered")
+	}
+}
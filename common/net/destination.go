@@ -109,6 +109,8 @@ func (d Destination) String() string {
 		prefix = "udp:"
 	case Network_UNIX:
 		prefix = "unix:"
+	case Network_ICMP:
+		prefix = "icmp:"
 	}
 	return prefix + d.NetAddr()
 }
@@ -8,21 +8,22 @@ const (
 )
 
 var (
-	CIDRMask        = net.CIDRMask
-	Dial            = net.Dial
-	DialTCP         = net.DialTCP
-	DialUDP         = net.DialUDP
-	DialUnix        = net.DialUnix
-	FileConn        = net.FileConn
-	Listen          = net.Listen
-	ListenTCP       = net.ListenTCP
-	ListenUDP       = net.ListenUDP
-	ListenUnix      = net.ListenUnix
-	LookupIP        = net.LookupIP
-	ParseIP         = net.ParseIP
-	ResolveUDPAddr  = net.ResolveUDPAddr
-	ResolveUnixAddr = net.ResolveUnixAddr
-	SplitHostPort   = net.SplitHostPort
+	CIDRMask         = net.CIDRMask
+	Dial             = net.Dial
+	DialTCP          = net.DialTCP
+	DialUDP          = net.DialUDP
+	DialUnix         = net.DialUnix
+	FileConn         = net.FileConn
+	InterfaceByIndex = net.InterfaceByIndex
+	Listen           = net.Listen
+	ListenTCP        = net.ListenTCP
+	ListenUDP        = net.ListenUDP
+	ListenUnix       = net.ListenUnix
+	LookupIP         = net.LookupIP
+	ParseIP          = net.ParseIP
+	ResolveUDPAddr   = net.ResolveUDPAddr
+	ResolveUnixAddr  = net.ResolveUnixAddr
+	SplitHostPort    = net.SplitHostPort
 )
 
 type (
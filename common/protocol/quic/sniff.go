@@ -0,0 +1,309 @@
+package quic
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/protocol/tls"
+)
+
+type SniffHeader struct {
+	domain string
+}
+
+func (h *SniffHeader) Protocol() string {
+	return "quic"
+}
+
+func (h *SniffHeader) Domain() string {
+	return h.domain
+}
+
+const (
+	version1       = 0x00000001
+	versionDraft29 = 0xff00001d
+
+	// Upper bound of the CRYPTO stream to reassemble, which is far more than a
+	// ClientHello needs.
+	maxCryptoDataSize = 64 * 1024
+)
+
+var (
+	errNotQuic        = errors.New("not QUIC")
+	errNotQuicInitial = errors.New("not QUIC initial packet")
+)
+
+// Salts to derive initial secrets from, see RFC 9001 Section 5.2.
+var initialSalts = map[uint32][]byte{
+	version1: {
+		0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3, 0x4d, 0x17,
+		0x9a, 0xe6, 0xa4, 0xc8, 0x0c, 0xad, 0xcc, 0xbb, 0x7f, 0x0a,
+	},
+	versionDraft29: {
+		0xaf, 0xbf, 0xec, 0x28, 0x99, 0x93, 0xd2, 0x4c, 0x9e, 0x97,
+		0x86, 0xf1, 0x9c, 0x61, 0x11, 0xe0, 0x43, 0x90, 0xa8, 0x99,
+	},
+}
+
+type cryptoFrame struct {
+	offset uint64
+	data   []byte
+}
+
+// SniffQUIC returns the server name from the ClientHello in QUIC Initial
+// packets sent by a client. The payload may contain multiple packets, in
+// case the ClientHello doesn't fit in one.
+func SniffQUIC(b []byte) (*SniffHeader, error) {
+	var frames []cryptoFrame
+	for len(b) > 0 && b[0]&0x80 != 0 {
+		packetLen, packetFrames, err := readInitialPacket(b)
+		if err != nil {
+			if len(frames) == 0 {
+				return nil, err
+			}
+			// Following packets are not Initial packets.
+			break
+		}
+		frames = append(frames, packetFrames...)
+		b = b[packetLen:]
+	}
+	if len(frames) == 0 {
+		return nil, errNotQuic
+	}
+
+	data := assembleCryptoData(frames)
+	if len(data) < 4 {
+		return nil, common.ErrNoClue
+	}
+	if data[0] != 0x01 /* ClientHello */ {
+		return nil, errNotQuicInitial
+	}
+	helloLen := 4 + (int(data[1])<<16 | int(data[2])<<8 | int(data[3]))
+	if helloLen > maxCryptoDataSize {
+		return nil, errNotQuicInitial
+	}
+	if len(data) < helloLen {
+		return nil, common.ErrNoClue
+	}
+
+	tlsHeader := &tls.SniffHeader{}
+	if err := tls.ReadClientHello(data[:helloLen], tlsHeader); err != nil {
+		return nil, err
+	}
+	return &SniffHeader{domain: tlsHeader.Domain()}, nil
+}
+
+// readInitialPacket decrypts the Initial packet at the beginning of b, and
+// returns its length and the CRYPTO frames in it.
+func readInitialPacket(b []byte) (int, []cryptoFrame, error) {
+	if len(b) < 7 {
+		return 0, nil, errNotQuic
+	}
+	version := binary.BigEndian.Uint32(b[1:5])
+	salt, found := initialSalts[version]
+	if !found || b[0]&0x40 == 0 {
+		return 0, nil, errNotQuic
+	}
+	if b[0]&0x30 != 0 {
+		return 0, nil, errNotQuicInitial
+	}
+
+	offset := 5
+	dcidLen := int(b[offset])
+	offset++
+	if dcidLen > 20 || len(b) < offset+dcidLen+1 {
+		return 0, nil, errNotQuic
+	}
+	dcid := b[offset : offset+dcidLen]
+	offset += dcidLen
+	scidLen := int(b[offset])
+	offset++
+	if scidLen > 20 || len(b) < offset+scidLen {
+		return 0, nil, errNotQuic
+	}
+	offset += scidLen
+
+	tokenLen, n := readVarint(b[offset:])
+	if n == 0 || uint64(len(b)-offset-n) < tokenLen {
+		return 0, nil, errNotQuic
+	}
+	offset += n + int(tokenLen)
+	length, n := readVarint(b[offset:])
+	if n == 0 || uint64(len(b)-offset-n) < length {
+		return 0, nil, errNotQuic
+	}
+	offset += n
+	packetLen := offset + int(length)
+
+	key, iv, hp := initialKeys(salt, dcid)
+
+	// Remove header protection on a copy of the header, see RFC 9001 Section 5.4.
+	if int(length) < 4+aes.BlockSize {
+		return 0, nil, errNotQuic
+	}
+	hpBlock, err := aes.NewCipher(hp)
+	if err != nil {
+		return 0, nil, err
+	}
+	mask := make([]byte, aes.BlockSize)
+	hpBlock.Encrypt(mask, b[offset+4:offset+4+aes.BlockSize])
+	header := make([]byte, offset+4)
+	copy(header, b)
+	header[0] ^= mask[0] & 0x0f
+	pnLen := int(header[0]&0x03) + 1
+	var pn uint64
+	for i := 0; i < pnLen; i++ {
+		header[offset+i] ^= mask[1+i]
+		pn = pn<<8 | uint64(header[offset+i])
+	}
+	header = header[:offset+pnLen]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return 0, nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return 0, nil, err
+	}
+	nonce := iv
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(pn >> (8 * i))
+	}
+	payload, err := aead.Open(nil, nonce, b[offset+pnLen:packetLen], header)
+	if err != nil {
+		return 0, nil, errNotQuicInitial
+	}
+
+	frames, err := readCryptoFrames(payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	return packetLen, frames, nil
+}
+
+// initialKeys derives the packet protection keys of the client from the
+// destination connection ID, see RFC 9001 Section 5.2.
+func initialKeys(salt, dcid []byte) (key, iv, hp []byte) {
+	initialSecret := hkdf.Extract(sha256.New, dcid, salt)
+	clientSecret := hkdfExpandLabel(initialSecret, "client in", sha256.Size)
+	return hkdfExpandLabel(clientSecret, "quic key", 16),
+		hkdfExpandLabel(clientSecret, "quic iv", 12),
+		hkdfExpandLabel(clientSecret, "quic hp", 16)
+}
+
+// hkdfExpandLabel implements HKDF-Expand-Label of TLS 1.3 with empty context.
+func hkdfExpandLabel(secret []byte, label string, length int) []byte {
+	const prefix = "tls13 "
+	info := make([]byte, 0, 4+len(prefix)+len(label))
+	info = append(info, byte(length>>8), byte(length), byte(len(prefix)+len(label)))
+	info = append(info, prefix...)
+	info = append(info, label...)
+	info = append(info, 0)
+
+	out := make([]byte, length)
+	common.Must2(io.ReadFull(hkdf.Expand(sha256.New, secret, info), out))
+	return out
+}
+
+// readVarint reads a variable-length integer, see RFC 9000 Section 16. It
+// returns 0 as the number of bytes read if b is too short.
+func readVarint(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	n := 1 << (b[0] >> 6)
+	if len(b) < n {
+		return 0, 0
+	}
+	v := uint64(b[0] & 0x3f)
+	for i := 1; i < n; i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v, n
+}
+
+// readCryptoFrames returns the CRYPTO frames in the payload of an Initial
+// packet, skipping other frames allowed in it.
+func readCryptoFrames(b []byte) ([]cryptoFrame, error) {
+	var frames []cryptoFrame
+	for len(b) > 0 {
+		frameType := b[0]
+		b = b[1:]
+		switch frameType {
+		case 0x00, 0x01: // PADDING, PING
+		case 0x02, 0x03: // ACK
+			fields := 4
+			var rangeCount uint64
+			for i := 0; i < fields; i++ {
+				v, n := readVarint(b)
+				if n == 0 {
+					return nil, errNotQuicInitial
+				}
+				b = b[n:]
+				if i == 2 {
+					rangeCount = v
+					if rangeCount > uint64(len(b)) {
+						return nil, errNotQuicInitial
+					}
+					fields += 2 * int(rangeCount)
+				}
+			}
+			if frameType == 0x03 {
+				for i := 0; i < 3; i++ {
+					_, n := readVarint(b)
+					if n == 0 {
+						return nil, errNotQuicInitial
+					}
+					b = b[n:]
+				}
+			}
+		case 0x06: // CRYPTO
+			offset, n := readVarint(b)
+			if n == 0 {
+				return nil, errNotQuicInitial
+			}
+			b = b[n:]
+			length, n := readVarint(b)
+			if n == 0 || uint64(len(b)-n) < length || offset+length > maxCryptoDataSize {
+				return nil, errNotQuicInitial
+			}
+			b = b[n:]
+			frames = append(frames, cryptoFrame{
+				offset: offset,
+				data:   b[:length],
+			})
+			b = b[length:]
+		case 0x1c: // CONNECTION_CLOSE
+			return frames, nil
+		default:
+			return nil, errNotQuicInitial
+		}
+	}
+	return frames, nil
+}
+
+// assembleCryptoData returns the contiguous data of CRYPTO frames from offset 0.
+func assembleCryptoData(frames []cryptoFrame) []byte {
+	sort.Slice(frames, func(i, j int) bool {
+		return frames[i].offset < frames[j].offset
+	})
+	var data []byte
+	for _, f := range frames {
+		if f.offset > uint64(len(data)) {
+			break
+		}
+		if end := f.offset + uint64(len(f.data)); end > uint64(len(data)) {
+			data = append(data, f.data[uint64(len(data))-f.offset:]...)
+		}
+	}
+	return data
+}
@@ -0,0 +1,112 @@
+package quic_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	. "github.com/v2fly/v2ray-core/v4/common/protocol/quic"
+)
+
+// Initial packets captured from a client connecting to "www.example.com".
+const (
+	initialV1 = "" +
+		"c4000000010bf79885064b77d2f9012285000044cf95519c6b54bf7ace465d605a02b9723769dca5b4bb22ff5ddf0e81" +
+		"e61b405da68d38966c4c62a23780e474080d2ca56c9cef1b9a521071b37d436602abe5d9f7fb60be9a7c9d0a3b200db2" +
+		"d3936a9340886471c3754537a212eafe56e21ff921ef5d83131f43e32fbd2882992615f90f7504012d9c886f3d5f724c" +
+		"f2a3359d2b0f750339b5a7661e6f5d830456ae18d18cd7ad823a93d6497fd41ec22b28189c9e60796691d3315c16fe9d" +
+		"aa42e78bbdbd937d494f008db0a323408ce193b88281e2dae534ef7db55e885b0ba1b2e1a7260ca26a42a40f1b2fa948" +
+		"009f06dd7f6a9745c1200840515f9343f987a4022d8b364dfadd3f4667a5d6cf53d7e8c4756722d37b7eae6c8ee77944" +
+		"afc23ce49d02b8daeb083d134653d9214a3d812584ec387904b33d9336b9d31d77d80c2f5a767b662d5f464fa6e7412c" +
+		"fb1b78bb4c46b5d7d93e995906f4e585394079fd6af16c51668402d8d62107be919dacaa6d6efe8c09398dc2bc5527c7" +
+		"7cf092973109afdc41894592c0ada9b1dae465e426651384a0b254767c956e105422e0916c89ca0d82584b21c28dbbe5" +
+		"32375e7a99f15f1e7a983808d1b40a75ee9b776683b1919b8d63cff50c48959cfb966efad2530e2526267a994fda2507" +
+		"a65f8f0c3d55c30b0bc64f17557738888df0fb99c710eedb10e20f697f8a27e7be9f0018f733958823df82fc830195a7" +
+		"ebdd98792bd03b5306b25368815e22718382eaac2c816a6d2fe4027abc7bd48c494d66df2730ec3c050afcb78bc67b9e" +
+		"17af0ebda903c11b029f58250d4572ca8d7e0538c5652bdd545efbe79693661b6457ac60c7e678414abc24cac8397116" +
+		"dd022a35151cae222bc71f38663da816123cb73a5269e9678a70f45040f46573d6123a4ca0abd76640b76d865712eca8" +
+		"38cfde0c1aca2ab7e436ff526c798931e16e663404175f5400df08fb3065e414f0f2090117566702eb601026ac74d4cc" +
+		"b0377641cafb6a16e8f198bea8d24a4144ebf92179df4dfa53fbdf36e494e3a09ff01a989b9daece421dcf24c9ddf2b8" +
+		"fb3ba941bea96c98aec850b80a47fe56264332e6e2bb6decdc60bd4283e2766b23322f27f06f43e328e84ccb6875a95f" +
+		"54da972b41cca58de68780e2b3fd873a11c414d4754113cc31febe9b680c73b810f59d5809a0c633ce841428b90a7f0c" +
+		"26a03bd480a940042bbc363ed6d8bc261d69f908b13db0bb536ca04bc7fd9f94962450689deebaee1e582573ee79dbcc" +
+		"fc6f270db14f57fbd36e605770583a846e5b5d83d6eae0a419884dd29e5c7f33bbea1db593f505a7126618a39a7dbbe0" +
+		"d9b59632b06a911533e865a4ce33153ebe2a3d8df906dd3354b850f3b923185e5475189968022ece2a744930837812bb" +
+		"adfc04d97b78fad5a54814e777a4093c3dc281e3de1605d7b6ae2bebc8a63d29cb9fc9c5c42422fd55862bf263455782" +
+		"f883a59500567f14fe16c6f0b12ddddf8be3534489d68999bb112d20fa38595f7da180f3a227687e47f015f9d1896767" +
+		"7df25263dec0ca244d085f71ba1d36f68b4dc2558bc3cb62898668d98336a616d9ec10e7441ab5a9b3faa2466c4de8a9" +
+		"7dd8a287b26f4d0e10f48541edba21d45c0049d3ac1b5e1576862dc6acea373c8120e7841478dccc3d41556e1c58180d" +
+		"97df7546e9810152c2e06e8f3c1c71ef9bc5818b17edbced497697cce31f844bbb24ddbe51f0027c3f34125042c6ed2f" +
+		"1fe121ff"
+
+	initialDraft29 = "" +
+		"c6ff00001d087ebd5a3100218789000044d21c05b66f90cef9a9bffcc17c0df8abb735a2ea4347234b9f49f72ba85bd7" +
+		"f5d10f519b30aeff6c64e945e3740672557dc6eaeb5e15dabfdfbb0909391f707e96cf2755bf1621d7435ce0164da473" +
+		"221e30812f7a50583b7cfdba5d281426fdc5b8319c546c4b76a372e7b3e9101c7a2f6c88d82c69d616f367668bde0211" +
+		"dbca143bbc9e93fc198ddc78663b9d47645e13122ff4d0c30857b9fdf6444871f6779a95a9224fb6c64b7e9534d6eda8" +
+		"b74e3dd0dffefc08fc89b66a47a060e46abf22e37f140e521c1078059633091eceeb1f874a490e177eafd08ed2ffb5a1" +
+		"b4da24a311de689f960409f25b0138e3a4c72b02a6d35f29cd9dcb2819d95a64eee5b28f5c1043c8a215ce7b6af950a8" +
+		"45b18827150a7fe0c8ac959eabd4100be764ef52c777250113385dab65765cd1f237c192d486eb920be9065bbf811dda" +
+		"44190d94be83fa1a2895ee6d75b2e090b3a5e01ec24a729a3808b7cb8b4321266c51efe1e2b4d39ddda421f7b207f54e" +
+		"73f54171683b7acadec7378a871305927ec61fe0ff4005952531d007727adcc2ee1d3a7398b2b1d3dfc7cd2659ed9bd9" +
+		"dd71c7e5cee596b17329082cf06ff277716cbbee17c34945eac56802db3d333941e4fb7c8ea5b1aefcf5e19dd35ab240" +
+		"2d5bd35b8d637beb1bd3c40fd86cfa6c24cbeb908a2bff0f233c7a84b2f49a3ae20f9ccf774674afad7d348f047d2c18" +
+		"99f763a09afd1ac0b88b5a88a2288d531dcb52777f6db7a12dd1caa74e0777e71fc5d3dd7eb025d0caee867e288bdb75" +
+		"031525b260a09d0c158f9159a7bd9d5f78ab7b9cc3f6168a4ad56eb083980e8e03cfb443de5ef36e07ab4d9d4bfccdd1" +
+		"7cabf73f9a5644ee15cf22cbfb6a3a707f899b6b7de403ed665f4ada5d2aa97d8943af8fa3a489e9214f51ba0b006a5c" +
+		"842affdc9e4c4394f3f4403d1499b41dbadebd5f54c5a697a72c48a09f07eee8942c43ecdce2dfc7b481b05e7b1dab42" +
+		"aeae86f7ca18e28e9b4143c7fed35e7730fd9b3ed411aba8b50a254fedfeeda89b85ef670cb0e3535efae8dbf5447e76" +
+		"15261c910404dfcb1d122405230017b0f1589f5f5e5719271943f93b94f327bb1ea7a4ab31635ddf61e7915440fca631" +
+		"f538fd95963b2a6c815d41a03fb902ebe03add6b8113617769b2b8ebb6ea43c893af7093004e5b4da6f8fe707dc58113" +
+		"286702321f79928b3bfc8cafeda48fbb56bf7ccf3b42196ea8dc7bb296a78f08c183d5d97fe3f9bc26037bcadb1b21e5" +
+		"6e0565dbeb881a972fc95e7bf2e0b5bac2444e398f15bcaa6f36e8bd0e5203e784269ca540d2f926ac17a204a2667999" +
+		"a745a174ceb64809ef54e28009361bee8097434d37a8281e5b30aa422d9631c94cb58cda507164cfa2318eff856d8669" +
+		"517a34a972fac9d9b8d4181d24ffde3995355a85a22f190b9dc05174a52ca49ae134f13add099b625e17f9bf274207c3" +
+		"e4bd3ad7a47648f096e1a1104fe59ea6328450838962ad8c07b587dc01d3280610e6f79925b0f2b3d46a4a8ae5e16b3d" +
+		"4ba6cc0cb151d400f618d12d87ddddee9642cfe6688058191d14c3b0d9a089f14e8bf3172c0c184b42b7e10251b97119" +
+		"534a791939150dadc33bc8601d96f0708071eec9748124b1b06f45dd94ccbd181bcc89cb508351eaa455e255411cd785" +
+		"68417b3adf054c6ae71dbc0eb5f73019a30095fac6946932f653568d106593d68a37e7f62d512b66b34e21540c55be6c" +
+		"3a9aac61"
+)
+
+func TestSniffQUIC(t *testing.T) {
+	for _, packet := range []string{initialV1, initialDraft29} {
+		b, err := hex.DecodeString(packet)
+		common.Must(err)
+
+		header, err := SniffQUIC(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Protocol() != "quic" {
+			t.Error("unexpected protocol ", header.Protocol())
+		}
+		if header.Domain() != "www.example.com" {
+			t.Error("unexpected domain ", header.Domain())
+		}
+	}
+}
+
+func TestSniffQUICInvalid(t *testing.T) {
+	b, err := hex.DecodeString(initialV1)
+	common.Must(err)
+
+	corrupted := append([]byte(nil), b...)
+	corrupted[len(corrupted)-1] ^= 0xff
+
+	unknownVersion := append([]byte(nil), b...)
+	unknownVersion[4] = 0x02
+
+	cases := [][]byte{
+		[]byte("GET / HTTP/1.1\r\n"),
+		b[:100],
+		corrupted,
+		unknownVersion,
+	}
+	for _, c := range cases {
+		if _, err := SniffQUIC(c); err == nil || errors.Is(err, common.ErrNoClue) {
+			t.Error("expected error for invalid packet, but got ", err)
+		}
+	}
+}
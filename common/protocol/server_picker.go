@@ -2,6 +2,8 @@ package protocol
 
 import (
 	"sync"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
 )
 
 type ServerList struct {
@@ -46,6 +48,18 @@ func (sl *ServerList) GetServer(idx uint32) *ServerSpec {
 	}
 }
 
+// Destinations returns the destinations of all servers in the list.
+func (sl *ServerList) Destinations() []net.Destination {
+	sl.RLock()
+	defer sl.RUnlock()
+
+	dests := make([]net.Destination, 0, len(sl.servers))
+	for _, server := range sl.servers {
+		dests = append(dests, server.Destination())
+	}
+	return dests
+}
+
 func (sl *ServerList) removeServer(idx uint32) {
 	n := len(sl.servers)
 	sl.servers[idx] = sl.servers[n-1]
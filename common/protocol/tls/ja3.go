@@ -0,0 +1,129 @@
+package tls
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// isGREASE returns whether the value is reserved by GREASE (RFC 8701), which
+// is ignored by JA3.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// uint16List appends the non-GREASE big-endian uint16 values in data to b,
+// separated by dashes.
+func uint16List(b *strings.Builder, data []byte) {
+	first := true
+	for ; len(data) >= 2; data = data[2:] {
+		v := uint16(data[0])<<8 | uint16(data[1])
+		if isGREASE(v) {
+			continue
+		}
+		if !first {
+			b.WriteByte('-')
+		}
+		first = false
+		b.WriteString(strconv.Itoa(int(v)))
+	}
+}
+
+// JA3String returns the JA3 string of the TLS client hello message, i.e. its
+// version, cipher suites, extensions, supported groups and EC point formats,
+// in decimal.
+func JA3String(data []byte) (string, error) {
+	if len(data) < 39 || data[0] != 1 /* client hello */ {
+		return "", errNotClientHello
+	}
+	version := int(data[4])<<8 | int(data[5])
+	sessionIDLen := int(data[38])
+	data = data[39:]
+	if len(data) < sessionIDLen+2 {
+		return "", errNotClientHello
+	}
+	data = data[sessionIDLen:]
+	cipherSuiteLen := int(data[0])<<8 | int(data[1])
+	if len(data) < 2+cipherSuiteLen+1 {
+		return "", errNotClientHello
+	}
+	cipherSuites := data[2 : 2+cipherSuiteLen]
+	data = data[2+cipherSuiteLen:]
+	compressionMethodsLen := int(data[0])
+	if len(data) < 1+compressionMethodsLen {
+		return "", errNotClientHello
+	}
+	data = data[1+compressionMethodsLen:]
+
+	var extensions, groups, pointFormats strings.Builder
+	if len(data) >= 2 {
+		extensionsLength := int(data[0])<<8 | int(data[1])
+		data = data[2:]
+		if extensionsLength != len(data) {
+			return "", errNotClientHello
+		}
+	}
+	for len(data) != 0 {
+		if len(data) < 4 {
+			return "", errNotClientHello
+		}
+		extension := uint16(data[0])<<8 | uint16(data[1])
+		length := int(data[2])<<8 | int(data[3])
+		data = data[4:]
+		if len(data) < length {
+			return "", errNotClientHello
+		}
+		d := data[:length]
+		data = data[length:]
+
+		if isGREASE(extension) {
+			continue
+		}
+		if extensions.Len() > 0 {
+			extensions.WriteByte('-')
+		}
+		extensions.WriteString(strconv.Itoa(int(extension)))
+
+		switch extension {
+		case 0x0a: /* extensionSupportedCurves */
+			if len(d) < 2 || int(d[0])<<8|int(d[1]) != len(d)-2 {
+				return "", errNotClientHello
+			}
+			uint16List(&groups, d[2:])
+		case 0x0b: /* extensionSupportedPoints */
+			if len(d) < 1 || int(d[0]) != len(d)-1 {
+				return "", errNotClientHello
+			}
+			for i, format := range d[1:] {
+				if i > 0 {
+					pointFormats.WriteByte('-')
+				}
+				pointFormats.WriteString(strconv.Itoa(int(format)))
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(version))
+	b.WriteByte(',')
+	uint16List(&b, cipherSuites)
+	b.WriteByte(',')
+	b.WriteString(extensions.String())
+	b.WriteByte(',')
+	b.WriteString(groups.String())
+	b.WriteByte(',')
+	b.WriteString(pointFormats.String())
+	return b.String(), nil
+}
+
+// JA3 returns the JA3 fingerprint of the TLS client hello message, which is
+// the MD5 hash of its JA3 string in hex.
+func JA3(data []byte) (string, error) {
+	s, err := JA3String(data)
+	if err != nil {
+		return "", err
+	}
+	hash := md5.Sum([]byte(s))
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,37 @@
+package tls_test
+
+import (
+	"testing"
+
+	. "github.com/v2fly/v2ray-core/v4/common/protocol/tls"
+)
+
+func TestJA3String(t *testing.T) {
+	// Client hello with GREASE cipher suite 0x1a1a, extensions 0xbaba and
+	// 0xaaaa, and supported group 0xaaaa, which are ignored.
+	hello := []byte{
+		0x01, 0x00, 0x00, 0x4b, 0x03, 0x03, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x06, 0x1a, 0x1a, 0x13, 0x01, 0xc0, 0x2b, 0x01,
+		0x00, 0x00, 0x1c, 0xba, 0xba, 0x00, 0x00, 0x00,
+		0x17, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x06, 0x00,
+		0x04, 0xaa, 0xaa, 0x00, 0x1d, 0x00, 0x0b, 0x00,
+		0x02, 0x01, 0x00, 0xaa, 0xaa, 0x00, 0x00,
+	}
+	s, err := JA3String(hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "771,4865-49195,23-10-11,29,0"; s != expected {
+		t.Error("expect JA3 string ", expected, " but got ", s)
+	}
+
+	for _, n := range []int{0, 38, 45, len(hello) - 1} {
+		if _, err := JA3(hello[:n]); err == nil {
+			t.Error("expect error on client hello truncated to ", n, " bytes")
+		}
+	}
+}
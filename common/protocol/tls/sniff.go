@@ -10,6 +10,7 @@ import (
 
 type SniffHeader struct {
 	domain string
+	ja3    string
 }
 
 func (h *SniffHeader) Protocol() string {
@@ -20,6 +21,12 @@ func (h *SniffHeader) Domain() string {
 	return h.domain
 }
 
+// JA3 returns the JA3 fingerprint of the client hello, or empty if it can't
+// be computed.
+func (h *SniffHeader) JA3() string {
+	return h.ja3
+}
+
 var (
 	errNotTLS         = errors.New("not TLS header")
 	errNotClientHello = errors.New("not client hello")
@@ -142,6 +149,7 @@ func SniffTLS(b []byte) (*SniffHeader, error) {
 	h := &SniffHeader{}
 	err := ReadClientHello(b[5:5+headerLen], h)
 	if err == nil {
+		h.ja3, _ = JA3(b[5 : 5+headerLen])
 		return h, nil
 	}
 	return nil, err
@@ -10,6 +10,7 @@ func TestTLSHeaders(t *testing.T) {
 	cases := []struct {
 		input  []byte
 		domain string
+		ja3    string
 		err    bool
 	}{
 		{
@@ -42,6 +43,7 @@ func TestTLSHeaders(t *testing.T) {
 				0xaa, 0xaa, 0x00, 0x01, 0x00,
 			},
 			domain: "c.s-microsoft.com",
+			ja3:    "b8f81673c0e1d29908346f3bab892b9b",
 			err:    false,
 		},
 		{
@@ -79,6 +81,7 @@ func TestTLSHeaders(t *testing.T) {
 				0x00, 0x01, 0x00,
 			},
 			domain: "www07.clicktale.net",
+			ja3:    "83e04bc58d402f9633983cbf22724b02",
 			err:    false,
 		},
 		{
@@ -100,6 +103,7 @@ func TestTLSHeaders(t *testing.T) {
 				0x7e, 0x86, 0xd3, 0xb7, 0x0c, 0x29, 0x1a, 0x9e, 0x5b, 0x38, 0x3f, 0x01, 0x72,
 			},
 			domain: "dogfish",
+			ja3:    "a66e498c488aa0523759691248cdfb01",
 			err:    false,
 		},
 		{
@@ -139,6 +143,7 @@ func TestTLSHeaders(t *testing.T) {
 				0x75, 0x6e, 0xb4, 0x76, 0xf9, 0x48, 0x8f, 0x36,
 			},
 			domain: "10.42.0.243",
+			ja3:    "0f07bc69e01592785bad20835bc85ab9",
 			err:    false,
 		},
 	}
@@ -156,6 +161,9 @@ func TestTLSHeaders(t *testing.T) {
 			if header.Domain() != test.domain {
 				t.Error("expect domain ", test.domain, " but got ", header.Domain())
 			}
+			if header.JA3() != test.ja3 {
+				t.Error("expect JA3 ", test.ja3, " but got ", header.JA3())
+			}
 		}
 	}
 }
@@ -0,0 +1,166 @@
+// Package ratelimit provides token buckets to limit bandwidth.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket, where a token is a byte. It is safe for
+// concurrent use.
+type Bucket struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	access sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket creates a new full Bucket filled at rate bytes per second, holding
+// at most burst bytes. If now is nil, time.Now is used.
+func NewBucket(rate uint64, burst uint64, now func() time.Time) *Bucket {
+	if now == nil {
+		now = time.Now
+	}
+	if burst == 0 {
+		burst = rate
+	}
+	return &Bucket{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		now:    now,
+		tokens: float64(burst),
+		last:   now(),
+	}
+}
+
+// Take takes n tokens from the bucket, and returns how long the caller should
+// wait before using them. Tokens may be taken in advance, so that n may exceed
+// the burst.
+func (b *Bucket) Take(n int) time.Duration {
+	b.access.Lock()
+	defer b.access.Unlock()
+
+	now := b.fill()
+	b.last = now
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Wait takes n tokens from the bucket, and waits until they are available or
+// ctx is done.
+func (b *Bucket) Wait(ctx context.Context, n int) error {
+	wait := b.Take(n)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// idle returns whether the bucket is full, i.e. not used for long. Callers
+// must not hold the access lock.
+func (b *Bucket) idle() bool {
+	b.access.Lock()
+	defer b.access.Unlock()
+	b.fill()
+	return b.tokens >= b.burst
+}
+
+// fill adds tokens accumulated since the last fill, and returns the current
+// time. Callers must hold the access lock.
+func (b *Bucket) fill() time.Time {
+	now := b.now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	return now
+}
+
+// Limiter keeps a Bucket for each key, e.g. source IP. Buckets staying full
+// for the idle timeout are evicted, since a new bucket would be the same. It
+// is safe for concurrent use.
+type Limiter struct {
+	rate    uint64
+	burst   uint64
+	timeout time.Duration
+	now     func() time.Time
+
+	access    sync.Mutex
+	buckets   map[string]*limiterEntry
+	nextSweep time.Time
+}
+
+type limiterEntry struct {
+	bucket *Bucket
+	used   time.Time
+}
+
+// NewLimiter creates a new Limiter of buckets filled at rate bytes per second,
+// holding at most burst bytes. If now is nil, time.Now is used.
+func NewLimiter(rate uint64, burst uint64, timeout time.Duration, now func() time.Time) *Limiter {
+	if now == nil {
+		now = time.Now
+	}
+	return &Limiter{
+		rate:    rate,
+		burst:   burst,
+		timeout: timeout,
+		now:     now,
+		buckets: make(map[string]*limiterEntry),
+	}
+}
+
+// Get returns the bucket of the key, creating it if not found.
+func (l *Limiter) Get(key string) *Bucket {
+	l.access.Lock()
+	defer l.access.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	entry, found := l.buckets[key]
+	if !found {
+		entry = &limiterEntry{bucket: NewBucket(l.rate, l.burst, l.now)}
+		l.buckets[key] = entry
+	}
+	entry.used = now
+	return entry.bucket
+}
+
+// Len returns the number of buckets, including idle ones not evicted yet.
+func (l *Limiter) Len() int {
+	l.access.Lock()
+	defer l.access.Unlock()
+	return len(l.buckets)
+}
+
+// sweep evicts buckets not got for the idle timeout and full, at most once per
+// timeout. Callers must hold the access lock.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Before(l.nextSweep) {
+		return
+	}
+	l.nextSweep = now.Add(l.timeout)
+	start := now.Add(-l.timeout)
+	for key, entry := range l.buckets {
+		if !entry.used.After(start) && entry.bucket.idle() {
+			delete(l.buckets, key)
+		}
+	}
+}
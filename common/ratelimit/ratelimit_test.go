@@ -0,0 +1,82 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/v2fly/v2ray-core/v4/common/ratelimit"
+)
+
+func TestBucketTake(t *testing.T) {
+	now := time.Unix(10000, 0)
+	bucket := NewBucket(1000, 500, func() time.Time { return now })
+
+	testCases := []struct {
+		elapsed time.Duration
+		n       int
+		wait    time.Duration
+	}{
+		{n: 500, wait: 0},
+		{n: 100, wait: 100 * time.Millisecond},
+		{elapsed: 100 * time.Millisecond, n: 0, wait: 0},
+		{elapsed: time.Second, n: 2000, wait: 1500 * time.Millisecond},
+		{elapsed: 5 * time.Second, n: 400, wait: 0},
+	}
+	for i, tc := range testCases {
+		now = now.Add(tc.elapsed)
+		if wait := bucket.Take(tc.n); wait != tc.wait {
+			t.Error("case ", i, ": expect wait ", tc.wait, ", but got ", wait)
+		}
+	}
+
+	// Burst defaults to the rate.
+	bucket = NewBucket(1000, 0, func() time.Time { return now })
+	if wait := bucket.Take(1000); wait != 0 {
+		t.Error("expect burst of rate, but got wait ", wait)
+	}
+}
+
+func TestBucketWait(t *testing.T) {
+	bucket := NewBucket(100000, 10000, nil)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := bucket.Wait(context.Background(), 10000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond || elapsed > time.Second {
+		t.Error("expect 60KB at 100KB/s after a burst of 10KB to take 0.5s, but took ", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := bucket.Wait(ctx, 100000); err == nil {
+		t.Error("expect error waiting with context done")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(10000, 0)
+	limiter := NewLimiter(1000, 1000, time.Minute, func() time.Time { return now })
+
+	a := limiter.Get("a")
+	if limiter.Get("a") != a {
+		t.Error("expect the same bucket of the same key")
+	}
+	if limiter.Get("b") == a {
+		t.Error("expect different buckets of different keys")
+	}
+
+	// a is not full yet, so it is kept after the timeout.
+	a.Take(1000000)
+	now = now.Add(2 * time.Minute)
+	limiter.Get("c")
+	if n := limiter.Len(); n != 2 {
+		t.Error("expect idle bucket evicted, but got ", n, " buckets")
+	}
+	if limiter.Get("a") != a {
+		t.Error("expect bucket in use to be kept")
+	}
+}
@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"time"
 )
 
 type sessionKey int
@@ -14,6 +15,9 @@ const (
 	muxPreferedSessionKey
 	sockoptSessionKey
 	trackedConnectionErrorKey
+	tosSessionKey
+	dialTimeoutSessionKey
+	resolverTagSessionKey
 )
 
 // ContextWithID returns a new context with the given ID.
@@ -88,6 +92,45 @@ func SockoptFromContext(ctx context.Context) *Sockopt {
 	return nil
 }
 
+// ContextWithTOS returns a new context with the TOS value of outbound connections.
+func ContextWithTOS(ctx context.Context, tos uint32) context.Context {
+	return context.WithValue(ctx, tosSessionKey, tos)
+}
+
+// TOSFromContext returns the TOS value of outbound connections in this context, or 0 if not contained.
+func TOSFromContext(ctx context.Context) uint32 {
+	if tos, ok := ctx.Value(tosSessionKey).(uint32); ok {
+		return tos
+	}
+	return 0
+}
+
+// ContextWithDialTimeout returns a new context with the timeout of dialing outbound connections.
+func ContextWithDialTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, dialTimeoutSessionKey, timeout)
+}
+
+// DialTimeoutFromContext returns the timeout of dialing outbound connections in this context, or 0 if not contained.
+func DialTimeoutFromContext(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(dialTimeoutSessionKey).(time.Duration); ok {
+		return timeout
+	}
+	return 0
+}
+
+// ContextWithResolverTag returns a new context with the tag of the name server resolving domain destinations of outbound connections.
+func ContextWithResolverTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, resolverTagSessionKey, tag)
+}
+
+// ResolverTagFromContext returns the tag of the name server resolving domain destinations of outbound connections in this context, or "" if not contained.
+func ResolverTagFromContext(ctx context.Context) string {
+	if tag, ok := ctx.Value(resolverTagSessionKey).(string); ok {
+		return tag
+	}
+	return ""
+}
+
 func GetTransportLayerProxyTagFromContext(ctx context.Context) string {
 	if ContentFromContext(ctx) == nil {
 		return ""
@@ -39,8 +39,13 @@ type Inbound struct {
 	Source net.Destination
 	// Gateway address
 	Gateway net.Destination
+	// Local address the inbound connection was accepted on. Unlike Gateway,
+	// it is never a wildcard address. May be invalid if unknown.
+	Local net.Destination
 	// Tag of the inbound proxy that handles the connection.
 	Tag string
+	// Name of the transport protocol the connection came in over, e.g. "websocket".
+	TransportProtocol string
 	// User is the user that authencates for the inbound. May be nil if the protocol allows anounymous traffic.
 	User *protocol.MemoryUser
 }
@@ -51,6 +56,9 @@ type Outbound struct {
 	Target net.Destination
 	// Gateway address
 	Gateway net.Address
+	// Domain of the target used for routing, when it is sniffed but not used
+	// as the target address.
+	RouteDomain string
 }
 
 // SniffingRequest controls the behavior of content sniffing.
@@ -58,6 +66,7 @@ type SniffingRequest struct {
 	OverrideDestinationForProtocol []string
 	Enabled                        bool
 	MetadataOnly                   bool
+	RouteOnly                      bool
 }
 
 // Content is the metadata of the connection content.
@@ -72,6 +81,23 @@ type Content struct {
 	SkipDNSResolve bool
 }
 
+// Attributes of content recording names sniffed from the connection.
+const (
+	// AttributeSniffedSNI is the server name sniffed from TLS client hello.
+	AttributeSniffedSNI = ":sni"
+	// AttributeSniffedHost is the host sniffed from HTTP request headers.
+	AttributeSniffedHost = ":host"
+	// AttributeSniffedJA3 is the JA3 fingerprint of TLS client hello.
+	AttributeSniffedJA3 = ":ja3"
+)
+
+// AttributeDomainLabel is the label of the domain matched by routing rules.
+const AttributeDomainLabel = ":label"
+
+// AttributeOriginalTarget is the target of the connection before it is
+// redirected by routing rules.
+const AttributeOriginalTarget = ":original-target"
+
 // Sockopt is the settings for socket connection.
 type Sockopt struct {
 	// Mark of the socket connection.
@@ -1,6 +1,8 @@
 package dns
 
 import (
+	"time"
+
 	"github.com/v2fly/v2ray-core/v4/common/errors"
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/common/serial"
@@ -38,6 +40,31 @@ type IPv6Lookup interface {
 	LookupIPv6(domain string) ([]net.IP, error)
 }
 
+// TTLLookup is an optional feature for querying IP addresses along with the
+// remaining TTL of their records, which is zero if unknown, e.g. for static
+// hosts.
+//
+// v2ray:api:beta
+type TTLLookup interface {
+	LookupIPWithTTL(domain string, option IPOption) ([]net.IP, time.Duration, error)
+}
+
+// TaggedLookup is an optional feature for querying IP addresses with only the
+// name server of the tag.
+//
+// v2ray:api:beta
+type TaggedLookup interface {
+	LookupIPWithTag(tag string, domain string) ([]net.IP, error)
+}
+
+// ReverseLookup is an optional feature for querying host names of an IP
+// address, as in PTR records.
+//
+// v2ray:api:beta
+type ReverseLookup interface {
+	LookupAddr(ip net.IP) ([]string, error)
+}
+
 // ClientWithIPOption is an optional feature for querying DNS information.
 //
 // v2ray:api:beta
@@ -1,6 +1,10 @@
 package localdns
 
 import (
+	"context"
+	gonet "net"
+	"time"
+
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/features/dns"
 )
@@ -74,6 +78,23 @@ func (c *Client) LookupIPv6(host string) ([]net.IP, error) {
 	return ipv6, nil
 }
 
+// reverseLookupTimeout is the timeout of querying PTR records.
+const reverseLookupTimeout = 5 * time.Second
+
+// LookupAddr implements ReverseLookup.
+func (*Client) LookupAddr(ip net.IP) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reverseLookupTimeout)
+	defer cancel()
+	names, err := gonet.DefaultResolver.LookupAddr(ctx, ip.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, dns.ErrEmptyResponse
+	}
+	return names, nil
+}
+
 // New create a new dns.Client that queries localhost for DNS.
 func New() *Client {
 	return &Client{}
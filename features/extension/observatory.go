@@ -14,6 +14,14 @@ type Observatory interface {
 	GetObservation(ctx context.Context) (proto.Message, error)
 }
 
+// ObservationVersioner is implemented by observatories that track changes of
+// their observation, so that results derived from it can be cached.
+type ObservationVersioner interface {
+	// ObservationVersion returns a number that changes whenever the result of
+	// GetObservation changes.
+	ObservationVersion() uint64
+}
+
 func ObservatoryType() interface{} {
 	return (*Observatory)(nil)
 }
@@ -2,6 +2,7 @@ package outbound
 
 import (
 	"context"
+	"time"
 
 	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/features"
@@ -17,6 +18,21 @@ type Handler interface {
 	Dispatch(ctx context.Context, link *transport.Link)
 }
 
+// ConnectionCounter is the interface for Handlers that count their active connections.
+type ConnectionCounter interface {
+	// ActiveConnections returns the number of connections being processed by the handler.
+	ActiveConnections() int64
+}
+
+// Warmer is the interface for Handlers that can keep connections to their
+// servers dialed in advance.
+type Warmer interface {
+	// SetWarmPool keeps size idle connections to each server of the handler,
+	// closing those idle for longer than maxIdle. If called more than once,
+	// e.g. by several balancers, the largest size and max idle time are kept.
+	SetWarmPool(size int, maxIdle time.Duration)
+}
+
 type HandlerSelector interface {
 	Select([]string) []string
 }
@@ -1,6 +1,8 @@
 package routing
 
 import (
+	"time"
+
 	"github.com/v2fly/v2ray-core/v4/common/net"
 )
 
@@ -41,3 +43,26 @@ type Context interface {
 	// GetSkipDNSResolve returns a flag switch for weather skip dns resolve during route pick.
 	GetSkipDNSResolve() bool
 }
+
+// AttributeSetter is implemented by Contexts whose attributes can be set by
+// conditions during routing.
+type AttributeSetter interface {
+	// SetAttribute sets an extra attribute of the connection content.
+	SetAttribute(name string, value string)
+}
+
+// InboundTransportGetter is implemented by Contexts knowing the transport
+// protocol of the inbound connection.
+type InboundTransportGetter interface {
+	// GetInboundTransport returns the transport protocol of the inbound the
+	// connection was from.
+	GetInboundTransport() string
+}
+
+// ResolvedTTLGetter is implemented by Contexts resolving target domains, which
+// know the remaining TTL of the resolved records.
+type ResolvedTTLGetter interface {
+	// GetResolvedTTL returns the TTL of records resolved by GetTargetIPs, or
+	// zero if unknown.
+	GetResolvedTTL() time.Duration
+}
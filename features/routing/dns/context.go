@@ -3,6 +3,8 @@ package dns
 //go:generate go run github.com/v2fly/v2ray-core/v4/common/errors/errorgen
 
 import (
+	"time"
+
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/features/dns"
 	"github.com/v2fly/v2ray-core/v4/features/routing"
@@ -13,6 +15,7 @@ type ResolvableContext struct {
 	routing.Context
 	dnsClient   dns.Client
 	resolvedIPs []net.IP
+	resolvedTTL time.Duration
 }
 
 // GetTargetIPs overrides original routing.Context's implementation.
@@ -39,6 +42,19 @@ func (ctx *ResolvableContext) GetTargetIPs() []net.IP {
 			newError("ctx.dnsClient doesn't implement ClientWithIPOption").AtDebug().WriteToLog()
 		}
 
+		if ttlLookup, ok := ctx.dnsClient.(dns.TTLLookup); ok {
+			option := *ipOption
+			option.FakeEnable = false
+			ips, ttl, err := ttlLookup.LookupIPWithTTL(domain, option)
+			if err == nil {
+				ctx.resolvedIPs = ips
+				ctx.resolvedTTL = ttl
+				return ips
+			}
+			newError("resolve ip for ", domain).Base(err).WriteToLog()
+			return nil
+		}
+
 		switch {
 		case ipOption.IPv4Enable && !ipOption.IPv6Enable:
 			if lookupIPv4, ok := ctx.dnsClient.(dns.IPv4Lookup); ok {
@@ -65,6 +81,30 @@ func (ctx *ResolvableContext) GetTargetIPs() []net.IP {
 	return nil
 }
 
+// GetResolvedTTL implements routing.ResolvedTTLGetter. The TTL is unknown if
+// the DNS client doesn't implement dns.TTLLookup.
+func (ctx *ResolvableContext) GetResolvedTTL() time.Duration {
+	ctx.GetTargetIPs()
+	return ctx.resolvedTTL
+}
+
+// SetAttribute implements routing.AttributeSetter, if the original
+// routing.Context does.
+func (ctx *ResolvableContext) SetAttribute(name string, value string) {
+	if setter, ok := ctx.Context.(routing.AttributeSetter); ok {
+		setter.SetAttribute(name, value)
+	}
+}
+
+// GetInboundTransport implements routing.InboundTransportGetter, if the
+// original routing.Context does.
+func (ctx *ResolvableContext) GetInboundTransport() string {
+	if getter, ok := ctx.Context.(routing.InboundTransportGetter); ok {
+		return getter.GetInboundTransport()
+	}
+	return ""
+}
+
 // ContextWithDNSClient creates a new routing context with domain resolving capability.
 // Resolved domain IPs can be retrieved by GetTargetIPs().
 func ContextWithDNSClient(ctx routing.Context, client dns.Client) routing.Context {
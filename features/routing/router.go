@@ -1,7 +1,11 @@
 package routing
 
 import (
+	"time"
+
 	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/ratelimit"
 	"github.com/v2fly/v2ray-core/v4/features"
 )
 
@@ -29,6 +33,51 @@ type Route interface {
 	GetOutboundTag() string
 }
 
+// HandlerFallbackRoute is a Route that also provides alternative outbounds, in
+// case no handler of the chosen one exists, e.g. it is removed after the route
+// is picked. Connections failing to dial are not retried on them.
+type HandlerFallbackRoute interface {
+	Route
+
+	// GetHandlerFallbackTags returns the tags of outbounds to be tried in sequence if no handler of the outbound of GetOutboundTag exists.
+	GetHandlerFallbackTags() []string
+}
+
+// RuleRoute is a Route that carries information of the rule it is decided by.
+type RuleRoute interface {
+	Route
+
+	// GetRuleTag returns the tag of the rule this route is decided by, or empty if the rule is not tagged.
+	GetRuleTag() string
+
+	// GetRuleTrafficStats returns whether traffic of this route should be counted for the rule.
+	GetRuleTrafficStats() bool
+
+	// GetRuleTOS returns the TOS value for outbound connections of this route, or 0 if the system default is used.
+	GetRuleTOS() uint32
+
+	// GetRuleDialTimeout returns the timeout of dialing outbound connections of this route, or 0 if the default is used.
+	GetRuleDialTimeout() time.Duration
+
+	// GetRuleResolverTag returns the tag of the name server resolving domain destinations of outbound connections of this route, or empty if that of the outbound is used.
+	GetRuleResolverTag() string
+
+	// GetRuleAttributes returns the attributes to be set to the connection content by the rule.
+	GetRuleAttributes() map[string]string
+
+	// GetRuleMirrorTag returns the tag of the outbound to mirror uplink traffic of this route to, or empty if not mirrored.
+	GetRuleMirrorTag() string
+
+	// GetRuleRedirectTarget returns the destination to redirect the connection to, or an invalid one if not redirected.
+	GetRuleRedirectTarget() net.Destination
+
+	// GetRuleRateLimit returns the token bucket limiting bandwidth of this route, shared with other connections from the same source, or nil if not limited.
+	GetRuleRateLimit() *ratelimit.Bucket
+
+	// GetRuleLogged returns whether routing of this connection should be logged, as sampled by the rule.
+	GetRuleLogged() bool
+}
+
 // RouterType return the type of Router interface. Can be used to implement common.HasType.
 //
 // v2ray:api:stable
@@ -23,6 +23,14 @@ func (ctx *Context) GetInboundTag() string {
 	return ctx.Inbound.Tag
 }
 
+// GetInboundTransport implements routing.InboundTransportGetter.
+func (ctx *Context) GetInboundTransport() string {
+	if ctx.Inbound == nil {
+		return ""
+	}
+	return ctx.Inbound.TransportProtocol
+}
+
 // GetSourceIPs implements routing.Context.
 func (ctx *Context) GetSourceIPs() []net.IP {
 	if ctx.Inbound == nil || !ctx.Inbound.Source.IsValid() {
@@ -72,7 +80,7 @@ func (ctx *Context) GetTargetDomain() string {
 	}
 	dest := ctx.Outbound.Target
 	if !dest.Address.Family().IsDomain() {
-		return ""
+		return ctx.Outbound.RouteDomain
 	}
 	return dest.Address.Domain()
 }
@@ -109,6 +117,14 @@ func (ctx *Context) GetAttributes() map[string]string {
 	return ctx.Content.Attributes
 }
 
+// SetAttribute implements routing.AttributeSetter.
+func (ctx *Context) SetAttribute(name string, value string) {
+	if ctx.Content == nil {
+		ctx.Content = new(session.Content)
+	}
+	ctx.Content.SetAttribute(name, value)
+}
+
 // GetSkipDNSResolve implements routing.Context.
 func (ctx *Context) GetSkipDNSResolve() bool {
 	if ctx.Content == nil {
@@ -66,18 +66,21 @@ func (v Network) Build() net.Network {
 		return net.Network_UDP
 	case "unix":
 		return net.Network_UNIX
+	case "icmp":
+		return net.Network_ICMP
 	default:
 		return net.Network_Unknown
 	}
 }
 
+// NetworkList is a list of networks, in form of either an array or a comma
+// separated string. Entries may combine networks with "+", e.g. "tcp+udp".
 type NetworkList []Network
 
 func (v *NetworkList) UnmarshalJSON(data []byte) error {
 	var strarray []Network
 	if err := json.Unmarshal(data, &strarray); err == nil {
-		nl := NetworkList(strarray)
-		*v = nl
+		*v = splitNetworks(strarray)
 		return nil
 	}
 
@@ -88,12 +91,23 @@ func (v *NetworkList) UnmarshalJSON(data []byte) error {
 		for idx, network := range strlist {
 			nl[idx] = Network(network)
 		}
-		*v = nl
+		*v = splitNetworks(nl)
 		return nil
 	}
 	return newError("unknown format of a string list: " + string(data))
 }
 
+// splitNetworks expands entries combining networks with "+".
+func splitNetworks(list []Network) NetworkList {
+	nl := make(NetworkList, 0, len(list))
+	for _, network := range list {
+		for _, n := range strings.Split(string(network), "+") {
+			nl = append(nl, Network(strings.TrimSpace(n)))
+		}
+	}
+	return nl
+}
+
 func (v *NetworkList) Build() []net.Network {
 	if v == nil {
 		return []net.Network{net.Network_TCP}
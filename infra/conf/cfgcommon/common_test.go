@@ -114,6 +114,24 @@ func TestStringNetworkList(t *testing.T) {
 	}
 }
 
+func TestCombinedNetworkList(t *testing.T) {
+	testCases := []struct {
+		input  string
+		output []net.Network
+	}{
+		{input: `"tcp+udp"`, output: []net.Network{net.Network_TCP, net.Network_UDP}},
+		{input: `["tcp+udp", "icmp"]`, output: []net.Network{net.Network_TCP, net.Network_UDP, net.Network_ICMP}},
+		{input: `"udp, icmp"`, output: []net.Network{net.Network_UDP, net.Network_ICMP}},
+	}
+	for _, tc := range testCases {
+		var list cfgcommon.NetworkList
+		common.Must(json.Unmarshal([]byte(tc.input), &list))
+		if r := cmp.Diff(list.Build(), tc.output); r != "" {
+			t.Error(tc.input, ": ", r)
+		}
+	}
+}
+
 func TestInvalidNetworkJson(t *testing.T) {
 	var list cfgcommon.NetworkList
 	err := json.Unmarshal([]byte("0"), &list)
@@ -12,7 +12,8 @@ type configureLoadingContext int
 const confContextKey = configureLoadingContext(1)
 
 type configureLoadingEnvironment struct {
-	geoLoader geodata.Loader
+	geoLoader     geodata.Loader
+	geoDataMapped bool
 }
 
 func (c *configureLoadingEnvironment) GetGeoLoader() geodata.Loader {
@@ -40,3 +41,17 @@ func GetConfigureLoadingEnvironment(ctx context.Context) ConfigureLoadingEnviron
 func SetGeoDataLoader(ctx context.Context, loader geodata.Loader) {
 	GetConfigureLoadingEnvironment(ctx).(*configureLoadingEnvironment).geoLoader = loader
 }
+
+// SetGeoDataMapped makes rules loaded in the context refer to entries of
+// geodata files by their sources only, without loading them, for the router to
+// search them in place in the files mapped into memory.
+func SetGeoDataMapped(ctx context.Context, mapped bool) {
+	GetConfigureLoadingEnvironment(ctx).(*configureLoadingEnvironment).geoDataMapped = mapped
+}
+
+// IsGeoDataMapped returns whether rules loaded in the context refer to entries
+// of geodata files by their sources only.
+func IsGeoDataMapped(ctx context.Context) bool {
+	environment, ok := GetConfigureLoadingEnvironment(ctx).(*configureLoadingEnvironment)
+	return ok && environment.geoDataMapped
+}
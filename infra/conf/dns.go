@@ -22,6 +22,7 @@ type NameServerConfig struct {
 	SkipFallback bool
 	Domains      []string
 	ExpectIPs    cfgcommon.StringList
+	Tag          string
 
 	cfgctx context.Context
 }
@@ -40,6 +41,7 @@ func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
 		SkipFallback bool                 `json:"skipFallback"`
 		Domains      []string             `json:"domains"`
 		ExpectIPs    cfgcommon.StringList `json:"expectIps"`
+		Tag          string               `json:"tag"`
 	}
 	if err := json.Unmarshal(data, &advanced); err == nil {
 		c.Address = advanced.Address
@@ -48,6 +50,7 @@ func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
 		c.SkipFallback = advanced.SkipFallback
 		c.Domains = advanced.Domains
 		c.ExpectIPs = advanced.ExpectIPs
+		c.Tag = advanced.Tag
 		return nil
 	}
 
@@ -121,6 +124,7 @@ func (c *NameServerConfig) Build() (*dns.NameServer, error) {
 		PrioritizedDomain: domains,
 		Geoip:             geoipList,
 		OriginalRules:     originalRules,
+		Tag:               c.Tag,
 	}, nil
 }
 
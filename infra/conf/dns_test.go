@@ -66,7 +66,8 @@ func TestDNSConfigParsing(t *testing.T) {
 					"clientIp": "10.0.0.1",
 					"port": 5353,
 					"skipFallback": true,
-					"domains": ["domain:v2fly.org"]
+					"domains": ["domain:v2fly.org"],
+					"tag": "exit-dns"
 				}],
 				"hosts": {
 					"v2fly.org": "127.0.0.1",
@@ -108,6 +109,7 @@ func TestDNSConfigParsing(t *testing.T) {
 								Size: 1,
 							},
 						},
+						Tag: "exit-dns",
 					},
 				},
 				StaticHosts: []*dns.Config_HostMapping{
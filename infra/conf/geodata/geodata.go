@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common/platform"
 )
 
 type loader struct {
@@ -81,3 +82,17 @@ func GetGeoDataLoader(name string) (Loader, error) {
 	}
 	return nil, err
 }
+
+func init() {
+	// Routing rules are reloaded with the loader they were built with.
+	router.RegisterGeoDataLoaderCreator(func() (router.GeoDataLoader, error) {
+		name := platform.NewEnvFlag("v2ray.conf.geoloader").GetValue(func() string {
+			return "standard"
+		})
+		loader, err := GetGeoDataLoader(name)
+		if err != nil {
+			return nil, err
+		}
+		return loader, nil
+	})
+}
@@ -7,9 +7,13 @@ import (
 )
 
 type GunConfig struct {
-	ServiceName string `json:"serviceName"`
+	ServiceName            string `json:"serviceName"`
+	DisableConnectionReuse bool   `json:"disableConnectionReuse"`
 }
 
 func (g GunConfig) Build() (proto.Message, error) {
-	return &grpc.Config{ServiceName: g.ServiceName}, nil
+	return &grpc.Config{
+		ServiceName:            g.ServiceName,
+		DisableConnectionReuse: g.DisableConnectionReuse,
+	}, nil
 }
@@ -11,8 +11,23 @@ type ObservatoryConfig struct {
 	SubjectSelector []string          `json:"subjectSelector"`
 	ProbeURL        string            `json:"probeURL"`
 	ProbeInterval   duration.Duration `json:"probeInterval"`
+
+	PersistentFile   string            `json:"persistentFile"`
+	PersistentMaxAge duration.Duration `json:"persistentMaxAge"`
+
+	AvailabilityWindow duration.Duration `json:"availabilityWindow"`
+
+	BackoffMaxFailures uint32 `json:"backoffMaxFailures"`
 }
 
 func (o *ObservatoryConfig) Build() (proto.Message, error) {
-	return &observatory.Config{SubjectSelector: o.SubjectSelector, ProbeUrl: o.ProbeURL, ProbeInterval: int64(o.ProbeInterval)}, nil
+	return &observatory.Config{
+		SubjectSelector:    o.SubjectSelector,
+		ProbeUrl:           o.ProbeURL,
+		ProbeInterval:      int64(o.ProbeInterval),
+		PersistentFile:     o.PersistentFile,
+		PersistentMaxAge:   int64(o.PersistentMaxAge),
+		AvailabilityWindow: int64(o.AvailabilityWindow),
+		BackoffMaxFailures: o.BackoffMaxFailures,
+	}, nil
 }
@@ -3,11 +3,15 @@ package conf
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"strings"
+	"time"
 
 	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/common/platform"
 	"github.com/v2fly/v2ray-core/v4/infra/conf/cfgcommon"
+	"github.com/v2fly/v2ray-core/v4/infra/conf/cfgcommon/duration"
 	"github.com/v2fly/v2ray-core/v4/infra/conf/geodata"
 	rule2 "github.com/v2fly/v2ray-core/v4/infra/conf/rule"
 )
@@ -24,9 +28,15 @@ type StrategyConfig struct {
 }
 
 type BalancingRule struct {
-	Tag       string               `json:"tag"`
-	Selectors cfgcommon.StringList `json:"selector"`
-	Strategy  StrategyConfig       `json:"strategy"`
+	Tag              string               `json:"tag"`
+	Selectors        cfgcommon.StringList `json:"selector"`
+	SelectorMatch    string               `json:"selectorMatch"`
+	DrainGracePeriod duration.Duration    `json:"drainGracePeriod"`
+	AffinityTTL      duration.Duration    `json:"affinityTTL"`
+	MaxFailures      uint32               `json:"maxFailures"`
+	WarmPoolSize     uint32               `json:"warmPoolSize"`
+	WarmPoolMaxIdle  duration.Duration    `json:"warmPoolMaxIdle"`
+	Strategy         StrategyConfig       `json:"strategy"`
 }
 
 func (r *BalancingRule) Build() (*router.BalancingRule, error) {
@@ -37,30 +47,92 @@ func (r *BalancingRule) Build() (*router.BalancingRule, error) {
 		return nil, newError("empty selector list")
 	}
 
-	var strategy string
+	rule := &router.BalancingRule{
+		Tag:              r.Tag,
+		OutboundSelector: []string(r.Selectors),
+		MaxFailures:      r.MaxFailures,
+		WarmPoolSize:     r.WarmPoolSize,
+	}
+	switch strings.ToLower(r.SelectorMatch) {
+	case "prefix", "":
+		rule.SelectorMatch = router.BalancingRule_Prefix
+	case "glob":
+		rule.SelectorMatch = router.BalancingRule_Glob
+	case "regex", "regexp":
+		rule.SelectorMatch = router.BalancingRule_Regex
+	default:
+		return nil, newError("unknown selector match type: " + r.SelectorMatch)
+	}
+	drainGracePeriod := time.Duration(r.DrainGracePeriod)
+	if drainGracePeriod < 0 || drainGracePeriod/time.Millisecond > math.MaxUint32 {
+		return nil, newError("invalid drain grace period of balancer: ", drainGracePeriod)
+	}
+	rule.DrainGracePeriod = uint32(drainGracePeriod / time.Millisecond)
+	affinityTTL := time.Duration(r.AffinityTTL)
+	if affinityTTL < 0 || affinityTTL/time.Millisecond > math.MaxUint32 {
+		return nil, newError("invalid affinity TTL of balancer: ", affinityTTL)
+	}
+	rule.AffinityTtl = uint32(affinityTTL / time.Millisecond)
+	warmPoolMaxIdle := time.Duration(r.WarmPoolMaxIdle)
+	if warmPoolMaxIdle < 0 || warmPoolMaxIdle/time.Millisecond > math.MaxUint32 {
+		return nil, newError("invalid warm pool max idle time of balancer: ", warmPoolMaxIdle)
+	}
+	rule.WarmPoolMaxIdle = uint32(warmPoolMaxIdle / time.Millisecond)
 	switch strings.ToLower(r.Strategy.Type) {
 	case strategyRandom, "":
-		strategy = strategyRandom
+		rule.Strategy = strategyRandom
 	case strategyLeastPing:
-		strategy = "leastPing"
+		rule.Strategy = "leastPing"
+		if r.Strategy.Settings != nil {
+			settings := new(leastPingStrategyConfig)
+			if err := json.Unmarshal(*r.Strategy.Settings, settings); err != nil {
+				return nil, newError("invalid settings of leastPing strategy").Base(err)
+			}
+			rule.MaxConnPerNode = settings.MaxConnPerNode
+		}
+	case strategyComposite:
+		rule.Strategy = strategyComposite
+		if r.Strategy.Settings != nil {
+			settings := new(compositeStrategyConfig)
+			if err := json.Unmarshal(*r.Strategy.Settings, settings); err != nil {
+				return nil, newError("invalid settings of composite strategy").Base(err)
+			}
+			if settings.LatencyWeight < 0 || settings.LoadWeight < 0 {
+				return nil, newError("weights of composite strategy must not be negative")
+			}
+			rule.LatencyWeight = settings.LatencyWeight
+			rule.LoadWeight = settings.LoadWeight
+		}
+	case strategyWeightedHealthy:
+		rule.Strategy = "weightedHealthy"
+		if r.Strategy.Settings != nil {
+			settings := new(weightedHealthyStrategyConfig)
+			if err := json.Unmarshal(*r.Strategy.Settings, settings); err != nil {
+				return nil, newError("invalid settings of weightedHealthy strategy").Base(err)
+			}
+			rule.OutboundWeight = settings.Weights
+		}
 	default:
-		return nil, newError("unknown balancing strategy: " + r.Strategy.Type)
+		// Strategies registered to the router by external code are resolved
+		// by name when the router starts.
+		rule.Strategy = r.Strategy.Type
 	}
 
-	return &router.BalancingRule{
-		Tag:              r.Tag,
-		OutboundSelector: []string(r.Selectors),
-		Strategy:         strategy,
-	}, nil
+	return rule, nil
 }
 
 type RouterConfig struct {
-	Settings       *RouterRulesConfig `json:"settings"` // Deprecated
-	RuleList       []json.RawMessage  `json:"rules"`
-	DomainStrategy *string            `json:"domainStrategy"`
-	Balancers      []*BalancingRule   `json:"balancers"`
-
-	DomainMatcher string `json:"domainMatcher"`
+	Settings       *RouterRulesConfig             `json:"settings"` // Deprecated
+	RuleList       []json.RawMessage              `json:"rules"`
+	DomainStrategy *string                        `json:"domainStrategy"`
+	Balancers      []*BalancingRule               `json:"balancers"`
+	PortSets       map[string]*cfgcommon.PortList `json:"portSets"`
+
+	DomainMatcher          string `json:"domainMatcher"`
+	DomainMatcherCacheSize uint32 `json:"domainMatcherCacheSize"`
+	LazyMatchers           bool   `json:"lazyMatchers"`
+	DefaultRuleTag         string `json:"defaultRuleTag"`
+	MapGeoData             bool   `json:"mapGeoData"`
 }
 
 func (c *RouterConfig) getDomainStrategy() router.Config_DomainStrategy {
@@ -86,6 +158,10 @@ func (c *RouterConfig) getDomainStrategy() router.Config_DomainStrategy {
 func (c *RouterConfig) Build() (*router.Config, error) {
 	config := new(router.Config)
 	config.DomainStrategy = c.getDomainStrategy()
+	config.LazyMatchers = c.LazyMatchers
+	config.DomainMatcherCacheSize = c.DomainMatcherCacheSize
+	config.DefaultRuleTag = c.DefaultRuleTag
+	config.MapGeodata = c.MapGeoData
 
 	cfgctx := cfgcommon.NewConfigureLoadingContext(context.Background())
 
@@ -98,6 +174,7 @@ func (c *RouterConfig) Build() (*router.Config, error) {
 	} else {
 		return nil, newError("unable to create geo data loader ").Base(err)
 	}
+	cfgcommon.SetGeoDataMapped(cfgctx, c.MapGeoData)
 
 	var rawRuleList []json.RawMessage
 	if c != nil {
@@ -118,8 +195,21 @@ func (c *RouterConfig) Build() (*router.Config, error) {
 			rule.DomainMatcher = c.DomainMatcher
 		}
 
+		if err := checkPortSets(rule, c.PortSets); err != nil {
+			return nil, err
+		}
+
 		config.Rule = append(config.Rule, rule)
 	}
+	if len(config.DefaultRuleTag) > 0 && !hasRuleTag(config.Rule, config.DefaultRuleTag) {
+		return nil, newError("default rule not found: ", config.DefaultRuleTag)
+	}
+	if len(c.PortSets) > 0 {
+		config.PortSet = make(map[string]*net.PortList, len(c.PortSets))
+		for name, list := range c.PortSets {
+			config.PortSet[name] = list.Build()
+		}
+	}
 	for _, rawBalancer := range c.Balancers {
 		balancer, err := rawBalancer.Build()
 		if err != nil {
@@ -129,3 +219,30 @@ func (c *RouterConfig) Build() (*router.Config, error) {
 	}
 	return config, nil
 }
+
+// hasRuleTag returns whether any of the rules has the rule tag.
+func hasRuleTag(rules []*router.RoutingRule, tag string) bool {
+	for _, rule := range rules {
+		if rule.RuleTag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPortSets returns an error if the rule or its condition groups refer to
+// port sets not defined in portSets.
+func checkPortSets(rule *router.RoutingRule, portSets map[string]*cfgcommon.PortList) error {
+	names := append(append([]string(nil), rule.PortSetName...), rule.SourcePortSetName...)
+	for _, name := range names {
+		if _, found := portSets[name]; !found {
+			return newError("port set not found: ", name)
+		}
+	}
+	for _, group := range rule.OrGroups {
+		if err := checkPortSets(group, portSets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
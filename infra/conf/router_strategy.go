@@ -3,4 +3,24 @@ package conf
 const (
 	strategyRandom    string = "random"
 	strategyLeastPing string = "leastping"
+	strategyComposite string = "composite"
+
+	strategyWeightedHealthy string = "weightedhealthy"
 )
+
+// compositeStrategyConfig is the settings of the composite balancing strategy.
+type compositeStrategyConfig struct {
+	LatencyWeight float32 `json:"latencyWeight"`
+	LoadWeight    float32 `json:"loadWeight"`
+}
+
+// leastPingStrategyConfig is the settings of the leastPing balancing strategy.
+type leastPingStrategyConfig struct {
+	MaxConnPerNode uint32 `json:"maxConnPerNode"`
+}
+
+// weightedHealthyStrategyConfig is the settings of the weightedHealthy
+// balancing strategy.
+type weightedHealthyStrategyConfig struct {
+	Weights map[string]uint32 `json:"weights"`
+}
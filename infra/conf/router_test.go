@@ -8,10 +8,128 @@ import (
 	"github.com/golang/protobuf/proto"
 
 	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	. "github.com/v2fly/v2ray-core/v4/infra/conf"
 )
 
+func TestRouterConfigPortSets(t *testing.T) {
+	config := new(RouterConfig)
+	common.Must(json.Unmarshal([]byte(`{
+		"portSets": {
+			"web": "80,443",
+			"dns": 53
+		},
+		"rules": [
+			{
+				"type": "field",
+				"portSet": ["web", "dns"],
+				"sourcePortSet": "dns",
+				"outboundTag": "test"
+			}
+		]
+	}`), config))
+	actual, err := config.Build()
+	common.Must(err)
+
+	expected := &router.Config{
+		PortSet: map[string]*net.PortList{
+			"web": {Range: []*net.PortRange{{From: 80, To: 80}, {From: 443, To: 443}}},
+			"dns": {Range: []*net.PortRange{{From: 53, To: 53}}},
+		},
+		Rule: []*router.RoutingRule{
+			{
+				TargetTag: &router.RoutingRule_Tag{
+					Tag: "test",
+				},
+				PortSetName:       []string{"web", "dns"},
+				SourcePortSetName: []string{"dns"},
+			},
+		},
+	}
+	if !proto.Equal(actual, expected) {
+		t.Error("expect ", expected, ", but got ", actual)
+	}
+
+	config = new(RouterConfig)
+	common.Must(json.Unmarshal([]byte(`{
+		"rules": [
+			{
+				"type": "field",
+				"portSet": "ssh",
+				"outboundTag": "test"
+			}
+		]
+	}`), config))
+	if _, err := config.Build(); err == nil {
+		t.Error("expect error for undefined port set")
+	}
+}
+
+func TestRouterConfigDomainLabels(t *testing.T) {
+	config := new(RouterConfig)
+	common.Must(json.Unmarshal([]byte(`{
+		"rules": [
+			{
+				"type": "field",
+				"domain": ["domain:ads.example"],
+				"domainLabels": {
+					"domain:ad.example": "ads"
+				},
+				"outboundTag": "blocked"
+			}
+		]
+	}`), config))
+	if _, err := config.Build(); err == nil {
+		t.Error("expect error for label of domain not in rule")
+	}
+}
+
+func TestRouterConfigInvalidJA3(t *testing.T) {
+	config := new(RouterConfig)
+	common.Must(json.Unmarshal([]byte(`{
+		"rules": [
+			{
+				"type": "field",
+				"ja3": ["771,4865-4866,0-23,29,0"],
+				"outboundTag": "blocked"
+			}
+		]
+	}`), config))
+	if _, err := config.Build(); err == nil {
+		t.Error("expect error for JA3 string instead of hash")
+	}
+}
+
+func TestRouterConfigDefaultRule(t *testing.T) {
+	config := new(RouterConfig)
+	common.Must(json.Unmarshal([]byte(`{
+		"defaultRuleTag": "final",
+		"rules": [
+			{
+				"type": "field",
+				"domain": ["v2fly.org"],
+				"outboundTag": "direct"
+			},
+			{
+				"type": "field",
+				"ruleTag": "final",
+				"outboundTag": "proxy"
+			}
+		]
+	}`), config))
+	actual, err := config.Build()
+	common.Must(err)
+	if actual.DefaultRuleTag != "final" || len(actual.Rule) != 2 || actual.Rule[1].RuleTag != "final" {
+		t.Error("unexpected router config ", actual)
+	}
+
+	config.DefaultRuleTag = "missing"
+	if _, err := config.Build(); err == nil {
+		t.Error("expect error for undefined default rule")
+	}
+}
+
 func TestRouterConfig(t *testing.T) {
 	createParser := func() func(string) (proto.Message, error) {
 		return func(s string) (proto.Message, error) {
@@ -248,6 +366,8 @@ func TestRouterConfig(t *testing.T) {
 		{
 			Input: `{
 				"domainStrategy": "AsIs",
+				"lazyMatchers": true,
+				"domainMatcherCacheSize": 4096,
 				"rules": [
 					{
 						"type": "field",
@@ -277,7 +397,9 @@ func TestRouterConfig(t *testing.T) {
 			}`,
 			Parser: createParser(),
 			Output: &router.Config{
-				DomainStrategy: router.Config_AsIs,
+				DomainStrategy:         router.Config_AsIs,
+				LazyMatchers:           true,
+				DomainMatcherCacheSize: 4096,
 				Rule: []*router.RoutingRule{
 					{
 						Domain: []*router.Domain{
@@ -331,5 +453,651 @@ func TestRouterConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"inboundTag": ["in"],
+						"negatePort": true,
+						"port": 53,
+						"orGroups": [
+							{
+								"domain": ["domain:v2fly.org"],
+								"network": "tcp"
+							},
+							{
+								"ip": ["10.0.0.0/8"]
+							}
+						],
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						InboundTag: []string{"in"},
+						PortList: &net.PortList{
+							Range: []*net.PortRange{
+								{From: 53, To: 53},
+							},
+						},
+						NegatePort: true,
+						OrGroups: []*router.RoutingRule{
+							{
+								Domain: []*router.Domain{
+									{
+										Type:  router.Domain_Domain,
+										Value: "v2fly.org",
+									},
+								},
+								Networks: []net.Network{net.Network_TCP},
+							},
+							{
+								Geoip: []*router.GeoIP{
+									{
+										Cidr: []*router.CIDR{
+											{
+												Ip:     []byte{10, 0, 0, 0},
+												Prefix: 8,
+											},
+										},
+									},
+								},
+							},
+						},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"schedule": {
+							"timezone": "Asia/Shanghai",
+							"windows": [
+								{
+									"weekday": "Mon-Fri",
+									"time": "09:00-18:00"
+								},
+								{
+									"weekday": "6",
+									"time": "22:30-06:00"
+								}
+							]
+						},
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						Schedule: &router.Schedule{
+							Timezone: "Asia/Shanghai",
+							Window: []*router.Schedule_Window{
+								{
+									WeekdayFrom: 1,
+									WeekdayTo:   5,
+									Begin:       9 * 3600,
+									End:         18 * 3600,
+								},
+								{
+									WeekdayFrom: 6,
+									WeekdayTo:   6,
+									Begin:       22*3600 + 30*60,
+									End:         6 * 3600,
+								},
+							},
+						},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"ip": ["10.0.0.0/8", "!192.168.0.0/16", "!127.0.0.1"],
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						Geoip: []*router.GeoIP{
+							{
+								Cidr: []*router.CIDR{
+									{Ip: []byte{10, 0, 0, 0}, Prefix: 8},
+								},
+							},
+							{
+								Cidr: []*router.CIDR{
+									{Ip: []byte{192, 168, 0, 0}, Prefix: 16},
+									{Ip: []byte{127, 0, 0, 1}, Prefix: 32},
+								},
+								ReverseMatch: true,
+							},
+						},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"network": "tcp",
+						"ruleTag": "myrule",
+						"ruleTrafficStats": true,
+						"tos": 184,
+						"dialTimeout": "30s",
+						"resolverTag": "exit",
+						"setAttrs": {"tier": "premium"},
+						"mirrorTag": "measure",
+						"logSamplingRate": 0.25,
+						"redirectTarget": "10.0.0.2:3128",
+						"rateLimit": {"rate": 1048576, "burst": 65536},
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						Networks:         []net.Network{net.Network_TCP},
+						RuleTag:          "myrule",
+						RuleTrafficStats: true,
+						Tos:              184,
+						DialTimeout:      30000,
+						ResolverTag:      "exit",
+						SetAttributes:    map[string]string{"tier": "premium"},
+						MirrorTag:        "measure",
+						LogSampling:      &router.LogSampling{Rate: 0.25},
+						RedirectTarget: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.ParseAddress("10.0.0.2")),
+							Port:    3128,
+						},
+						RateLimit: &router.RateLimit{Rate: 1048576, Burst: 65536},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"reverseDomain": ["domain:v2fly.org"],
+						"reverseLookupTtl": "1h",
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						ReverseDomain: []*router.Domain{
+							{Type: router.Domain_Domain, Value: "v2fly.org"},
+						},
+						ReverseLookupTtl: 3600,
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"mapGeoData": true,
+				"rules": [
+					{
+						"type": "field",
+						"ip": ["geoip:cn"],
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				MapGeodata:     true,
+				Rule: []*router.RoutingRule{
+					{
+						Geoip: []*router.GeoIP{
+							{
+								CountryCode: "CN",
+								Source:      []*router.GeoDataSource{{File: "geoip.dat", Code: "cn"}},
+							},
+						},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"ipRange": ["10.0.0.5-10.0.0.200", "2001:db8::1 - 2001:db8::ff"],
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						IpRange: []*router.IPRange{
+							{
+								From: []byte{10, 0, 0, 5},
+								To:   []byte{10, 0, 0, 200},
+							},
+							{
+								From: net.ParseAddress("2001:db8::1").IP(),
+								To:   net.ParseAddress("2001:db8::ff").IP(),
+							},
+						},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"processPath": ["/usr/lib/firefox/", "/usr/bin/curl"],
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						ProcessPath: []string{"/usr/lib/firefox/", "/usr/bin/curl"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"domain": ["regexp:^ads?\\."],
+						"anchorRegex": true,
+						"outboundTag": "blocked"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						Domain: []*router.Domain{
+							{
+								Type:  router.Domain_Regex,
+								Value: "^ads?\\.",
+							},
+						},
+						AnchorRegex: true,
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "blocked",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"balancers": [
+					{
+						"tag": "b1",
+						"selector": ["test"],
+						"strategy": {
+							"type": "composite",
+							"settings": {
+								"latencyWeight": 0.5,
+								"loadWeight": 20
+							}
+						}
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				BalancingRule: []*router.BalancingRule{
+					{
+						Tag:              "b1",
+						OutboundSelector: []string{"test"},
+						Strategy:         "composite",
+						LatencyWeight:    0.5,
+						LoadWeight:       20,
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"balancers": [
+					{
+						"tag": "b1",
+						"selector": ["proxy-us-*"],
+						"selectorMatch": "glob",
+						"drainGracePeriod": "30s",
+						"affinityTTL": "10m",
+						"maxFailures": 3,
+						"warmPoolSize": 2,
+						"warmPoolMaxIdle": "30s"
+					},
+					{
+						"tag": "b2",
+						"selector": ["proxy-"],
+						"strategy": {
+							"type": "myStrategy"
+						}
+					},
+					{
+						"tag": "b3",
+						"selector": ["proxy-"],
+						"maxFailures": 2,
+						"strategy": {
+							"type": "weightedHealthy",
+							"settings": {
+								"weights": {"proxy-a": 3, "proxy-b": 1}
+							}
+						}
+					},
+					{
+						"tag": "b4",
+						"selector": ["proxy-"],
+						"strategy": {
+							"type": "leastPing",
+							"settings": {
+								"maxConnPerNode": 100
+							}
+						}
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				BalancingRule: []*router.BalancingRule{
+					{
+						Tag:              "b1",
+						OutboundSelector: []string{"proxy-us-*"},
+						SelectorMatch:    router.BalancingRule_Glob,
+						DrainGracePeriod: 30000,
+						AffinityTtl:      600000,
+						MaxFailures:      3,
+						WarmPoolSize:     2,
+						WarmPoolMaxIdle:  30000,
+						Strategy:         "random",
+					},
+					{
+						Tag:              "b2",
+						OutboundSelector: []string{"proxy-"},
+						Strategy:         "myStrategy",
+					},
+					{
+						Tag:              "b3",
+						OutboundSelector: []string{"proxy-"},
+						MaxFailures:      2,
+						Strategy:         "weightedHealthy",
+						OutboundWeight:   map[string]uint32{"proxy-a": 3, "proxy-b": 1},
+					},
+					{
+						Tag:              "b4",
+						OutboundSelector: []string{"proxy-"},
+						Strategy:         "leastPing",
+						MaxConnPerNode:   100,
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"transportProtocol": ["ws", "mKCP"],
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						TransportProtocol: []string{"websocket", "mkcp"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"domain": ["domain:ads.example", "full:www.example.com"],
+						"domainLabels": {
+							"domain:ads.example": "ads"
+						},
+						"outboundTag": "blocked"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						Domain: []*router.Domain{
+							{Type: router.Domain_Domain, Value: "ads.example", Label: "ads"},
+							{Type: router.Domain_Full, Value: "www.example.com"},
+						},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "blocked",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"domainSuffixPSL": [".cn", "example.co.uk"],
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						DomainSuffixPsl: []string{".cn", "example.co.uk"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"domainFronting": "mismatching",
+						"isIpQuery": "ipOnly",
+						"portClass": ["Privileged", "ephemeral"],
+						"ja3": ["B8F81673C0E1D29908346F3BAB892B9B"],
+						"outboundTag": "blocked"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						DomainFronting: router.DomainFronting_Mismatching,
+						IsIpQuery:      router.RoutingRule_IpOnly,
+						PortClass:      []router.RoutingRule_PortClass{router.RoutingRule_Privileged, router.RoutingRule_Ephemeral},
+						Ja3:            []string{"b8f81673c0e1d29908346f3bab892b9b"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "blocked",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"connectionRate": {
+							"window": "10s",
+							"threshold": 20
+						},
+						"outboundTag": "blocked"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						ConnectionRate: &router.ConnectionRate{
+							Window:    10000,
+							Threshold: 20,
+						},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "blocked",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"domainStrategy": "IPOnDemand",
+				"rules": [
+					{
+						"type": "field",
+						"dnsResult": {
+							"minRecords": 4,
+							"maxTtl": "60s"
+						},
+						"outboundTag": "direct"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_IpOnDemand,
+				Rule: []*router.RoutingRule{
+					{
+						DnsResult: &router.DnsResult{
+							MinRecordCount: 4,
+							MaxTtl:         60,
+						},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "direct",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"samplePercent": 10,
+						"sampleHashKey": "source",
+						"outboundTag": "canary"
+					},
+					{
+						"type": "field",
+						"network": "tcp,udp",
+						"outboundTag": "stable"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				DomainStrategy: router.Config_AsIs,
+				Rule: []*router.RoutingRule{
+					{
+						SamplePercent: 10,
+						SampleHashKey: router.RoutingRule_SourceIp,
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "canary",
+						},
+					},
+					{
+						Networks: []net.Network{net.Network_TCP, net.Network_UDP},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "stable",
+						},
+					},
+				},
+			},
+		},
 	})
 }
@@ -0,0 +1,125 @@
+package rule
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
+	"github.com/v2fly/v2ray-core/v4/infra/conf/geodata"
+)
+
+// countryContinents maps ISO 3166-1 alpha-2 country codes to codes of their
+// continents, following GeoNames: AF (Africa), AN (Antarctica), AS (Asia), EU
+// (Europe), NA (North America), OC (Oceania) and SA (South America).
+var countryContinents = map[string]string{
+	"AD": "EU", "AE": "AS", "AF": "AS", "AG": "NA", "AI": "NA", "AL": "EU", "AM": "AS", "AO": "AF",
+	"AQ": "AN", "AR": "SA", "AS": "OC", "AT": "EU", "AU": "OC", "AW": "NA", "AX": "EU", "AZ": "AS",
+	"BA": "EU", "BB": "NA", "BD": "AS", "BE": "EU", "BF": "AF", "BG": "EU", "BH": "AS", "BI": "AF",
+	"BJ": "AF", "BL": "NA", "BM": "NA", "BN": "AS", "BO": "SA", "BQ": "NA", "BR": "SA", "BS": "NA",
+	"BT": "AS", "BV": "AN", "BW": "AF", "BY": "EU", "BZ": "NA", "CA": "NA", "CC": "AS", "CD": "AF",
+	"CF": "AF", "CG": "AF", "CH": "EU", "CI": "AF", "CK": "OC", "CL": "SA", "CM": "AF", "CN": "AS",
+	"CO": "SA", "CR": "NA", "CU": "NA", "CV": "AF", "CW": "NA", "CX": "OC", "CY": "EU", "CZ": "EU",
+	"DE": "EU", "DJ": "AF", "DK": "EU", "DM": "NA", "DO": "NA", "DZ": "AF", "EC": "SA", "EE": "EU",
+	"EG": "AF", "EH": "AF", "ER": "AF", "ES": "EU", "ET": "AF", "FI": "EU", "FJ": "OC", "FK": "SA",
+	"FM": "OC", "FO": "EU", "FR": "EU", "GA": "AF", "GB": "EU", "GD": "NA", "GE": "AS", "GF": "SA",
+	"GG": "EU", "GH": "AF", "GI": "EU", "GL": "NA", "GM": "AF", "GN": "AF", "GP": "NA", "GQ": "AF",
+	"GR": "EU", "GS": "AN", "GT": "NA", "GU": "OC", "GW": "AF", "GY": "SA", "HK": "AS", "HM": "AN",
+	"HN": "NA", "HR": "EU", "HT": "NA", "HU": "EU", "ID": "AS", "IE": "EU", "IL": "AS", "IM": "EU",
+	"IN": "AS", "IO": "AS", "IQ": "AS", "IR": "AS", "IS": "EU", "IT": "EU", "JE": "EU", "JM": "NA",
+	"JO": "AS", "JP": "AS", "KE": "AF", "KG": "AS", "KH": "AS", "KI": "OC", "KM": "AF", "KN": "NA",
+	"KP": "AS", "KR": "AS", "KW": "AS", "KY": "NA", "KZ": "AS", "LA": "AS", "LB": "AS", "LC": "NA",
+	"LI": "EU", "LK": "AS", "LR": "AF", "LS": "AF", "LT": "EU", "LU": "EU", "LV": "EU", "LY": "AF",
+	"MA": "AF", "MC": "EU", "MD": "EU", "ME": "EU", "MF": "NA", "MG": "AF", "MH": "OC", "MK": "EU",
+	"ML": "AF", "MM": "AS", "MN": "AS", "MO": "AS", "MP": "OC", "MQ": "NA", "MR": "AF", "MS": "NA",
+	"MT": "EU", "MU": "AF", "MV": "AS", "MW": "AF", "MX": "NA", "MY": "AS", "MZ": "AF", "NA": "AF",
+	"NC": "OC", "NE": "AF", "NF": "OC", "NG": "AF", "NI": "NA", "NL": "EU", "NO": "EU", "NP": "AS",
+	"NR": "OC", "NU": "OC", "NZ": "OC", "OM": "AS", "PA": "NA", "PE": "SA", "PF": "OC", "PG": "OC",
+	"PH": "AS", "PK": "AS", "PL": "EU", "PM": "NA", "PN": "OC", "PR": "NA", "PS": "AS", "PT": "EU",
+	"PW": "OC", "PY": "SA", "QA": "AS", "RE": "AF", "RO": "EU", "RS": "EU", "RU": "EU", "RW": "AF",
+	"SA": "AS", "SB": "OC", "SC": "AF", "SD": "AF", "SE": "EU", "SG": "AS", "SH": "AF", "SI": "EU",
+	"SJ": "EU", "SK": "EU", "SL": "AF", "SM": "EU", "SN": "AF", "SO": "AF", "SR": "SA", "SS": "AF",
+	"ST": "AF", "SV": "NA", "SX": "NA", "SY": "AS", "SZ": "AF", "TC": "NA", "TD": "AF", "TF": "AN",
+	"TG": "AF", "TH": "AS", "TJ": "AS", "TK": "OC", "TL": "OC", "TM": "AS", "TN": "AF", "TO": "OC",
+	"TR": "AS", "TT": "NA", "TV": "OC", "TW": "AS", "TZ": "AF", "UA": "EU", "UG": "AF", "UM": "OC",
+	"US": "NA", "UY": "SA", "UZ": "AS", "VA": "EU", "VC": "NA", "VE": "SA", "VG": "NA", "VI": "NA",
+	"VN": "AS", "VU": "OC", "WF": "OC", "WS": "OC", "XK": "EU", "YE": "AS", "YT": "AF", "ZA": "AF",
+	"ZM": "AF", "ZW": "AF",
+}
+
+// ContinentCountries returns the sorted country codes in the continent.
+func ContinentCountries(continent string) ([]string, error) {
+	continent = strings.ToUpper(continent)
+	var countries []string
+	for country, c := range countryContinents {
+		if c == continent {
+			countries = append(countries, country)
+		}
+	}
+	if len(countries) == 0 {
+		return nil, newError("unknown continent: ", continent)
+	}
+	sort.Strings(countries)
+	return countries, nil
+}
+
+// loadContinentGeoIPs returns GeoIPs of the countries in the continent found
+// in geoip.dat. Each country is a GeoIP on its own, so that its CIDR table is
+// shared with other rules referencing it. A reverse match has to be a single
+// GeoIP of all CIDRs of the continent instead.
+func loadContinentGeoIPs(geoLoader geodata.Loader, continent string, reverseMatch bool) ([]*router.GeoIP, error) {
+	countries, err := ContinentCountries(continent)
+	if err != nil {
+		return nil, err
+	}
+
+	var geoips []*router.GeoIP
+	var lastErr error
+	for _, country := range countries {
+		cidrs, err := geoLoader.LoadGeoIP(country)
+		if err != nil {
+			// Some territories, e.g. those in Antarctica, have no entries.
+			lastErr = err
+			continue
+		}
+		geoips = append(geoips, &router.GeoIP{
+			CountryCode: country,
+			Cidr:        cidrs,
+			Source:      []*router.GeoDataSource{{File: "geoip.dat", Code: country}},
+		})
+	}
+	if len(geoips) == 0 {
+		return nil, newError("no country of continent found: ", continent).Base(lastErr)
+	}
+	if !reverseMatch {
+		return geoips, nil
+	}
+
+	merged := &router.GeoIP{
+		CountryCode:  "CONTINENT_" + strings.ToUpper(continent),
+		ReverseMatch: true,
+	}
+	for _, geoip := range geoips {
+		merged.Cidr = append(merged.Cidr, geoip.Cidr...)
+		merged.Source = append(merged.Source, geoip.Source...)
+	}
+	return []*router.GeoIP{merged}, nil
+}
+
+// mappedContinentGeoIPs returns a GeoIP of the continent referring to entries
+// of its countries in geoip.dat, for the router to search them in place. Those
+// not found in the file are skipped by the router.
+func mappedContinentGeoIPs(continent string, reverseMatch bool) ([]*router.GeoIP, error) {
+	countries, err := ContinentCountries(continent)
+	if err != nil {
+		return nil, err
+	}
+
+	geoip := &router.GeoIP{
+		CountryCode:  "CONTINENT_" + strings.ToUpper(continent),
+		ReverseMatch: reverseMatch,
+	}
+	for _, country := range countries {
+		geoip.Source = append(geoip.Source, &router.GeoDataSource{File: "geoip.dat", Code: country})
+	}
+	return []*router.GeoIP{geoip}, nil
+}
@@ -2,32 +2,30 @@ package rule
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/v2fly/v2ray-core/v4/app/router"
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/infra/conf/cfgcommon"
+	"github.com/v2fly/v2ray-core/v4/infra/conf/cfgcommon/duration"
 )
 
 //go:generate go run github.com/v2fly/v2ray-core/v4/common/errors/errorgen
 
-func parseDomainRule(ctx context.Context, domain string) ([]*router.Domain, error) {
-	cfgEnv := cfgcommon.GetConfigureLoadingEnvironment(ctx)
-	geoLoader := cfgEnv.GetGeoLoader()
-
+// geoSiteSource returns the entry of a geodata file the domain rule loads
+// domains from, or nil if it is not such a rule.
+func geoSiteSource(domain string) (*router.GeoDataSource, error) {
 	if strings.HasPrefix(domain, "geosite:") {
 		list := domain[8:]
 		if len(list) == 0 {
 			return nil, newError("empty listname in rule: ", domain)
 		}
-		domains, err := geoLoader.LoadGeoSite(list)
-		if err != nil {
-			return nil, newError("failed to load geosite: ", list).Base(err)
-		}
-
-		return domains, nil
+		return &router.GeoDataSource{File: "geosite.dat", Code: list}, nil
 	}
 
 	isExtDatFile := 0
@@ -47,14 +45,30 @@ func parseDomainRule(ctx context.Context, domain string) ([]*router.Domain, erro
 		if len(kv) != 2 {
 			return nil, newError("invalid external resource: ", domain)
 		}
-		filename := kv[0]
-		list := kv[1]
-		domains, err := geoLoader.LoadGeoSiteWithAttr(filename, list)
-		if err != nil {
-			return nil, newError("failed to load external geosite: ", list, " from ", filename).Base(err)
-		}
+		return &router.GeoDataSource{File: kv[0], Code: kv[1]}, nil
+	}
 
-		return domains, nil
+	return nil, nil
+}
+
+func loadGeoSite(ctx context.Context, source *router.GeoDataSource) ([]*router.Domain, error) {
+	cfgEnv := cfgcommon.GetConfigureLoadingEnvironment(ctx)
+	geoLoader := cfgEnv.GetGeoLoader()
+
+	domains, err := geoLoader.LoadGeoSiteWithAttr(source.File, source.Code)
+	if err != nil {
+		return nil, newError("failed to load geosite: ", source.Code, " from ", source.File).Base(err)
+	}
+	return domains, nil
+}
+
+func parseDomainRule(ctx context.Context, domain string) ([]*router.Domain, error) {
+	source, err := geoSiteSource(domain)
+	if err != nil {
+		return nil, err
+	}
+	if source != nil {
+		return loadGeoSite(ctx, source)
 	}
 
 	domainRule := new(router.Domain)
@@ -112,9 +126,12 @@ func parseDomainRule(ctx context.Context, domain string) ([]*router.Domain, erro
 func toCidrList(ctx context.Context, ips cfgcommon.StringList) ([]*router.GeoIP, error) {
 	cfgEnv := cfgcommon.GetConfigureLoadingEnvironment(ctx)
 	geoLoader := cfgEnv.GetGeoLoader()
+	// Mapped entries are searched in place by the router.
+	mapped := cfgcommon.IsGeoDataMapped(ctx)
 
 	var geoipList []*router.GeoIP
 	var customCidrs []*router.CIDR
+	var reversedCustomCidrs []*router.CIDR
 
 	for _, ip := range ips {
 		if strings.HasPrefix(ip, "geoip:") {
@@ -127,15 +144,65 @@ func toCidrList(ctx context.Context, ips cfgcommon.StringList) ([]*router.GeoIP,
 			if len(country) == 0 {
 				return nil, newError("empty country name in rule")
 			}
-			geoip, err := geoLoader.LoadGeoIP(country)
-			if err != nil {
-				return nil, newError("failed to load geoip: ", country).Base(err)
+			if strings.HasPrefix(country, "continent:") {
+				var geoips []*router.GeoIP
+				var err error
+				if mapped {
+					geoips, err = mappedContinentGeoIPs(country[10:], isReverseMatch)
+				} else {
+					geoips, err = loadContinentGeoIPs(geoLoader, country[10:], isReverseMatch)
+				}
+				if err != nil {
+					return nil, newError("failed to load geoip: ", country).Base(err)
+				}
+				geoipList = append(geoipList, geoips...)
+				continue
+			}
+			var geoip []*router.CIDR
+			if !mapped {
+				var err error
+				geoip, err = geoLoader.LoadGeoIP(country)
+				if err != nil {
+					return nil, newError("failed to load geoip: ", country).Base(err)
+				}
 			}
 
 			geoipList = append(geoipList, &router.GeoIP{
 				CountryCode:  strings.ToUpper(country),
 				Cidr:         geoip,
 				ReverseMatch: isReverseMatch,
+				Source:       []*router.GeoDataSource{{File: "geoip.dat", Code: country}},
+			})
+
+			continue
+		}
+
+		if strings.HasPrefix(ip, "asn:") {
+			asn := ip[4:]
+			isReverseMatch := false
+			if strings.HasPrefix(asn, "!") {
+				asn = asn[1:]
+				isReverseMatch = true
+			}
+			asnNumber, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(asn), "AS"), 10, 32)
+			if err != nil || asnNumber == 0 {
+				return nil, newError("invalid ASN in rule: ", ip)
+			}
+			code := "AS" + strconv.FormatUint(asnNumber, 10)
+			var geoip []*router.CIDR
+			if !mapped {
+				geoip, err = geoLoader.LoadGeoIP(code)
+				if err != nil {
+					return nil, newError("failed to load prefixes of ", code).Base(err)
+				}
+			}
+
+			geoipList = append(geoipList, &router.GeoIP{
+				CountryCode:  code,
+				Cidr:         geoip,
+				ReverseMatch: isReverseMatch,
+				Asn:          uint32(asnNumber),
+				Source:       []*router.GeoDataSource{{File: "geoip.dat", Code: code}},
 			})
 
 			continue
@@ -170,25 +237,39 @@ func toCidrList(ctx context.Context, ips cfgcommon.StringList) ([]*router.GeoIP,
 				country = country[1:]
 				isReverseMatch = true
 			}
-			geoip, err := geoLoader.LoadIP(filename, country)
-			if err != nil {
-				return nil, newError("failed to load geoip: ", country, " from ", filename).Base(err)
+			var geoip []*router.CIDR
+			if !mapped {
+				var err error
+				geoip, err = geoLoader.LoadIP(filename, country)
+				if err != nil {
+					return nil, newError("failed to load geoip: ", country, " from ", filename).Base(err)
+				}
 			}
 
 			geoipList = append(geoipList, &router.GeoIP{
 				CountryCode:  strings.ToUpper(filename + "_" + country),
 				Cidr:         geoip,
 				ReverseMatch: isReverseMatch,
+				Source:       []*router.GeoDataSource{{File: filename, Code: country}},
 			})
 
 			continue
 		}
 
+		isReverseMatch := false
+		if strings.HasPrefix(ip, "!") {
+			ip = ip[1:]
+			isReverseMatch = true
+		}
 		ipRule, err := ParseIP(ip)
 		if err != nil {
 			return nil, newError("invalid IP: ", ip).Base(err)
 		}
-		customCidrs = append(customCidrs, ipRule)
+		if isReverseMatch {
+			reversedCustomCidrs = append(reversedCustomCidrs, ipRule)
+		} else {
+			customCidrs = append(customCidrs, ipRule)
+		}
 	}
 
 	if len(customCidrs) > 0 {
@@ -197,25 +278,274 @@ func toCidrList(ctx context.Context, ips cfgcommon.StringList) ([]*router.GeoIP,
 		})
 	}
 
+	// Reversed custom IPs are merged, so that they match IPs outside of all of them.
+	if len(reversedCustomCidrs) > 0 {
+		geoipList = append(geoipList, &router.GeoIP{
+			Cidr:         reversedCustomCidrs,
+			ReverseMatch: true,
+		})
+	}
+
 	return geoipList, nil
 }
 
+// parseRedirectTarget parses a redirect target of form "host:port", or "host"
+// to keep the original port.
+func parseRedirectTarget(s string) (*net.Endpoint, error) {
+	host, port := s, uint32(0)
+	if h, p, err := net.SplitHostPort(s); err == nil {
+		parsedPort, err := net.PortFromString(p)
+		if err != nil {
+			return nil, err
+		}
+		host, port = h, uint32(parsedPort)
+	}
+	if len(host) == 0 {
+		return nil, newError("empty host")
+	}
+	return &net.Endpoint{
+		Address: net.NewIPOrDomain(net.ParseAddress(host)),
+		Port:    port,
+	}, nil
+}
+
+// parseIPRange parses an IP range of form "from-to".
+func parseIPRange(s string) (*router.IPRange, error) {
+	ips := strings.SplitN(s, "-", 2)
+	if len(ips) != 2 {
+		return nil, newError("invalid IP range: ", s)
+	}
+	from := net.ParseAddress(strings.TrimSpace(ips[0]))
+	to := net.ParseAddress(strings.TrimSpace(ips[1]))
+	if from.Family().IsDomain() || to.Family().IsDomain() || from.Family() != to.Family() {
+		return nil, newError("invalid IP range: ", s)
+	}
+	return &router.IPRange{
+		From: []byte(from.IP()),
+		To:   []byte(to.IP()),
+	}, nil
+}
+
+type fieldRuleConfig struct {
+	RouterRule
+	Domain     *cfgcommon.StringList  `json:"domain"`
+	Domains    *cfgcommon.StringList  `json:"domains"`
+	IP         *cfgcommon.StringList  `json:"ip"`
+	IPRange    *cfgcommon.StringList  `json:"ipRange"`
+	Port       *cfgcommon.PortList    `json:"port"`
+	Network    *cfgcommon.NetworkList `json:"network"`
+	SourceIP   *cfgcommon.StringList  `json:"source"`
+	SourcePort *cfgcommon.PortList    `json:"sourcePort"`
+	User       *cfgcommon.StringList  `json:"user"`
+	InboundTag *cfgcommon.StringList  `json:"inboundTag"`
+	Protocols  *cfgcommon.StringList  `json:"protocol"`
+	Attributes string                 `json:"attrs"`
+
+	PortSet       *cfgcommon.StringList `json:"portSet"`
+	SourcePortSet *cfgcommon.StringList `json:"sourcePortSet"`
+	PortClass     *cfgcommon.StringList `json:"portClass"`
+
+	ReverseDomain    *cfgcommon.StringList `json:"reverseDomain"`
+	ReverseLookupTTL duration.Duration     `json:"reverseLookupTtl"`
+	DomainLabels     map[string]string     `json:"domainLabels"`
+	ProcessPath      *cfgcommon.StringList `json:"processPath"`
+	AnchorRegex      bool                  `json:"anchorRegex"`
+
+	TransportProtocol *cfgcommon.StringList `json:"transportProtocol"`
+	DomainSuffixPSL   *cfgcommon.StringList `json:"domainSuffixPSL"`
+	DomainFronting    string                `json:"domainFronting"`
+	IsIPQuery         string                `json:"isIpQuery"`
+	JA3               *cfgcommon.StringList `json:"ja3"`
+	SamplePercent     float32               `json:"samplePercent"`
+	SampleHashKey     string                `json:"sampleHashKey"`
+
+	NegateDomain     bool `json:"negateDomain"`
+	NegateIP         bool `json:"negateIp"`
+	NegatePort       bool `json:"negatePort"`
+	NegateNetwork    bool `json:"negateNetwork"`
+	NegateSourceIP   bool `json:"negateSource"`
+	NegateSourcePort bool `json:"negateSourcePort"`
+	NegateUser       bool `json:"negateUser"`
+	NegateInboundTag bool `json:"negateInboundTag"`
+	NegateProtocols  bool `json:"negateProtocol"`
+	NegateAttributes bool `json:"negateAttrs"`
+
+	OrGroups       []*fieldRuleConfig    `json:"orGroups"`
+	Schedule       *scheduleConfig       `json:"schedule"`
+	ConnectionRate *connectionRateConfig `json:"connectionRate"`
+	DNSResult      *dnsResultConfig      `json:"dnsResult"`
+
+	RuleTag          string            `json:"ruleTag"`
+	RuleTrafficStats bool              `json:"ruleTrafficStats"`
+	TOS              uint32            `json:"tos"`
+	DialTimeout      duration.Duration `json:"dialTimeout"`
+	ResolverTag      string            `json:"resolverTag"`
+	SetAttributes    map[string]string `json:"setAttrs"`
+	MirrorTag        string            `json:"mirrorTag"`
+	LogSamplingRate  *float32          `json:"logSamplingRate"`
+	RedirectTarget   string            `json:"redirectTarget"`
+	RateLimit        *rateLimitConfig  `json:"rateLimit"`
+}
+
+type scheduleWindowConfig struct {
+	Weekday string `json:"weekday"`
+	Time    string `json:"time"`
+}
+
+type scheduleConfig struct {
+	Timezone string                  `json:"timezone"`
+	Windows  []*scheduleWindowConfig `json:"windows"`
+}
+
+// transportProtocolNames maps names of transport protocols in stream settings
+// to those reported by inbounds.
+var transportProtocolNames = map[string]string{
+	"tcp":          "tcp",
+	"kcp":          "mkcp",
+	"mkcp":         "mkcp",
+	"ws":           "websocket",
+	"websocket":    "websocket",
+	"h2":           "http",
+	"http":         "http",
+	"ds":           "domainsocket",
+	"domainsocket": "domainsocket",
+	"quic":         "quic",
+	"gun":          "gun",
+	"grpc":         "gun",
+}
+
+var weekdayNames = map[string]uint32{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+func parseWeekday(s string) (uint32, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if day, found := weekdayNames[s]; found {
+		return day, nil
+	}
+	day, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || day > 6 {
+		return 0, newError("invalid weekday: ", s)
+	}
+	return uint32(day), nil
+}
+
+// parseTimeOfDay parses "hh:mm" into seconds since midnight.
+func parseTimeOfDay(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	if s == "24:00" {
+		return 24 * 3600, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, newError("invalid time of day: ", s).Base(err)
+	}
+	return uint32(t.Hour()*3600 + t.Minute()*60), nil
+}
+
+type connectionRateConfig struct {
+	Window    duration.Duration `json:"window"`
+	Threshold uint32            `json:"threshold"`
+}
+
+func (c *connectionRateConfig) Build() (*router.ConnectionRate, error) {
+	window := time.Duration(c.Window)
+	if window < time.Millisecond || window/time.Millisecond > math.MaxUint32 {
+		return nil, newError("invalid window: ", window)
+	}
+	if c.Threshold == 0 {
+		return nil, newError("threshold must be positive")
+	}
+	return &router.ConnectionRate{
+		Window:    uint32(window / time.Millisecond),
+		Threshold: c.Threshold,
+	}, nil
+}
+
+type dnsResultConfig struct {
+	MinRecords uint32            `json:"minRecords"`
+	MaxTTL     duration.Duration `json:"maxTtl"`
+}
+
+func (c *dnsResultConfig) Build() (*router.DnsResult, error) {
+	ttl := time.Duration(c.MaxTTL)
+	if ttl < 0 || ttl%time.Second != 0 || ttl/time.Second > math.MaxUint32 {
+		return nil, newError("invalid max TTL: ", ttl)
+	}
+	if c.MinRecords == 0 && ttl == 0 {
+		return nil, newError("neither minRecords nor maxTtl is specified")
+	}
+	return &router.DnsResult{
+		MinRecordCount: c.MinRecords,
+		MaxTtl:         uint32(ttl / time.Second),
+	}, nil
+}
+
+type rateLimitConfig struct {
+	Rate  uint64 `json:"rate"`
+	Burst uint64 `json:"burst"`
+}
+
+func (c *rateLimitConfig) Build() (*router.RateLimit, error) {
+	if c.Rate == 0 {
+		return nil, newError("rate must be positive")
+	}
+	return &router.RateLimit{
+		Rate:  c.Rate,
+		Burst: c.Burst,
+	}, nil
+}
+
+func (c *scheduleConfig) Build() (*router.Schedule, error) {
+	schedule := &router.Schedule{
+		Timezone: c.Timezone,
+	}
+	for _, w := range c.Windows {
+		window := &router.Schedule_Window{
+			WeekdayTo: 6,
+		}
+		if len(w.Weekday) > 0 {
+			days := strings.SplitN(w.Weekday, "-", 2)
+			from, err := parseWeekday(days[0])
+			if err != nil {
+				return nil, err
+			}
+			to := from
+			if len(days) == 2 {
+				to, err = parseWeekday(days[1])
+				if err != nil {
+					return nil, err
+				}
+			}
+			window.WeekdayFrom = from
+			window.WeekdayTo = to
+		}
+		if len(w.Time) > 0 {
+			times := strings.SplitN(w.Time, "-", 2)
+			if len(times) != 2 {
+				return nil, newError("invalid time range: ", w.Time)
+			}
+			begin, err := parseTimeOfDay(times[0])
+			if err != nil {
+				return nil, err
+			}
+			end, err := parseTimeOfDay(times[1])
+			if err != nil {
+				return nil, err
+			}
+			window.Begin = begin
+			window.End = end
+		}
+		schedule.Window = append(schedule.Window, window)
+	}
+	if len(schedule.Window) == 0 {
+		return nil, newError("empty schedule")
+	}
+	return schedule, nil
+}
+
 func parseFieldRule(ctx context.Context, msg json.RawMessage) (*router.RoutingRule, error) {
-	type RawFieldRule struct {
-		RouterRule
-		Domain     *cfgcommon.StringList  `json:"domain"`
-		Domains    *cfgcommon.StringList  `json:"domains"`
-		IP         *cfgcommon.StringList  `json:"ip"`
-		Port       *cfgcommon.PortList    `json:"port"`
-		Network    *cfgcommon.NetworkList `json:"network"`
-		SourceIP   *cfgcommon.StringList  `json:"source"`
-		SourcePort *cfgcommon.PortList    `json:"sourcePort"`
-		User       *cfgcommon.StringList  `json:"user"`
-		InboundTag *cfgcommon.StringList  `json:"inboundTag"`
-		Protocols  *cfgcommon.StringList  `json:"protocol"`
-		Attributes string                 `json:"attrs"`
-	}
-	rawFieldRule := new(RawFieldRule)
+	rawFieldRule := new(fieldRuleConfig)
 	err := json.Unmarshal(msg, rawFieldRule)
 	if err != nil {
 		return nil, err
@@ -235,81 +565,286 @@ func parseFieldRule(ctx context.Context, msg json.RawMessage) (*router.RoutingRu
 		return nil, newError("neither outboundTag nor balancerTag is specified in routing rule")
 	}
 
-	if rawFieldRule.DomainMatcher != "" {
-		rule.DomainMatcher = rawFieldRule.DomainMatcher
+	if err := rawFieldRule.build(ctx, rule); err != nil {
+		return nil, err
 	}
 
-	if rawFieldRule.Domain != nil {
-		for _, domain := range *rawFieldRule.Domain {
-			rules, err := parseDomainRule(ctx, domain)
-			if err != nil {
-				return nil, newError("failed to parse domain rule: ", domain).Base(err)
-			}
-			rule.Domain = append(rule.Domain, rules...)
+	rule.RuleTag = rawFieldRule.RuleTag
+	rule.RuleTrafficStats = rawFieldRule.RuleTrafficStats
+	if rawFieldRule.TOS > 0xff {
+		return nil, newError("invalid TOS value in routing rule: ", rawFieldRule.TOS)
+	}
+	rule.Tos = rawFieldRule.TOS
+	dialTimeout := time.Duration(rawFieldRule.DialTimeout)
+	if dialTimeout < 0 || dialTimeout/time.Millisecond > math.MaxUint32 {
+		return nil, newError("invalid dial timeout in routing rule: ", dialTimeout)
+	}
+	rule.DialTimeout = uint32(dialTimeout / time.Millisecond)
+	rule.ResolverTag = rawFieldRule.ResolverTag
+	for key := range rawFieldRule.SetAttributes {
+		if key == "" {
+			return nil, newError("empty attribute key in routing rule")
+		}
+	}
+	rule.SetAttributes = rawFieldRule.SetAttributes
+	rule.MirrorTag = rawFieldRule.MirrorTag
+	if len(rawFieldRule.RedirectTarget) > 0 {
+		target, err := parseRedirectTarget(rawFieldRule.RedirectTarget)
+		if err != nil {
+			return nil, newError("invalid redirect target in routing rule: ", rawFieldRule.RedirectTarget).Base(err)
+		}
+		rule.RedirectTarget = target
+	}
+	if rawFieldRule.RateLimit != nil {
+		limit, err := rawFieldRule.RateLimit.Build()
+		if err != nil {
+			return nil, newError("invalid rate limit in routing rule").Base(err)
+		}
+		rule.RateLimit = limit
+	}
+	if rate := rawFieldRule.LogSamplingRate; rate != nil {
+		if *rate < 0 || *rate > 1 {
+			return nil, newError("invalid log sampling rate in routing rule: ", *rate)
 		}
+		rule.LogSampling = &router.LogSampling{Rate: *rate}
 	}
 
-	if rawFieldRule.Domains != nil {
-		for _, domain := range *rawFieldRule.Domains {
-			rules, err := parseDomainRule(ctx, domain)
-			if err != nil {
-				return nil, newError("failed to parse domain rule: ", domain).Base(err)
-			}
-			rule.Domain = append(rule.Domain, rules...)
+	return rule, nil
+}
+
+// build fills the matching conditions of c into rule.
+func (c *fieldRuleConfig) build(ctx context.Context, rule *router.RoutingRule) error {
+	if c.DomainMatcher != "" {
+		rule.DomainMatcher = c.DomainMatcher
+	}
+
+	rule.AnchorRegex = c.AnchorRegex
+
+	for _, list := range []*cfgcommon.StringList{c.Domain, c.Domains} {
+		if list == nil {
+			continue
+		}
+		domains, sites, err := parseDomainRules(ctx, *list, c.DomainLabels)
+		if err != nil {
+			return err
+		}
+		rule.Domain = append(rule.Domain, domains...)
+		rule.Geosite = append(rule.Geosite, sites...)
+	}
+
+	for domain := range c.DomainLabels {
+		if !containsString(c.Domain, domain) && !containsString(c.Domains, domain) {
+			return newError("label of domain not in rule: ", domain)
 		}
 	}
 
-	if rawFieldRule.IP != nil {
-		geoipList, err := toCidrList(ctx, *rawFieldRule.IP)
+	if c.ReverseDomain != nil {
+		domains, sites, err := parseDomainRules(ctx, *c.ReverseDomain, nil)
 		if err != nil {
-			return nil, err
+			return newError("failed to parse reverse domain rules").Base(err)
+		}
+		rule.ReverseDomain = domains
+		rule.ReverseGeosite = sites
+	}
+	ttl := time.Duration(c.ReverseLookupTTL)
+	if ttl < 0 || ttl/time.Second > math.MaxUint32 {
+		return newError("invalid reverse lookup TTL in routing rule: ", ttl)
+	}
+	rule.ReverseLookupTtl = uint32(ttl / time.Second)
+
+	if c.IP != nil {
+		geoipList, err := toCidrList(ctx, *c.IP)
+		if err != nil {
+			return err
 		}
 		rule.Geoip = geoipList
 	}
 
-	if rawFieldRule.Port != nil {
-		rule.PortList = rawFieldRule.Port.Build()
+	if c.IPRange != nil {
+		for _, r := range *c.IPRange {
+			ipRange, err := parseIPRange(r)
+			if err != nil {
+				return err
+			}
+			rule.IpRange = append(rule.IpRange, ipRange)
+		}
 	}
 
-	if rawFieldRule.Network != nil {
-		rule.Networks = rawFieldRule.Network.Build()
+	if c.Port != nil {
+		rule.PortList = c.Port.Build()
 	}
 
-	if rawFieldRule.SourceIP != nil {
-		geoipList, err := toCidrList(ctx, *rawFieldRule.SourceIP)
+	if c.PortSet != nil {
+		rule.PortSetName = append(rule.PortSetName, *c.PortSet...)
+	}
+
+	if c.PortClass != nil {
+		for _, class := range *c.PortClass {
+			switch strings.ToLower(class) {
+			case "privileged":
+				rule.PortClass = append(rule.PortClass, router.RoutingRule_Privileged)
+			case "registered":
+				rule.PortClass = append(rule.PortClass, router.RoutingRule_Registered)
+			case "ephemeral":
+				rule.PortClass = append(rule.PortClass, router.RoutingRule_Ephemeral)
+			default:
+				return newError("unknown port class: ", class)
+			}
+		}
+	}
+
+	if c.Network != nil {
+		rule.Networks = c.Network.Build()
+	}
+
+	if c.SourceIP != nil {
+		geoipList, err := toCidrList(ctx, *c.SourceIP)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		rule.SourceGeoip = geoipList
 	}
 
-	if rawFieldRule.SourcePort != nil {
-		rule.SourcePortList = rawFieldRule.SourcePort.Build()
+	if c.SourcePort != nil {
+		rule.SourcePortList = c.SourcePort.Build()
+	}
+
+	if c.SourcePortSet != nil {
+		rule.SourcePortSetName = append(rule.SourcePortSetName, *c.SourcePortSet...)
 	}
 
-	if rawFieldRule.User != nil {
-		for _, s := range *rawFieldRule.User {
+	if c.User != nil {
+		for _, s := range *c.User {
 			rule.UserEmail = append(rule.UserEmail, s)
 		}
 	}
 
-	if rawFieldRule.InboundTag != nil {
-		for _, s := range *rawFieldRule.InboundTag {
+	if c.DomainSuffixPSL != nil {
+		rule.DomainSuffixPsl = append(rule.DomainSuffixPsl, *c.DomainSuffixPSL...)
+	}
+
+	if c.ProcessPath != nil {
+		rule.ProcessPath = append(rule.ProcessPath, *c.ProcessPath...)
+	}
+
+	if c.InboundTag != nil {
+		for _, s := range *c.InboundTag {
 			rule.InboundTag = append(rule.InboundTag, s)
 		}
 	}
 
-	if rawFieldRule.Protocols != nil {
-		for _, s := range *rawFieldRule.Protocols {
+	if c.TransportProtocol != nil {
+		for _, s := range *c.TransportProtocol {
+			name, found := transportProtocolNames[strings.ToLower(s)]
+			if !found {
+				return newError("unknown transport protocol: ", s)
+			}
+			rule.TransportProtocol = append(rule.TransportProtocol, name)
+		}
+	}
+
+	if c.Protocols != nil {
+		for _, s := range *c.Protocols {
 			rule.Protocol = append(rule.Protocol, s)
 		}
 	}
 
-	if len(rawFieldRule.Attributes) > 0 {
-		rule.Attributes = rawFieldRule.Attributes
+	if len(c.Attributes) > 0 {
+		rule.Attributes = c.Attributes
 	}
 
-	return rule, nil
+	switch strings.ToLower(c.IsIPQuery) {
+	case "", "any":
+		rule.IsIpQuery = router.RoutingRule_Any
+	case "iponly":
+		rule.IsIpQuery = router.RoutingRule_IpOnly
+	case "domainonly":
+		rule.IsIpQuery = router.RoutingRule_DomainOnly
+	default:
+		return newError("unknown IP query kind: ", c.IsIPQuery)
+	}
+
+	switch strings.ToLower(c.DomainFronting) {
+	case "", "any":
+		rule.DomainFronting = router.DomainFronting_Any
+	case "matching":
+		rule.DomainFronting = router.DomainFronting_Matching
+	case "mismatching":
+		rule.DomainFronting = router.DomainFronting_Mismatching
+	default:
+		return newError("unknown domain fronting mode: ", c.DomainFronting)
+	}
+
+	if c.SamplePercent < 0 || c.SamplePercent > 100 {
+		return newError("invalid sample percent: ", c.SamplePercent)
+	}
+	rule.SamplePercent = c.SamplePercent
+	switch strings.ToLower(c.SampleHashKey) {
+	case "", "random":
+		rule.SampleHashKey = router.RoutingRule_Random
+	case "source", "sourceip":
+		rule.SampleHashKey = router.RoutingRule_SourceIp
+	case "user":
+		rule.SampleHashKey = router.RoutingRule_User
+	case "target":
+		rule.SampleHashKey = router.RoutingRule_Target
+	default:
+		return newError("unknown sample hash key: ", c.SampleHashKey)
+	}
+
+	if c.JA3 != nil {
+		for _, hash := range *c.JA3 {
+			if _, err := hex.DecodeString(hash); err != nil || len(hash) != 32 {
+				return newError("invalid JA3 fingerprint: ", hash)
+			}
+			rule.Ja3 = append(rule.Ja3, strings.ToLower(hash))
+		}
+	}
+
+	rule.NegateDomain = c.NegateDomain
+	rule.NegateIp = c.NegateIP
+	rule.NegatePort = c.NegatePort
+	rule.NegateNetwork = c.NegateNetwork
+	rule.NegateSourceIp = c.NegateSourceIP
+	rule.NegateSourcePort = c.NegateSourcePort
+	rule.NegateUserEmail = c.NegateUser
+	rule.NegateInboundTag = c.NegateInboundTag
+	rule.NegateProtocol = c.NegateProtocols
+	rule.NegateAttributes = c.NegateAttributes
+
+	if c.Schedule != nil {
+		schedule, err := c.Schedule.Build()
+		if err != nil {
+			return newError("failed to parse schedule").Base(err)
+		}
+		rule.Schedule = schedule
+	}
+
+	if c.ConnectionRate != nil {
+		rate, err := c.ConnectionRate.Build()
+		if err != nil {
+			return newError("failed to parse connection rate").Base(err)
+		}
+		rule.ConnectionRate = rate
+	}
+
+	if c.DNSResult != nil {
+		result, err := c.DNSResult.Build()
+		if err != nil {
+			return newError("failed to parse DNS result").Base(err)
+		}
+		rule.DnsResult = result
+	}
+
+	for _, group := range c.OrGroups {
+		groupRule := new(router.RoutingRule)
+		if err := group.build(ctx, groupRule); err != nil {
+			return newError("failed to parse condition group").Base(err)
+		}
+		rule.OrGroups = append(rule.OrGroups, groupRule)
+	}
+
+	return nil
 }
 
 func ParseRule(ctx context.Context, msg json.RawMessage) (*router.RoutingRule, error) {
@@ -377,6 +912,73 @@ func ParseIP(s string) (*router.CIDR, error) {
 	}
 }
 
+// parseDomainRules parses domain rules into domains and geosite entries, which
+// are kept apart so that they can be loaded again when geodata is reloaded, or
+// searched in place if geodata is mapped. Domains of rules with a label in
+// labels are labeled.
+func parseDomainRules(ctx context.Context, list cfgcommon.StringList, labels map[string]string) ([]*router.Domain, []*router.GeoSite, error) {
+	var domains []*router.Domain
+	var sites []*router.GeoSite
+	for _, domain := range list {
+		source, err := geoSiteSource(domain)
+		if err != nil {
+			return nil, nil, newError("failed to parse domain rule: ", domain).Base(err)
+		}
+		if source != nil {
+			site := &router.GeoSite{
+				Source: []*router.GeoDataSource{source},
+				Label:  labels[domain],
+			}
+			// Mapped entries are searched in place by the router.
+			if !cfgcommon.IsGeoDataMapped(ctx) {
+				domains, err := loadGeoSite(ctx, source)
+				if err != nil {
+					return nil, nil, newError("failed to parse domain rule: ", domain).Base(err)
+				}
+				site.Domain = labelDomains(domains, site.Label)
+			}
+			sites = append(sites, site)
+			continue
+		}
+		rules, err := parseDomainRule(ctx, domain)
+		if err != nil {
+			return nil, nil, newError("failed to parse domain rule: ", domain).Base(err)
+		}
+		domains = append(domains, labelDomains(rules, labels[domain])...)
+	}
+	return domains, sites, nil
+}
+
+// labelDomains returns copies of domains with the label. Domains loaded from
+// geosite files may be shared, so they are not modified in place.
+func labelDomains(domains []*router.Domain, label string) []*router.Domain {
+	if len(label) == 0 {
+		return domains
+	}
+	labeled := make([]*router.Domain, len(domains))
+	for i, d := range domains {
+		labeled[i] = &router.Domain{
+			Type:      d.Type,
+			Value:     d.Value,
+			Attribute: d.Attribute,
+			Label:     label,
+		}
+	}
+	return labeled
+}
+
+func containsString(list *cfgcommon.StringList, s string) bool {
+	if list == nil {
+		return false
+	}
+	for _, item := range *list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 func ParseDomainRule(ctx context.Context, domain string) ([]*router.Domain, error) {
 	return parseDomainRule(ctx, domain)
 }
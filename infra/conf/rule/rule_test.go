@@ -6,8 +6,13 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/v2fly/v2ray-core/v4/app/router"
 	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/common/platform"
 	"github.com/v2fly/v2ray-core/v4/common/platform/filesystem"
@@ -64,3 +69,257 @@ func TestToCidrList(t *testing.T) {
 		t.Fatalf("Failed to parse geoip list, got %s", err)
 	}
 }
+
+func TestContinentCountries(t *testing.T) {
+	testCases := []struct {
+		continent string
+		includes  []string
+		excludes  []string
+	}{
+		{
+			continent: "eu",
+			includes:  []string{"DE", "FR", "GB", "RU"},
+			excludes:  []string{"US", "TR"},
+		},
+		{
+			continent: "NA",
+			includes:  []string{"CA", "MX", "US"},
+			excludes:  []string{"BR", "NA"},
+		},
+		{
+			continent: "oc",
+			includes:  []string{"AS", "AU", "NZ"},
+			excludes:  []string{"ID"},
+		},
+	}
+	for _, tc := range testCases {
+		countries, err := rule.ContinentCountries(tc.continent)
+		common.Must(err)
+		if !sort.StringsAreSorted(countries) {
+			t.Error("expect sorted countries, but got ", countries)
+		}
+		for _, country := range tc.includes {
+			if i := sort.SearchStrings(countries, country); i == len(countries) || countries[i] != country {
+				t.Error("expect ", country, " in continent ", tc.continent)
+			}
+		}
+		for _, country := range tc.excludes {
+			if i := sort.SearchStrings(countries, country); i < len(countries) && countries[i] == country {
+				t.Error("expect ", country, " not in continent ", tc.continent)
+			}
+		}
+	}
+
+	// Each country is in exactly one continent.
+	seen := make(map[string]bool)
+	for _, continent := range []string{"AF", "AN", "AS", "EU", "NA", "OC", "SA"} {
+		countries, err := rule.ContinentCountries(continent)
+		common.Must(err)
+		for _, country := range countries {
+			if seen[country] {
+				t.Error("duplicate country: ", country)
+			}
+			seen[country] = true
+		}
+	}
+	if len(seen) != 250 {
+		t.Error("expect 250 countries, but got ", len(seen))
+	}
+
+	if _, err := rule.ContinentCountries("XX"); err == nil {
+		t.Error("expect error on unknown continent")
+	}
+}
+
+// asnLoader serves a synthetic ASN table as entries of geoip.dat.
+type asnLoader struct {
+	geodata.Loader
+}
+
+func (asnLoader) LoadGeoIP(country string) ([]*router.CIDR, error) {
+	switch country {
+	case "AS15169":
+		return []*router.CIDR{{Ip: []byte{8, 8, 8, 0}, Prefix: 24}}, nil
+	case "AS13335":
+		return []*router.CIDR{{Ip: []byte{1, 1, 1, 0}, Prefix: 24}}, nil
+	default:
+		return nil, errors.New("country not found: " + country)
+	}
+}
+
+func TestToCidrListASN(t *testing.T) {
+	cfgctx := cfgcommon.NewConfigureLoadingContext(context.Background())
+	cfgcommon.SetGeoDataLoader(cfgctx, asnLoader{})
+
+	geoips, err := rule.ToCidrList(cfgctx, cfgcommon.StringList{"asn:15169", "asn:!AS13335"})
+	common.Must(err)
+	expected := []*router.GeoIP{
+		{
+			CountryCode: "AS15169",
+			Cidr:        []*router.CIDR{{Ip: []byte{8, 8, 8, 0}, Prefix: 24}},
+			Asn:         15169,
+			Source:      []*router.GeoDataSource{{File: "geoip.dat", Code: "AS15169"}},
+		},
+		{
+			CountryCode:  "AS13335",
+			Cidr:         []*router.CIDR{{Ip: []byte{1, 1, 1, 0}, Prefix: 24}},
+			ReverseMatch: true,
+			Asn:          13335,
+			Source:       []*router.GeoDataSource{{File: "geoip.dat", Code: "AS13335"}},
+		},
+	}
+	if r := cmp.Diff(geoips, expected, protocmp.Transform()); r != "" {
+		t.Error(r)
+	}
+
+	for _, ips := range []string{"asn:64512", "asn:0", "asn:ASX"} {
+		if _, err := rule.ToCidrList(cfgctx, cfgcommon.StringList{ips}); err == nil {
+			t.Error("expect error for ", ips)
+		}
+	}
+}
+
+func TestToCidrListContinent(t *testing.T) {
+	common.Must(filesystem.CopyFile(platform.GetAssetLocation("geoiptestrouter.dat"), platform.GetAssetLocation("geoip.dat")))
+
+	cfgctx := cfgcommon.NewConfigureLoadingContext(context.Background())
+	loader, err := geodata.GetGeoDataLoader("standard")
+	common.Must(err)
+	cfgcommon.SetGeoDataLoader(cfgctx, loader)
+
+	geoips, err := rule.ToCidrList(cfgctx, cfgcommon.StringList{"geoip:continent:na"})
+	common.Must(err)
+	countries := make(map[string]bool)
+	cidrs := 0
+	for _, geoip := range geoips {
+		if geoip.ReverseMatch || len(geoip.Cidr) == 0 {
+			t.Error("unexpected GeoIP of country ", geoip.CountryCode)
+		}
+		countries[geoip.CountryCode] = true
+		cidrs += len(geoip.Cidr)
+	}
+	if !countries["US"] || !countries["CA"] || countries["CN"] {
+		t.Error("unexpected countries of continent NA: ", countries)
+	}
+
+	geoips, err = rule.ToCidrList(cfgctx, cfgcommon.StringList{"geoip:!continent:na"})
+	common.Must(err)
+	if len(geoips) != 1 || !geoips[0].ReverseMatch || len(geoips[0].Cidr) != cidrs {
+		t.Error("expect reverse match of all CIDRs of continent NA, but got ", geoips)
+	}
+	// All countries are reloaded into the merged GeoIP.
+	if len(geoips) == 1 && len(geoips[0].Source) != len(countries) {
+		t.Error("expect sources of all ", len(countries), " countries, but got ", geoips[0].Source)
+	}
+
+	if _, err := rule.ToCidrList(cfgctx, cfgcommon.StringList{"geoip:continent:xx"}); err == nil {
+		t.Error("expect error on unknown continent")
+	}
+}
+
+// siteLoader serves a synthetic list of each file as entries of geosite files.
+type siteLoader struct {
+	geodata.Loader
+}
+
+func (l siteLoader) LoadGeoSite(list string) ([]*router.Domain, error) {
+	return l.LoadGeoSiteWithAttr("geosite.dat", list)
+}
+
+func (siteLoader) LoadGeoSiteWithAttr(file string, siteWithAttr string) ([]*router.Domain, error) {
+	return []*router.Domain{{Type: router.Domain_Full, Value: siteWithAttr + "." + file}}, nil
+}
+
+func TestParseRuleGeoSite(t *testing.T) {
+	cfgctx := cfgcommon.NewConfigureLoadingContext(context.Background())
+	cfgcommon.SetGeoDataLoader(cfgctx, siteLoader{})
+
+	r, err := rule.ParseRule(cfgctx, []byte(`{
+		"type": "field",
+		"outboundTag": "direct",
+		"domain": ["geosite:cn", "full:v2fly.org"],
+		"domains": ["ext:sites.dat:ads@x"],
+		"domainLabels": {"geosite:cn": "cn"},
+		"reverseDomain": ["ext-domain:sites.dat:cdn"]
+	}`))
+	common.Must(err)
+
+	expected := &router.RoutingRule{
+		TargetTag: &router.RoutingRule_Tag{Tag: "direct"},
+		Domain:    []*router.Domain{{Type: router.Domain_Full, Value: "v2fly.org"}},
+		Geosite: []*router.GeoSite{
+			{
+				Domain: []*router.Domain{{Type: router.Domain_Full, Value: "cn.geosite.dat", Label: "cn"}},
+				Source: []*router.GeoDataSource{{File: "geosite.dat", Code: "cn"}},
+				Label:  "cn",
+			},
+			{
+				Domain: []*router.Domain{{Type: router.Domain_Full, Value: "ads@x.sites.dat"}},
+				Source: []*router.GeoDataSource{{File: "sites.dat", Code: "ads@x"}},
+			},
+		},
+		ReverseGeosite: []*router.GeoSite{
+			{
+				Domain: []*router.Domain{{Type: router.Domain_Full, Value: "cdn.sites.dat"}},
+				Source: []*router.GeoDataSource{{File: "sites.dat", Code: "cdn"}},
+			},
+		},
+	}
+	if r := cmp.Diff(r, expected, protocmp.Transform()); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestParseRuleMappedGeoData(t *testing.T) {
+	cfgctx := cfgcommon.NewConfigureLoadingContext(context.Background())
+	cfgcommon.SetGeoDataLoader(cfgctx, siteLoader{})
+	cfgcommon.SetGeoDataMapped(cfgctx, true)
+
+	r, err := rule.ParseRule(cfgctx, []byte(`{
+		"type": "field",
+		"outboundTag": "direct",
+		"domain": ["geosite:cn", "full:v2fly.org"],
+		"domainLabels": {"geosite:cn": "cn"},
+		"ip": ["geoip:!cn", "ext:other.dat:xa", "asn:15169", "10.0.0.0/8"],
+		"source": ["geoip:continent:oc"]
+	}`))
+	common.Must(err)
+
+	countries, err := rule.ContinentCountries("oc")
+	common.Must(err)
+	continent := &router.GeoIP{CountryCode: "CONTINENT_OC"}
+	for _, country := range countries {
+		continent.Source = append(continent.Source, &router.GeoDataSource{File: "geoip.dat", Code: country})
+	}
+
+	// Entries of geodata files refer to their sources only.
+	expected := &router.RoutingRule{
+		TargetTag: &router.RoutingRule_Tag{Tag: "direct"},
+		Domain:    []*router.Domain{{Type: router.Domain_Full, Value: "v2fly.org"}},
+		Geosite: []*router.GeoSite{{
+			Source: []*router.GeoDataSource{{File: "geosite.dat", Code: "cn"}},
+			Label:  "cn",
+		}},
+		Geoip: []*router.GeoIP{
+			{
+				CountryCode:  "CN",
+				ReverseMatch: true,
+				Source:       []*router.GeoDataSource{{File: "geoip.dat", Code: "cn"}},
+			},
+			{
+				CountryCode: "OTHER.DAT_XA",
+				Source:      []*router.GeoDataSource{{File: "other.dat", Code: "xa"}},
+			},
+			{
+				CountryCode: "AS15169",
+				Asn:         15169,
+				Source:      []*router.GeoDataSource{{File: "geoip.dat", Code: "AS15169"}},
+			},
+			{Cidr: []*router.CIDR{{Ip: []byte{10, 0, 0, 0}, Prefix: 8}}},
+		},
+		SourceGeoip: []*router.GeoIP{continent},
+	}
+	if r := cmp.Diff(r, expected, protocmp.Transform()); r != "" {
+		t.Error(r)
+	}
+}
@@ -3,14 +3,20 @@ package conf
 import (
 	"encoding/base64"
 	"encoding/json"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 
+	"github.com/v2fly/v2ray-core/v4/app/router"
 	"github.com/v2fly/v2ray-core/v4/common/platform/filesystem"
 	"github.com/v2fly/v2ray-core/v4/common/protocol"
 	"github.com/v2fly/v2ray-core/v4/common/serial"
 	"github.com/v2fly/v2ray-core/v4/infra/conf/cfgcommon"
+	"github.com/v2fly/v2ray-core/v4/infra/conf/cfgcommon/duration"
+	rule2 "github.com/v2fly/v2ray-core/v4/infra/conf/rule"
 	"github.com/v2fly/v2ray-core/v4/transport/internet"
 	"github.com/v2fly/v2ray-core/v4/transport/internet/domainsocket"
 	httpheader "github.com/v2fly/v2ray-core/v4/transport/internet/headers/http"
@@ -174,12 +180,14 @@ type HTTPConfig struct {
 	Path    string                           `json:"path"`
 	Method  string                           `json:"method"`
 	Headers map[string]*cfgcommon.StringList `json:"headers"`
+	H2C     bool                             `json:"h2c"`
 }
 
 // Build implements Buildable.
 func (c *HTTPConfig) Build() (proto.Message, error) {
 	config := &http.Config{
 		Path: c.Path,
+		H2C:  c.H2C,
 	}
 	if c.Host != nil {
 		config.Host = []string(*c.Host)
@@ -208,12 +216,15 @@ type QUICConfig struct {
 	Header   json.RawMessage `json:"header"`
 	Security string          `json:"security"`
 	Key      string          `json:"key"`
+
+	DisablePathMTUDiscovery bool `json:"disablePathMTUDiscovery"`
 }
 
 // Build implements Buildable.
 func (c *QUICConfig) Build() (proto.Message, error) {
 	config := &quic.Config{
-		Key: c.Key,
+		Key:                     c.Key,
+		DisablePathMtuDiscovery: c.DisablePathMTUDiscovery,
 	}
 
 	if len(c.Header) > 0 {
@@ -246,18 +257,31 @@ func (c *QUICConfig) Build() (proto.Message, error) {
 }
 
 type DomainSocketConfig struct {
-	Path     string `json:"path"`
-	Abstract bool   `json:"abstract"`
-	Padding  bool   `json:"padding"`
+	Path        string  `json:"path"`
+	Abstract    bool    `json:"abstract"`
+	Padding     bool    `json:"padding"`
+	Permissions string  `json:"permissions"`
+	UID         *uint32 `json:"uid"`
+	GID         *uint32 `json:"gid"`
 }
 
 // Build implements Buildable.
 func (c *DomainSocketConfig) Build() (proto.Message, error) {
-	return &domainsocket.Config{
+	config := &domainsocket.Config{
 		Path:     c.Path,
 		Abstract: c.Abstract,
 		Padding:  c.Padding,
-	}, nil
+		Uid:      c.UID,
+		Gid:      c.GID,
+	}
+	if len(c.Permissions) > 0 {
+		mode, err := strconv.ParseUint(c.Permissions, 8, 32)
+		if err != nil || mode > 0o7777 {
+			return nil, newError("invalid domain socket permissions: ", c.Permissions)
+		}
+		config.Permissions = uint32(mode)
+	}
+	return config, nil
 }
 
 func readFileOrString(f string, s []string) ([]byte, error) {
@@ -381,11 +405,56 @@ func (p TransportProtocol) Build() (string, error) {
 }
 
 type SocketConfig struct {
-	Mark                 int32  `json:"mark"`
-	TFO                  *bool  `json:"tcpFastOpen"`
-	TProxy               string `json:"tproxy"`
-	AcceptProxyProtocol  bool   `json:"acceptProxyProtocol"`
-	TCPKeepAliveInterval int32  `json:"tcpKeepAliveInterval"`
+	Mark                      int32  `json:"mark"`
+	InboundMark               int32  `json:"inboundMark"`
+	OutboundMark              int32  `json:"outboundMark"`
+	TFO                       *bool  `json:"tcpFastOpen"`
+	TFOQueueLength            int32  `json:"tcpFastOpenQueueLength"`
+	TProxy                    string `json:"tproxy"`
+	AcceptProxyProtocol       bool   `json:"acceptProxyProtocol"`
+	TCPKeepAliveInterval      int32  `json:"tcpKeepAliveInterval"`
+	TCPKeepAliveIdle          int32  `json:"tcpKeepAliveIdle"`
+	RxBufSize                 int32  `json:"rxBufSize"`
+	TxBufSize                 int32  `json:"txBufSize"`
+	BindToDevice              string `json:"bindToDevice"`
+	TCPCongestion             string `json:"tcpCongestion"`
+	TCPUserTimeout            int32  `json:"tcpUserTimeout"`
+	HappyEyeballs             uint32 `json:"happyEyeballs"`
+	DialAddressFamily         string `json:"dialAddressFamily"`
+	SendProxyProtocol         uint32 `json:"sendProxyProtocol"`
+	AllowMissingProxyProtocol bool   `json:"allowMissingProxyProtocol"`
+	TOS                       uint32 `json:"tos"`
+	MPTCP                     bool   `json:"tcpMptcp"`
+	UDPGSO                    bool   `json:"udpGSO"`
+	TCPNoDelay                *bool  `json:"tcpNoDelay"`
+	ReusePort                 bool   `json:"reusePort"`
+	IdleTimeout               uint32 `json:"idleTimeout"`
+	PMTUDiscovery             string `json:"pmtuDiscovery"`
+	Backlog                   int32  `json:"backlog"`
+	BindInterfaceIndex        uint32 `json:"bindInterfaceIndex"`
+	ResolverTag               string `json:"resolverTag"`
+	SoPriority                int32  `json:"soPriority"`
+	V6Only                    *bool  `json:"v6only"`
+	TCPMaxSeg                 int32  `json:"tcpMaxSeg"`
+
+	HappyEyeballsResolutionDelay uint32 `json:"happyEyeballsResolutionDelay"`
+	HappyEyeballsPreferIPv4      bool   `json:"happyEyeballsPreferIPv4"`
+
+	SourceAddress *cfgcommon.StringList `json:"sourceAddress"`
+	SourceSubnet  string                `json:"sourceSubnet"`
+}
+
+// toSocketOptionState converts an optional boolean socket option, leaving it
+// at the default if not set.
+func toSocketOptionState(b *bool) internet.SocketOptionState {
+	switch {
+	case b == nil:
+		return internet.SocketOptionState_Default
+	case *b:
+		return internet.SocketOptionState_Enable
+	default:
+		return internet.SocketOptionState_Disable
+	}
 }
 
 // Build implements Buildable.
@@ -408,12 +477,136 @@ func (c *SocketConfig) Build() (*internet.SocketConfig, error) {
 		tproxy = internet.SocketConfig_Off
 	}
 
+	var dialAddressFamily internet.DialAddressFamily
+	switch strings.ToLower(c.DialAddressFamily) {
+	case "", "asis":
+		dialAddressFamily = internet.DialAddressFamily_AsIs
+	case "ipv4", "ipv4only":
+		dialAddressFamily = internet.DialAddressFamily_IPv4Only
+	case "ipv6", "ipv6only":
+		dialAddressFamily = internet.DialAddressFamily_IPv6Only
+	default:
+		return nil, newError("unknown dial address family: ", c.DialAddressFamily)
+	}
+
+	var pmtuDiscovery internet.SocketConfig_PMTUDiscovery
+	switch strings.ToLower(c.PMTUDiscovery) {
+	case "", "default":
+		pmtuDiscovery = internet.SocketConfig_Default
+	case "do":
+		pmtuDiscovery = internet.SocketConfig_Do
+	case "dont":
+		pmtuDiscovery = internet.SocketConfig_Dont
+	case "want":
+		pmtuDiscovery = internet.SocketConfig_Want
+	default:
+		return nil, newError("unknown path MTU discovery mode: ", c.PMTUDiscovery)
+	}
+
+	if c.TFOQueueLength < 0 {
+		return nil, newError("invalid TFO queue length: ", c.TFOQueueLength)
+	}
+
+	if c.Backlog < 0 {
+		return nil, newError("invalid listen backlog: ", c.Backlog)
+	}
+
+	var sourceAddress [][]byte
+	if c.SourceAddress != nil {
+		for _, addr := range *c.SourceAddress {
+			cidr, err := rule2.ParseIP(addr)
+			if err != nil || cidr.Prefix != uint32(len(cidr.Ip)*8) {
+				return nil, newError("invalid source address: ", addr).Base(err)
+			}
+			sourceAddress = append(sourceAddress, cidr.Ip)
+		}
+	}
+
+	var sourceSubnet *router.CIDR
+	if len(c.SourceSubnet) > 0 {
+		cidr, err := rule2.ParseIP(c.SourceSubnet)
+		if err != nil {
+			return nil, newError("invalid source subnet: ", c.SourceSubnet).Base(err)
+		}
+		sourceSubnet = cidr
+	}
+
+	if c.SendProxyProtocol > 2 {
+		return nil, newError("unsupported PROXY protocol version: ", c.SendProxyProtocol)
+	}
+
+	if c.TOS > 0xff {
+		return nil, newError("invalid TOS value: ", c.TOS)
+	}
+
+	if c.SoPriority < 0 {
+		return nil, newError("invalid socket priority: ", c.SoPriority)
+	}
+
+	if c.TCPMaxSeg < 0 {
+		return nil, newError("invalid TCP maximum segment size: ", c.TCPMaxSeg)
+	}
+
 	return &internet.SocketConfig{
-		Mark:                 c.Mark,
-		Tfo:                  tfoSettings,
-		Tproxy:               tproxy,
-		AcceptProxyProtocol:  c.AcceptProxyProtocol,
-		TcpKeepAliveInterval: c.TCPKeepAliveInterval,
+		Mark:                      c.Mark,
+		InboundMark:               c.InboundMark,
+		OutboundMark:              c.OutboundMark,
+		Tfo:                       tfoSettings,
+		TfoQueueLength:            c.TFOQueueLength,
+		Tproxy:                    tproxy,
+		AcceptProxyProtocol:       c.AcceptProxyProtocol,
+		TcpKeepAliveInterval:      c.TCPKeepAliveInterval,
+		TcpKeepAliveIdle:          c.TCPKeepAliveIdle,
+		RxBufSize:                 c.RxBufSize,
+		TxBufSize:                 c.TxBufSize,
+		BindToDevice:              c.BindToDevice,
+		TcpCongestion:             c.TCPCongestion,
+		TcpUserTimeout:            c.TCPUserTimeout,
+		HappyEyeballs:             c.HappyEyeballs,
+		DialAddressFamily:         dialAddressFamily,
+		SendProxyProtocol:         c.SendProxyProtocol,
+		AllowMissingProxyProtocol: c.AllowMissingProxyProtocol,
+		Tos:                       c.TOS,
+		Mptcp:                     c.MPTCP,
+		UdpGso:                    c.UDPGSO,
+		TcpNoDelay:                toSocketOptionState(c.TCPNoDelay),
+		ReusePort:                 c.ReusePort,
+		IdleTimeout:               c.IdleTimeout,
+		PmtuDiscovery:             pmtuDiscovery,
+		Backlog:                   c.Backlog,
+		BindInterfaceIndex:        c.BindInterfaceIndex,
+		ResolverTag:               c.ResolverTag,
+		SoPriority:                c.SoPriority,
+		V6Only:                    toSocketOptionState(c.V6Only),
+		TcpMaxSeg:                 c.TCPMaxSeg,
+		SourceAddress:             sourceAddress,
+		SourceSubnet:              sourceSubnet.GetIp(),
+		SourceSubnetPrefix:        sourceSubnet.GetPrefix(),
+
+		HappyEyeballsResolutionDelay: c.HappyEyeballsResolutionDelay,
+		HappyEyeballsPreferIpv4:      c.HappyEyeballsPreferIPv4,
+	}, nil
+}
+
+type DialRetryConfig struct {
+	MaxAttempts uint32            `json:"maxAttempts"`
+	BaseBackoff duration.Duration `json:"baseBackoff"`
+	Jitter      float32           `json:"jitter"`
+}
+
+// Build implements Buildable.
+func (c *DialRetryConfig) Build() (*internet.DialRetryConfig, error) {
+	baseBackoff := time.Duration(c.BaseBackoff)
+	if baseBackoff < 0 || baseBackoff/time.Millisecond > math.MaxUint32 {
+		return nil, newError("invalid base backoff of dial retry: ", baseBackoff)
+	}
+	if c.Jitter < 0 || c.Jitter > 1 {
+		return nil, newError("jitter of dial retry must be in [0, 1], but got ", c.Jitter)
+	}
+	return &internet.DialRetryConfig{
+		MaxAttempts: c.MaxAttempts,
+		BaseBackoff: uint32(baseBackoff / time.Millisecond),
+		Jitter:      c.Jitter,
 	}, nil
 }
 
@@ -430,6 +623,7 @@ type StreamConfig struct {
 	GunSettings    *GunConfig          `json:"gunSettings"`
 	GRPCSettings   *GunConfig          `json:"grpcSettings"`
 	SocketSettings *SocketConfig       `json:"sockopt"`
+	DialRetry      *DialRetryConfig    `json:"dialRetry"`
 }
 
 // Build implements Buildable.
@@ -537,6 +731,18 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 		}
 		config.SocketSettings = ss
 	}
+	if c.DialRetry != nil {
+		dr, err := c.DialRetry.Build()
+		if err != nil {
+			return nil, newError("Failed to build dial retry config.").Base(err)
+		}
+		config.DialRetry = dr
+	}
+	for _, settings := range config.TransportSettings {
+		if err := settings.Validate(); err != nil {
+			return nil, newError("invalid transport settings").Base(err)
+		}
+	}
 	return config, nil
 }
 
@@ -11,9 +11,13 @@ import (
 	. "github.com/v2fly/v2ray-core/v4/infra/conf"
 	"github.com/v2fly/v2ray-core/v4/transport"
 	"github.com/v2fly/v2ray-core/v4/transport/internet"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/domainsocket"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/grpc"
 	"github.com/v2fly/v2ray-core/v4/transport/internet/headers/http"
 	"github.com/v2fly/v2ray-core/v4/transport/internet/headers/noop"
 	"github.com/v2fly/v2ray-core/v4/transport/internet/headers/tls"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/headers/wechat"
+	httptransport "github.com/v2fly/v2ray-core/v4/transport/internet/http"
 	"github.com/v2fly/v2ray-core/v4/transport/internet/kcp"
 	"github.com/v2fly/v2ray-core/v4/transport/internet/quic"
 	"github.com/v2fly/v2ray-core/v4/transport/internet/tcp"
@@ -43,7 +47,239 @@ func TestSocketConfig(t *testing.T) {
 				Tfo:  internet.SocketConfig_Enable,
 			},
 		},
+		{
+			Input: `{
+				"mark": 1,
+				"inboundMark": 2,
+				"outboundMark": 3
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				Mark:         1,
+				InboundMark:  2,
+				OutboundMark: 3,
+			},
+		},
+		{
+			Input: `{
+				"tcpKeepAliveInterval": 15,
+				"tcpKeepAliveIdle": -1
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				TcpKeepAliveInterval: 15,
+				TcpKeepAliveIdle:     -1,
+			},
+		},
+		{
+			Input: `{
+				"rxBufSize": 4194304,
+				"txBufSize": 1048576
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				RxBufSize: 4194304,
+				TxBufSize: 1048576,
+			},
+		},
+		{
+			Input: `{
+				"bindToDevice": "eth0"
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				BindToDevice: "eth0",
+			},
+		},
+		{
+			Input: `{
+				"tcpCongestion": "bbr"
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				TcpCongestion: "bbr",
+			},
+		},
+		{
+			Input: `{
+				"tcpUserTimeout": 10000
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				TcpUserTimeout: 10000,
+			},
+		},
+		{
+			Input: `{
+				"happyEyeballs": 250
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				HappyEyeballs: 250,
+			},
+		},
+		{
+			Input: `{
+				"happyEyeballs": 250,
+				"happyEyeballsResolutionDelay": 50,
+				"happyEyeballsPreferIPv4": true
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				HappyEyeballs:                250,
+				HappyEyeballsResolutionDelay: 50,
+				HappyEyeballsPreferIpv4:      true,
+			},
+		},
+		{
+			Input: `{
+				"dialAddressFamily": "IPv4Only"
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				DialAddressFamily: internet.DialAddressFamily_IPv4Only,
+			},
+		},
+		{
+			Input: `{
+				"sendProxyProtocol": 2
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				SendProxyProtocol: 2,
+			},
+		},
+		{
+			Input: `{
+				"acceptProxyProtocol": true,
+				"allowMissingProxyProtocol": true
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				AcceptProxyProtocol:       true,
+				AllowMissingProxyProtocol: true,
+			},
+		},
+		{
+			Input: `{
+				"tos": 184
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				Tos: 184,
+			},
+		},
+		{
+			Input: `{
+				"tcpMptcp": true
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				Mptcp: true,
+			},
+		},
+		{
+			Input: `{
+				"udpGSO": true
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				UdpGso: true,
+			},
+		},
+		{
+			Input: `{
+				"tcpNoDelay": false
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				TcpNoDelay: internet.SocketOptionState_Disable,
+			},
+		},
+		{
+			Input: `{
+				"reusePort": true,
+				"idleTimeout": 300
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				ReusePort:   true,
+				IdleTimeout: 300,
+			},
+		},
+		{
+			Input: `{
+				"pmtuDiscovery": "Dont",
+				"backlog": 16,
+				"tcpFastOpen": true,
+				"tcpFastOpenQueueLength": 64,
+				"resolverTag": "exit-dns",
+				"soPriority": 4,
+				"v6only": true,
+				"tcpMaxSeg": 1360
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				PmtuDiscovery:  internet.SocketConfig_Dont,
+				Backlog:        16,
+				Tfo:            internet.SocketConfig_Enable,
+				TfoQueueLength: 64,
+				ResolverTag:    "exit-dns",
+				SoPriority:     4,
+				V6Only:         internet.SocketOptionState_Enable,
+				TcpMaxSeg:      1360,
+			},
+		},
+		{
+			Input: `{
+				"sourceAddress": ["192.0.2.1", "2001:db8::1"],
+				"sourceSubnet": "2001:db8:1::/64",
+				"bindInterfaceIndex": 2
+			}`,
+			Parser: createParser(),
+			Output: &internet.SocketConfig{
+				SourceAddress: [][]byte{
+					{192, 0, 2, 1},
+					{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+				},
+				SourceSubnet:       []byte{0x20, 0x01, 0x0d, 0xb8, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+				SourceSubnetPrefix: 64,
+				BindInterfaceIndex: 2,
+			},
+		},
+	})
+}
+
+func TestDialRetryConfig(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(DialRetryConfig)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"maxAttempts": 3,
+				"baseBackoff": "200ms",
+				"jitter": 0.5
+			}`,
+			Parser: createParser(),
+			Output: &internet.DialRetryConfig{
+				MaxAttempts: 3,
+				BaseBackoff: 200,
+				Jitter:      0.5,
+			},
+		},
 	})
+
+	if _, err := createParser()(`{"maxAttempts": 3, "jitter": 1.5}`); err == nil {
+		t.Error("expect error on jitter out of range")
+	}
 }
 
 func TestTransportConfig(t *testing.T) {
@@ -88,11 +324,25 @@ func TestTransportConfig(t *testing.T) {
 				"wsSettings": {
 					"path": "/t"
 				},
+				"httpSettings": {
+					"path": "/h",
+					"h2c": true
+				},
+				"dsSettings": {
+					"path": "/run/v2ray.sock",
+					"permissions": "0660",
+					"gid": 33
+				},
 				"quicSettings": {
 					"key": "abcd",
 					"header": {
 						"type": "dtls"
-					}
+					},
+					"disablePathMTUDiscovery": true
+				},
+				"grpcSettings": {
+					"serviceName": "example.Tunnel",
+					"disableConnectionReuse": true
 				}
 			}`,
 			Parser: createParser(),
@@ -153,6 +403,21 @@ func TestTransportConfig(t *testing.T) {
 							Path: "/t",
 						}),
 					},
+					{
+						ProtocolName: "http",
+						Settings: serial.ToTypedMessage(&httptransport.Config{
+							Path: "/h",
+							H2C:  true,
+						}),
+					},
+					{
+						ProtocolName: "domainsocket",
+						Settings: serial.ToTypedMessage(&domainsocket.Config{
+							Path:        "/run/v2ray.sock",
+							Permissions: 0o660,
+							Gid:         proto.Uint32(33),
+						}),
+					},
 					{
 						ProtocolName: "quic",
 						Settings: serial.ToTypedMessage(&quic.Config{
@@ -160,7 +425,37 @@ func TestTransportConfig(t *testing.T) {
 							Security: &protocol.SecurityConfig{
 								Type: protocol.SecurityType_NONE,
 							},
-							Header: serial.ToTypedMessage(&tls.PacketConfig{}),
+							Header:                  serial.ToTypedMessage(&tls.PacketConfig{}),
+							DisablePathMtuDiscovery: true,
+						}),
+					},
+					{
+						ProtocolName: "gun",
+						Settings: serial.ToTypedMessage(&grpc.Config{
+							ServiceName:            "example.Tunnel",
+							DisableConnectionReuse: true,
+						}),
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"kcpSettings": {
+					"header": {
+						"type": "wechat-video"
+					},
+					"seed": "secret"
+				}
+			}`,
+			Parser: createParser(),
+			Output: &transport.Config{
+				TransportSettings: []*internet.TransportConfig{
+					{
+						ProtocolName: "mkcp",
+						Settings: serial.ToTypedMessage(&kcp.Config{
+							HeaderConfig: serial.ToTypedMessage(&wechat.VideoConfig{}),
+							Seed:         &kcp.EncryptionSeed{Seed: "secret"},
 						}),
 					},
 				},
@@ -62,6 +62,7 @@ type SniffingConfig struct {
 	Enabled      bool                  `json:"enabled"`
 	DestOverride *cfgcommon.StringList `json:"destOverride"`
 	MetadataOnly bool                  `json:"metadataOnly"`
+	RouteOnly    bool                  `json:"routeOnly"`
 }
 
 // Build implements Buildable.
@@ -74,6 +75,8 @@ func (c *SniffingConfig) Build() (*proxyman.SniffingConfig, error) {
 				p = append(p, "http")
 			case "tls", "https", "ssl":
 				p = append(p, "tls")
+			case "quic":
+				p = append(p, "quic")
 			case "fakedns":
 				p = append(p, "fakedns")
 			case "fakedns+others":
@@ -88,6 +91,7 @@ func (c *SniffingConfig) Build() (*proxyman.SniffingConfig, error) {
 		Enabled:             c.Enabled,
 		DestinationOverride: p,
 		MetadataOnly:        c.MetadataOnly,
+		RouteOnly:           c.RouteOnly,
 	}, nil
 }
 
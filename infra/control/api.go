@@ -32,12 +32,14 @@ func (c *APICommand) Description() Description {
 			"\tLoggerService.RestartLogger",
 			"\tStatsService.GetStats",
 			"\tStatsService.QueryStats",
+			"\tStatsService.GetRuleStats",
 			"API calls in this command have a timeout to the server of 3 seconds.",
 			"Examples:",
 			"v2ctl api --server=127.0.0.1:8080 LoggerService.RestartLogger '' ",
 			"v2ctl api --server=127.0.0.1:8080 StatsService.QueryStats 'pattern: \"\" reset: false'",
 			"v2ctl api --server=127.0.0.1:8080 StatsService.GetStats 'name: \"inbound>>>statin>>>traffic>>>downlink\" reset: false'",
 			"v2ctl api --server=127.0.0.1:8080 StatsService.GetSysStats ''",
+			"v2ctl api --server=127.0.0.1:8080 StatsService.GetRuleStats 'reset: true'",
 		},
 	}
 }
@@ -148,6 +150,16 @@ func callStatsService(ctx context.Context, conn *grpc.ClientConn, method string,
 			return "", err
 		}
 		return proto.MarshalTextString(resp), nil
+	case "getrulestats":
+		r := &statsService.GetRuleStatsRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.GetRuleStats(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
 	default:
 		return "", errors.New("Unknown method: " + method)
 	}
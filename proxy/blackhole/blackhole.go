@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/session"
 	"github.com/v2fly/v2ray-core/v4/transport"
 	"github.com/v2fly/v2ray-core/v4/transport/internet"
 )
@@ -33,6 +34,12 @@ func New(ctx context.Context, config *Config) (*Handler, error) {
 
 // Process implements OutboundHandler.Dispatch().
 func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer internet.Dialer) error {
+	if content := session.ContentFromContext(ctx); content != nil {
+		if label := content.Attribute(session.AttributeDomainLabel); len(label) > 0 {
+			newError("blocked: ", label).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+		}
+	}
+
 	nBytes := h.response.WriteTo(link.Writer)
 	if nBytes > 0 {
 		// Sleep a little here to make sure the response is sent to client.
@@ -30,6 +30,13 @@ type Outbound interface {
 	Process(context.Context, *transport.Link, internet.Dialer) error
 }
 
+// ServerOutbound is the interface for Outbounds that connect to fixed servers,
+// rather than to the targets of connections.
+type ServerOutbound interface {
+	// Servers returns the destinations of the servers.
+	Servers() []net.Destination
+}
+
 // UserManager is the interface for Inbounds and Outbounds that can manage their users.
 type UserManager interface {
 	// AddUser adds a new user.
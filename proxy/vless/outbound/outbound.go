@@ -59,6 +59,11 @@ func New(ctx context.Context, config *Config) (*Handler, error) {
 	return handler, nil
 }
 
+// Servers implements proxy.ServerOutbound.
+func (h *Handler) Servers() []net.Destination {
+	return h.serverList.Destinations()
+}
+
 // Process implements proxy.Outbound.Process().
 func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer internet.Dialer) error {
 	var rec *protocol.ServerSpec
@@ -1,6 +1,10 @@
 package internet
 
 import (
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+
 	"github.com/v2fly/v2ray-core/v4/common/serial"
 	"github.com/v2fly/v2ray-core/v4/features"
 )
@@ -14,23 +18,13 @@ var (
 
 const unknownProtocol = "unknown"
 
+// transportProtocolToString returns the registry key of a protocol in the
+// deprecated TransportProtocol enum.
 func transportProtocolToString(protocol TransportProtocol) string {
-	switch protocol {
-	case TransportProtocol_TCP:
-		return "tcp"
-	case TransportProtocol_UDP:
-		return "udp"
-	case TransportProtocol_HTTP:
-		return "http"
-	case TransportProtocol_MKCP:
-		return "mkcp"
-	case TransportProtocol_WebSocket:
-		return "websocket"
-	case TransportProtocol_DomainSocket:
-		return "domainsocket"
-	default:
-		return unknownProtocol
+	if name, found := TransportProtocol_name[int32(protocol)]; found {
+		return strings.ToLower(name)
 	}
+	return unknownProtocol
 }
 
 func RegisterProtocolConfigCreator(name string, creator ConfigCreator) error {
@@ -53,7 +47,27 @@ func (c *TransportConfig) GetTypedSettings() (interface{}, error) {
 	return c.Settings.GetInstance()
 }
 
-func (c *TransportConfig) GetUnifiedProtocolName() string {
+// Validate checks whether the type of Settings is the config type registered
+// for the protocol. Protocols without registered config type are not checked.
+func (c *TransportConfig) Validate() error {
+	protocol := c.EffectiveProtocolName()
+	creator, found := globalTransportConfigCreatorCache[protocol]
+	if !found || c.Settings == nil {
+		return nil
+	}
+	message, ok := creator().(proto.Message)
+	if !ok {
+		return nil
+	}
+	if expected := serial.GetMessageType(message); c.Settings.Type != expected {
+		return newError("settings of type ", c.Settings.Type, " is not for transport protocol ", protocol, ", ", expected, " is expected")
+	}
+	return nil
+}
+
+// EffectiveProtocolName returns ProtocolName if set, or the name of the
+// deprecated Protocol otherwise.
+func (c *TransportConfig) EffectiveProtocolName() string {
 	if len(c.ProtocolName) > 0 {
 		return c.ProtocolName
 	}
@@ -61,7 +75,16 @@ func (c *TransportConfig) GetUnifiedProtocolName() string {
 	return transportProtocolToString(c.Protocol)
 }
 
-func (c *StreamConfig) GetEffectiveProtocol() string {
+// GetUnifiedProtocolName is the same as EffectiveProtocolName.
+//
+// Deprecated: Use EffectiveProtocolName.
+func (c *TransportConfig) GetUnifiedProtocolName() string {
+	return c.EffectiveProtocolName()
+}
+
+// EffectiveProtocolName returns ProtocolName if set, or the name of the
+// deprecated Protocol otherwise. It returns "tcp" for nil StreamConfig.
+func (c *StreamConfig) EffectiveProtocolName() string {
 	if c == nil {
 		return "tcp"
 	}
@@ -73,36 +96,102 @@ func (c *StreamConfig) GetEffectiveProtocol() string {
 	return transportProtocolToString(c.Protocol)
 }
 
-func (c *StreamConfig) GetEffectiveTransportSettings() (interface{}, error) {
-	protocol := c.GetEffectiveProtocol()
-	return c.GetTransportSettingsFor(protocol)
+// GetEffectiveProtocol is the same as EffectiveProtocolName.
+//
+// Deprecated: Use EffectiveProtocolName.
+func (c *StreamConfig) GetEffectiveProtocol() string {
+	return c.EffectiveProtocolName()
 }
 
-func (c *StreamConfig) GetTransportSettingsFor(protocol string) (interface{}, error) {
-	if c != nil {
-		for _, settings := range c.TransportSettings {
-			if settings.GetUnifiedProtocolName() == protocol {
-				return settings.GetTypedSettings()
-			}
+// ErrNoTransportSettings is returned by GetEffectiveTransportSettings if no
+// settings are configured for the effective protocol.
+var ErrNoTransportSettings = newError("no transport settings for the effective protocol")
+
+// findTransportSettings returns the validated config of the protocol in
+// configs, or nil if there is none.
+func findTransportSettings(configs []*TransportConfig, protocol string) (*TransportConfig, error) {
+	for _, settings := range configs {
+		if settings.EffectiveProtocolName() != protocol {
+			continue
+		}
+		if err := settings.Validate(); err != nil {
+			return nil, err
 		}
+		return settings, nil
 	}
+	return nil, nil
+}
 
-	for _, settings := range globalTransportSettings {
-		if settings.GetUnifiedProtocolName() == protocol {
+// GetEffectiveTransportSettings returns the settings in TransportSettings for
+// the effective protocol of the StreamConfig. Unlike GetTransportSettingsFor,
+// it does not fall back to global or default settings, and it returns
+// ErrNoTransportSettings if none is configured.
+func (c *StreamConfig) GetEffectiveTransportSettings() (*serial.TypedMessage, error) {
+	if c == nil {
+		return nil, ErrNoTransportSettings
+	}
+	settings, err := findTransportSettings(c.TransportSettings, c.EffectiveProtocolName())
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, ErrNoTransportSettings
+	}
+	return settings.Settings, nil
+}
+
+// GetTransportSettingsFor returns the settings instance of the protocol, from
+// TransportSettings, global transport settings, or defaults of the protocol,
+// in order.
+func (c *StreamConfig) GetTransportSettingsFor(protocol string) (interface{}, error) {
+	if c != nil {
+		settings, err := findTransportSettings(c.TransportSettings, protocol)
+		if err != nil {
+			return nil, err
+		}
+		if settings != nil {
 			return settings.GetTypedSettings()
 		}
 	}
+	return defaultTransportSettingsFor(protocol)
+}
 
+// defaultTransportSettingsFor returns the settings instance of the protocol
+// from global transport settings, or defaults of the protocol.
+func defaultTransportSettingsFor(protocol string) (interface{}, error) {
+	settings, err := findTransportSettings(globalTransportSettings, protocol)
+	if err != nil {
+		return nil, err
+	}
+	if settings != nil {
+		return settings.GetTypedSettings()
+	}
 	return CreateTransportConfig(protocol)
 }
 
+// GetEffectiveSecuritySettings returns the security settings whose type
+// matches SecurityType. If SecuritySettings is empty, default settings of
+// SecurityType are returned. It is an error if SecuritySettings is not empty
+// but none or more than one of them matches SecurityType.
 func (c *StreamConfig) GetEffectiveSecuritySettings() (interface{}, error) {
+	if len(c.SecuritySettings) == 0 {
+		return serial.GetInstance(c.SecurityType)
+	}
+
+	var effective *serial.TypedMessage
 	for _, settings := range c.SecuritySettings {
-		if settings.Type == c.SecurityType {
-			return settings.GetInstance()
+		if settings.Type != c.SecurityType {
+			continue
+		}
+		if effective != nil {
+			return nil, newError("multiple security settings of type ", c.SecurityType)
 		}
+		effective = settings
 	}
-	return serial.GetInstance(c.SecurityType)
+	if effective == nil {
+		return nil, newError("no security settings of type ", c.SecurityType)
+	}
+	return effective.GetInstance()
 }
 
 func (c *StreamConfig) HasSecuritySettings() bool {
@@ -119,6 +208,24 @@ func (c *ProxyConfig) HasTag() bool {
 	return c != nil && len(c.Tag) > 0
 }
 
+// GetEffectiveInboundMark returns the mark of inbound sockets, falling back to
+// Mark if InboundMark is zero.
+func (c *SocketConfig) GetEffectiveInboundMark() int32 {
+	if c.InboundMark != 0 {
+		return c.InboundMark
+	}
+	return c.Mark
+}
+
+// GetEffectiveOutboundMark returns the mark of outbound sockets, falling back
+// to Mark if OutboundMark is zero.
+func (c *SocketConfig) GetEffectiveOutboundMark() int32 {
+	if c.OutboundMark != 0 {
+		return c.OutboundMark
+	}
+	return c.Mark
+}
+
 func (m SocketConfig_TProxyMode) IsEnabled() bool {
 	return m != SocketConfig_Off
 }
@@ -30,6 +30,7 @@ const (
 	TransportProtocol_WebSocket    TransportProtocol = 3
 	TransportProtocol_HTTP         TransportProtocol = 4
 	TransportProtocol_DomainSocket TransportProtocol = 5
+	TransportProtocol_QUIC         TransportProtocol = 6
 )
 
 // Enum value maps for TransportProtocol.
@@ -41,6 +42,7 @@ var (
 		3: "WebSocket",
 		4: "HTTP",
 		5: "DomainSocket",
+		6: "QUIC",
 	}
 	TransportProtocol_value = map[string]int32{
 		"TCP":          0,
@@ -49,6 +51,7 @@ var (
 		"WebSocket":    3,
 		"HTTP":         4,
 		"DomainSocket": 5,
+		"QUIC":         6,
 	}
 )
 
@@ -79,6 +82,112 @@ func (TransportProtocol) EnumDescriptor() ([]byte, []int) {
 	return file_transport_internet_config_proto_rawDescGZIP(), []int{0}
 }
 
+// DialAddressFamily is the address family of destinations to dial.
+type DialAddressFamily int32
+
+const (
+	// Addresses of both families are used.
+	DialAddressFamily_AsIs DialAddressFamily = 0
+	// Only IPv4 addresses are used.
+	DialAddressFamily_IPv4Only DialAddressFamily = 1
+	// Only IPv6 addresses are used.
+	DialAddressFamily_IPv6Only DialAddressFamily = 2
+)
+
+// Enum value maps for DialAddressFamily.
+var (
+	DialAddressFamily_name = map[int32]string{
+		0: "AsIs",
+		1: "IPv4Only",
+		2: "IPv6Only",
+	}
+	DialAddressFamily_value = map[string]int32{
+		"AsIs":     0,
+		"IPv4Only": 1,
+		"IPv6Only": 2,
+	}
+)
+
+func (x DialAddressFamily) Enum() *DialAddressFamily {
+	p := new(DialAddressFamily)
+	*p = x
+	return p
+}
+
+func (x DialAddressFamily) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DialAddressFamily) Descriptor() protoreflect.EnumDescriptor {
+	return file_transport_internet_config_proto_enumTypes[1].Descriptor()
+}
+
+func (DialAddressFamily) Type() protoreflect.EnumType {
+	return &file_transport_internet_config_proto_enumTypes[1]
+}
+
+func (x DialAddressFamily) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DialAddressFamily.Descriptor instead.
+func (DialAddressFamily) EnumDescriptor() ([]byte, []int) {
+	return file_transport_internet_config_proto_rawDescGZIP(), []int{1}
+}
+
+// SocketOptionState is the state of a boolean socket option.
+type SocketOptionState int32
+
+const (
+	// The option is left at the default of the system or the Go runtime.
+	SocketOptionState_Default SocketOptionState = 0
+	// The option is enabled explicitly.
+	SocketOptionState_Enable SocketOptionState = 1
+	// The option is disabled explicitly.
+	SocketOptionState_Disable SocketOptionState = 2
+)
+
+// Enum value maps for SocketOptionState.
+var (
+	SocketOptionState_name = map[int32]string{
+		0: "Default",
+		1: "Enable",
+		2: "Disable",
+	}
+	SocketOptionState_value = map[string]int32{
+		"Default": 0,
+		"Enable":  1,
+		"Disable": 2,
+	}
+)
+
+func (x SocketOptionState) Enum() *SocketOptionState {
+	p := new(SocketOptionState)
+	*p = x
+	return p
+}
+
+func (x SocketOptionState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SocketOptionState) Descriptor() protoreflect.EnumDescriptor {
+	return file_transport_internet_config_proto_enumTypes[2].Descriptor()
+}
+
+func (SocketOptionState) Type() protoreflect.EnumType {
+	return &file_transport_internet_config_proto_enumTypes[2]
+}
+
+func (x SocketOptionState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SocketOptionState.Descriptor instead.
+func (SocketOptionState) EnumDescriptor() ([]byte, []int) {
+	return file_transport_internet_config_proto_rawDescGZIP(), []int{2}
+}
+
 type SocketConfig_TCPFastOpenState int32
 
 const (
@@ -115,11 +224,11 @@ func (x SocketConfig_TCPFastOpenState) String() string {
 }
 
 func (SocketConfig_TCPFastOpenState) Descriptor() protoreflect.EnumDescriptor {
-	return file_transport_internet_config_proto_enumTypes[1].Descriptor()
+	return file_transport_internet_config_proto_enumTypes[3].Descriptor()
 }
 
 func (SocketConfig_TCPFastOpenState) Type() protoreflect.EnumType {
-	return &file_transport_internet_config_proto_enumTypes[1]
+	return &file_transport_internet_config_proto_enumTypes[3]
 }
 
 func (x SocketConfig_TCPFastOpenState) Number() protoreflect.EnumNumber {
@@ -128,7 +237,7 @@ func (x SocketConfig_TCPFastOpenState) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use SocketConfig_TCPFastOpenState.Descriptor instead.
 func (SocketConfig_TCPFastOpenState) EnumDescriptor() ([]byte, []int) {
-	return file_transport_internet_config_proto_rawDescGZIP(), []int{3, 0}
+	return file_transport_internet_config_proto_rawDescGZIP(), []int{4, 0}
 }
 
 type SocketConfig_TProxyMode int32
@@ -167,11 +276,11 @@ func (x SocketConfig_TProxyMode) String() string {
 }
 
 func (SocketConfig_TProxyMode) Descriptor() protoreflect.EnumDescriptor {
-	return file_transport_internet_config_proto_enumTypes[2].Descriptor()
+	return file_transport_internet_config_proto_enumTypes[4].Descriptor()
 }
 
 func (SocketConfig_TProxyMode) Type() protoreflect.EnumType {
-	return &file_transport_internet_config_proto_enumTypes[2]
+	return &file_transport_internet_config_proto_enumTypes[4]
 }
 
 func (x SocketConfig_TProxyMode) Number() protoreflect.EnumNumber {
@@ -180,7 +289,64 @@ func (x SocketConfig_TProxyMode) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use SocketConfig_TProxyMode.Descriptor instead.
 func (SocketConfig_TProxyMode) EnumDescriptor() ([]byte, []int) {
-	return file_transport_internet_config_proto_rawDescGZIP(), []int{3, 1}
+	return file_transport_internet_config_proto_rawDescGZIP(), []int{4, 1}
+}
+
+type SocketConfig_PMTUDiscovery int32
+
+const (
+	// Use the system default, which follows the ip_no_pmtu_disc sysctl on
+	// Linux.
+	SocketConfig_Default SocketConfig_PMTUDiscovery = 0
+	// Set the Don't-Fragment bit, and never fragment packets locally.
+	SocketConfig_Do SocketConfig_PMTUDiscovery = 1
+	// Clear the Don't-Fragment bit, and fragment packets if needed.
+	SocketConfig_Dont SocketConfig_PMTUDiscovery = 2
+	// Set the Don't-Fragment bit, unless the path MTU is exceeded.
+	SocketConfig_Want SocketConfig_PMTUDiscovery = 3
+)
+
+// Enum value maps for SocketConfig_PMTUDiscovery.
+var (
+	SocketConfig_PMTUDiscovery_name = map[int32]string{
+		0: "Default",
+		1: "Do",
+		2: "Dont",
+		3: "Want",
+	}
+	SocketConfig_PMTUDiscovery_value = map[string]int32{
+		"Default": 0,
+		"Do":      1,
+		"Dont":    2,
+		"Want":    3,
+	}
+)
+
+func (x SocketConfig_PMTUDiscovery) Enum() *SocketConfig_PMTUDiscovery {
+	p := new(SocketConfig_PMTUDiscovery)
+	*p = x
+	return p
+}
+
+func (x SocketConfig_PMTUDiscovery) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SocketConfig_PMTUDiscovery) Descriptor() protoreflect.EnumDescriptor {
+	return file_transport_internet_config_proto_enumTypes[5].Descriptor()
+}
+
+func (SocketConfig_PMTUDiscovery) Type() protoreflect.EnumType {
+	return &file_transport_internet_config_proto_enumTypes[5]
+}
+
+func (x SocketConfig_PMTUDiscovery) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SocketConfig_PMTUDiscovery.Descriptor instead.
+func (SocketConfig_PMTUDiscovery) EnumDescriptor() ([]byte, []int) {
+	return file_transport_internet_config_proto_rawDescGZIP(), []int{4, 2}
 }
 
 type TransportConfig struct {
@@ -270,6 +436,9 @@ type StreamConfig struct {
 	// Settings for transport security. For now the only choice is TLS.
 	SecuritySettings []*serial.TypedMessage `protobuf:"bytes,4,rep,name=security_settings,json=securitySettings,proto3" json:"security_settings,omitempty"`
 	SocketSettings   *SocketConfig          `protobuf:"bytes,6,opt,name=socket_settings,json=socketSettings,proto3" json:"socket_settings,omitempty"`
+	// Policy of retrying failed dials of the transport. Dials are not retried
+	// if not set.
+	DialRetry *DialRetryConfig `protobuf:"bytes,7,opt,name=dial_retry,json=dialRetry,proto3" json:"dial_retry,omitempty"`
 }
 
 func (x *StreamConfig) Reset() {
@@ -347,6 +516,85 @@ func (x *StreamConfig) GetSocketSettings() *SocketConfig {
 	return nil
 }
 
+func (x *StreamConfig) GetDialRetry() *DialRetryConfig {
+	if x != nil {
+		return x.DialRetry
+	}
+	return nil
+}
+
+// DialRetryConfig is the policy of retrying transport dials that fail
+// transiently, e.g. on handshake timeouts. Permanent failures, such as
+// nonexistent domains and refused connections, are not retried.
+type DialRetryConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Maximum number of attempts including the first one. Dials are not retried
+	// if it is 0 or 1.
+	MaxAttempts uint32 `protobuf:"varint,1,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	// Backoff in milliseconds before the second attempt, which doubles before
+	// each following attempt.
+	BaseBackoff uint32 `protobuf:"varint,2,opt,name=base_backoff,json=baseBackoff,proto3" json:"base_backoff,omitempty"`
+	// Fraction of each backoff, in [0, 1], by which it is randomly lengthened or
+	// shortened.
+	Jitter float32 `protobuf:"fixed32,3,opt,name=jitter,proto3" json:"jitter,omitempty"`
+}
+
+func (x *DialRetryConfig) Reset() {
+	*x = DialRetryConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_transport_internet_config_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DialRetryConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DialRetryConfig) ProtoMessage() {}
+
+func (x *DialRetryConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_config_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DialRetryConfig.ProtoReflect.Descriptor instead.
+func (*DialRetryConfig) Descriptor() ([]byte, []int) {
+	return file_transport_internet_config_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DialRetryConfig) GetMaxAttempts() uint32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *DialRetryConfig) GetBaseBackoff() uint32 {
+	if x != nil {
+		return x.BaseBackoff
+	}
+	return 0
+}
+
+func (x *DialRetryConfig) GetJitter() float32 {
+	if x != nil {
+		return x.Jitter
+	}
+	return 0
+}
+
 type ProxyConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -359,7 +607,7 @@ type ProxyConfig struct {
 func (x *ProxyConfig) Reset() {
 	*x = ProxyConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_transport_internet_config_proto_msgTypes[2]
+		mi := &file_transport_internet_config_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -372,7 +620,7 @@ func (x *ProxyConfig) String() string {
 func (*ProxyConfig) ProtoMessage() {}
 
 func (x *ProxyConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_transport_internet_config_proto_msgTypes[2]
+	mi := &file_transport_internet_config_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -385,7 +633,7 @@ func (x *ProxyConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProxyConfig.ProtoReflect.Descriptor instead.
 func (*ProxyConfig) Descriptor() ([]byte, []int) {
-	return file_transport_internet_config_proto_rawDescGZIP(), []int{2}
+	return file_transport_internet_config_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *ProxyConfig) GetTag() string {
@@ -409,6 +657,8 @@ type SocketConfig struct {
 	unknownFields protoimpl.UnknownFields
 
 	// Mark of the connection. If non-zero, the value will be set to SO_MARK.
+	// It applies to both inbound and outbound sockets, unless overridden by
+	// inbound_mark or outbound_mark.
 	Mark int32 `protobuf:"varint,1,opt,name=mark,proto3" json:"mark,omitempty"`
 	// TFO is the state of TFO settings.
 	Tfo SocketConfig_TCPFastOpenState `protobuf:"varint,2,opt,name=tfo,proto3,enum=v2ray.core.transport.internet.SocketConfig_TCPFastOpenState" json:"tfo,omitempty"`
@@ -420,13 +670,134 @@ type SocketConfig struct {
 	BindAddress                []byte `protobuf:"bytes,5,opt,name=bind_address,json=bindAddress,proto3" json:"bind_address,omitempty"`
 	BindPort                   uint32 `protobuf:"varint,6,opt,name=bind_port,json=bindPort,proto3" json:"bind_port,omitempty"`
 	AcceptProxyProtocol        bool   `protobuf:"varint,7,opt,name=accept_proxy_protocol,json=acceptProxyProtocol,proto3" json:"accept_proxy_protocol,omitempty"`
-	TcpKeepAliveInterval       int32  `protobuf:"varint,8,opt,name=tcp_keep_alive_interval,json=tcpKeepAliveInterval,proto3" json:"tcp_keep_alive_interval,omitempty"`
+	// Interval between TCP keep-alive probes, in seconds. 0 for OS default, -1
+	// to disable TCP keep-alive.
+	TcpKeepAliveInterval int32 `protobuf:"varint,8,opt,name=tcp_keep_alive_interval,json=tcpKeepAliveInterval,proto3" json:"tcp_keep_alive_interval,omitempty"`
+	// Idle time before the first TCP keep-alive probe, in seconds. 0 for OS
+	// default, -1 to disable TCP keep-alive.
+	TcpKeepAliveIdle int32 `protobuf:"varint,9,opt,name=tcp_keep_alive_idle,json=tcpKeepAliveIdle,proto3" json:"tcp_keep_alive_idle,omitempty"`
+	// Size of the socket receive buffer (SO_RCVBUF), in bytes. 0 for OS default.
+	// On Linux, the kernel doubles the requested size for bookkeeping overhead,
+	// after capping it at net.core.rmem_max.
+	RxBufSize int32 `protobuf:"varint,10,opt,name=rx_buf_size,json=rxBufSize,proto3" json:"rx_buf_size,omitempty"`
+	// Size of the socket send buffer (SO_SNDBUF), in bytes. 0 for OS default.
+	// On Linux, the kernel doubles the requested size for bookkeeping overhead,
+	// after capping it at net.core.wmem_max.
+	TxBufSize int32 `protobuf:"varint,11,opt,name=tx_buf_size,json=txBufSize,proto3" json:"tx_buf_size,omitempty"`
+	// Name of the network interface to bind sockets to (SO_BINDTODEVICE). Only
+	// supported on Linux, and requires CAP_NET_RAW.
+	BindToDevice string `protobuf:"bytes,12,opt,name=bind_to_device,json=bindToDevice,proto3" json:"bind_to_device,omitempty"`
+	// Name of the TCP congestion control algorithm, e.g. "bbr". Only supported on
+	// Linux. System default is used if empty or unavailable.
+	TcpCongestion string `protobuf:"bytes,13,opt,name=tcp_congestion,json=tcpCongestion,proto3" json:"tcp_congestion,omitempty"`
+	// Maximum time in milliseconds that transmitted data may remain
+	// unacknowledged before the connection is closed (TCP_USER_TIMEOUT). Only
+	// supported on Linux. 0 for OS default.
+	TcpUserTimeout int32 `protobuf:"varint,14,opt,name=tcp_user_timeout,json=tcpUserTimeout,proto3" json:"tcp_user_timeout,omitempty"`
+	// Delay in milliseconds before connecting to addresses of the other family,
+	// when a domain of TCP destination resolves to both IPv4 and IPv6 addresses
+	// (Happy Eyeballs, RFC 8305). 0 to dial as usual.
+	HappyEyeballs uint32 `protobuf:"varint,15,opt,name=happy_eyeballs,json=happyEyeballs,proto3" json:"happy_eyeballs,omitempty"`
+	// Address family of destinations to dial. Resolved addresses of other
+	// families are dropped, and dialing fails if none is left.
+	DialAddressFamily DialAddressFamily `protobuf:"varint,16,opt,name=dial_address_family,json=dialAddressFamily,proto3,enum=v2ray.core.transport.internet.DialAddressFamily" json:"dial_address_family,omitempty"`
+	// Version of PROXY protocol header to send on outbound TCP connections,
+	// either 1 or 2. The header conveys the source and gateway addresses of the
+	// inbound connection. 0 to disable.
+	SendProxyProtocol uint32 `protobuf:"varint,17,opt,name=send_proxy_protocol,json=sendProxyProtocol,proto3" json:"send_proxy_protocol,omitempty"`
+	// Whether to accept connections without PROXY protocol header, when
+	// accept_proxy_protocol is set. Connections with a malformed header are
+	// always rejected.
+	AllowMissingProxyProtocol bool `protobuf:"varint,18,opt,name=allow_missing_proxy_protocol,json=allowMissingProxyProtocol,proto3" json:"allow_missing_proxy_protocol,omitempty"`
+	// Value of the TOS field (IPv4) or traffic class (IPv6) of outgoing
+	// packets. 0 keeps the system default. Supported on Linux, macOS and
+	// FreeBSD. A TOS value chosen by routing rule takes precedence on outbound
+	// connections.
+	Tos uint32 `protobuf:"varint,19,opt,name=tos,proto3" json:"tos,omitempty"`
+	// Whether to use Multipath TCP instead of TCP. Falls back to TCP if not
+	// supported by the kernel. Only supported on Linux 5.6 and later.
+	Mptcp bool `protobuf:"varint,20,opt,name=mptcp,proto3" json:"mptcp,omitempty"`
+	// Whether to batch outgoing datagrams of mKCP with UDP generic segmentation
+	// offload (UDP_SEGMENT). Falls back to sending datagrams one by one if not
+	// supported. Only supported on Linux.
+	UdpGso bool `protobuf:"varint,21,opt,name=udp_gso,json=udpGso,proto3" json:"udp_gso,omitempty"`
+	// State of TCP_NODELAY on TCP connections, which Go runtime enables by
+	// default. Disable turns Nagle's algorithm back on.
+	TcpNoDelay SocketOptionState `protobuf:"varint,22,opt,name=tcp_no_delay,json=tcpNoDelay,proto3,enum=v2ray.core.transport.internet.SocketOptionState" json:"tcp_no_delay,omitempty"`
+	// Whether listening fails if SO_REUSEPORT can't be set, so that multiple
+	// processes can share the port with the kernel balancing connections among
+	// them. Listeners set SO_REUSEPORT on a best-effort basis otherwise. Only
+	// supported on Linux, FreeBSD and macOS, where the kernel doesn't balance
+	// connections among the processes.
+	ReusePort bool `protobuf:"varint,23,opt,name=reuse_port,json=reusePort,proto3" json:"reuse_port,omitempty"`
+	// Time in seconds after which TCP and Unix domain socket connections with
+	// no data read or written in either direction are closed. 0 to disable.
+	IdleTimeout uint32 `protobuf:"varint,24,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	// Path MTU discovery mode of UDP sockets, e.g. those of mKCP and QUIC,
+	// applied via IP_MTU_DISCOVER and IPV6_MTU_DISCOVER. Only supported on
+	// Linux, and ignored on other platforms.
+	PmtuDiscovery SocketConfig_PMTUDiscovery `protobuf:"varint,25,opt,name=pmtu_discovery,json=pmtuDiscovery,proto3,enum=v2ray.core.transport.internet.SocketConfig_PMTUDiscovery" json:"pmtu_discovery,omitempty"`
+	// Backlog of pending connections of listening sockets, replacing the one
+	// Go runtime listens with, which is net.core.somaxconn on Linux. The kernel
+	// caps it at net.core.somaxconn (kern.ipc.somaxconn on BSD), so raising it
+	// beyond requires raising the sysctl as well. 0 keeps the default. Only
+	// supported on Linux, macOS and FreeBSD.
+	Backlog int32 `protobuf:"varint,26,opt,name=backlog,proto3" json:"backlog,omitempty"`
+	// Local addresses that outbound connections are bound to in turn, when the
+	// outbound doesn't send through a specific address. Only addresses of the
+	// same family as the destination are used for IP destinations.
+	SourceAddress [][]byte `protobuf:"bytes,27,rep,name=source_address,json=sourceAddress,proto3" json:"source_address,omitempty"`
+	// Subnet of local addresses, a random one in which is bound to by each
+	// outbound connection instead, e.g. an IPv6 /64 routed to the host. Takes
+	// precedence over source_address.
+	SourceSubnet       []byte `protobuf:"bytes,28,opt,name=source_subnet,json=sourceSubnet,proto3" json:"source_subnet,omitempty"`
+	SourceSubnetPrefix uint32 `protobuf:"varint,29,opt,name=source_subnet_prefix,json=sourceSubnetPrefix,proto3" json:"source_subnet_prefix,omitempty"`
+	// Maximum length of the queue of pending TFO requests of listening sockets,
+	// when TFO is enabled. 0 for the default of 256. Only supported on Linux.
+	TfoQueueLength int32 `protobuf:"varint,30,opt,name=tfo_queue_length,json=tfoQueueLength,proto3" json:"tfo_queue_length,omitempty"`
+	// Index of the network interface, used as the zone of IPv6 link-local bind
+	// and source addresses. 0 for none.
+	BindInterfaceIndex uint32 `protobuf:"varint,31,opt,name=bind_interface_index,json=bindInterfaceIndex,proto3" json:"bind_interface_index,omitempty"`
+	// Tag of the name server of the DNS app for resolving domain destinations,
+	// unless overridden by the routing rule. Empty for the system resolver.
+	ResolverTag string `protobuf:"bytes,32,opt,name=resolver_tag,json=resolverTag,proto3" json:"resolver_tag,omitempty"`
+	// Priority of packets of sockets (SO_PRIORITY), which queuing disciplines
+	// classify packets by, e.g. into bands of prio. It is independent of mark,
+	// which policy routing and netfilter match on, so both can be set. Linux
+	// also derives the priority from tos, so it is set after tos. Values above 6
+	// require CAP_NET_ADMIN. 0 keeps the system default. Only supported on Linux.
+	SoPriority int32 `protobuf:"varint,33,opt,name=so_priority,json=soPriority,proto3" json:"so_priority,omitempty"`
+	// State of IPV6_V6ONLY on IPv6 listening sockets. Enable makes them accept
+	// IPv6 connections only, and Disable makes them accept IPv4-mapped ones as
+	// well. Default keeps the Go runtime default, which is dual-stack for
+	// unspecified addresses. Supported on Linux, macOS, FreeBSD and Windows.
+	V6Only SocketOptionState `protobuf:"varint,34,opt,name=v6only,proto3,enum=v2ray.core.transport.internet.SocketOptionState" json:"v6only,omitempty"`
+	// Maximum time in milliseconds to wait for addresses of the preferred family
+	// after those of the other family are resolved, when happy_eyeballs is set
+	// (Resolution Delay, RFC 8305 Section 3). IPv4 and IPv6 addresses are then
+	// queried concurrently, instead of waiting for both. 0 to resolve as usual.
+	// Only applies to the default resolver.
+	HappyEyeballsResolutionDelay uint32 `protobuf:"varint,35,opt,name=happy_eyeballs_resolution_delay,json=happyEyeballsResolutionDelay,proto3" json:"happy_eyeballs_resolution_delay,omitempty"`
+	// Whether IPv4 is the preferred family when resolving with
+	// happy_eyeballs_resolution_delay, instead of IPv6. Addresses of the
+	// preferred family are dialed first.
+	HappyEyeballsPreferIpv4 bool `protobuf:"varint,36,opt,name=happy_eyeballs_prefer_ipv4,json=happyEyeballsPreferIpv4,proto3" json:"happy_eyeballs_prefer_ipv4,omitempty"`
+	// Maximum segment size of outbound TCP connections (TCP_MAXSEG), set before
+	// connecting, e.g. to clamp MSS on tunneled paths of small MTU. The kernel
+	// may reduce it further, e.g. by the path MTU or the MSS advertised by the
+	// peer. 0 keeps the system default. Supported on Linux, macOS and FreeBSD.
+	TcpMaxSeg int32 `protobuf:"varint,37,opt,name=tcp_max_seg,json=tcpMaxSeg,proto3" json:"tcp_max_seg,omitempty"`
+	// Marks of sockets listened by inbounds, including connections accepted on
+	// them, and of sockets dialed by outbounds, e.g. to route only return
+	// traffic by a specific routing table. mark is used if zero.
+	InboundMark  int32 `protobuf:"varint,38,opt,name=inbound_mark,json=inboundMark,proto3" json:"inbound_mark,omitempty"`
+	OutboundMark int32 `protobuf:"varint,39,opt,name=outbound_mark,json=outboundMark,proto3" json:"outbound_mark,omitempty"`
 }
 
 func (x *SocketConfig) Reset() {
 	*x = SocketConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_transport_internet_config_proto_msgTypes[3]
+		mi := &file_transport_internet_config_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -439,7 +810,7 @@ func (x *SocketConfig) String() string {
 func (*SocketConfig) ProtoMessage() {}
 
 func (x *SocketConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_transport_internet_config_proto_msgTypes[3]
+	mi := &file_transport_internet_config_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -452,7 +823,7 @@ func (x *SocketConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SocketConfig.ProtoReflect.Descriptor instead.
 func (*SocketConfig) Descriptor() ([]byte, []int) {
-	return file_transport_internet_config_proto_rawDescGZIP(), []int{3}
+	return file_transport_internet_config_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *SocketConfig) GetMark() int32 {
@@ -511,6 +882,223 @@ func (x *SocketConfig) GetTcpKeepAliveInterval() int32 {
 	return 0
 }
 
+func (x *SocketConfig) GetTcpKeepAliveIdle() int32 {
+	if x != nil {
+		return x.TcpKeepAliveIdle
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetRxBufSize() int32 {
+	if x != nil {
+		return x.RxBufSize
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetTxBufSize() int32 {
+	if x != nil {
+		return x.TxBufSize
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetBindToDevice() string {
+	if x != nil {
+		return x.BindToDevice
+	}
+	return ""
+}
+
+func (x *SocketConfig) GetTcpCongestion() string {
+	if x != nil {
+		return x.TcpCongestion
+	}
+	return ""
+}
+
+func (x *SocketConfig) GetTcpUserTimeout() int32 {
+	if x != nil {
+		return x.TcpUserTimeout
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetHappyEyeballs() uint32 {
+	if x != nil {
+		return x.HappyEyeballs
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetDialAddressFamily() DialAddressFamily {
+	if x != nil {
+		return x.DialAddressFamily
+	}
+	return DialAddressFamily_AsIs
+}
+
+func (x *SocketConfig) GetSendProxyProtocol() uint32 {
+	if x != nil {
+		return x.SendProxyProtocol
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetAllowMissingProxyProtocol() bool {
+	if x != nil {
+		return x.AllowMissingProxyProtocol
+	}
+	return false
+}
+
+func (x *SocketConfig) GetTos() uint32 {
+	if x != nil {
+		return x.Tos
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetMptcp() bool {
+	if x != nil {
+		return x.Mptcp
+	}
+	return false
+}
+
+func (x *SocketConfig) GetUdpGso() bool {
+	if x != nil {
+		return x.UdpGso
+	}
+	return false
+}
+
+func (x *SocketConfig) GetTcpNoDelay() SocketOptionState {
+	if x != nil {
+		return x.TcpNoDelay
+	}
+	return SocketOptionState_Default
+}
+
+func (x *SocketConfig) GetReusePort() bool {
+	if x != nil {
+		return x.ReusePort
+	}
+	return false
+}
+
+func (x *SocketConfig) GetIdleTimeout() uint32 {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetPmtuDiscovery() SocketConfig_PMTUDiscovery {
+	if x != nil {
+		return x.PmtuDiscovery
+	}
+	return SocketConfig_Default
+}
+
+func (x *SocketConfig) GetBacklog() int32 {
+	if x != nil {
+		return x.Backlog
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetSourceAddress() [][]byte {
+	if x != nil {
+		return x.SourceAddress
+	}
+	return nil
+}
+
+func (x *SocketConfig) GetSourceSubnet() []byte {
+	if x != nil {
+		return x.SourceSubnet
+	}
+	return nil
+}
+
+func (x *SocketConfig) GetSourceSubnetPrefix() uint32 {
+	if x != nil {
+		return x.SourceSubnetPrefix
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetTfoQueueLength() int32 {
+	if x != nil {
+		return x.TfoQueueLength
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetBindInterfaceIndex() uint32 {
+	if x != nil {
+		return x.BindInterfaceIndex
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetResolverTag() string {
+	if x != nil {
+		return x.ResolverTag
+	}
+	return ""
+}
+
+func (x *SocketConfig) GetSoPriority() int32 {
+	if x != nil {
+		return x.SoPriority
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetV6Only() SocketOptionState {
+	if x != nil {
+		return x.V6Only
+	}
+	return SocketOptionState_Default
+}
+
+func (x *SocketConfig) GetHappyEyeballsResolutionDelay() uint32 {
+	if x != nil {
+		return x.HappyEyeballsResolutionDelay
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetHappyEyeballsPreferIpv4() bool {
+	if x != nil {
+		return x.HappyEyeballsPreferIpv4
+	}
+	return false
+}
+
+func (x *SocketConfig) GetTcpMaxSeg() int32 {
+	if x != nil {
+		return x.TcpMaxSeg
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetInboundMark() int32 {
+	if x != nil {
+		return x.InboundMark
+	}
+	return 0
+}
+
+func (x *SocketConfig) GetOutboundMark() int32 {
+	if x != nil {
+		return x.OutboundMark
+	}
+	return 0
+}
+
 var File_transport_internet_config_proto protoreflect.FileDescriptor
 
 var file_transport_internet_config_proto_rawDesc = []byte{
@@ -533,7 +1121,7 @@ var file_transport_internet_config_proto_rawDesc = []byte{
 	0x32, 0x26, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x63, 0x6f,
 	0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x54, 0x79, 0x70, 0x65,
 	0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x08, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e,
-	0x67, 0x73, 0x22, 0xb4, 0x03, 0x0a, 0x0c, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6e,
+	0x67, 0x73, 0x22, 0x83, 0x04, 0x0a, 0x0c, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6e,
 	0x66, 0x69, 0x67, 0x12, 0x50, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x30, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f,
 	0x72, 0x65, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74,
@@ -560,12 +1148,24 @@ var file_transport_internet_config_proto_rawDesc = []byte{
 	0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70,
 	0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x53, 0x6f, 0x63,
 	0x6b, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x0e, 0x73, 0x6f, 0x63, 0x6b, 0x65,
-	0x74, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x22, 0x51, 0x0a, 0x0b, 0x50, 0x72, 0x6f,
+	0x74, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x4d, 0x0a, 0x0a, 0x64, 0x69, 0x61,
+	0x6c, 0x5f, 0x72, 0x65, 0x74, 0x72, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2e, 0x2e,
+	0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x44, 0x69,
+	0x61, 0x6c, 0x52, 0x65, 0x74, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x09, 0x64,
+	0x69, 0x61, 0x6c, 0x52, 0x65, 0x74, 0x72, 0x79, 0x22, 0x6f, 0x0a, 0x0f, 0x44, 0x69, 0x61, 0x6c,
+	0x52, 0x65, 0x74, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x21, 0x0a, 0x0c, 0x6d,
+	0x61, 0x78, 0x5f, 0x61, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x41, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x73, 0x12, 0x21,
+	0x0a, 0x0c, 0x62, 0x61, 0x73, 0x65, 0x5f, 0x62, 0x61, 0x63, 0x6b, 0x6f, 0x66, 0x66, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x62, 0x61, 0x73, 0x65, 0x42, 0x61, 0x63, 0x6b, 0x6f, 0x66,
+	0x66, 0x12, 0x16, 0x0a, 0x06, 0x6a, 0x69, 0x74, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x06, 0x6a, 0x69, 0x74, 0x74, 0x65, 0x72, 0x22, 0x51, 0x0a, 0x0b, 0x50, 0x72, 0x6f,
 	0x78, 0x79, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61, 0x67, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x74, 0x61, 0x67, 0x12, 0x30, 0x0a, 0x13, 0x74, 0x72,
 	0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x4c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x78,
 	0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f,
-	0x72, 0x74, 0x4c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x22, 0x98, 0x04, 0x0a,
+	0x72, 0x74, 0x4c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x22, 0xd9, 0x0f, 0x0a,
 	0x0c, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a,
 	0x04, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x6d, 0x61, 0x72,
 	0x6b, 0x12, 0x4e, 0x0a, 0x03, 0x74, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x3c,
@@ -592,28 +1192,128 @@ var file_transport_internet_config_proto_rawDesc = []byte{
 	0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12, 0x35, 0x0a, 0x17, 0x74, 0x63, 0x70, 0x5f,
 	0x6b, 0x65, 0x65, 0x70, 0x5f, 0x61, 0x6c, 0x69, 0x76, 0x65, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72,
 	0x76, 0x61, 0x6c, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x14, 0x74, 0x63, 0x70, 0x4b, 0x65,
-	0x65, 0x70, 0x41, 0x6c, 0x69, 0x76, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x22,
-	0x35, 0x0a, 0x10, 0x54, 0x43, 0x50, 0x46, 0x61, 0x73, 0x74, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x74,
-	0x61, 0x74, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x41, 0x73, 0x49, 0x73, 0x10, 0x00, 0x12, 0x0a, 0x0a,
-	0x06, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x44, 0x69, 0x73,
-	0x61, 0x62, 0x6c, 0x65, 0x10, 0x02, 0x22, 0x2f, 0x0a, 0x0a, 0x54, 0x50, 0x72, 0x6f, 0x78, 0x79,
-	0x4d, 0x6f, 0x64, 0x65, 0x12, 0x07, 0x0a, 0x03, 0x4f, 0x66, 0x66, 0x10, 0x00, 0x12, 0x0a, 0x0a,
-	0x06, 0x54, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x52, 0x65, 0x64,
-	0x69, 0x72, 0x65, 0x63, 0x74, 0x10, 0x02, 0x2a, 0x5a, 0x0a, 0x11, 0x54, 0x72, 0x61, 0x6e, 0x73,
-	0x70, 0x6f, 0x72, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12, 0x07, 0x0a, 0x03,
-	0x54, 0x43, 0x50, 0x10, 0x00, 0x12, 0x07, 0x0a, 0x03, 0x55, 0x44, 0x50, 0x10, 0x01, 0x12, 0x08,
-	0x0a, 0x04, 0x4d, 0x4b, 0x43, 0x50, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x57, 0x65, 0x62, 0x53,
-	0x6f, 0x63, 0x6b, 0x65, 0x74, 0x10, 0x03, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x54, 0x54, 0x50, 0x10,
-	0x04, 0x12, 0x10, 0x0a, 0x0c, 0x44, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x53, 0x6f, 0x63, 0x6b, 0x65,
-	0x74, 0x10, 0x05, 0x42, 0x78, 0x0a, 0x21, 0x63, 0x6f, 0x6d, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79,
-	0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e,
-	0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x50, 0x01, 0x5a, 0x31, 0x67, 0x69, 0x74, 0x68,
-	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x76, 0x32, 0x66, 0x6c, 0x79, 0x2f, 0x76, 0x32, 0x72,
-	0x61, 0x79, 0x2d, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x76, 0x34, 0x2f, 0x74, 0x72, 0x61, 0x6e, 0x73,
-	0x70, 0x6f, 0x72, 0x74, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0xaa, 0x02, 0x1d,
-	0x56, 0x32, 0x52, 0x61, 0x79, 0x2e, 0x43, 0x6f, 0x72, 0x65, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73,
-	0x70, 0x6f, 0x72, 0x74, 0x2e, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x70, 0x41, 0x6c, 0x69, 0x76, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12,
+	0x2d, 0x0a, 0x13, 0x74, 0x63, 0x70, 0x5f, 0x6b, 0x65, 0x65, 0x70, 0x5f, 0x61, 0x6c, 0x69, 0x76,
+	0x65, 0x5f, 0x69, 0x64, 0x6c, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x10, 0x74, 0x63,
+	0x70, 0x4b, 0x65, 0x65, 0x70, 0x41, 0x6c, 0x69, 0x76, 0x65, 0x49, 0x64, 0x6c, 0x65, 0x12, 0x1e,
+	0x0a, 0x0b, 0x72, 0x78, 0x5f, 0x62, 0x75, 0x66, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x09, 0x72, 0x78, 0x42, 0x75, 0x66, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1e,
+	0x0a, 0x0b, 0x74, 0x78, 0x5f, 0x62, 0x75, 0x66, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x09, 0x74, 0x78, 0x42, 0x75, 0x66, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x24,
+	0x0a, 0x0e, 0x62, 0x69, 0x6e, 0x64, 0x5f, 0x74, 0x6f, 0x5f, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x62, 0x69, 0x6e, 0x64, 0x54, 0x6f, 0x44, 0x65,
+	0x76, 0x69, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x74, 0x63, 0x70, 0x5f, 0x63, 0x6f, 0x6e, 0x67,
+	0x65, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x74, 0x63,
+	0x70, 0x43, 0x6f, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x28, 0x0a, 0x10, 0x74,
+	0x63, 0x70, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18,
+	0x0e, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x74, 0x63, 0x70, 0x55, 0x73, 0x65, 0x72, 0x54, 0x69,
+	0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x68, 0x61, 0x70, 0x70, 0x79, 0x5f, 0x65,
+	0x79, 0x65, 0x62, 0x61, 0x6c, 0x6c, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x68,
+	0x61, 0x70, 0x70, 0x79, 0x45, 0x79, 0x65, 0x62, 0x61, 0x6c, 0x6c, 0x73, 0x12, 0x60, 0x0a, 0x13,
+	0x64, 0x69, 0x61, 0x6c, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x5f, 0x66, 0x61, 0x6d,
+	0x69, 0x6c, 0x79, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x30, 0x2e, 0x76, 0x32, 0x72, 0x61,
+	0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74,
+	0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x44, 0x69, 0x61, 0x6c, 0x41, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x52, 0x11, 0x64, 0x69, 0x61,
+	0x6c, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x12, 0x2e,
+	0x0a, 0x13, 0x73, 0x65, 0x6e, 0x64, 0x5f, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x5f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x11, 0x73, 0x65, 0x6e,
+	0x64, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12, 0x3f,
+	0x0a, 0x1c, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x5f, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x5f,
+	0x70, 0x72, 0x6f, 0x78, 0x79, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x12,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x19, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x4d, 0x69, 0x73, 0x73, 0x69,
+	0x6e, 0x67, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12,
+	0x10, 0x0a, 0x03, 0x74, 0x6f, 0x73, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x74, 0x6f,
+	0x73, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x70, 0x74, 0x63, 0x70, 0x18, 0x14, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x05, 0x6d, 0x70, 0x74, 0x63, 0x70, 0x12, 0x17, 0x0a, 0x07, 0x75, 0x64, 0x70, 0x5f, 0x67,
+	0x73, 0x6f, 0x18, 0x15, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x75, 0x64, 0x70, 0x47, 0x73, 0x6f,
+	0x12, 0x52, 0x0a, 0x0c, 0x74, 0x63, 0x70, 0x5f, 0x6e, 0x6f, 0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79,
+	0x18, 0x16, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x30, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x0a, 0x74, 0x63, 0x70, 0x4e, 0x6f, 0x44,
+	0x65, 0x6c, 0x61, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x75, 0x73, 0x65, 0x5f, 0x70, 0x6f,
+	0x72, 0x74, 0x18, 0x17, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x72, 0x65, 0x75, 0x73, 0x65, 0x50,
+	0x6f, 0x72, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x69, 0x64, 0x6c, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x18, 0x18, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x69, 0x64, 0x6c, 0x65, 0x54,
+	0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x60, 0x0a, 0x0e, 0x70, 0x6d, 0x74, 0x75, 0x5f, 0x64,
+	0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x18, 0x19, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x39,
+	0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x74, 0x72, 0x61, 0x6e,
+	0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x53,
+	0x6f, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x50, 0x4d, 0x54, 0x55,
+	0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x52, 0x0d, 0x70, 0x6d, 0x74, 0x75, 0x44,
+	0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x61, 0x63, 0x6b,
+	0x6c, 0x6f, 0x67, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x6c,
+	0x6f, 0x67, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x61, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x18, 0x1b, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0d, 0x73, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x5f, 0x73, 0x75, 0x62, 0x6e, 0x65, 0x74, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x0c, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x53, 0x75, 0x62, 0x6e, 0x65, 0x74, 0x12, 0x30,
+	0x0a, 0x14, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x73, 0x75, 0x62, 0x6e, 0x65, 0x74, 0x5f,
+	0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x12, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x53, 0x75, 0x62, 0x6e, 0x65, 0x74, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78,
+	0x12, 0x28, 0x0a, 0x10, 0x74, 0x66, 0x6f, 0x5f, 0x71, 0x75, 0x65, 0x75, 0x65, 0x5f, 0x6c, 0x65,
+	0x6e, 0x67, 0x74, 0x68, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x74, 0x66, 0x6f, 0x51,
+	0x75, 0x65, 0x75, 0x65, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x30, 0x0a, 0x14, 0x62, 0x69,
+	0x6e, 0x64, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x6e, 0x64,
+	0x65, 0x78, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x12, 0x62, 0x69, 0x6e, 0x64, 0x49, 0x6e,
+	0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x21, 0x0a, 0x0c,
+	0x72, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x72, 0x5f, 0x74, 0x61, 0x67, 0x18, 0x20, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x72, 0x54, 0x61, 0x67, 0x12,
+	0x1f, 0x0a, 0x0b, 0x73, 0x6f, 0x5f, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x18, 0x21,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x73, 0x6f, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79,
+	0x12, 0x48, 0x0a, 0x06, 0x76, 0x36, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x22, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x30, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x74, 0x72,
+	0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74,
+	0x2e, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x52, 0x06, 0x76, 0x36, 0x6f, 0x6e, 0x6c, 0x79, 0x12, 0x45, 0x0a, 0x1f, 0x68, 0x61,
+	0x70, 0x70, 0x79, 0x5f, 0x65, 0x79, 0x65, 0x62, 0x61, 0x6c, 0x6c, 0x73, 0x5f, 0x72, 0x65, 0x73,
+	0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x23, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x1c, 0x68, 0x61, 0x70, 0x70, 0x79, 0x45, 0x79, 0x65, 0x62, 0x61, 0x6c,
+	0x6c, 0x73, 0x52, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x44, 0x65, 0x6c, 0x61,
+	0x79, 0x12, 0x3b, 0x0a, 0x1a, 0x68, 0x61, 0x70, 0x70, 0x79, 0x5f, 0x65, 0x79, 0x65, 0x62, 0x61,
+	0x6c, 0x6c, 0x73, 0x5f, 0x70, 0x72, 0x65, 0x66, 0x65, 0x72, 0x5f, 0x69, 0x70, 0x76, 0x34, 0x18,
+	0x24, 0x20, 0x01, 0x28, 0x08, 0x52, 0x17, 0x68, 0x61, 0x70, 0x70, 0x79, 0x45, 0x79, 0x65, 0x62,
+	0x61, 0x6c, 0x6c, 0x73, 0x50, 0x72, 0x65, 0x66, 0x65, 0x72, 0x49, 0x70, 0x76, 0x34, 0x12, 0x1e,
+	0x0a, 0x0b, 0x74, 0x63, 0x70, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x73, 0x65, 0x67, 0x18, 0x25, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x09, 0x74, 0x63, 0x70, 0x4d, 0x61, 0x78, 0x53, 0x65, 0x67, 0x12, 0x21,
+	0x0a, 0x0c, 0x69, 0x6e, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x26,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x69, 0x6e, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x4d, 0x61, 0x72,
+	0x6b, 0x12, 0x23, 0x0a, 0x0d, 0x6f, 0x75, 0x74, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x6d, 0x61,
+	0x72, 0x6b, 0x18, 0x27, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x6f, 0x75, 0x74, 0x62, 0x6f, 0x75,
+	0x6e, 0x64, 0x4d, 0x61, 0x72, 0x6b, 0x22, 0x35, 0x0a, 0x10, 0x54, 0x43, 0x50, 0x46, 0x61, 0x73,
+	0x74, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x41, 0x73,
+	0x49, 0x73, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x10, 0x01,
+	0x12, 0x0b, 0x0a, 0x07, 0x44, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x10, 0x02, 0x22, 0x2f, 0x0a,
+	0x0a, 0x54, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x07, 0x0a, 0x03, 0x4f,
+	0x66, 0x66, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x54, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x10, 0x01,
+	0x12, 0x0c, 0x0a, 0x08, 0x52, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x10, 0x02, 0x22, 0x38,
+	0x0a, 0x0d, 0x50, 0x4d, 0x54, 0x55, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x12,
+	0x0b, 0x0a, 0x07, 0x44, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x10, 0x00, 0x12, 0x06, 0x0a, 0x02,
+	0x44, 0x6f, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x44, 0x6f, 0x6e, 0x74, 0x10, 0x02, 0x12, 0x08,
+	0x0a, 0x04, 0x57, 0x61, 0x6e, 0x74, 0x10, 0x03, 0x2a, 0x64, 0x0a, 0x11, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x70, 0x6f, 0x72, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12, 0x07, 0x0a,
+	0x03, 0x54, 0x43, 0x50, 0x10, 0x00, 0x12, 0x07, 0x0a, 0x03, 0x55, 0x44, 0x50, 0x10, 0x01, 0x12,
+	0x08, 0x0a, 0x04, 0x4d, 0x4b, 0x43, 0x50, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x57, 0x65, 0x62,
+	0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x10, 0x03, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x54, 0x54, 0x50,
+	0x10, 0x04, 0x12, 0x10, 0x0a, 0x0c, 0x44, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x53, 0x6f, 0x63, 0x6b,
+	0x65, 0x74, 0x10, 0x05, 0x12, 0x08, 0x0a, 0x04, 0x51, 0x55, 0x49, 0x43, 0x10, 0x06, 0x2a, 0x39,
+	0x0a, 0x11, 0x44, 0x69, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x46, 0x61, 0x6d,
+	0x69, 0x6c, 0x79, 0x12, 0x08, 0x0a, 0x04, 0x41, 0x73, 0x49, 0x73, 0x10, 0x00, 0x12, 0x0c, 0x0a,
+	0x08, 0x49, 0x50, 0x76, 0x34, 0x4f, 0x6e, 0x6c, 0x79, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x49,
+	0x50, 0x76, 0x36, 0x4f, 0x6e, 0x6c, 0x79, 0x10, 0x02, 0x2a, 0x39, 0x0a, 0x11, 0x53, 0x6f, 0x63,
+	0x6b, 0x65, 0x74, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x0b,
+	0x0a, 0x07, 0x44, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x45,
+	0x6e, 0x61, 0x62, 0x6c, 0x65, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x44, 0x69, 0x73, 0x61, 0x62,
+	0x6c, 0x65, 0x10, 0x02, 0x42, 0x78, 0x0a, 0x21, 0x63, 0x6f, 0x6d, 0x2e, 0x76, 0x32, 0x72, 0x61,
+	0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74,
+	0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x50, 0x01, 0x5a, 0x31, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x76, 0x32, 0x66, 0x6c, 0x79, 0x2f, 0x76, 0x32,
+	0x72, 0x61, 0x79, 0x2d, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x76, 0x34, 0x2f, 0x74, 0x72, 0x61, 0x6e,
+	0x73, 0x70, 0x6f, 0x72, 0x74, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0xaa, 0x02,
+	0x1d, 0x56, 0x32, 0x52, 0x61, 0x79, 0x2e, 0x43, 0x6f, 0x72, 0x65, 0x2e, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -628,32 +1328,41 @@ func file_transport_internet_config_proto_rawDescGZIP() []byte {
 	return file_transport_internet_config_proto_rawDescData
 }
 
-var file_transport_internet_config_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_transport_internet_config_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_transport_internet_config_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_transport_internet_config_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_transport_internet_config_proto_goTypes = []interface{}{
 	(TransportProtocol)(0),             // 0: v2ray.core.transport.internet.TransportProtocol
-	(SocketConfig_TCPFastOpenState)(0), // 1: v2ray.core.transport.internet.SocketConfig.TCPFastOpenState
-	(SocketConfig_TProxyMode)(0),       // 2: v2ray.core.transport.internet.SocketConfig.TProxyMode
-	(*TransportConfig)(nil),            // 3: v2ray.core.transport.internet.TransportConfig
-	(*StreamConfig)(nil),               // 4: v2ray.core.transport.internet.StreamConfig
-	(*ProxyConfig)(nil),                // 5: v2ray.core.transport.internet.ProxyConfig
-	(*SocketConfig)(nil),               // 6: v2ray.core.transport.internet.SocketConfig
-	(*serial.TypedMessage)(nil),        // 7: v2ray.core.common.serial.TypedMessage
+	(DialAddressFamily)(0),             // 1: v2ray.core.transport.internet.DialAddressFamily
+	(SocketOptionState)(0),             // 2: v2ray.core.transport.internet.SocketOptionState
+	(SocketConfig_TCPFastOpenState)(0), // 3: v2ray.core.transport.internet.SocketConfig.TCPFastOpenState
+	(SocketConfig_TProxyMode)(0),       // 4: v2ray.core.transport.internet.SocketConfig.TProxyMode
+	(SocketConfig_PMTUDiscovery)(0),    // 5: v2ray.core.transport.internet.SocketConfig.PMTUDiscovery
+	(*TransportConfig)(nil),            // 6: v2ray.core.transport.internet.TransportConfig
+	(*StreamConfig)(nil),               // 7: v2ray.core.transport.internet.StreamConfig
+	(*DialRetryConfig)(nil),            // 8: v2ray.core.transport.internet.DialRetryConfig
+	(*ProxyConfig)(nil),                // 9: v2ray.core.transport.internet.ProxyConfig
+	(*SocketConfig)(nil),               // 10: v2ray.core.transport.internet.SocketConfig
+	(*serial.TypedMessage)(nil),        // 11: v2ray.core.common.serial.TypedMessage
 }
 var file_transport_internet_config_proto_depIdxs = []int32{
-	0, // 0: v2ray.core.transport.internet.TransportConfig.protocol:type_name -> v2ray.core.transport.internet.TransportProtocol
-	7, // 1: v2ray.core.transport.internet.TransportConfig.settings:type_name -> v2ray.core.common.serial.TypedMessage
-	0, // 2: v2ray.core.transport.internet.StreamConfig.protocol:type_name -> v2ray.core.transport.internet.TransportProtocol
-	3, // 3: v2ray.core.transport.internet.StreamConfig.transport_settings:type_name -> v2ray.core.transport.internet.TransportConfig
-	7, // 4: v2ray.core.transport.internet.StreamConfig.security_settings:type_name -> v2ray.core.common.serial.TypedMessage
-	6, // 5: v2ray.core.transport.internet.StreamConfig.socket_settings:type_name -> v2ray.core.transport.internet.SocketConfig
-	1, // 6: v2ray.core.transport.internet.SocketConfig.tfo:type_name -> v2ray.core.transport.internet.SocketConfig.TCPFastOpenState
-	2, // 7: v2ray.core.transport.internet.SocketConfig.tproxy:type_name -> v2ray.core.transport.internet.SocketConfig.TProxyMode
-	8, // [8:8] is the sub-list for method output_type
-	8, // [8:8] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	0,  // 0: v2ray.core.transport.internet.TransportConfig.protocol:type_name -> v2ray.core.transport.internet.TransportProtocol
+	11, // 1: v2ray.core.transport.internet.TransportConfig.settings:type_name -> v2ray.core.common.serial.TypedMessage
+	0,  // 2: v2ray.core.transport.internet.StreamConfig.protocol:type_name -> v2ray.core.transport.internet.TransportProtocol
+	6,  // 3: v2ray.core.transport.internet.StreamConfig.transport_settings:type_name -> v2ray.core.transport.internet.TransportConfig
+	11, // 4: v2ray.core.transport.internet.StreamConfig.security_settings:type_name -> v2ray.core.common.serial.TypedMessage
+	10, // 5: v2ray.core.transport.internet.StreamConfig.socket_settings:type_name -> v2ray.core.transport.internet.SocketConfig
+	8,  // 6: v2ray.core.transport.internet.StreamConfig.dial_retry:type_name -> v2ray.core.transport.internet.DialRetryConfig
+	3,  // 7: v2ray.core.transport.internet.SocketConfig.tfo:type_name -> v2ray.core.transport.internet.SocketConfig.TCPFastOpenState
+	4,  // 8: v2ray.core.transport.internet.SocketConfig.tproxy:type_name -> v2ray.core.transport.internet.SocketConfig.TProxyMode
+	1,  // 9: v2ray.core.transport.internet.SocketConfig.dial_address_family:type_name -> v2ray.core.transport.internet.DialAddressFamily
+	2,  // 10: v2ray.core.transport.internet.SocketConfig.tcp_no_delay:type_name -> v2ray.core.transport.internet.SocketOptionState
+	5,  // 11: v2ray.core.transport.internet.SocketConfig.pmtu_discovery:type_name -> v2ray.core.transport.internet.SocketConfig.PMTUDiscovery
+	2,  // 12: v2ray.core.transport.internet.SocketConfig.v6only:type_name -> v2ray.core.transport.internet.SocketOptionState
+	13, // [13:13] is the sub-list for method output_type
+	13, // [13:13] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_transport_internet_config_proto_init() }
@@ -687,7 +1396,7 @@ func file_transport_internet_config_proto_init() {
 			}
 		}
 		file_transport_internet_config_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ProxyConfig); i {
+			switch v := v.(*DialRetryConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -699,6 +1408,18 @@ func file_transport_internet_config_proto_init() {
 			}
 		}
 		file_transport_internet_config_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProxyConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_transport_internet_config_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SocketConfig); i {
 			case 0:
 				return &v.state
@@ -716,8 +1437,8 @@ func file_transport_internet_config_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_transport_internet_config_proto_rawDesc,
-			NumEnums:      3,
-			NumMessages:   4,
+			NumEnums:      6,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,294 @@
+package internet_test
+
+import (
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v4/common/protocol"
+	"github.com/v2fly/v2ray-core/v4/common/serial"
+	. "github.com/v2fly/v2ray-core/v4/transport/internet"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/tcp"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/tls"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/websocket"
+)
+
+func TestGetEffectiveSecuritySettings(t *testing.T) {
+	tlsType := serial.GetMessageType(&tls.Config{})
+
+	testCases := []struct {
+		config     *StreamConfig
+		serverName string
+		err        bool
+	}{
+		{
+			config: &StreamConfig{
+				SecurityType: tlsType,
+			},
+		},
+		{
+			config: &StreamConfig{
+				SecurityType: tlsType,
+				SecuritySettings: []*serial.TypedMessage{
+					serial.ToTypedMessage(&protocol.SecurityConfig{}),
+					serial.ToTypedMessage(&tls.Config{ServerName: "v2fly.org"}),
+				},
+			},
+			serverName: "v2fly.org",
+		},
+		{
+			config: &StreamConfig{
+				SecurityType: tlsType,
+				SecuritySettings: []*serial.TypedMessage{
+					serial.ToTypedMessage(&protocol.SecurityConfig{}),
+				},
+			},
+			err: true,
+		},
+		{
+			config: &StreamConfig{
+				SecurityType: tlsType,
+				SecuritySettings: []*serial.TypedMessage{
+					serial.ToTypedMessage(&tls.Config{ServerName: "v2fly.org"}),
+					serial.ToTypedMessage(&tls.Config{ServerName: "example.com"}),
+				},
+			},
+			err: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		settings, err := tc.config.GetEffectiveSecuritySettings()
+		if tc.err {
+			if err == nil {
+				t.Error("expected error, but got ", settings)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if serverName := settings.(*tls.Config).ServerName; serverName != tc.serverName {
+			t.Error("expected server name ", tc.serverName, ", but got ", serverName)
+		}
+	}
+}
+
+func TestGetEffectiveTransportSettings(t *testing.T) {
+	transportSettings := []*TransportConfig{
+		{
+			ProtocolName: "tcp",
+			Settings:     serial.ToTypedMessage(&tcp.Config{}),
+		},
+		{
+			Protocol: TransportProtocol_WebSocket,
+			Settings: serial.ToTypedMessage(&websocket.Config{Path: "/ws"}),
+		},
+	}
+
+	testCases := []struct {
+		config *StreamConfig
+		path   string
+		err    bool
+	}{
+		{
+			config: &StreamConfig{
+				ProtocolName:      "websocket",
+				TransportSettings: transportSettings,
+			},
+			path: "/ws",
+		},
+		{
+			config: &StreamConfig{
+				Protocol:          TransportProtocol_TCP,
+				TransportSettings: transportSettings,
+			},
+		},
+		{
+			config: &StreamConfig{
+				ProtocolName:      "mkcp",
+				TransportSettings: transportSettings,
+			},
+			err: true,
+		},
+		{
+			config: &StreamConfig{
+				ProtocolName: "websocket",
+				TransportSettings: []*TransportConfig{
+					{
+						ProtocolName: "websocket",
+						Settings:     serial.ToTypedMessage(&tcp.Config{}),
+					},
+				},
+			},
+			err: true,
+		},
+		{
+			config: nil,
+			err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		settings, err := tc.config.GetEffectiveTransportSettings()
+		if tc.err {
+			if err == nil {
+				t.Error("expected error, but got ", settings)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		instance, err := settings.GetInstance()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		switch instance := instance.(type) {
+		case *websocket.Config:
+			if instance.Path != tc.path {
+				t.Error("expected path ", tc.path, ", but got ", instance.Path)
+			}
+		case *tcp.Config:
+			if tc.config.EffectiveProtocolName() != "tcp" {
+				t.Error("unexpected tcp settings for ", tc.config.EffectiveProtocolName())
+			}
+		default:
+			t.Error("unexpected settings ", instance)
+		}
+	}
+}
+
+func TestToMemoryStreamConfigTransportSettings(t *testing.T) {
+	config, err := ToMemoryStreamConfig(&StreamConfig{
+		ProtocolName: "websocket",
+		TransportSettings: []*TransportConfig{
+			{
+				ProtocolName: "websocket",
+				Settings:     serial.ToTypedMessage(&websocket.Config{Path: "/ws"}),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings, ok := config.ProtocolSettings.(*websocket.Config); !ok || settings.Path != "/ws" {
+		t.Error("expected configured websocket settings, but got ", config.ProtocolSettings)
+	}
+
+	// Defaults are used if nothing is configured for the protocol.
+	config, err = ToMemoryStreamConfig(&StreamConfig{ProtocolName: "websocket"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings, ok := config.ProtocolSettings.(*websocket.Config); !ok || settings.Path != "" {
+		t.Error("expected default websocket settings, but got ", config.ProtocolSettings)
+	}
+
+	_, err = ToMemoryStreamConfig(&StreamConfig{
+		ProtocolName: "websocket",
+		TransportSettings: []*TransportConfig{
+			{
+				ProtocolName: "websocket",
+				Settings:     serial.ToTypedMessage(&tcp.Config{}),
+			},
+		},
+	})
+	if err == nil {
+		t.Error("expected error for mismatched settings")
+	}
+}
+
+func TestValidateTransportConfig(t *testing.T) {
+	testCases := []struct {
+		config *TransportConfig
+		err    bool
+	}{
+		{
+			config: &TransportConfig{
+				ProtocolName: "websocket",
+				Settings:     serial.ToTypedMessage(&websocket.Config{Path: "/ws"}),
+			},
+		},
+		{
+			config: &TransportConfig{
+				ProtocolName: "websocket",
+				Settings:     serial.ToTypedMessage(&tcp.Config{}),
+			},
+			err: true,
+		},
+		{
+			config: &TransportConfig{
+				Protocol: TransportProtocol_WebSocket,
+				Settings: serial.ToTypedMessage(&tcp.Config{}),
+			},
+			err: true,
+		},
+		{
+			config: &TransportConfig{
+				ProtocolName: "unregistered",
+				Settings:     serial.ToTypedMessage(&tcp.Config{}),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		if err := tc.config.Validate(); (err != nil) != tc.err {
+			t.Error("unexpected validation result of ", tc.config, ": ", err)
+		}
+	}
+
+	streamConfig := &StreamConfig{
+		ProtocolName: "websocket",
+		TransportSettings: []*TransportConfig{
+			{
+				ProtocolName: "websocket",
+				Settings:     serial.ToTypedMessage(&tcp.Config{}),
+			},
+		},
+	}
+	if _, err := ToMemoryStreamConfig(streamConfig); err == nil {
+		t.Error("expected error for mismatched transport settings")
+	}
+}
+
+func TestEffectiveProtocolName(t *testing.T) {
+	testCases := []struct {
+		protocol     TransportProtocol
+		protocolName string
+		expected     string
+	}{
+		{protocol: TransportProtocol_TCP, expected: "tcp"},
+		{protocol: TransportProtocol_UDP, expected: "udp"},
+		{protocol: TransportProtocol_MKCP, expected: "mkcp"},
+		{protocol: TransportProtocol_WebSocket, expected: "websocket"},
+		{protocol: TransportProtocol_HTTP, expected: "http"},
+		{protocol: TransportProtocol_DomainSocket, expected: "domainsocket"},
+		{protocol: TransportProtocol_QUIC, expected: "quic"},
+		{protocol: TransportProtocol(100), expected: "unknown"},
+		{protocol: TransportProtocol_MKCP, protocolName: "websocket", expected: "websocket"},
+		{protocolName: "gun", expected: "gun"},
+	}
+
+	for _, tc := range testCases {
+		transportConfig := &TransportConfig{
+			Protocol:     tc.protocol,
+			ProtocolName: tc.protocolName,
+		}
+		if name := transportConfig.EffectiveProtocolName(); name != tc.expected {
+			t.Error("expected protocol name of transport config ", tc.expected, ", but got ", name)
+		}
+		streamConfig := &StreamConfig{
+			Protocol:     tc.protocol,
+			ProtocolName: tc.protocolName,
+		}
+		if name := streamConfig.EffectiveProtocolName(); name != tc.expected {
+			t.Error("expected protocol name of stream config ", tc.expected, ", but got ", name)
+		}
+	}
+
+	if name := (*StreamConfig)(nil).EffectiveProtocolName(); name != "tcp" {
+		t.Error("expected protocol name of nil stream config tcp, but got ", name)
+	}
+}
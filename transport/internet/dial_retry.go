@@ -0,0 +1,67 @@
+package internet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/common/dice"
+)
+
+// dialWithRetry calls dial until it succeeds, fails permanently, or the
+// attempts of the retry policy run out.
+func dialWithRetry(ctx context.Context, retry *DialRetryConfig, dial func() (Connection, error)) (Connection, error) {
+	attempts := retry.GetMaxAttempts()
+	backoff := time.Duration(retry.GetBaseBackoff()) * time.Millisecond
+	for attempt := uint32(1); ; attempt++ {
+		conn, err := dial()
+		if err == nil || attempt >= attempts || isPermanentDialError(err) {
+			return conn, err
+		}
+
+		delay := jitterBackoff(backoff, retry.GetJitter())
+		newError("dial attempt ", attempt, " of ", attempts, " failed, retrying in ", delay).Base(err).AtInfo().WriteToLog()
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, newError("dial retry canceled").Base(err)
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// jitterBackoff returns the backoff randomly lengthened or shortened by up to
+// the fraction jitter of it.
+func jitterBackoff(backoff time.Duration, jitter float32) time.Duration {
+	if jitter <= 0 || backoff <= 0 {
+		return backoff
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	r := float64(dice.Roll(1<<20))/(1<<20)*2 - 1
+	return backoff + time.Duration(float64(backoff)*float64(jitter)*r)
+}
+
+// isPermanentDialError returns whether err is caused by a failure that
+// retrying doesn't help, i.e. nonexistent domains and refused connections.
+func isPermanentDialError(err error) bool {
+	for err != nil {
+		if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+			return true
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return true
+		}
+		if inner, ok := err.(interface{ Inner() error }); ok {
+			err = inner.Inner()
+		} else {
+			err = errors.Unwrap(err)
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package internet_test
+
+import (
+	"context"
+	gonet "net"
+	"syscall"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	. "github.com/v2fly/v2ray-core/v4/transport/internet"
+)
+
+func TestDialRetry(t *testing.T) {
+	var attempts int
+	var failure error
+	common.Must(RegisterTransportDialer("test-flaky", func(ctx context.Context, dest net.Destination, streamSettings *MemoryStreamConfig) (Connection, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, failure
+		}
+		conn, _ := gonet.Pipe()
+		return conn, nil
+	}))
+
+	streamSettings := &MemoryStreamConfig{
+		ProtocolName: "test-flaky",
+		DialRetry: &DialRetryConfig{
+			MaxAttempts: 3,
+			BaseBackoff: 1,
+		},
+	}
+	dest := net.TCPDestination(net.LocalHostIP, 80)
+
+	failure = syscall.ETIMEDOUT
+	conn, err := Dial(context.Background(), dest, streamSettings)
+	common.Must(err)
+	conn.Close()
+	if attempts != 3 {
+		t.Error("expect 3 dial attempts, but got ", attempts)
+	}
+
+	attempts = 0
+	failure = newWrappedError(syscall.ECONNREFUSED)
+	if _, err := Dial(context.Background(), dest, streamSettings); err == nil {
+		t.Error("expect dial to fail")
+	}
+	if attempts != 1 {
+		t.Error("expect refused connection not to be retried, but got ", attempts, " attempts")
+	}
+}
+
+type wrappedError struct {
+	err error
+}
+
+func newWrappedError(err error) error {
+	return &wrappedError{err: err}
+}
+
+func (e *wrappedError) Error() string { return "wrapped: " + e.err.Error() }
+
+func (e *wrappedError) Unwrap() error { return e.err }
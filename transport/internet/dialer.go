@@ -2,6 +2,7 @@ package internet
 
 import (
 	"context"
+	"time"
 
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/common/session"
@@ -47,6 +48,11 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *MemoryStrea
 		if dialer == nil {
 			return nil, newError(protocol, " dialer not registered").AtError()
 		}
+		if streamSettings.DialRetry.GetMaxAttempts() > 1 {
+			return dialWithRetry(ctx, streamSettings.DialRetry, func() (Connection, error) {
+				return dialer(ctx, dest, streamSettings)
+			})
+		}
 		return dialer(ctx, dest, streamSettings)
 	}
 
@@ -72,7 +78,15 @@ func DialSystem(ctx context.Context, dest net.Destination, sockopt *SocketConfig
 		return DialTaggedOutbound(ctx, dest, transportLayerOutgoingTag)
 	}
 
-	return effectiveSystemDialer.Dial(ctx, src, dest, sockopt)
+	if tos := session.TOSFromContext(ctx); tos != 0 {
+		sockopt = withTOS(sockopt, tos)
+	}
+
+	conn, err := effectiveSystemDialer.Dial(ctx, src, dest, sockopt)
+	if err == nil && sockopt != nil && sockopt.IdleTimeout > 0 && dest.Network != net.Network_UDP {
+		conn = withIdleTimeout(conn, time.Duration(sockopt.IdleTimeout)*time.Second, time.Now)
+	}
+	return conn, err
 }
 
 func DialTaggedOutbound(ctx context.Context, dest net.Destination, tag string) (net.Conn, error) {
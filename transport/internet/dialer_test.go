@@ -3,11 +3,14 @@ package internet_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	"github.com/v2fly/v2ray-core/v4/features/dns"
 	"github.com/v2fly/v2ray-core/v4/testing/servers/tcp"
 	. "github.com/v2fly/v2ray-core/v4/transport/internet"
 )
@@ -25,3 +28,111 @@ func TestDialWithLocalAddr(t *testing.T) {
 	}
 	conn.Close()
 }
+
+func TestDialAddressFamily(t *testing.T) {
+	server := &tcp.Server{}
+	dest, err := server.Start()
+	common.Must(err)
+	defer server.Close()
+
+	dialer := DefaultSystemDialer{}
+
+	conn, err := dialer.Dial(context.Background(), nil, net.TCPDestination(net.DomainAddress("localhost"), dest.Port), &SocketConfig{
+		DialAddressFamily: DialAddressFamily_IPv4Only,
+	})
+	common.Must(err)
+	if r := cmp.Diff(conn.RemoteAddr().String(), "127.0.0.1:"+dest.Port.String()); r != "" {
+		t.Error(r)
+	}
+	conn.Close()
+
+	if _, err := dialer.Dial(context.Background(), nil, dest, &SocketConfig{
+		DialAddressFamily: DialAddressFamily_IPv6Only,
+	}); err == nil {
+		t.Error("expect error on dialing IPv4 address with IPv6 only, but got nil")
+	}
+
+	if _, err := dialer.Dial(context.Background(), nil, net.UDPDestination(net.LocalHostIP, dest.Port), &SocketConfig{
+		DialAddressFamily: DialAddressFamily_IPv6Only,
+	}); err == nil {
+		t.Error("expect error on dialing IPv4 address with IPv6 only, but got nil")
+	}
+}
+
+func TestDialTimeoutFromContext(t *testing.T) {
+	server := &tcp.Server{}
+	dest, err := server.Start()
+	common.Must(err)
+	defer server.Close()
+
+	// Make dialing the server slow.
+	slowAddress := "127.0.0.1:" + dest.Port.String()
+	common.Must(RegisterDialerController(func(network, address string, fd uintptr) error {
+		if address == slowAddress {
+			time.Sleep(300 * time.Millisecond)
+		}
+		return nil
+	}))
+
+	ctx := session.ContextWithDialTimeout(context.Background(), 100*time.Millisecond)
+	if conn, err := DialSystem(ctx, net.TCPDestination(net.LocalHostIP, dest.Port), nil); err == nil {
+		conn.Close()
+		t.Error("expect dial to time out")
+	}
+
+	ctx = session.ContextWithDialTimeout(context.Background(), 5*time.Second)
+	conn, err := DialSystem(ctx, net.TCPDestination(net.LocalHostIP, dest.Port), nil)
+	common.Must(err)
+	conn.Close()
+}
+
+// taggedResolver is a DNS client with tagged name servers answering fixed IPs.
+type taggedResolver struct {
+	dns.Client
+	ips map[string]net.IP
+}
+
+func (r *taggedResolver) LookupIPWithTag(tag string, domain string) ([]net.IP, error) {
+	ip, found := r.ips[tag]
+	if !found {
+		return nil, dns.ErrEmptyResponse
+	}
+	return []net.IP{ip}, nil
+}
+
+func TestDialWithResolver(t *testing.T) {
+	dest := net.UDPDestination(net.DomainAddress("example.com"), 53)
+	resolver := &taggedResolver{ips: map[string]net.IP{
+		"test-a": {127, 0, 0, 1},
+		"test-b": {127, 0, 0, 2},
+	}}
+	ctx := ContextWithDNSClient(context.Background(), resolver)
+
+	cases := []struct {
+		ctx      context.Context
+		sockopt  *SocketConfig
+		expected string
+	}{
+		{ctx, &SocketConfig{ResolverTag: "test-a"}, "127.0.0.1:53"},
+		{ctx, &SocketConfig{ResolverTag: "test-b"}, "127.0.0.2:53"},
+		// The tag of the routing rule overrides that of the socket settings.
+		{session.ContextWithResolverTag(ctx, "test-b"), &SocketConfig{ResolverTag: "test-a"}, "127.0.0.2:53"},
+		{session.ContextWithResolverTag(ctx, "test-a"), nil, "127.0.0.1:53"},
+	}
+	for i, c := range cases {
+		conn, err := DialSystem(c.ctx, dest, c.sockopt)
+		common.Must(err)
+		if addr := conn.RemoteAddr().String(); addr != c.expected {
+			t.Error("expect ", c.expected, " in case ", i, ", but got ", addr)
+		}
+		conn.Close()
+	}
+
+	if _, err := DialSystem(ctx, dest, &SocketConfig{ResolverTag: "unknown"}); err == nil {
+		t.Error("expect error for unknown resolver tag")
+	}
+	// Resolver tags are not resolved without the DNS client of the instance.
+	if _, err := DialSystem(context.Background(), dest, &SocketConfig{ResolverTag: "test-a"}); err == nil {
+		t.Error("expect error for resolver tag without DNS client")
+	}
+}
@@ -4,6 +4,8 @@
 package domainsocket
 
 import (
+	"runtime"
+
 	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/transport/internet"
@@ -19,6 +21,9 @@ func (c *Config) GetUnixAddr() (*net.UnixAddr, error) {
 	if path == "" {
 		return nil, newError("empty domain socket path")
 	}
+	if c.Abstract && runtime.GOOS != "linux" && runtime.GOOS != "android" {
+		return nil, newError("abstract domain socket is not supported on ", runtime.GOOS)
+	}
 	if c.Abstract && path[0] != '@' {
 		path = "@" + path
 	}
@@ -33,11 +33,20 @@ func Listen(ctx context.Context, address net.Address, port net.Port, streamSetti
 		return nil, err
 	}
 
+	if settings.Abstract && (settings.Permissions != 0 || settings.Uid != nil || settings.Gid != nil) {
+		return nil, newError("permissions and owner are not applicable to abstract domain socket")
+	}
+
 	unixListener, err := net.ListenUnix("unix", addr)
 	if err != nil {
 		return nil, newError("failed to listen domain socket").Base(err).AtWarning()
 	}
 
+	if err := setFileAttributes(settings); err != nil {
+		unixListener.Close()
+		return nil, err
+	}
+
 	ln := &Listener{
 		addr:    addr,
 		ln:      unixListener,
@@ -94,6 +103,30 @@ func (ln *Listener) run() {
 	}
 }
 
+// setFileAttributes changes owner and mode of the socket file as configured.
+// Owner is changed first, so that the file never ends up with the requested
+// mode but a wrong owner.
+func setFileAttributes(config *Config) error {
+	if config.Uid != nil || config.Gid != nil {
+		uid, gid := -1, -1
+		if config.Uid != nil {
+			uid = int(*config.Uid)
+		}
+		if config.Gid != nil {
+			gid = int(*config.Gid)
+		}
+		if err := os.Chown(config.Path, uid, gid); err != nil {
+			return newError("failed to change owner of domain socket ", config.Path, " to ", uid, ":", gid).Base(err)
+		}
+	}
+	if config.Permissions != 0 {
+		if err := os.Chmod(config.Path, os.FileMode(config.Permissions)); err != nil {
+			return newError("failed to change mode of domain socket ", config.Path).Base(err)
+		}
+	}
+	return nil
+}
+
 type fileLocker struct {
 	path string
 	file *os.File
@@ -5,7 +5,9 @@ package domainsocket_test
 
 import (
 	"context"
+	"os"
 	"runtime"
+	"syscall"
 	"testing"
 
 	"github.com/v2fly/v2ray-core/v4/common"
@@ -91,3 +93,91 @@ func TestListenAbstract(t *testing.T) {
 		t.Error("expected response as 'RequestResponse' but got ", b.String())
 	}
 }
+
+func TestListenAbstractUnsupported(t *testing.T) {
+	if runtime.GOOS == "linux" {
+		return
+	}
+
+	streamSettings := &internet.MemoryStreamConfig{
+		ProtocolName: "domainsocket",
+		ProtocolSettings: &Config{
+			Path:     "/tmp/ts3",
+			Abstract: true,
+		},
+	}
+	if _, err := Listen(context.Background(), nil, net.Port(0), streamSettings, func(conn internet.Connection) {
+		conn.Close()
+	}); err == nil {
+		t.Error("expected error listening abstract domain socket on ", runtime.GOOS)
+	}
+	if _, err := Dial(context.Background(), net.Destination{}, streamSettings); err == nil {
+		t.Error("expected error dialing abstract domain socket on ", runtime.GOOS)
+	}
+}
+
+func TestListenFileAttributes(t *testing.T) {
+	ctx := context.Background()
+	path := "/tmp/ts4"
+
+	streamSettings := &internet.MemoryStreamConfig{
+		ProtocolName: "domainsocket",
+		ProtocolSettings: &Config{
+			Path: path,
+		},
+	}
+	listener, err := Listen(ctx, nil, net.Port(0), streamSettings, func(conn internet.Connection) {
+		conn.Close()
+	})
+	common.Must(err)
+	info, err := os.Stat(path)
+	common.Must(err)
+	defaultMode := info.Mode().Perm()
+	listener.Close()
+
+	uid := uint32(os.Getuid())
+	gid := uint32(os.Getgid())
+	streamSettings.ProtocolSettings = &Config{
+		Path:        path,
+		Permissions: 0o600,
+		Uid:         &uid,
+		Gid:         &gid,
+	}
+	listener, err = Listen(ctx, nil, net.Port(0), streamSettings, func(conn internet.Connection) {
+		conn.Close()
+	})
+	common.Must(err)
+	defer listener.Close()
+
+	info, err = os.Stat(path)
+	common.Must(err)
+	if info.Mode().Perm() != 0o600 {
+		t.Error("expected mode 0600, but got ", info.Mode().Perm(), " (default ", defaultMode, ")")
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && (stat.Uid != uid || stat.Gid != gid) {
+		t.Error("expected owner ", uid, ":", gid, ", but got ", stat.Uid, ":", stat.Gid)
+	}
+}
+
+func TestListenFileOwnerDenied(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("chown is always permitted for root")
+	}
+
+	otherUID := uint32(os.Getuid() + 1)
+	streamSettings := &internet.MemoryStreamConfig{
+		ProtocolName: "domainsocket",
+		ProtocolSettings: &Config{
+			Path: "/tmp/ts5",
+			Uid:  &otherUID,
+		},
+	}
+	if _, err := Listen(context.Background(), nil, net.Port(0), streamSettings, func(conn internet.Connection) {
+		conn.Close()
+	}); err == nil {
+		t.Error("expected error changing owner of domain socket")
+	}
+	if _, err := os.Stat("/tmp/ts5"); err == nil {
+		t.Error("expected socket file to be removed on failure")
+	}
+}
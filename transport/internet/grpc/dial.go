@@ -54,6 +54,22 @@ func dialgRPC(ctx context.Context, dest net.Destination, streamSettings *interne
 		dialOption = grpc.WithTransportCredentials(credentials.NewTLS(config.GetTLSConfig()))
 	}
 
+	if grpcSettings.DisableConnectionReuse {
+		conn, err := newGrpcClient(ctx, dest, dialOption)
+		if err != nil {
+			return nil, newError("Cannot dial grpc").Base(err)
+		}
+		client := encoding.NewGunServiceClient(conn)
+		gunService, err := client.(encoding.GunServiceClientX).TunCustomName(ctx, grpcSettings.ServiceName)
+		if err != nil {
+			conn.Close()
+			return nil, newError("Cannot dial grpc").Base(err)
+		}
+		return encoding.NewGunConn(gunService, func() {
+			conn.Close()
+		}), nil
+	}
+
 	conn, canceller, err := getGrpcClient(ctx, dest, dialOption)
 	if err != nil {
 		return nil, newError("Cannot dial grpc").Base(err)
@@ -86,7 +102,13 @@ func getGrpcClient(ctx context.Context, dest net.Destination, dialOption grpc.Di
 		return client, canceller, nil
 	}
 
-	conn, err := grpc.Dial(
+	conn, err := newGrpcClient(ctx, dest, dialOption)
+	globalDialerMap[dest] = conn
+	return conn, canceller, err
+}
+
+func newGrpcClient(ctx context.Context, dest net.Destination, dialOption grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpc.Dial(
 		dest.Address.String()+":"+dest.Port.String(),
 		dialOption,
 		grpc.WithConnectParams(grpc.ConnectParams{
@@ -115,6 +137,4 @@ func getGrpcClient(ctx context.Context, dest net.Destination, dialOption grpc.Di
 			return internet.DialSystem(detachedContext, net.TCPDestination(address, port), nil)
 		}),
 	)
-	globalDialerMap[dest] = conn
-	return conn, canceller, err
 }
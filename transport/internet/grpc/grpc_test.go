@@ -0,0 +1,128 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/buf"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/testing/servers/tcp"
+	"github.com/v2fly/v2ray-core/v4/transport/internet"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/grpc"
+)
+
+func listenEcho(port net.Port, config *grpc.Config, remotes chan<- string) internet.Listener {
+	listener, err := grpc.Listen(context.Background(), net.LocalHostIP, port, &internet.MemoryStreamConfig{
+		ProtocolName:     "gun",
+		ProtocolSettings: config,
+	}, func(conn internet.Connection) {
+		remotes <- conn.RemoteAddr().String()
+		go func() {
+			defer conn.Close()
+
+			b := buf.New()
+			defer b.Release()
+
+			for {
+				b.Clear()
+				if _, err := b.ReadFrom(conn); err != nil {
+					return
+				}
+				if _, err := conn.Write(b.Bytes()); err != nil {
+					return
+				}
+			}
+		}()
+	})
+	common.Must(err)
+	time.Sleep(500 * time.Millisecond)
+	return listener
+}
+
+func dialAndEcho(t *testing.T, port net.Port, config *grpc.Config) internet.Connection {
+	conn, err := grpc.Dial(context.Background(), net.TCPDestination(net.LocalHostIP, port), &internet.MemoryStreamConfig{
+		ProtocolName:     "gun",
+		ProtocolSettings: config,
+	})
+	common.Must(err)
+
+	const N = 1024
+	b1 := make([]byte, N)
+	common.Must2(rand.Read(b1))
+	common.Must2(conn.Write(b1))
+
+	b2 := buf.New()
+	defer b2.Release()
+	common.Must2(b2.ReadFullFrom(conn, N))
+	if r := cmp.Diff(b2.Bytes(), b1); r != "" {
+		t.Error(r)
+	}
+	return conn
+}
+
+func TestGrpcConnection(t *testing.T) {
+	port := tcp.PickPort()
+	config := &grpc.Config{
+		ServiceName: "example.Tunnel",
+	}
+	remotes := make(chan string, 2)
+	listener := listenEcho(port, config, remotes)
+	defer listener.Close()
+
+	conn1 := dialAndEcho(t, port, config)
+	defer conn1.Close()
+	conn2 := dialAndEcho(t, port, config)
+	defer conn2.Close()
+
+	if r1, r2 := <-remotes, <-remotes; r1 != r2 {
+		t.Error("expected connections to share one HTTP/2 connection, but got ", r1, " and ", r2)
+	}
+}
+
+func TestGrpcConnectionWithoutReuse(t *testing.T) {
+	port := tcp.PickPort()
+	config := &grpc.Config{
+		ServiceName:            "example.Tunnel",
+		DisableConnectionReuse: true,
+	}
+	remotes := make(chan string, 2)
+	listener := listenEcho(port, config, remotes)
+	defer listener.Close()
+
+	conn1 := dialAndEcho(t, port, config)
+	defer conn1.Close()
+	conn2 := dialAndEcho(t, port, config)
+	defer conn2.Close()
+
+	if r1, r2 := <-remotes, <-remotes; r1 == r2 {
+		t.Error("expected connections over separate HTTP/2 connections, but both came from ", r1)
+	}
+}
+
+func TestGrpcServiceNameMismatch(t *testing.T) {
+	port := tcp.PickPort()
+	remotes := make(chan string, 1)
+	listener := listenEcho(port, &grpc.Config{ServiceName: "example.Tunnel"}, remotes)
+	defer listener.Close()
+
+	conn, err := grpc.Dial(context.Background(), net.TCPDestination(net.LocalHostIP, port), &internet.MemoryStreamConfig{
+		ProtocolName: "gun",
+		ProtocolSettings: &grpc.Config{
+			ServiceName:            "example.Other",
+			DisableConnectionReuse: true,
+		},
+	})
+	common.Must(err)
+	defer conn.Close()
+
+	common.Must2(conn.Write([]byte("test")))
+	b := make([]byte, 4)
+	if _, err := conn.Read(b); err == nil {
+		t.Error("expected error reading from unknown service")
+	}
+}
@@ -0,0 +1,225 @@
+package internet
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// lookupIP resolves domain with the default resolver of Go runtime.
+func lookupIP(ctx context.Context, domain string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, "ip", domain)
+}
+
+// lookupIPNetwork resolves domain to addresses of the network, "ip4" or "ip6",
+// with the default resolver of Go runtime.
+func lookupIPNetwork(ctx context.Context, network string, domain string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, network, domain)
+}
+
+type familyLookupFunc func(ctx context.Context, network string) ([]net.IP, error)
+
+// resolveHappyEyeballs queries addresses of both families concurrently, as
+// described in RFC 8305 Section 3. It returns as soon as addresses of the
+// preferred family are resolved, or delay after addresses of the other family
+// are resolved first. Addresses of the preferred family come first.
+func resolveHappyEyeballs(ctx context.Context, lookup familyLookupFunc, preferIPv4 bool, delay time.Duration) ([]net.IP, error) {
+	preferred, other := "ip6", "ip4"
+	if preferIPv4 {
+		preferred, other = other, preferred
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type lookupResult struct {
+		ips       []net.IP
+		err       error
+		preferred bool
+	}
+	results := make(chan lookupResult, 2)
+	for _, network := range []string{preferred, other} {
+		go func(network string) {
+			ips, err := lookup(ctx, network)
+			if err == nil && len(ips) == 0 {
+				err = newError("no ", network, " address found")
+			}
+			results <- lookupResult{ips: ips, err: err, preferred: network == preferred}
+		}(network)
+	}
+
+	var preferredResult, otherResult *lookupResult
+	var delayTimer <-chan time.Time
+	for preferredResult == nil || otherResult == nil {
+		select {
+		case res := <-results:
+			if res.preferred {
+				preferredResult = &res
+				if res.err == nil {
+					// Addresses of the other family are used too, if already
+					// resolved.
+					select {
+					case res := <-results:
+						otherResult = &res
+					default:
+					}
+					if otherResult == nil || otherResult.err != nil {
+						return res.ips, nil
+					}
+					return append(res.ips, otherResult.ips...), nil
+				}
+			} else {
+				otherResult = &res
+				if res.err == nil && delayTimer == nil {
+					timer := time.NewTimer(delay)
+					defer timer.Stop()
+					delayTimer = timer.C
+				}
+			}
+		case <-delayTimer:
+			return otherResult.ips, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if otherResult.err == nil {
+		return otherResult.ips, nil
+	}
+	return nil, preferredResult.err
+}
+
+// filterAddressFamily returns the addresses in ips of the given family.
+func filterAddressFamily(ips []net.IP, family DialAddressFamily) []net.IP {
+	if family == DialAddressFamily_AsIs {
+		return ips
+	}
+	filtered := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		if (ip.To4() != nil) == (family == DialAddressFamily_IPv4Only) {
+			filtered = append(filtered, ip)
+		}
+	}
+	return filtered
+}
+
+type ipDialFunc func(ctx context.Context, ip net.IP) (net.Conn, error)
+
+// partitionAddressFamilies splits ips into the ones of the same address family
+// as the first address, and the rest.
+func partitionAddressFamilies(ips []net.IP) (primaries []net.IP, fallbacks []net.IP) {
+	if len(ips) == 0 {
+		return nil, nil
+	}
+	isIPv4 := ips[0].To4() != nil
+	for _, ip := range ips {
+		if (ip.To4() != nil) == isIPv4 {
+			primaries = append(primaries, ip)
+		} else {
+			fallbacks = append(fallbacks, ip)
+		}
+	}
+	return primaries, fallbacks
+}
+
+// dialSerial tries ips one by one until a connection is established.
+func dialSerial(ctx context.Context, ips []net.IP, dial ipDialFunc) (net.Conn, error) {
+	var firstErr error
+	for _, ip := range ips {
+		select {
+		case <-ctx.Done():
+			if firstErr == nil {
+				firstErr = ctx.Err()
+			}
+			return nil, firstErr
+		default:
+		}
+
+		conn, err := dial(ctx, ip)
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = newError("no address to dial")
+	}
+	return nil, firstErr
+}
+
+// dialIPs dials ips one by one, or races address families as in
+// dialHappyEyeballs if delay is positive.
+func dialIPs(ctx context.Context, ips []net.IP, delay time.Duration, dial ipDialFunc) (net.Conn, error) {
+	if delay <= 0 {
+		return dialSerial(ctx, ips, dial)
+	}
+	return dialHappyEyeballs(ctx, ips, delay, dial)
+}
+
+// dialHappyEyeballs races connection attempts to addresses of both families,
+// as described in RFC 8305. Addresses of the family of the first address are
+// tried first, and addresses of the other family are tried after delay, or as
+// soon as all attempts of the first family fail. The first established
+// connection is returned, and the other attempts are cancelled.
+func dialHappyEyeballs(ctx context.Context, ips []net.IP, delay time.Duration, dial ipDialFunc) (net.Conn, error) {
+	primaries, fallbacks := partitionAddressFamilies(ips)
+	if len(fallbacks) == 0 {
+		return dialSerial(ctx, primaries, dial)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type dialResult struct {
+		conn    net.Conn
+		err     error
+		primary bool
+	}
+	results := make(chan dialResult)
+	returned := make(chan struct{})
+	defer close(returned)
+
+	start := func(ips []net.IP, primary bool) {
+		conn, err := dialSerial(ctx, ips, dial)
+		select {
+		case results <- dialResult{conn: conn, err: err, primary: primary}:
+		case <-returned:
+			if conn != nil {
+				conn.Close()
+			}
+		}
+	}
+
+	go start(primaries, true)
+	fallbackTimer := time.NewTimer(delay)
+	defer fallbackTimer.Stop()
+	fallbackStarted := false
+
+	var primaryErr, fallbackErr error
+	for {
+		select {
+		case <-fallbackTimer.C:
+			if !fallbackStarted {
+				fallbackStarted = true
+				go start(fallbacks, false)
+			}
+		case res := <-results:
+			if res.err == nil {
+				return res.conn, nil
+			}
+			if res.primary {
+				primaryErr = res.err
+			} else {
+				fallbackErr = res.err
+			}
+			if primaryErr != nil && fallbackErr != nil {
+				return nil, primaryErr
+			}
+			if !fallbackStarted {
+				fallbackStarted = true
+				go start(fallbacks, false)
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package internet
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeAddrConn struct {
+	net.Conn
+	ip net.IP
+}
+
+func blackHoledDial(family4 bool) (ipDialFunc, *int32) {
+	var cancelled int32
+	return func(ctx context.Context, ip net.IP) (net.Conn, error) {
+		if (ip.To4() != nil) == family4 {
+			<-ctx.Done()
+			atomic.AddInt32(&cancelled, 1)
+			return nil, ctx.Err()
+		}
+		c, _ := net.Pipe()
+		return &fakeAddrConn{Conn: c, ip: ip}, nil
+	}, &cancelled
+}
+
+func TestHappyEyeballsBlackHoledIPv6(t *testing.T) {
+	ips := []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1")}
+	dial, cancelled := blackHoledDial(false)
+
+	start := time.Now()
+	conn, err := dialHappyEyeballs(context.Background(), ips, 50*time.Millisecond, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if ip := conn.(*fakeAddrConn).ip; !ip.Equal(ips[1]) {
+		t.Error("expect connection to IPv4 address, but got ", ip)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Error("unexpected elapsed time ", elapsed)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(cancelled) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("IPv6 attempt is not cancelled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHappyEyeballsBlackHoledIPv4(t *testing.T) {
+	ips := []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")}
+	dial, _ := blackHoledDial(true)
+
+	conn, err := dialHappyEyeballs(context.Background(), ips, 50*time.Millisecond, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if ip := conn.(*fakeAddrConn).ip; !ip.Equal(ips[1]) {
+		t.Error("expect connection to IPv6 address, but got ", ip)
+	}
+}
+
+func TestHappyEyeballsPrimaryFirst(t *testing.T) {
+	ips := []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1")}
+	var dialed int32
+	dial := func(ctx context.Context, ip net.IP) (net.Conn, error) {
+		atomic.AddInt32(&dialed, 1)
+		c, _ := net.Pipe()
+		return &fakeAddrConn{Conn: c, ip: ip}, nil
+	}
+
+	conn, err := dialHappyEyeballs(context.Background(), ips, time.Second, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if ip := conn.(*fakeAddrConn).ip; !ip.Equal(ips[0]) {
+		t.Error("expect connection to IPv6 address, but got ", ip)
+	}
+	if n := atomic.LoadInt32(&dialed); n != 1 {
+		t.Error("expect only one attempt, but got ", n)
+	}
+}
+
+func TestHappyEyeballsAllFailed(t *testing.T) {
+	ips := []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1")}
+	dial := func(ctx context.Context, ip net.IP) (net.Conn, error) {
+		return nil, newError("unreachable")
+	}
+
+	start := time.Now()
+	if _, err := dialHappyEyeballs(context.Background(), ips, time.Second, dial); err == nil {
+		t.Fatal("expect error, but got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Error("fallback is not started immediately on failure, elapsed ", elapsed)
+	}
+}
+
+func TestDialIPsSerial(t *testing.T) {
+	ips := []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1")}
+	dial, cancelled := blackHoledDial(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := dialIPs(ctx, ips, 0, dial); err == nil {
+		t.Fatal("expect IPv4 address not to be dialed before IPv6 attempt fails")
+	}
+	if n := atomic.LoadInt32(cancelled); n != 1 {
+		t.Error("expect IPv6 attempt to be cancelled, but got ", n)
+	}
+}
+
+// delayedLookup resolves each family after its delay, or fails if it has no
+// delay.
+func delayedLookup(delays map[string]time.Duration) familyLookupFunc {
+	addresses := map[string][]net.IP{
+		"ip4": {net.ParseIP("192.0.2.1")},
+		"ip6": {net.ParseIP("2001:db8::1")},
+	}
+	return func(ctx context.Context, network string) ([]net.IP, error) {
+		delay, found := delays[network]
+		if !found {
+			return nil, newError("no ", network, " address")
+		}
+		select {
+		case <-time.After(delay):
+			return addresses[network], nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func TestResolveHappyEyeballs(t *testing.T) {
+	testCases := []struct {
+		name       string
+		delays     map[string]time.Duration
+		preferIPv4 bool
+		ips        []string
+		minElapsed time.Duration
+		maxElapsed time.Duration
+	}{
+		{
+			name:       "AAAA answers first",
+			delays:     map[string]time.Duration{"ip4": 300 * time.Millisecond, "ip6": 0},
+			ips:        []string{"2001:db8::1"},
+			maxElapsed: 200 * time.Millisecond,
+		},
+		{
+			name:       "AAAA delayed within resolution delay",
+			delays:     map[string]time.Duration{"ip4": 0, "ip6": 50 * time.Millisecond},
+			ips:        []string{"2001:db8::1", "192.0.2.1"},
+			minElapsed: 50 * time.Millisecond,
+			maxElapsed: 150 * time.Millisecond,
+		},
+		{
+			name:       "AAAA delayed beyond resolution delay",
+			delays:     map[string]time.Duration{"ip4": 0, "ip6": time.Second},
+			ips:        []string{"192.0.2.1"},
+			minElapsed: 100 * time.Millisecond,
+			maxElapsed: 500 * time.Millisecond,
+		},
+		{
+			name:       "AAAA failed",
+			delays:     map[string]time.Duration{"ip4": 50 * time.Millisecond},
+			ips:        []string{"192.0.2.1"},
+			maxElapsed: 100 * time.Millisecond,
+		},
+		{
+			name:       "IPv4 preferred",
+			delays:     map[string]time.Duration{"ip4": 0, "ip6": time.Second},
+			preferIPv4: true,
+			ips:        []string{"192.0.2.1"},
+			maxElapsed: 50 * time.Millisecond,
+		},
+	}
+	for _, tc := range testCases {
+		start := time.Now()
+		ips, err := resolveHappyEyeballs(context.Background(), delayedLookup(tc.delays), tc.preferIPv4, 100*time.Millisecond)
+		elapsed := time.Since(start)
+		if err != nil {
+			t.Error(tc.name, ": ", err)
+			continue
+		}
+		if len(ips) != len(tc.ips) {
+			t.Error(tc.name, ": expect ", tc.ips, ", but got ", ips)
+			continue
+		}
+		for i, ip := range ips {
+			if !ip.Equal(net.ParseIP(tc.ips[i])) {
+				t.Error(tc.name, ": expect ", tc.ips, ", but got ", ips)
+				break
+			}
+		}
+		if elapsed < tc.minElapsed || elapsed > tc.maxElapsed {
+			t.Error(tc.name, ": unexpected elapsed time ", elapsed)
+		}
+	}
+
+	if _, err := resolveHappyEyeballs(context.Background(), delayedLookup(nil), false, 100*time.Millisecond); err == nil {
+		t.Error("expect error when both families fail")
+	}
+}
+
+func TestFilterAddressFamily(t *testing.T) {
+	ips := []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1"), net.ParseIP("::ffff:192.0.2.2")}
+
+	if v4 := filterAddressFamily(ips, DialAddressFamily_IPv4Only); len(v4) != 2 || !v4[0].Equal(ips[1]) || !v4[1].Equal(ips[2]) {
+		t.Error("unexpected IPv4 addresses ", v4)
+	}
+	if v6 := filterAddressFamily(ips, DialAddressFamily_IPv6Only); len(v6) != 1 || !v6[0].Equal(ips[0]) {
+		t.Error("unexpected IPv6 addresses ", v6)
+	}
+	if all := filterAddressFamily(ips, DialAddressFamily_AsIs); len(all) != 3 {
+		t.Error("unexpected addresses ", all)
+	}
+}
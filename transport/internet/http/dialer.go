@@ -21,28 +21,51 @@ import (
 	"github.com/v2fly/v2ray-core/v4/transport/pipe"
 )
 
+// dialerKey identifies clients shared by connections to the same destination.
+type dialerKey struct {
+	dest net.Destination
+	h2c  bool
+}
+
 var (
-	globalDialerMap    map[net.Destination]*http.Client
+	globalDialerMap    map[dialerKey]*http.Client
 	globalDialerAccess sync.Mutex
 )
 
 type dialerCanceller func()
 
+// getHTTPClient returns the client for dest. It speaks h2c if tlsSettings is
+// nil.
 func getHTTPClient(ctx context.Context, dest net.Destination, tlsSettings *tls.Config) (*http.Client, dialerCanceller) {
 	globalDialerAccess.Lock()
 	defer globalDialerAccess.Unlock()
 
+	key := dialerKey{dest: dest, h2c: tlsSettings == nil}
 	canceller := func() {
 		globalDialerAccess.Lock()
 		defer globalDialerAccess.Unlock()
-		delete(globalDialerMap, dest)
+		delete(globalDialerMap, key)
 	}
 
 	if globalDialerMap == nil {
-		globalDialerMap = make(map[net.Destination]*http.Client)
+		globalDialerMap = make(map[dialerKey]*http.Client)
+	}
+
+	if client, found := globalDialerMap[key]; found {
+		return client, canceller
 	}
 
-	if client, found := globalDialerMap[dest]; found {
+	if tlsSettings == nil {
+		client := &http.Client{
+			Transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLS: func(network string, addr string, _ *gotls.Config) (net.Conn, error) {
+					detachedContext := core.ToBackgroundDetachedContext(ctx)
+					return internet.DialSystem(detachedContext, dest, nil)
+				},
+			},
+		}
+		globalDialerMap[key] = client
 		return client, canceller
 	}
 
@@ -89,7 +112,7 @@ func getHTTPClient(ctx context.Context, dest net.Destination, tlsSettings *tls.C
 		Transport: transport,
 	}
 
-	globalDialerMap[dest] = client
+	globalDialerMap[key] = client
 	return client, canceller
 }
 
@@ -97,7 +120,13 @@ func getHTTPClient(ctx context.Context, dest net.Destination, tlsSettings *tls.C
 func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.MemoryStreamConfig) (internet.Connection, error) {
 	httpSettings := streamSettings.ProtocolSettings.(*Config)
 	tlsConfig := tls.ConfigFromStreamSettings(streamSettings)
-	if tlsConfig == nil {
+	scheme := "https"
+	switch {
+	case httpSettings.H2C && tlsConfig != nil:
+		return nil, newError("TLS must not be enabled for http transport in h2c mode.").AtWarning()
+	case httpSettings.H2C:
+		scheme = "http"
+	case tlsConfig == nil:
 		return nil, newError("TLS must be enabled for http transport.").AtWarning()
 	}
 	client, canceller := getHTTPClient(ctx, dest, tlsConfig)
@@ -124,7 +153,7 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 		Host:   httpSettings.getRandomHost(),
 		Body:   breader,
 		URL: &url.URL{
-			Scheme: "https",
+			Scheme: scheme,
 			Host:   dest.NetAddr(),
 			Path:   httpSettings.getNormalizedPath(),
 		},
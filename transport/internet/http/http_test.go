@@ -3,6 +3,7 @@ package http_test
 import (
 	"context"
 	"crypto/rand"
+	"io"
 	"testing"
 	"time"
 
@@ -92,3 +93,68 @@ func TestHTTPConnection(t *testing.T) {
 		t.Error(r)
 	}
 }
+
+func TestH2CConnection(t *testing.T) {
+	port := tcp.PickPort()
+
+	listener, err := Listen(context.Background(), net.LocalHostIP, port, &internet.MemoryStreamConfig{
+		ProtocolName:     "http",
+		ProtocolSettings: &Config{H2C: true, Path: "/tunnel"},
+	}, func(conn internet.Connection) {
+		go func() {
+			defer conn.Close()
+
+			b := buf.New()
+			defer b.Release()
+
+			for {
+				b.Clear()
+				if _, err := b.ReadFrom(conn); err != nil {
+					return
+				}
+				if _, err := conn.Write(b.Bytes()); err != nil {
+					return
+				}
+			}
+		}()
+	})
+	common.Must(err)
+	defer listener.Close()
+
+	time.Sleep(time.Second)
+
+	conn, err := Dial(context.Background(), net.TCPDestination(net.LocalHostIP, port), &internet.MemoryStreamConfig{
+		ProtocolName:     "http",
+		ProtocolSettings: &Config{H2C: true, Path: "/tunnel"},
+	})
+	common.Must(err)
+	defer conn.Close()
+
+	// Larger than the initial flow control window of HTTP/2 streams.
+	const N = 256 * 1024
+	b1 := make([]byte, N)
+	common.Must2(rand.Read(b1))
+
+	go func() {
+		for i := 0; i < N; i += 16 * 1024 {
+			if _, err := conn.Write(b1[i : i+16*1024]); err != nil {
+				return
+			}
+		}
+	}()
+
+	b2 := make([]byte, N)
+	common.Must2(io.ReadFull(conn, b2))
+	if r := cmp.Diff(b2, b1); r != "" {
+		t.Error(r)
+	}
+
+	if _, err := Dial(context.Background(), net.TCPDestination(net.LocalHostIP, port), &internet.MemoryStreamConfig{
+		ProtocolName:     "http",
+		ProtocolSettings: &Config{H2C: true},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{ServerName: "www.v2fly.org"},
+	}); err == nil {
+		t.Error("expect error for TLS in h2c mode")
+	}
+}
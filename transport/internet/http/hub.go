@@ -140,6 +140,9 @@ func Listen(ctx context.Context, address net.Address, port net.Port, streamSetti
 
 	var server *http.Server
 	config := tls.ConfigFromStreamSettings(streamSettings)
+	if httpSettings.H2C && config != nil {
+		return nil, newError("TLS must not be enabled for http transport in h2c mode.")
+	}
 	if config == nil {
 		h2s := &http2.Server{}
 
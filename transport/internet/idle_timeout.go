@@ -0,0 +1,169 @@
+package internet
+
+import (
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+// idleTimeoutConn closes the underlying connection when no data is read or
+// written for a period of time. Activity in either direction keeps the
+// connection open, so that half-duplex transfers are not interrupted.
+type idleTimeoutConn struct {
+	net.Conn
+	timeout    time.Duration
+	now        func() time.Time
+	lastActive int64 // Unix time in nanoseconds, accessed atomically.
+
+	access sync.Mutex
+	timer  *time.Timer
+	closed bool
+}
+
+func newIdleTimeoutConn(conn net.Conn, timeout time.Duration, now func() time.Time) *idleTimeoutConn {
+	c := &idleTimeoutConn{
+		Conn:    conn,
+		timeout: timeout,
+		now:     now,
+	}
+	c.touch()
+	c.access.Lock()
+	c.timer = time.AfterFunc(timeout, c.check)
+	c.access.Unlock()
+	return c
+}
+
+func (c *idleTimeoutConn) touch() {
+	atomic.StoreInt64(&c.lastActive, c.now().UnixNano())
+}
+
+// check closes the connection if it has been idle for the timeout, or
+// schedules the next check otherwise.
+func (c *idleTimeoutConn) check() {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.closed {
+		return
+	}
+
+	idle := c.now().Sub(time.Unix(0, atomic.LoadInt64(&c.lastActive)))
+	if idle < c.timeout {
+		c.timer.Reset(c.timeout - idle)
+		return
+	}
+	newError("closing connection to ", c.RemoteAddr(), " idle for ", idle).AtDebug().WriteToLog()
+	c.closed = true
+	c.Conn.Close()
+}
+
+// Read implements net.Conn.
+func (c *idleTimeoutConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.touch()
+	}
+	return n, err
+}
+
+// Write implements net.Conn.
+func (c *idleTimeoutConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.touch()
+	}
+	return n, err
+}
+
+// Close implements net.Conn.
+func (c *idleTimeoutConn) Close() error {
+	c.access.Lock()
+	c.closed = true
+	c.timer.Stop()
+	c.access.Unlock()
+	return c.Conn.Close()
+}
+
+func (c *idleTimeoutConn) isClosed() bool {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.closed
+}
+
+// sysConn is implemented by TCP and Unix domain socket connections.
+type sysConn interface {
+	syscall.Conn
+	CloseWrite() error
+}
+
+// idleTimeoutSysConn is an idleTimeoutConn over a connection with access to
+// the underlying socket, which it keeps exposing so that buf.NewReader can
+// still use readv.
+type idleTimeoutSysConn struct {
+	*idleTimeoutConn
+	sys sysConn
+}
+
+// SyscallConn implements syscall.Conn. Reads and writes done on the raw
+// connection count as activity.
+func (c *idleTimeoutSysConn) SyscallConn() (syscall.RawConn, error) {
+	rawConn, err := c.sys.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	return &idleTimeoutRawConn{RawConn: rawConn, conn: c.idleTimeoutConn}, nil
+}
+
+// CloseWrite shuts down the writing side of the underlying connection.
+func (c *idleTimeoutSysConn) CloseWrite() error {
+	return c.sys.CloseWrite()
+}
+
+type idleTimeoutRawConn struct {
+	syscall.RawConn
+	conn *idleTimeoutConn
+}
+
+// Read implements syscall.RawConn.
+func (c *idleTimeoutRawConn) Read(f func(fd uintptr) bool) error {
+	err := c.RawConn.Read(f)
+	if err == nil {
+		c.conn.touch()
+	}
+	return err
+}
+
+// Write implements syscall.RawConn.
+func (c *idleTimeoutRawConn) Write(f func(fd uintptr) bool) error {
+	err := c.RawConn.Write(f)
+	if err == nil {
+		c.conn.touch()
+	}
+	return err
+}
+
+// withIdleTimeout wraps the connection to be closed when it becomes idle.
+func withIdleTimeout(conn net.Conn, timeout time.Duration, now func() time.Time) net.Conn {
+	c := newIdleTimeoutConn(conn, timeout, now)
+	if sys, ok := conn.(sysConn); ok {
+		return &idleTimeoutSysConn{idleTimeoutConn: c, sys: sys}
+	}
+	return c
+}
+
+// idleTimeoutListener closes accepted connections when they become idle.
+type idleTimeoutListener struct {
+	net.Listener
+	timeout time.Duration
+}
+
+// Accept implements net.Listener.
+func (l *idleTimeoutListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return withIdleTimeout(conn, l.timeout, time.Now), nil
+}
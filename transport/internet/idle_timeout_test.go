@@ -0,0 +1,153 @@
+package internet
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/buf"
+)
+
+type fakeClock struct {
+	access sync.Mutex
+	now    time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestIdleTimeoutConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go io.Copy(ioutil.Discard, server)
+
+	clock := &fakeClock{now: time.Unix(10000, 0)}
+	// The timer is not expected to fire during the test, checks are triggered manually.
+	conn := newIdleTimeoutConn(client, time.Minute, clock.Now)
+	conn.timer.Reset(time.Hour)
+	defer conn.Close()
+
+	// Writing only, with nothing to read, keeps the connection open.
+	for i := 0; i < 3; i++ {
+		clock.Advance(50 * time.Second)
+		common.Must2(conn.Write([]byte("ping")))
+		conn.check()
+		if conn.isClosed() {
+			t.Fatal("expect connection to be kept open after ", i+1, " writes")
+		}
+	}
+
+	clock.Advance(59 * time.Second)
+	conn.check()
+	if conn.isClosed() {
+		t.Fatal("expect connection to be kept open before timeout")
+	}
+
+	clock.Advance(time.Second)
+	conn.check()
+	if !conn.isClosed() {
+		t.Fatal("expect connection to be closed after timeout")
+	}
+	if _, err := conn.Write([]byte("ping")); err == nil {
+		t.Error("expect error writing to closed connection")
+	}
+}
+
+func TestIdleTimeoutConnRead(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	clock := &fakeClock{now: time.Unix(10000, 0)}
+	conn := newIdleTimeoutConn(client, time.Minute, clock.Now)
+	conn.timer.Reset(time.Hour)
+	defer conn.Close()
+
+	go func() {
+		for i := 0; i < 3; i++ {
+			server.Write([]byte("pong"))
+		}
+	}()
+	b := make([]byte, 4)
+	for i := 0; i < 3; i++ {
+		clock.Advance(50 * time.Second)
+		common.Must2(io.ReadFull(conn, b))
+		conn.check()
+		if conn.isClosed() {
+			t.Fatal("expect connection to be kept open after ", i+1, " reads")
+		}
+	}
+}
+
+func TestIdleTimeoutConnTimer(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	conn := newIdleTimeoutConn(client, 50*time.Millisecond, time.Now)
+	defer conn.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expect error reading idle connection")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect idle connection to be closed")
+	}
+}
+
+func TestIdleTimeoutConnSyscallConn(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	client, err := net.Dial("tcp", listener.Addr().String())
+	common.Must(err)
+	defer client.Close()
+	server, err := listener.Accept()
+	common.Must(err)
+
+	clock := &fakeClock{now: time.Unix(10000, 0)}
+	conn := withIdleTimeout(server, time.Minute, clock.Now)
+	defer conn.Close()
+	c := conn.(*idleTimeoutSysConn)
+	c.timer.Reset(time.Hour)
+
+	if _, ok := conn.(syscall.Conn); !ok {
+		t.Fatal("expect syscall.Conn to be forwarded")
+	}
+	reader := buf.NewReader(conn)
+	for i := 0; i < 3; i++ {
+		clock.Advance(50 * time.Second)
+		common.Must2(client.Write([]byte("ping")))
+		mb, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		buf.ReleaseMulti(mb)
+		c.check()
+		if c.isClosed() {
+			t.Fatal("expect connection to be kept open after ", i+1, " raw reads")
+		}
+	}
+
+	common.Must(conn.(interface{ CloseWrite() error }).CloseWrite())
+	if _, err := client.Read(make([]byte, 1)); err != io.EOF {
+		t.Error("expect EOF after CloseWrite, but got ", err)
+	}
+}
@@ -198,7 +198,8 @@ type Connection struct {
 	receivingWorker *ReceivingWorker
 	sendingWorker   *SendingWorker
 
-	output SegmentWriter
+	output        SegmentWriter
+	outputFlusher flusher
 
 	dataUpdater *Updater
 	pingUpdater *Updater
@@ -223,6 +224,10 @@ func NewConnection(meta ConnMetadata, writer PacketWriter, closer io.Closer, con
 		},
 	}
 
+	if f, ok := writer.(flusher); ok {
+		conn.outputFlusher = f
+	}
+
 	conn.receivingWorker = NewReceivingWorker(conn)
 	conn.sendingWorker = NewSendingWorker(conn)
 
@@ -611,6 +616,8 @@ func (c *Connection) flush() {
 	if c.State() == StateTerminated {
 		return
 	}
+	defer c.flushOutput()
+
 	if c.State() == StateActive && current-atomic.LoadUint32(&c.lastIncomingTime) >= 30000 {
 		c.Close()
 	}
@@ -644,6 +651,16 @@ func (c *Connection) flush() {
 	}
 }
 
+// flushOutput sends segments buffered in the output, if it buffers.
+func (c *Connection) flushOutput() {
+	if c.outputFlusher == nil {
+		return
+	}
+	if err := c.outputFlusher.Flush(); err != nil {
+		newError("#", c.meta.Conversation, " failed to flush output").Base(err).AtDebug().WriteToLog()
+	}
+}
+
 func (c *Connection) State() State {
 	return State(atomic.LoadInt32((*int32)(&c.state)))
 }
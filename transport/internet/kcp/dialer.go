@@ -74,6 +74,11 @@ func DialKCP(ctx context.Context, dest net.Destination, streamSettings *internet
 		Security: security,
 		Writer:   rawConn,
 	}
+	if streamSettings.SocketSettings.GetUdpGso() {
+		if batchWriter := internet.NewDatagramBatchWriterFromConn(rawConn); batchWriter != nil {
+			writer.Writer = batchWriter
+		}
+	}
 
 	conv := uint16(atomic.AddUint32(&globalConv, 1))
 	session := NewConnection(ConnMetadata{
@@ -22,6 +22,10 @@ type PacketWriter interface {
 	io.Writer
 }
 
+type flusher interface {
+	Flush() error
+}
+
 type KCPPacketReader struct { // nolint: golint
 	Security cipher.AEAD
 	Header   internet.PacketHeader
@@ -96,3 +100,11 @@ func (w *KCPPacketWriter) Write(b []byte) (int, error) {
 	_, err := w.Writer.Write(bb.Bytes())
 	return len(b), err
 }
+
+// Flush sends packets buffered in the underlying writer, if it buffers.
+func (w *KCPPacketWriter) Flush() error {
+	if f, ok := w.Writer.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
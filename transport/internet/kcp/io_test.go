@@ -1,8 +1,15 @@
 package kcp_test
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/transport/internet"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/headers/noop"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/headers/srtp"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/headers/utp"
+	"github.com/v2fly/v2ray-core/v4/transport/internet/headers/wechat"
 	. "github.com/v2fly/v2ray-core/v4/transport/internet/kcp"
 )
 
@@ -34,3 +41,57 @@ func TestKCPPacketReader(t *testing.T) {
 		}
 	}
 }
+
+func TestKCPPacketSeed(t *testing.T) {
+	seg := &DataSegment{
+		Conv:   1,
+		Number: 2,
+	}
+	seg.Data().Write([]byte("payload"))
+	payload := make([]byte, seg.ByteSize())
+	seg.Serialize(payload)
+
+	headers := map[string]interface{}{
+		"none":         &noop.Config{},
+		"srtp":         &srtp.Config{},
+		"utp":          &utp.Config{},
+		"wechat-video": &wechat.VideoConfig{},
+	}
+	for name, headerConfig := range headers {
+		header, err := internet.CreatePacketHeader(headerConfig)
+		common.Must(err)
+
+		var packet bytes.Buffer
+		writer := &KCPPacketWriter{
+			Header:   header,
+			Security: NewAEADAESGCMBasedOnSeed("seed"),
+			Writer:   &packet,
+		}
+		common.Must2(writer.Write(payload))
+		if bytes.Contains(packet.Bytes(), []byte("payload")) {
+			t.Error(name, ": expect payload to be encrypted")
+		}
+
+		testCases := []struct {
+			security string
+			decoded  bool
+		}{
+			{security: "seed", decoded: true},
+			{security: "other seed", decoded: false},
+			{security: "", decoded: false},
+		}
+		for _, tc := range testCases {
+			reader := &KCPPacketReader{
+				Header:   header,
+				Security: NewSimpleAuthenticator(),
+			}
+			if tc.security != "" {
+				reader.Security = NewAEADAESGCMBasedOnSeed(tc.security)
+			}
+			segments := reader.Read(append([]byte(nil), packet.Bytes()...))
+			if decoded := len(segments) == 1; decoded != tc.decoded {
+				t.Error(name, ": expect decoded ", tc.decoded, " with seed '", tc.security, "', but got ", segments)
+			}
+		}
+	}
+}
@@ -83,3 +83,36 @@ func TestDialAndListen(t *testing.T) {
 		t.Error("active connections: ", v)
 	}
 }
+
+func TestDialAndListenWithGSO(t *testing.T) {
+	streamSettings := &internet.MemoryStreamConfig{
+		ProtocolName:     "mkcp",
+		ProtocolSettings: &Config{},
+		SocketSettings: &internet.SocketConfig{
+			UdpGso: true,
+		},
+	}
+	listerner, err := NewListener(context.Background(), net.LocalHostIP, net.Port(0), streamSettings, func(conn internet.Connection) {
+		go func(c internet.Connection) {
+			defer c.Close()
+			io.Copy(c, c)
+		}(conn)
+	})
+	common.Must(err)
+	defer listerner.Close()
+
+	port := net.Port(listerner.Addr().(*net.UDPAddr).Port)
+	clientConn, err := DialKCP(context.Background(), net.UDPDestination(net.LocalHostIP, port), streamSettings)
+	common.Must(err)
+	defer clientConn.Close()
+
+	clientSend := make([]byte, 1024*1024)
+	common.Must2(rand.Read(clientSend))
+	go clientConn.Write(clientSend)
+
+	clientReceived := make([]byte, 1024*1024)
+	common.Must2(io.ReadFull(clientConn, clientReceived))
+	if r := cmp.Diff(clientReceived, clientSend); r != "" {
+		t.Error(r)
+	}
+}
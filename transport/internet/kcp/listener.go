@@ -34,6 +34,7 @@ type Listener struct {
 	header    internet.PacketHeader
 	security  cipher.AEAD
 	addConn   internet.ConnHandler
+	gso       bool
 }
 
 func NewListener(ctx context.Context, address net.Address, port net.Port, streamSettings *internet.MemoryStreamConfig, addConn internet.ConnHandler) (*Listener, error) {
@@ -56,6 +57,7 @@ func NewListener(ctx context.Context, address net.Address, port net.Port, stream
 		sessions: make(map[ConnectionID]*Connection),
 		config:   kcpSettings,
 		addConn:  addConn,
+		gso:      streamSettings.SocketSettings.GetUdpGso(),
 	}
 
 	if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
@@ -121,15 +123,19 @@ func (l *Listener) OnReceive(payload *buf.Buffer, src net.Destination) {
 			Port: int(src.Port),
 		}
 		localAddr := l.hub.Addr()
+		packetWriter := &KCPPacketWriter{
+			Header:   l.header,
+			Security: l.security,
+			Writer:   writer,
+		}
+		if l.gso {
+			packetWriter.Writer = l.hub.NewBatchWriter(src)
+		}
 		conn = NewConnection(ConnMetadata{
 			LocalAddr:    localAddr,
 			RemoteAddr:   remoteAddr,
 			Conversation: conv,
-		}, &KCPPacketWriter{
-			Header:   l.header,
-			Security: l.security,
-			Writer:   writer,
-		}, writer, l.config)
+		}, packetWriter, writer, l.config)
 		var netConn internet.Connection = conn
 		if l.tlsConfig != nil {
 			netConn = tls.Server(conn, l.tlsConfig)
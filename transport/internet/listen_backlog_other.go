@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package internet
+
+import (
+	"net"
+)
+
+func setBacklog(l net.Listener, backlog int) error {
+	newError("setting listen backlog is only supported on Linux, macOS and FreeBSD, ignoring backlog ", backlog).AtWarning().WriteToLog()
+	return nil
+}
@@ -0,0 +1,32 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package internet
+
+import (
+	"net"
+	"syscall"
+)
+
+// setBacklog listens on the socket of l again with the backlog, which
+// replaces the backlog it was listened with.
+func setBacklog(l net.Listener, backlog int) error {
+	sc, ok := l.(syscall.Conn)
+	if !ok {
+		return newError("unable to set backlog of listener ", l.Addr())
+	}
+	rawConn, err := sc.SyscallConn()
+	if err != nil {
+		return newError("unable to set backlog of listener ", l.Addr()).Base(err)
+	}
+	var listenErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		listenErr = syscall.Listen(int(fd), backlog)
+	}); err != nil {
+		return newError("unable to set backlog of listener ", l.Addr()).Base(err)
+	}
+	if listenErr != nil {
+		return newError("failed to listen with backlog ", backlog).Base(listenErr)
+	}
+	return nil
+}
@@ -7,22 +7,37 @@ type MemoryStreamConfig struct {
 	SecurityType     string
 	SecuritySettings interface{}
 	SocketSettings   *SocketConfig
+	DialRetry        *DialRetryConfig
 }
 
 // ToMemoryStreamConfig converts a StreamConfig to MemoryStreamConfig. It returns a default non-nil MemoryStreamConfig for nil input.
 func ToMemoryStreamConfig(s *StreamConfig) (*MemoryStreamConfig, error) {
-	ets, err := s.GetEffectiveTransportSettings()
+	var ets interface{}
+	settings, err := s.GetEffectiveTransportSettings()
+	switch {
+	case err == ErrNoTransportSettings:
+		ets, err = defaultTransportSettingsFor(s.EffectiveProtocolName())
+	case err == nil:
+		ets, err = settings.GetInstance()
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	mss := &MemoryStreamConfig{
-		ProtocolName:     s.GetEffectiveProtocol(),
+		ProtocolName:     s.EffectiveProtocolName(),
 		ProtocolSettings: ets,
 	}
 
 	if s != nil {
+		if err := validateBindInterface(s.SocketSettings); err != nil {
+			return nil, err
+		}
+		if err := validateSourceAddresses(s.SocketSettings); err != nil {
+			return nil, err
+		}
 		mss.SocketSettings = s.SocketSettings
+		mss.DialRetry = s.DialRetry
 	}
 
 	if s != nil && s.HasSecuritySettings() {
@@ -0,0 +1,77 @@
+package internet
+
+import (
+	"context"
+
+	"github.com/pires/go-proxyproto"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+)
+
+// newProxyProtocolHeader creates a PROXY protocol header of the given version,
+// conveying the source and local address of the inbound connection in ctx.
+// The header carries the LOCAL command if the addresses are not available.
+func newProxyProtocolHeader(ctx context.Context, version uint32) *proxyproto.Header {
+	header := &proxyproto.Header{
+		Version:           byte(version),
+		Command:           proxyproto.LOCAL,
+		TransportProtocol: proxyproto.UNSPEC,
+	}
+
+	inbound := session.InboundFromContext(ctx)
+	if inbound == nil || !inbound.Source.IsValid() || !inbound.Local.IsValid() ||
+		!inbound.Source.Address.Family().IsIP() || !inbound.Local.Address.Family().IsIP() {
+		return header
+	}
+
+	srcIP := inbound.Source.Address.IP()
+	dstIP := inbound.Local.Address.IP()
+	if srcIP.To4() != nil && dstIP.To4() != nil {
+		header.TransportProtocol = proxyproto.TCPv4
+		srcIP, dstIP = srcIP.To4(), dstIP.To4()
+	} else {
+		// Addresses of different families are both sent as IPv6 addresses.
+		header.TransportProtocol = proxyproto.TCPv6
+		srcIP, dstIP = srcIP.To16(), dstIP.To16()
+	}
+	header.Command = proxyproto.PROXY
+	header.SourceAddr = &net.TCPAddr{
+		IP:   srcIP,
+		Port: int(inbound.Source.Port),
+	}
+	header.DestinationAddr = &net.TCPAddr{
+		IP:   dstIP,
+		Port: int(inbound.Local.Port),
+	}
+	return header
+}
+
+// writeProxyProtocolHeader writes a PROXY protocol header to a newly
+// established connection, before any payload.
+func writeProxyProtocolHeader(ctx context.Context, conn net.Conn, version uint32) error {
+	if version != 1 && version != 2 {
+		return newError("unsupported PROXY protocol version: ", version)
+	}
+	if _, err := newProxyProtocolHeader(ctx, version).WriteTo(conn); err != nil {
+		return newError("failed to write PROXY protocol header").Base(err)
+	}
+	return nil
+}
+
+// newProxyProtocolListener wraps l to read a PROXY protocol header of either
+// version from accepted connections, whose remote address is replaced with the
+// source address conveyed in the header. Connections with a malformed header
+// are rejected, as are connections without a header unless allowMissing is set.
+func newProxyProtocolListener(l net.Listener, allowMissing bool) net.Listener {
+	policy := proxyproto.REQUIRE
+	if allowMissing {
+		policy = proxyproto.USE
+	}
+	return &proxyproto.Listener{
+		Listener: l,
+		Policy: func(upstream net.Addr) (proxyproto.Policy, error) {
+			return policy, nil
+		},
+	}
+}
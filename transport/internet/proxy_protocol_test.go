@@ -0,0 +1,207 @@
+package internet_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/pires/go-proxyproto"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	"github.com/v2fly/v2ray-core/v4/testing/servers/tcp"
+	. "github.com/v2fly/v2ray-core/v4/transport/internet"
+)
+
+func TestSendProxyProtocol(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	testCases := []struct {
+		version  uint32
+		inbound  *session.Inbound
+		protocol proxyproto.AddressFamilyAndProtocol
+		source   string
+		dest     string
+	}{
+		{
+			version: 2,
+			inbound: &session.Inbound{
+				Source: net.TCPDestination(net.ParseAddress("192.0.2.1"), 12345),
+				Local:  net.TCPDestination(net.ParseAddress("198.51.100.1"), 443),
+			},
+			protocol: proxyproto.TCPv4,
+			source:   "192.0.2.1:12345",
+			dest:     "198.51.100.1:443",
+		},
+		{
+			version: 2,
+			inbound: &session.Inbound{
+				Source: net.TCPDestination(net.ParseAddress("2001:db8::1"), 12345),
+				Local:  net.TCPDestination(net.ParseAddress("2001:db8::2"), 443),
+			},
+			protocol: proxyproto.TCPv6,
+			source:   "[2001:db8::1]:12345",
+			dest:     "[2001:db8::2]:443",
+		},
+		{
+			version: 2,
+			inbound: &session.Inbound{
+				Source: net.TCPDestination(net.ParseAddress("2001:db8::1"), 12345),
+				Local:  net.TCPDestination(net.ParseAddress("198.51.100.1"), 443),
+			},
+			protocol: proxyproto.TCPv6,
+			source:   "[2001:db8::1]:12345",
+			dest:     "198.51.100.1:443",
+		},
+		{
+			version: 1,
+			inbound: &session.Inbound{
+				Source: net.TCPDestination(net.ParseAddress("192.0.2.1"), 12345),
+				Local:  net.TCPDestination(net.ParseAddress("198.51.100.1"), 443),
+			},
+			protocol: proxyproto.TCPv4,
+			source:   "192.0.2.1:12345",
+			dest:     "198.51.100.1:443",
+		},
+		{
+			version:  2,
+			protocol: proxyproto.UNSPEC,
+		},
+		{
+			version: 2,
+			inbound: &session.Inbound{
+				Source:  net.TCPDestination(net.ParseAddress("192.0.2.1"), 12345),
+				Gateway: net.TCPDestination(net.AnyIP, 443),
+			},
+			protocol: proxyproto.UNSPEC,
+		},
+	}
+
+	for _, tc := range testCases {
+		ctx := context.Background()
+		if tc.inbound != nil {
+			ctx = session.ContextWithInbound(ctx, tc.inbound)
+		}
+
+		dialer := DefaultSystemDialer{}
+		conn, err := dialer.Dial(ctx, nil, dest, &SocketConfig{SendProxyProtocol: tc.version})
+		common.Must(err)
+
+		payload := []byte("payload")
+		common.Must2(conn.Write(payload))
+
+		reader := bufio.NewReader(conn)
+		header, err := proxyproto.Read(reader)
+		common.Must(err)
+
+		if header.Version != byte(tc.version) {
+			t.Error("unexpected version ", header.Version, " want ", tc.version)
+		}
+		if header.TransportProtocol != tc.protocol {
+			t.Error("unexpected transport protocol ", header.TransportProtocol, " want ", tc.protocol)
+		}
+		if tc.protocol != proxyproto.UNSPEC {
+			if header.SourceAddr.String() != tc.source || header.DestinationAddr.String() != tc.dest {
+				t.Error("unexpected addresses ", header.SourceAddr, " ", header.DestinationAddr)
+			}
+		} else if !header.Command.IsLocal() {
+			t.Error("expect LOCAL command, but got ", header.Command)
+		}
+
+		received := make([]byte, len(payload))
+		common.Must2(io.ReadFull(reader, received))
+		if string(received) != string(payload) {
+			t.Error("unexpected payload ", string(received))
+		}
+
+		conn.Close()
+	}
+}
+
+func TestAcceptProxyProtocol(t *testing.T) {
+	v2Header, err := (&proxyproto.Header{
+		Version:           2,
+		Command:           proxyproto.PROXY,
+		TransportProtocol: proxyproto.TCPv6,
+		SourceAddr:        &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 12345},
+		DestinationAddr:   &net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 443},
+	}).Format()
+	common.Must(err)
+
+	testCases := []struct {
+		header       []byte
+		allowMissing bool
+		source       string
+		rejected     bool
+	}{
+		{
+			header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 12345 443\r\n"),
+			source: "192.0.2.1:12345",
+		},
+		{
+			header: v2Header,
+			source: "[2001:db8::1]:12345",
+		},
+		{
+			header:   nil,
+			rejected: true,
+		},
+		{
+			header:       nil,
+			allowMissing: true,
+		},
+		{
+			header:       []byte("PROXY TCP4 not-an-address\r\n"),
+			allowMissing: true,
+			rejected:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		listener, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.LocalHostIP.IP()}, &SocketConfig{
+			AcceptProxyProtocol:       true,
+			AllowMissingProxyProtocol: tc.allowMissing,
+		})
+		common.Must(err)
+
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		common.Must(err)
+		payload := []byte("payload")
+		common.Must2(conn.Write(append(tc.header, payload...)))
+
+		serverConn, err := listener.Accept()
+		common.Must(err)
+
+		received := make([]byte, len(payload))
+		_, err = io.ReadFull(serverConn, received)
+		switch {
+		case tc.rejected:
+			if err == nil {
+				t.Error("expect connection with header ", string(tc.header), " to be rejected")
+			}
+		case err != nil:
+			t.Error("unexpected error ", err)
+		case string(received) != string(payload):
+			t.Error("unexpected payload ", string(received))
+		case tc.header == nil:
+			if serverConn.RemoteAddr().String() != conn.LocalAddr().String() {
+				t.Error("unexpected source ", serverConn.RemoteAddr())
+			}
+		case serverConn.RemoteAddr().String() != tc.source:
+			t.Error("unexpected source ", serverConn.RemoteAddr(), " want ", tc.source)
+		}
+
+		serverConn.Close()
+		conn.Close()
+		listener.Close()
+	}
+}
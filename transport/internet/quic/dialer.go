@@ -151,10 +151,11 @@ func (s *clientSessions) openConnection(destAddr net.Addr, config *Config, tlsCo
 	}
 
 	quicConfig := &quic.Config{
-		ConnectionIDLength:   12,
-		HandshakeIdleTimeout: time.Second * 8,
-		MaxIdleTimeout:       time.Second * 30,
-		KeepAlive:            true,
+		ConnectionIDLength:      12,
+		HandshakeIdleTimeout:    time.Second * 8,
+		MaxIdleTimeout:          time.Second * 30,
+		KeepAlive:               true,
+		DisablePathMTUDiscovery: config.DisablePathMtuDiscovery,
 	}
 
 	conn, err := wrapSysConn(rawConn, config)
@@ -105,12 +105,13 @@ func Listen(ctx context.Context, address net.Address, port net.Port, streamSetti
 	}
 
 	quicConfig := &quic.Config{
-		ConnectionIDLength:    12,
-		HandshakeIdleTimeout:  time.Second * 8,
-		MaxIdleTimeout:        time.Second * 45,
-		MaxIncomingStreams:    32,
-		MaxIncomingUniStreams: -1,
-		KeepAlive:             true,
+		ConnectionIDLength:      12,
+		HandshakeIdleTimeout:    time.Second * 8,
+		MaxIdleTimeout:          time.Second * 45,
+		MaxIncomingStreams:      32,
+		MaxIncomingUniStreams:   -1,
+		KeepAlive:               true,
+		DisablePathMTUDiscovery: config.DisablePathMtuDiscovery,
 	}
 
 	conn, err := wrapSysConn(rawConn, config)
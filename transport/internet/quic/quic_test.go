@@ -221,3 +221,60 @@ func TestQuicConnectionAuthHeader(t *testing.T) {
 		t.Error(r)
 	}
 }
+
+func TestQuicConnectionWithoutPathMTUDiscovery(t *testing.T) {
+	port := udp.PickPort()
+	config := &quic.Config{
+		DisablePathMtuDiscovery: true,
+	}
+
+	listener, err := quic.Listen(context.Background(), net.LocalHostIP, port, &internet.MemoryStreamConfig{
+		ProtocolName:     "quic",
+		ProtocolSettings: config,
+	}, func(conn internet.Connection) {
+		go func() {
+			defer conn.Close()
+
+			b := buf.New()
+			defer b.Release()
+
+			for {
+				b.Clear()
+				if _, err := b.ReadFrom(conn); err != nil {
+					return
+				}
+				common.Must2(conn.Write(b.Bytes()))
+			}
+		}()
+	})
+	common.Must(err)
+
+	defer listener.Close()
+
+	time.Sleep(time.Second)
+
+	dctx := context.Background()
+	conn, err := quic.Dial(dctx, net.TCPDestination(net.LocalHostIP, port), &internet.MemoryStreamConfig{
+		ProtocolName:     "quic",
+		ProtocolSettings: config,
+	})
+	common.Must(err)
+	defer conn.Close()
+
+	const N = 64 * 1024
+	b1 := make([]byte, N)
+	common.Must2(rand.Read(b1))
+	b2 := buf.New()
+
+	common.Must2(conn.Write(b1))
+
+	var received []byte
+	for len(received) < N {
+		b2.Clear()
+		common.Must2(b2.ReadFrom(conn))
+		received = append(received, b2.Bytes()...)
+	}
+	if r := cmp.Diff(received, b1); r != "" {
+		t.Error(r)
+	}
+}
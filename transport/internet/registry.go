@@ -0,0 +1,52 @@
+package internet
+
+import (
+	"sort"
+)
+
+// TransportInfo describes a transport protocol registered in this build.
+type TransportInfo struct {
+	// Name is the name the protocol is registered with, e.g. "tcp" or "mkcp".
+	Name string
+
+	// HasDialer is whether a dialer of the protocol is registered.
+	HasDialer bool
+
+	// HasListener is whether a listener of the protocol is registered.
+	HasListener bool
+}
+
+// Complete returns whether the protocol can both dial and listen.
+func (i TransportInfo) Complete() bool {
+	return i.HasDialer && i.HasListener
+}
+
+// RegisteredTransports returns the transport protocols with a dialer or a
+// listener registered, sorted by name. Unlike TransportProtocol_name, it only
+// lists protocols compiled into this build.
+func RegisteredTransports() []TransportInfo {
+	infos := make(map[string]*TransportInfo)
+	get := func(name string) *TransportInfo {
+		info, found := infos[name]
+		if !found {
+			info = &TransportInfo{Name: name}
+			infos[name] = info
+		}
+		return info
+	}
+	for name := range transportDialerCache {
+		get(name).HasDialer = true
+	}
+	for name := range transportListenerCache {
+		get(name).HasListener = true
+	}
+
+	transports := make([]TransportInfo, 0, len(infos))
+	for _, info := range infos {
+		transports = append(transports, *info)
+	}
+	sort.Slice(transports, func(i, j int) bool {
+		return transports[i].Name < transports[j].Name
+	})
+	return transports
+}
@@ -0,0 +1,40 @@
+package internet_test
+
+import (
+	"testing"
+
+	. "github.com/v2fly/v2ray-core/v4/transport/internet"
+	_ "github.com/v2fly/v2ray-core/v4/transport/internet/grpc"
+	_ "github.com/v2fly/v2ray-core/v4/transport/internet/http"
+	_ "github.com/v2fly/v2ray-core/v4/transport/internet/kcp"
+	_ "github.com/v2fly/v2ray-core/v4/transport/internet/quic"
+	_ "github.com/v2fly/v2ray-core/v4/transport/internet/tcp"
+	_ "github.com/v2fly/v2ray-core/v4/transport/internet/udp"
+	_ "github.com/v2fly/v2ray-core/v4/transport/internet/websocket"
+)
+
+func TestRegisteredTransports(t *testing.T) {
+	transports := RegisteredTransports()
+	registered := make(map[string]TransportInfo, len(transports))
+	for i, info := range transports {
+		if i > 0 && transports[i-1].Name >= info.Name {
+			t.Error("transports not sorted: ", transports[i-1].Name, " before ", info.Name)
+		}
+		registered[info.Name] = info
+	}
+
+	for _, name := range []string{"tcp", "mkcp", "websocket", "http", "quic", "gun"} {
+		info, found := registered[name]
+		if !found {
+			t.Error("transport ", name, " not listed")
+			continue
+		}
+		if !info.Complete() {
+			t.Error("expect both dialer and listener of ", name, ", but got ", info)
+		}
+	}
+
+	if info := registered["udp"]; !info.HasDialer || info.HasListener {
+		t.Error("expect only dialer of udp, but got ", info)
+	}
+}
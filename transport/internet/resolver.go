@@ -0,0 +1,52 @@
+package internet
+
+import (
+	"context"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
+	"github.com/v2fly/v2ray-core/v4/features/dns"
+)
+
+type resolverKey int
+
+const dnsClientKey resolverKey = 0
+
+// ContextWithDNSClient returns a new context with the DNS client of the
+// instance, which resolves domain destinations of connections dialed with a
+// resolver tag.
+func ContextWithDNSClient(ctx context.Context, client dns.Client) context.Context {
+	return context.WithValue(ctx, dnsClientKey, client)
+}
+
+func dnsClientFromContext(ctx context.Context) dns.Client {
+	if client, ok := ctx.Value(dnsClientKey).(dns.Client); ok {
+		return client
+	}
+	return nil
+}
+
+// resolverTag returns the tag of the name server resolving domain destinations,
+// i.e. that of the routing rule if any, or that of sockopt.
+func resolverTag(ctx context.Context, sockopt *SocketConfig) string {
+	if tag := session.ResolverTagFromContext(ctx); len(tag) > 0 {
+		return tag
+	}
+	if sockopt != nil {
+		return sockopt.ResolverTag
+	}
+	return ""
+}
+
+// resolveDomain resolves domain with the name server of the tag, or the system
+// resolver if tag is empty.
+func resolveDomain(ctx context.Context, domain string, tag string) ([]net.IP, error) {
+	if len(tag) == 0 {
+		return lookupIP(ctx, domain)
+	}
+	client, ok := dnsClientFromContext(ctx).(dns.TaggedLookup)
+	if !ok {
+		return nil, newError("no DNS client with tagged name servers for resolver ", tag)
+	}
+	return client.LookupIPWithTag(tag, domain)
+}
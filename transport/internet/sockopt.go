@@ -1,5 +1,40 @@
 package internet
 
+import (
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+var errReusePortNotSupported = newError("SO_REUSEPORT is only supported on Linux, FreeBSD and macOS")
+
+var bindToDeviceWarning sync.Once
+
+// warnBindToDeviceNotSupported logs, once per process, that the bind device
+// of config is ignored on platforms other than Linux. Other socket options
+// are still applied.
+func warnBindToDeviceNotSupported(config *SocketConfig) {
+	if len(config.BindToDevice) == 0 {
+		return
+	}
+	bindToDeviceWarning.Do(func() {
+		newError("binding to a network interface (SO_BINDTODEVICE) is only supported on Linux, ignoring bindToDevice ", config.BindToDevice).AtWarning().WriteToLog()
+	})
+}
+
+// isIPv6Socket returns whether the network passed to socket control functions
+// is of an IPv6 socket.
+func isIPv6Socket(network string) bool {
+	switch network {
+	case "tcp6", "udp6", "ip6":
+		return true
+	default:
+		return false
+	}
+}
+
 func isTCPSocket(network string) bool {
 	switch network {
 	case "tcp", "tcp4", "tcp6":
@@ -17,3 +52,52 @@ func isUDPSocket(network string) bool {
 		return false
 	}
 }
+
+// withTOS returns a copy of sockopt with the TOS value replaced by tos.
+func withTOS(sockopt *SocketConfig, tos uint32) *SocketConfig {
+	if sockopt == nil {
+		return &SocketConfig{Tos: tos}
+	}
+	sockopt = proto.Clone(sockopt).(*SocketConfig)
+	sockopt.Tos = tos
+	return sockopt
+}
+
+// hasTCPKeepAliveConfig returns whether TCP keep-alive is explicitly
+// configured, in which case the keep-alive settings of Go runtime must not
+// override it.
+func hasTCPKeepAliveConfig(sockopt *SocketConfig) bool {
+	return sockopt != nil && (sockopt.TcpKeepAliveInterval != 0 || sockopt.TcpKeepAliveIdle != 0)
+}
+
+// applyTCPNoDelay sets TCP_NODELAY of conn as configured in sockopt. It must be
+// called after the connection is established, as Go runtime enables
+// TCP_NODELAY on every new TCP connection after socket options are applied.
+func applyTCPNoDelay(conn net.Conn, sockopt *SocketConfig) {
+	if sockopt == nil || sockopt.TcpNoDelay == SocketOptionState_Default {
+		return
+	}
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return
+	}
+	if err := tcpConn.SetNoDelay(sockopt.TcpNoDelay == SocketOptionState_Enable); err != nil {
+		newError("failed to set TCP_NODELAY").Base(err).AtWarning().WriteToLog()
+	}
+}
+
+// noDelayListener applies TCP_NODELAY settings to accepted connections.
+type noDelayListener struct {
+	net.Listener
+	sockopt *SocketConfig
+}
+
+// Accept implements net.Listener.
+func (l *noDelayListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	applyTCPNoDelay(conn, l.sockopt)
+	return conn, nil
+}
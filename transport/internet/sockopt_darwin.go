@@ -2,6 +2,8 @@ package internet
 
 import (
 	"syscall"
+
+	"golang.org/x/sys/unix"
 )
 
 const (
@@ -14,6 +16,14 @@ const (
 )
 
 func applyOutboundSocketOptions(network string, address string, fd uintptr, config *SocketConfig) error {
+	if err := setSocketBufferSizes(fd, config); err != nil {
+		return err
+	}
+	warnBindToDeviceNotSupported(config)
+	if err := setTOS(fd, config.Tos); err != nil {
+		return err
+	}
+
 	if isTCPSocket(network) {
 		switch config.Tfo {
 		case SocketConfig_Enable:
@@ -25,12 +35,28 @@ func applyOutboundSocketOptions(network string, address string, fd uintptr, conf
 				return err
 			}
 		}
+
+		if err := setTCPKeepAlive(fd, config); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig) error {
+	if err := setSocketBufferSizes(fd, config); err != nil {
+		return err
+	}
+	warnBindToDeviceNotSupported(config)
+	if err := setTOS(fd, config.Tos); err != nil {
+		return err
+	}
+
+	if err := setV6Only(network, fd, config.V6Only); err != nil {
+		return err
+	}
+
 	if isTCPSocket(network) {
 		switch config.Tfo {
 		case SocketConfig_Enable:
@@ -42,12 +68,57 @@ func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig)
 				return err
 			}
 		}
+
+		if err := setTCPKeepAlive(fd, config); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func bindAddr(fd uintptr, address []byte, port uint32) error {
+func setSocketBufferSizes(fd uintptr, config *SocketConfig) error {
+	if config.RxBufSize > 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF, int(config.RxBufSize)); err != nil {
+			return newError("failed to set SO_RCVBUF").Base(err)
+		}
+	}
+	if config.TxBufSize > 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_SNDBUF, int(config.TxBufSize)); err != nil {
+			return newError("failed to set SO_SNDBUF").Base(err)
+		}
+	}
+	return nil
+}
+
+func setTCPKeepAlive(fd uintptr, config *SocketConfig) error {
+	if config.TcpKeepAliveInterval < 0 || config.TcpKeepAliveIdle < 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 0); err != nil {
+			return newError("failed to set SO_KEEPALIVE=0").Base(err)
+		}
+		return nil
+	}
+	if config.TcpKeepAliveInterval == 0 && config.TcpKeepAliveIdle == 0 {
+		return nil
+	}
+
+	if config.TcpKeepAliveInterval > 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, unix.TCP_KEEPINTVL, int(config.TcpKeepAliveInterval)); err != nil {
+			return newError("failed to set TCP_KEEPINTVL").Base(err)
+		}
+	}
+	if config.TcpKeepAliveIdle > 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, unix.TCP_KEEPALIVE, int(config.TcpKeepAliveIdle)); err != nil {
+			return newError("failed to set TCP_KEEPALIVE").Base(err)
+		}
+	}
+	if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 1); err != nil {
+		return newError("failed to set SO_KEEPALIVE=1").Base(err)
+	}
+	return nil
+}
+
+func bindAddr(fd uintptr, address []byte, port uint32, zone uint32) error {
 	return nil
 }
 
@@ -55,6 +126,11 @@ func setReuseAddr(fd uintptr) error {
 	return nil
 }
 
+// setReusePort sets SO_REUSEPORT. Unlike Linux, Darwin doesn't balance
+// connections among the sockets sharing the port.
 func setReusePort(fd uintptr) error {
+	if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, unix.SO_REUSEPORT, 1); err != nil {
+		return newError("failed to set SO_REUSEPORT").Base(err).AtWarning()
+	}
 	return nil
 }
@@ -124,12 +124,21 @@ func OriginalDst(la, ra net.Addr) (net.IP, int, error) {
 }
 
 func applyOutboundSocketOptions(network string, address string, fd uintptr, config *SocketConfig) error {
-	if config.Mark != 0 {
-		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_USER_COOKIE, int(config.Mark)); err != nil {
+	if mark := config.GetEffectiveOutboundMark(); mark != 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_USER_COOKIE, int(mark)); err != nil {
 			return newError("failed to set SO_USER_COOKIE").Base(err)
 		}
 	}
 
+	if err := setSocketBufferSizes(fd, config); err != nil {
+		return err
+	}
+
+	warnBindToDeviceNotSupported(config)
+	if err := setTOS(fd, config.Tos); err != nil {
+		return err
+	}
+
 	if isTCPSocket(network) {
 		switch config.Tfo {
 		case SocketConfig_Enable:
@@ -159,11 +168,24 @@ func applyOutboundSocketOptions(network string, address string, fd uintptr, conf
 }
 
 func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig) error {
-	if config.Mark != 0 {
-		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_USER_COOKIE, int(config.Mark)); err != nil {
+	if mark := config.GetEffectiveInboundMark(); mark != 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_USER_COOKIE, int(mark)); err != nil {
 			return newError("failed to set SO_USER_COOKIE").Base(err)
 		}
 	}
+
+	if err := setSocketBufferSizes(fd, config); err != nil {
+		return err
+	}
+
+	warnBindToDeviceNotSupported(config)
+	if err := setTOS(fd, config.Tos); err != nil {
+		return err
+	}
+
+	if err := setV6Only(network, fd, config.V6Only); err != nil {
+		return err
+	}
 	if isTCPSocket(network) {
 		switch config.Tfo {
 		case SocketConfig_Enable:
@@ -188,7 +210,21 @@ func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig)
 	return nil
 }
 
-func bindAddr(fd uintptr, ip []byte, port uint32) error {
+func setSocketBufferSizes(fd uintptr, config *SocketConfig) error {
+	if config.RxBufSize > 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF, int(config.RxBufSize)); err != nil {
+			return newError("failed to set SO_RCVBUF").Base(err)
+		}
+	}
+	if config.TxBufSize > 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_SNDBUF, int(config.TxBufSize)); err != nil {
+			return newError("failed to set SO_SNDBUF").Base(err)
+		}
+	}
+	return nil
+}
+
+func bindAddr(fd uintptr, ip []byte, port uint32, zone uint32) error {
 	setReuseAddr(fd)
 	setReusePort(fd)
 
@@ -206,6 +242,9 @@ func bindAddr(fd uintptr, ip []byte, port uint32) error {
 			Port: int(port),
 		}
 		copy(a6.Addr[:], ip)
+		if net.IP(ip).IsLinkLocalUnicast() {
+			a6.ZoneId = zone
+		}
 		sockaddr = a6
 	default:
 		return newError("unexpected length of ip")
@@ -10,11 +10,18 @@ import (
 const (
 	// For incoming connections.
 	TCP_FASTOPEN = 23 // nolint: golint,stylecheck
-	// For out-going connections.
+	// For out-going connections. Connecting is deferred until the first write,
+	// whose data is sent in the SYN as sendto with MSG_FASTOPEN does.
 	TCP_FASTOPEN_CONNECT = 30 // nolint: golint,stylecheck
+	// Protocol number of Multipath TCP.
+	IPPROTO_MPTCP = 262 // nolint: golint,stylecheck
 )
 
-func bindAddr(fd uintptr, ip []byte, port uint32) error {
+// defaultTFOQueueLength is the length of the queue of pending TFO requests of
+// listening sockets, if not configured.
+const defaultTFOQueueLength = 256
+
+func bindAddr(fd uintptr, ip []byte, port uint32, zone uint32) error {
 	setReuseAddr(fd)
 	setReusePort(fd)
 
@@ -32,6 +39,9 @@ func bindAddr(fd uintptr, ip []byte, port uint32) error {
 			Port: int(port),
 		}
 		copy(a6.Addr[:], ip)
+		if net.IP(ip).IsLinkLocalUnicast() {
+			a6.ZoneId = zone
+		}
 		sockaddr = a6
 	default:
 		return newError("unexpected length of ip")
@@ -41,12 +51,32 @@ func bindAddr(fd uintptr, ip []byte, port uint32) error {
 }
 
 func applyOutboundSocketOptions(network string, address string, fd uintptr, config *SocketConfig) error {
-	if config.Mark != 0 {
-		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_MARK, int(config.Mark)); err != nil {
+	if config.Mptcp && isTCPSocket(network) {
+		enableMPTCP(fd)
+	}
+
+	if mark := config.GetEffectiveOutboundMark(); mark != 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_MARK, int(mark)); err != nil {
 			return newError("failed to set SO_MARK").Base(err)
 		}
 	}
 
+	if err := setSocketBufferSizes(fd, config); err != nil {
+		return err
+	}
+
+	if err := bindToDevice(fd, config.BindToDevice); err != nil {
+		return err
+	}
+
+	if err := setTOS(fd, config.Tos); err != nil {
+		return err
+	}
+
+	if err := setPriority(fd, config.SoPriority); err != nil {
+		return err
+	}
+
 	if isTCPSocket(network) {
 		switch config.Tfo {
 		case SocketConfig_Enable:
@@ -59,9 +89,15 @@ func applyOutboundSocketOptions(network string, address string, fd uintptr, conf
 			}
 		}
 
-		if config.TcpKeepAliveInterval != 0 {
-			if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, int(config.TcpKeepAliveInterval)); err != nil {
-				return newError("failed to set TCP_KEEPINTVL", err)
+		if err := setTCPKeepAlive(fd, config); err != nil {
+			return err
+		}
+
+		setTCPCongestion(fd, config.TcpCongestion)
+
+		if config.TcpUserTimeout > 0 {
+			if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, unix.TCP_USER_TIMEOUT, int(config.TcpUserTimeout)); err != nil {
+				return newError("failed to set TCP_USER_TIMEOUT").Base(err)
 			}
 		}
 	}
@@ -76,16 +112,45 @@ func applyOutboundSocketOptions(network string, address string, fd uintptr, conf
 }
 
 func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig) error {
-	if config.Mark != 0 {
-		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_MARK, int(config.Mark)); err != nil {
+	if config.Mptcp && isTCPSocket(network) {
+		enableMPTCP(fd)
+	}
+
+	if mark := config.GetEffectiveInboundMark(); mark != 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_MARK, int(mark)); err != nil {
 			return newError("failed to set SO_MARK").Base(err)
 		}
 	}
+
+	if err := setSocketBufferSizes(fd, config); err != nil {
+		return err
+	}
+
+	if err := bindToDevice(fd, config.BindToDevice); err != nil {
+		return err
+	}
+
+	if err := setTOS(fd, config.Tos); err != nil {
+		return err
+	}
+
+	if err := setV6Only(network, fd, config.V6Only); err != nil {
+		return err
+	}
+
+	if err := setPriority(fd, config.SoPriority); err != nil {
+		return err
+	}
+
 	if isTCPSocket(network) {
 		switch config.Tfo {
 		case SocketConfig_Enable:
-			if err := syscall.SetsockoptInt(int(fd), syscall.SOL_TCP, TCP_FASTOPEN, 1); err != nil {
-				return newError("failed to set TCP_FASTOPEN=1").Base(err)
+			queueLength := int(config.TfoQueueLength)
+			if queueLength <= 0 {
+				queueLength = defaultTFOQueueLength
+			}
+			if err := syscall.SetsockoptInt(int(fd), syscall.SOL_TCP, TCP_FASTOPEN, queueLength); err != nil {
+				return newError("failed to set TCP_FASTOPEN=", queueLength).Base(err)
 			}
 		case SocketConfig_Disable:
 			if err := syscall.SetsockoptInt(int(fd), syscall.SOL_TCP, TCP_FASTOPEN, 0); err != nil {
@@ -93,9 +158,15 @@ func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig)
 			}
 		}
 
-		if config.TcpKeepAliveInterval != 0 {
-			if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, int(config.TcpKeepAliveInterval)); err != nil {
-				return newError("failed to set TCP_KEEPINTVL", err)
+		if err := setTCPKeepAlive(fd, config); err != nil {
+			return err
+		}
+
+		setTCPCongestion(fd, config.TcpCongestion)
+
+		if config.TcpUserTimeout > 0 {
+			if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, unix.TCP_USER_TIMEOUT, int(config.TcpUserTimeout)); err != nil {
+				return newError("failed to set TCP_USER_TIMEOUT").Base(err)
 			}
 		}
 	}
@@ -117,6 +188,139 @@ func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig)
 	return nil
 }
 
+// enableMPTCP replaces the TCP socket fd with a Multipath TCP socket of the
+// same address family. It must be called before any other option is set on fd,
+// as they are lost in the replacement. The socket is left as plain TCP if the
+// kernel doesn't support MPTCP.
+func enableMPTCP(fd uintptr) {
+	if err := replaceWithMPTCPSocket(int(fd)); err != nil {
+		newError("failed to enable MPTCP, falling back to TCP").Base(err).AtWarning().WriteToLog()
+	}
+}
+
+func replaceWithMPTCPSocket(fd int) error {
+	family, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_DOMAIN)
+	if err != nil {
+		return err
+	}
+	s, err := unix.Socket(family, unix.SOCK_STREAM|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, IPPROTO_MPTCP)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(s)
+
+	// Carry over the options set by Go runtime before the control function
+	// is called.
+	options := [][2]int{{unix.SOL_SOCKET, unix.SO_REUSEADDR}}
+	if family == unix.AF_INET6 {
+		options = append(options, [2]int{unix.IPPROTO_IPV6, unix.IPV6_V6ONLY})
+	}
+	for _, opt := range options {
+		v, err := unix.GetsockoptInt(fd, opt[0], opt[1])
+		if err != nil {
+			return err
+		}
+		if err := unix.SetsockoptInt(s, opt[0], opt[1], v); err != nil {
+			return err
+		}
+	}
+
+	return unix.Dup3(s, fd, unix.O_CLOEXEC)
+}
+
+func bindToDevice(fd uintptr, device string) error {
+	if len(device) == 0 {
+		return nil
+	}
+	if err := syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, device); err != nil {
+		if err == syscall.EPERM {
+			return newError("failed to set SO_BINDTODEVICE=", device, ", CAP_NET_RAW is required").Base(err)
+		}
+		return newError("failed to set SO_BINDTODEVICE=", device).Base(err)
+	}
+	return nil
+}
+
+// setPriority sets SO_PRIORITY of the socket. It must be called after setTOS,
+// as setting the TOS also changes the priority.
+func setPriority(fd uintptr, priority int32) error {
+	if priority == 0 {
+		return nil
+	}
+	if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_PRIORITY, int(priority)); err != nil {
+		if err == syscall.EPERM {
+			return newError("failed to set SO_PRIORITY=", priority, ", CAP_NET_ADMIN is required").Base(err)
+		}
+		return newError("failed to set SO_PRIORITY=", priority).Base(err)
+	}
+	return nil
+}
+
+func setSocketBufferSizes(fd uintptr, config *SocketConfig) error {
+	if config.RxBufSize > 0 {
+		if err := setSocketBufferSize(fd, syscall.SO_RCVBUF, "SO_RCVBUF", int(config.RxBufSize)); err != nil {
+			return err
+		}
+	}
+	if config.TxBufSize > 0 {
+		if err := setSocketBufferSize(fd, syscall.SO_SNDBUF, "SO_SNDBUF", int(config.TxBufSize)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setSocketBufferSize(fd uintptr, opt int, name string, size int) error {
+	if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, opt, size); err != nil {
+		return newError("failed to set ", name).Base(err)
+	}
+	// Linux doubles the requested size to leave space for bookkeeping overhead,
+	// after capping it at net.core.rmem_max or net.core.wmem_max.
+	if applied, err := syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, opt); err == nil && applied/2 < size {
+		newError(name, " is capped at ", applied/2, " while ", size, " is requested, net.core.rmem_max or net.core.wmem_max may need to be raised").AtWarning().WriteToLog()
+	}
+	return nil
+}
+
+// setTCPCongestion sets the congestion control algorithm of a TCP socket.
+// Failures are only logged, so that the connection goes on with the default
+// algorithm.
+func setTCPCongestion(fd uintptr, congestion string) {
+	if len(congestion) == 0 {
+		return
+	}
+	if err := syscall.SetsockoptString(int(fd), syscall.IPPROTO_TCP, unix.TCP_CONGESTION, congestion); err != nil {
+		newError("failed to set TCP_CONGESTION=", congestion, ", using default congestion control").Base(err).AtWarning().WriteToLog()
+	}
+}
+
+func setTCPKeepAlive(fd uintptr, config *SocketConfig) error {
+	if config.TcpKeepAliveInterval < 0 || config.TcpKeepAliveIdle < 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 0); err != nil {
+			return newError("failed to set SO_KEEPALIVE=0").Base(err)
+		}
+		return nil
+	}
+	if config.TcpKeepAliveInterval == 0 && config.TcpKeepAliveIdle == 0 {
+		return nil
+	}
+
+	if config.TcpKeepAliveInterval > 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, int(config.TcpKeepAliveInterval)); err != nil {
+			return newError("failed to set TCP_KEEPINTVL").Base(err)
+		}
+	}
+	if config.TcpKeepAliveIdle > 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE, int(config.TcpKeepAliveIdle)); err != nil {
+			return newError("failed to set TCP_KEEPIDLE").Base(err)
+		}
+	}
+	if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 1); err != nil {
+		return newError("failed to set SO_KEEPALIVE=1").Base(err)
+	}
+	return nil
+}
+
 func setReuseAddr(fd uintptr) error {
 	if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1); err != nil {
 		return newError("failed to set SO_REUSEADDR").Base(err).AtWarning()
@@ -2,11 +2,18 @@ package internet_test
 
 import (
 	"context"
+	gonet "net"
+	"os"
+	"strings"
 	"syscall"
 	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
 
 	"github.com/v2fly/v2ray-core/v4/common"
 	"github.com/v2fly/v2ray-core/v4/common/net"
+	"github.com/v2fly/v2ray-core/v4/common/session"
 	"github.com/v2fly/v2ray-core/v4/testing/servers/tcp"
 	. "github.com/v2fly/v2ray-core/v4/transport/internet"
 )
@@ -40,3 +47,751 @@ func TestSockOptMark(t *testing.T) {
 	})
 	common.Must(err)
 }
+
+func TestSockOptDirectionalMark(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires CAP_NET_ADMIN")
+	}
+
+	checkMark := func(name string, conn syscall.Conn, expected int) {
+		rawConn, err := conn.SyscallConn()
+		common.Must(err)
+		common.Must(rawConn.Control(func(fd uintptr) {
+			m, err := syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_MARK)
+			common.Must(err)
+			if m != expected {
+				t.Error("unexpected mark of ", name, ": ", m, " want ", expected)
+			}
+		}))
+	}
+
+	sockopt := &SocketConfig{Mark: 1, InboundMark: 2}
+	listener, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.LocalHostIP.IP()}, sockopt)
+	common.Must(err)
+	defer listener.Close()
+	checkMark("TCP listener", listener.(*net.TCPListener), 2)
+
+	packetConn, err := ListenSystemPacket(context.Background(), &net.UDPAddr{IP: net.LocalHostIP.IP()}, sockopt)
+	common.Must(err)
+	defer packetConn.Close()
+	checkMark("UDP listener", packetConn.(*net.UDPConn), 2)
+
+	dest := net.DestinationFromAddr(listener.Addr())
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+	}()
+
+	dialer := DefaultSystemDialer{}
+	conn, err := dialer.Dial(context.Background(), nil, dest, sockopt)
+	common.Must(err)
+	defer conn.Close()
+	// The outbound mark falls back to mark.
+	checkMark("dialed TCP connection", conn.(*net.TCPConn), 1)
+
+	serverConn, ok := <-accepted
+	if !ok {
+		t.Fatal("failed to accept connection")
+	}
+	defer serverConn.Close()
+	checkMark("accepted TCP connection", serverConn.(*net.TCPConn), 2)
+
+	outboundConn, err := dialer.Dial(context.Background(), nil, dest, &SocketConfig{Mark: 1, OutboundMark: 3})
+	common.Must(err)
+	defer outboundConn.Close()
+	checkMark("dialed TCP connection", outboundConn.(*net.TCPConn), 3)
+}
+
+func TestSockOptTCPKeepAlive(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	getKeepAlive := func(config *SocketConfig) (keepAlive, idle, interval int) {
+		dialer := DefaultSystemDialer{}
+		conn, err := dialer.Dial(context.Background(), nil, dest, config)
+		common.Must(err)
+		defer conn.Close()
+
+		rawConn, err := conn.(*net.TCPConn).SyscallConn()
+		common.Must(err)
+		common.Must(rawConn.Control(func(fd uintptr) {
+			keepAlive, err = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_KEEPALIVE)
+			common.Must(err)
+			idle, err = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE)
+			common.Must(err)
+			interval, err = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL)
+			common.Must(err)
+		}))
+		return
+	}
+
+	if keepAlive, idle, interval := getKeepAlive(&SocketConfig{TcpKeepAliveIdle: 42, TcpKeepAliveInterval: 7}); keepAlive != 1 || idle != 42 || interval != 7 {
+		t.Error("unexpected keep-alive settings: ", keepAlive, " ", idle, " ", interval)
+	}
+
+	if keepAlive, _, _ := getKeepAlive(&SocketConfig{TcpKeepAliveInterval: -1}); keepAlive != 0 {
+		t.Error("expect keep-alive disabled, but got ", keepAlive)
+	}
+}
+
+func TestSockOptBufferSize(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	const size = 16384
+	dialer := DefaultSystemDialer{}
+	conn, err := dialer.Dial(context.Background(), nil, dest, &SocketConfig{RxBufSize: size, TxBufSize: size})
+	common.Must(err)
+	defer conn.Close()
+
+	rawConn, err := conn.(*net.TCPConn).SyscallConn()
+	common.Must(err)
+	common.Must(rawConn.Control(func(fd uintptr) {
+		// Linux reports twice the requested size.
+		for _, opt := range []int{syscall.SO_RCVBUF, syscall.SO_SNDBUF} {
+			observed, err := syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, opt)
+			common.Must(err)
+			if observed != 2*size {
+				t.Error("unexpected buffer size ", observed, " want ", 2*size)
+			}
+		}
+	}))
+}
+
+func TestSockOptBindToDevice(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires CAP_NET_RAW")
+	}
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	dialer := DefaultSystemDialer{}
+	conn, err := dialer.Dial(context.Background(), nil, dest, &SocketConfig{BindToDevice: "lo"})
+	common.Must(err)
+	defer conn.Close()
+
+	rawConn, err := conn.(*net.TCPConn).SyscallConn()
+	common.Must(err)
+	common.Must(rawConn.Control(func(fd uintptr) {
+		device, err := unix.GetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE)
+		common.Must(err)
+		if device != "lo" {
+			t.Error("unexpected device ", device, " want lo")
+		}
+	}))
+}
+
+func TestSockOptTCPCongestion(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	dialer := DefaultSystemDialer{}
+	conn, err := dialer.Dial(context.Background(), nil, dest, &SocketConfig{TcpCongestion: "cubic"})
+	common.Must(err)
+	defer conn.Close()
+
+	rawConn, err := conn.(*net.TCPConn).SyscallConn()
+	common.Must(err)
+	common.Must(rawConn.Control(func(fd uintptr) {
+		congestion, err := unix.GetsockoptString(int(fd), syscall.IPPROTO_TCP, unix.TCP_CONGESTION)
+		common.Must(err)
+		// The kernel returns the name padded with NUL bytes.
+		if congestion = strings.TrimRight(congestion, "\x00"); congestion != "cubic" {
+			t.Error("unexpected congestion control ", congestion, " want cubic")
+		}
+	}))
+
+	// An unavailable algorithm doesn't fail the connection.
+	conn2, err := dialer.Dial(context.Background(), nil, dest, &SocketConfig{TcpCongestion: "non-existing"})
+	common.Must(err)
+	conn2.Close()
+}
+
+func TestSockOptTCPUserTimeout(t *testing.T) {
+	const timeout = 10000
+	sockopt := &SocketConfig{TcpUserTimeout: timeout}
+
+	listener, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.LocalHostIP.IP()}, sockopt)
+	common.Must(err)
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		common.Must(err)
+		accepted <- conn
+	}()
+
+	dialer := DefaultSystemDialer{}
+	conn, err := dialer.Dial(context.Background(), nil, net.DestinationFromAddr(listener.Addr()), sockopt)
+	common.Must(err)
+	defer conn.Close()
+
+	serverConn := <-accepted
+	defer serverConn.Close()
+
+	for _, c := range []net.Conn{conn, serverConn} {
+		rawConn, err := c.(*net.TCPConn).SyscallConn()
+		common.Must(err)
+		common.Must(rawConn.Control(func(fd uintptr) {
+			v, err := syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, unix.TCP_USER_TIMEOUT)
+			common.Must(err)
+			if v != timeout {
+				t.Error("unexpected TCP_USER_TIMEOUT ", v, " want ", timeout)
+			}
+		}))
+	}
+}
+
+func TestSockOptTCPMaxSeg(t *testing.T) {
+	const mss = 1000
+
+	listener, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.LocalHostIP.IP()}, nil)
+	common.Must(err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	dialer := DefaultSystemDialer{}
+	conn, err := dialer.Dial(context.Background(), nil, net.DestinationFromAddr(listener.Addr()), &SocketConfig{TcpMaxSeg: mss})
+	common.Must(err)
+	defer conn.Close()
+
+	// The effective MSS of the connection excludes TCP options, e.g. timestamps.
+	rawConn, err := conn.(*net.TCPConn).SyscallConn()
+	common.Must(err)
+	common.Must(rawConn.Control(func(fd uintptr) {
+		v, err := syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_MAXSEG)
+		common.Must(err)
+		if v <= 0 || v > mss {
+			t.Error("unexpected TCP_MAXSEG ", v, " want at most ", mss)
+		}
+	}))
+}
+
+func TestSockOptTOS(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	sockopt := &SocketConfig{Tos: 0x20}
+	testCases := []struct {
+		ctx context.Context
+		tos int
+	}{
+		{
+			ctx: context.Background(),
+			tos: 0x20,
+		},
+		{
+			ctx: session.ContextWithTOS(context.Background(), 0xb8),
+			tos: 0xb8,
+		},
+	}
+	for _, tc := range testCases {
+		conn, err := DialSystem(tc.ctx, dest, sockopt)
+		common.Must(err)
+
+		rawConn, err := conn.(*net.TCPConn).SyscallConn()
+		common.Must(err)
+		common.Must(rawConn.Control(func(fd uintptr) {
+			tos, err := syscall.GetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS)
+			common.Must(err)
+			if tos != tc.tos {
+				t.Error("unexpected IP_TOS ", tos, " want ", tc.tos)
+			}
+		}))
+		conn.Close()
+	}
+
+	if sockopt.Tos != 0x20 {
+		t.Error("socket config is modified: ", sockopt.Tos)
+	}
+}
+
+func TestSockOptTOSIPv6(t *testing.T) {
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 is not available: ", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	dest := net.DestinationFromAddr(listener.Addr())
+	conn, err := DialSystem(context.Background(), dest, &SocketConfig{Tos: 0xb8})
+	common.Must(err)
+	defer conn.Close()
+
+	rawConn, err := conn.(*net.TCPConn).SyscallConn()
+	common.Must(err)
+	common.Must(rawConn.Control(func(fd uintptr) {
+		class, err := syscall.GetsockoptInt(int(fd), syscall.IPPROTO_IPV6, unix.IPV6_TCLASS)
+		common.Must(err)
+		if class != 0xb8 {
+			t.Error("unexpected IPV6_TCLASS ", class, " want ", 0xb8)
+		}
+	}))
+}
+
+func TestSockOptMPTCP(t *testing.T) {
+	sockopt := &SocketConfig{Mptcp: true}
+
+	listener, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.LocalHostIP.IP()}, sockopt)
+	common.Must(err)
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		common.Must(err)
+		accepted <- conn
+	}()
+
+	// The connection falls back to TCP if MPTCP isn't supported.
+	dialer := DefaultSystemDialer{}
+	conn, err := dialer.Dial(context.Background(), nil, net.DestinationFromAddr(listener.Addr()), sockopt)
+	common.Must(err)
+	defer conn.Close()
+
+	serverConn := <-accepted
+	defer serverConn.Close()
+
+	common.Must2(conn.Write([]byte("test")))
+	b := make([]byte, 4)
+	common.Must2(serverConn.Read(b))
+
+	s, err := unix.Socket(unix.AF_INET, unix.SOCK_STREAM, IPPROTO_MPTCP)
+	if err != nil {
+		t.Skip("MPTCP is not supported: ", err)
+	}
+	unix.Close(s)
+
+	for _, c := range []net.Conn{conn, serverConn} {
+		rawConn, err := c.(*net.TCPConn).SyscallConn()
+		common.Must(err)
+		common.Must(rawConn.Control(func(fd uintptr) {
+			protocol, err := unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_PROTOCOL)
+			common.Must(err)
+			if protocol != IPPROTO_MPTCP {
+				t.Error("unexpected socket protocol ", protocol, " want ", IPPROTO_MPTCP)
+			}
+		}))
+	}
+}
+
+func TestSockOptTCPNoDelay(t *testing.T) {
+	getNoDelay := func(conn net.Conn) int {
+		rawConn, err := conn.(*net.TCPConn).SyscallConn()
+		common.Must(err)
+		var noDelay int
+		common.Must(rawConn.Control(func(fd uintptr) {
+			noDelay, err = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_NODELAY)
+			common.Must(err)
+		}))
+		return noDelay
+	}
+
+	testCases := []struct {
+		state   SocketOptionState
+		noDelay int
+	}{
+		{
+			state:   SocketOptionState_Default,
+			noDelay: 1,
+		},
+		{
+			state:   SocketOptionState_Enable,
+			noDelay: 1,
+		},
+		{
+			state:   SocketOptionState_Disable,
+			noDelay: 0,
+		},
+	}
+	for _, tc := range testCases {
+		sockopt := &SocketConfig{TcpNoDelay: tc.state}
+		listener, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.LocalHostIP.IP()}, sockopt)
+		common.Must(err)
+
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			conn, err := listener.Accept()
+			common.Must(err)
+			accepted <- conn
+		}()
+
+		addr := listener.Addr().(*net.TCPAddr)
+		conn, err := DialSystem(context.Background(), net.TCPDestination(net.IPAddress(addr.IP), net.Port(addr.Port)), sockopt)
+		common.Must(err)
+		serverConn := <-accepted
+
+		if v := getNoDelay(conn); v != tc.noDelay {
+			t.Error("unexpected TCP_NODELAY of dialed connection ", v, " want ", tc.noDelay, " for ", tc.state)
+		}
+		if v := getNoDelay(serverConn); v != tc.noDelay {
+			t.Error("unexpected TCP_NODELAY of accepted connection ", v, " want ", tc.noDelay, " for ", tc.state)
+		}
+
+		conn.Close()
+		serverConn.Close()
+		listener.Close()
+	}
+}
+
+func TestSockOptReusePort(t *testing.T) {
+	sockopt := &SocketConfig{ReusePort: true}
+	listener1, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.LocalHostIP.IP()}, sockopt)
+	common.Must(err)
+	defer listener1.Close()
+
+	listener2, err := ListenSystem(context.Background(), listener1.Addr(), sockopt)
+	if err != nil {
+		t.Fatal("failed to listen on the same port: ", err)
+	}
+	defer listener2.Close()
+
+	rawConn, err := listener2.(*net.TCPListener).SyscallConn()
+	common.Must(err)
+	common.Must(rawConn.Control(func(fd uintptr) {
+		reusePort, err := syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, unix.SO_REUSEPORT)
+		common.Must(err)
+		if reusePort != 1 {
+			t.Error("unexpected SO_REUSEPORT ", reusePort)
+		}
+	}))
+}
+
+func TestSockOptPMTUDiscovery(t *testing.T) {
+	testCases := []struct {
+		mode  SocketConfig_PMTUDiscovery
+		value int
+	}{
+		{mode: SocketConfig_Do, value: unix.IP_PMTUDISC_DO},
+		{mode: SocketConfig_Dont, value: unix.IP_PMTUDISC_DONT},
+		{mode: SocketConfig_Want, value: unix.IP_PMTUDISC_WANT},
+	}
+	for _, tc := range testCases {
+		conn, err := ListenSystemPacket(context.Background(), &net.UDPAddr{IP: net.IP{127, 0, 0, 1}}, &SocketConfig{PmtuDiscovery: tc.mode})
+		common.Must(err)
+
+		rawConn, err := conn.(*net.UDPConn).SyscallConn()
+		common.Must(err)
+		common.Must(rawConn.Control(func(fd uintptr) {
+			value, err := unix.GetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER)
+			common.Must(err)
+			if value != tc.value {
+				t.Error("unexpected IP_MTU_DISCOVER ", value, " for ", tc.mode, ", want ", tc.value)
+			}
+		}))
+		conn.Close()
+	}
+
+	conn, err := ListenSystemPacket(context.Background(), &net.UDPAddr{IP: net.ParseIP("::1")}, &SocketConfig{PmtuDiscovery: SocketConfig_Do})
+	if err != nil {
+		t.Skip("IPv6 is not available: ", err)
+	}
+	defer conn.Close()
+	rawConn, err := conn.(*net.UDPConn).SyscallConn()
+	common.Must(err)
+	common.Must(rawConn.Control(func(fd uintptr) {
+		value, err := unix.GetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MTU_DISCOVER)
+		common.Must(err)
+		if value != unix.IPV6_PMTUDISC_DO {
+			t.Error("unexpected IPV6_MTU_DISCOVER ", value)
+		}
+	}))
+}
+
+func TestSockOptBacklog(t *testing.T) {
+	// dialAll dials the listener without accepting, and returns the number of
+	// connections not established in time, as their SYNs are dropped after
+	// the accept queue is full.
+	dialAll := func(sockopt *SocketConfig) int {
+		listener, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.IP{127, 0, 0, 1}}, sockopt)
+		common.Must(err)
+		defer listener.Close()
+
+		failed := 0
+		for i := 0; i < 8; i++ {
+			conn, err := (&net.Dialer{Timeout: 200 * time.Millisecond}).Dial("tcp", listener.Addr().String())
+			if err != nil {
+				failed++
+				continue
+			}
+			defer conn.Close()
+		}
+		return failed
+	}
+
+	if failed := dialAll(nil); failed != 0 {
+		t.Error("expect all connections established with default backlog, but ", failed, " failed")
+	}
+	if failed := dialAll(&SocketConfig{Backlog: 1}); failed == 0 {
+		t.Error("expect connections beyond backlog to be dropped")
+	}
+}
+
+func TestSockOptSourceAddress(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	sources := [][]byte{{127, 0, 0, 2}, {127, 0, 0, 3}, {127, 0, 0, 4}}
+	streamSettings, err := ToMemoryStreamConfig(&StreamConfig{
+		SocketSettings: &SocketConfig{SourceAddress: sources},
+	})
+	common.Must(err)
+
+	counts := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		conn, err := DialSystem(context.Background(), dest, streamSettings.SocketSettings)
+		common.Must(err)
+		counts[conn.LocalAddr().(*net.TCPAddr).IP.String()]++
+		conn.Close()
+	}
+	for _, ip := range sources {
+		if c := counts[net.IP(ip).String()]; c != 10 {
+			t.Error("expect 10 connections from ", net.IP(ip), ", but got ", c)
+		}
+	}
+
+	streamSettings, err = ToMemoryStreamConfig(&StreamConfig{
+		SocketSettings: &SocketConfig{SourceSubnet: []byte{127, 1, 0, 0}, SourceSubnetPrefix: 16},
+	})
+	common.Must(err)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		conn, err := DialSystem(context.Background(), dest, streamSettings.SocketSettings)
+		common.Must(err)
+		ip := conn.LocalAddr().(*net.TCPAddr).IP.To4()
+		if ip[0] != 127 || ip[1] != 1 {
+			t.Error("expect source address in 127.1.0.0/16, but got ", ip)
+		}
+		seen[ip.String()] = true
+		conn.Close()
+	}
+	if len(seen) < 2 {
+		t.Error("expect source addresses to be randomized, but got ", seen)
+	}
+
+	if _, err := ToMemoryStreamConfig(&StreamConfig{
+		SocketSettings: &SocketConfig{SourceAddress: [][]byte{{192, 0, 2, 1}}},
+	}); err == nil {
+		t.Error("expect error on non-local source address")
+	}
+}
+
+func TestSockOptBindInterfaceIndex(t *testing.T) {
+	var iface *gonet.Interface
+	var ip net.IP
+	interfaces, err := gonet.Interfaces()
+	common.Must(err)
+	for i := range interfaces {
+		addrs, err := interfaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() == nil && ipNet.IP.IsLinkLocalUnicast() {
+				iface, ip = &interfaces[i], ipNet.IP
+				break
+			}
+		}
+		if iface != nil {
+			break
+		}
+	}
+	if iface == nil {
+		t.Skip("no interface with IPv6 link-local address")
+	}
+
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: ip, Zone: iface.Name})
+	if err != nil {
+		t.Skip("failed to listen on ", ip, "%", iface.Name, ": ", err)
+	}
+	defer listener.Close()
+
+	streamSettings, err := ToMemoryStreamConfig(&StreamConfig{
+		SocketSettings: &SocketConfig{
+			SourceAddress:      [][]byte{ip},
+			BindInterfaceIndex: uint32(iface.Index),
+		},
+	})
+	common.Must(err)
+
+	// The destination has no zone, which is taken from the bound source
+	// address.
+	dest := net.TCPDestination(net.IPAddress(ip), net.Port(listener.Addr().(*net.TCPAddr).Port))
+	conn, err := DialSystem(context.Background(), dest, streamSettings.SocketSettings)
+	common.Must(err)
+	defer conn.Close()
+
+	if localAddr := conn.LocalAddr().(*net.TCPAddr); !localAddr.IP.Equal(ip) {
+		t.Error("expect source address ", ip, ", but got ", localAddr.IP)
+	}
+
+	if _, err := ToMemoryStreamConfig(&StreamConfig{
+		SocketSettings: &SocketConfig{BindInterfaceIndex: 1<<31 - 1},
+	}); err == nil {
+		t.Error("expect error on non-existent bind interface")
+	}
+}
+
+func TestSockOptTFOQueueLength(t *testing.T) {
+	testCases := []struct {
+		queueLength int32
+		expected    int
+	}{
+		{queueLength: 10, expected: 10},
+		{queueLength: 0, expected: 256},
+	}
+
+	for _, tc := range testCases {
+		listener, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.LocalHostIP.IP()}, &SocketConfig{
+			Tfo:            SocketConfig_Enable,
+			TfoQueueLength: tc.queueLength,
+		})
+		common.Must(err)
+
+		rawConn, err := listener.(*net.TCPListener).SyscallConn()
+		common.Must(err)
+		common.Must(rawConn.Control(func(fd uintptr) {
+			queueLength, err := syscall.GetsockoptInt(int(fd), syscall.SOL_TCP, TCP_FASTOPEN)
+			common.Must(err)
+			if queueLength != tc.expected {
+				t.Error("expect TFO queue length ", tc.expected, ", but got ", queueLength)
+			}
+		}))
+		listener.Close()
+	}
+}
+
+func TestSockOptPriority(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	getPriority := func(conn syscall.Conn) int {
+		rawConn, err := conn.SyscallConn()
+		common.Must(err)
+		var priority int
+		common.Must(rawConn.Control(func(fd uintptr) {
+			priority, err = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_PRIORITY)
+			common.Must(err)
+		}))
+		return priority
+	}
+
+	// TOS 0x10 (low delay) maps to priority 6, which is overridden.
+	sockopt := &SocketConfig{Tos: 0x10, SoPriority: 5}
+	dialer := DefaultSystemDialer{}
+	conn, err := dialer.Dial(context.Background(), nil, dest, sockopt)
+	common.Must(err)
+	defer conn.Close()
+	if priority := getPriority(conn.(*net.TCPConn)); priority != 5 {
+		t.Error("expect priority 5 of dialed socket, but got ", priority)
+	}
+
+	listener, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.LocalHostIP.IP()}, sockopt)
+	common.Must(err)
+	defer listener.Close()
+	if priority := getPriority(listener.(*net.TCPListener)); priority != 5 {
+		t.Error("expect priority 5 of listening socket, but got ", priority)
+	}
+}
+
+func TestSockOptV6Only(t *testing.T) {
+	testCases := []struct {
+		v6Only    SocketOptionState
+		acceptsV4 bool
+	}{
+		{v6Only: SocketOptionState_Enable, acceptsV4: false},
+		{v6Only: SocketOptionState_Disable, acceptsV4: true},
+	}
+
+	for _, tc := range testCases {
+		listener, err := ListenSystem(context.Background(), &net.TCPAddr{IP: net.AnyIPv6.IP()}, &SocketConfig{V6Only: tc.v6Only})
+		if err != nil {
+			t.Skip("IPv6 is not supported: ", err)
+		}
+		port := listener.Addr().(*net.TCPAddr).Port
+
+		conn, err := net.DialTCP("tcp", nil, &net.TCPAddr{IP: net.LocalHostIP.IP(), Port: port})
+		if tc.acceptsV4 && err != nil {
+			t.Error("expect IPv4 connection to be accepted, but got ", err)
+		}
+		if !tc.acceptsV4 && err == nil {
+			t.Error("expect IPv4 connection to be refused with V6Only ", tc.v6Only)
+		}
+		if conn != nil {
+			conn.Close()
+		}
+
+		conn, err = net.DialTCP("tcp", nil, &net.TCPAddr{IP: net.LocalHostIPv6.IP(), Port: port})
+		if err != nil {
+			t.Error("expect IPv6 connection to be accepted, but got ", err)
+		} else {
+			conn.Close()
+		}
+		listener.Close()
+	}
+}
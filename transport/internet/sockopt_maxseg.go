@@ -0,0 +1,19 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package internet
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// setTCPMaxSeg sets the maximum segment size of TCP sockets.
+func setTCPMaxSeg(network string, fd uintptr, mss int32) error {
+	if mss == 0 || !isTCPSocket(network) {
+		return nil
+	}
+	if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_MAXSEG, int(mss)); err != nil {
+		return newError("failed to set TCP_MAXSEG=", mss).Base(err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package internet
+
+import "sync"
+
+var tcpMaxSegNotSupported sync.Once
+
+// setTCPMaxSeg logs once that the maximum segment size is ignored, as it is
+// only supported on Linux, macOS and FreeBSD.
+func setTCPMaxSeg(network string, fd uintptr, mss int32) error {
+	if mss != 0 && isTCPSocket(network) {
+		tcpMaxSegNotSupported.Do(func() {
+			newError("TCP_MAXSEG is only supported on Linux, macOS and FreeBSD, ignoring ", mss).AtInfo().WriteToLog()
+		})
+	}
+	return nil
+}
@@ -4,14 +4,16 @@
 package internet
 
 func applyOutboundSocketOptions(network string, address string, fd uintptr, config *SocketConfig) error {
+	warnBindToDeviceNotSupported(config)
 	return nil
 }
 
 func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig) error {
+	warnBindToDeviceNotSupported(config)
 	return nil
 }
 
-func bindAddr(fd uintptr, ip []byte, port uint32) error {
+func bindAddr(fd uintptr, ip []byte, port uint32, zone uint32) error {
 	return nil
 }
 
@@ -20,5 +22,5 @@ func setReuseAddr(fd uintptr) error {
 }
 
 func setReusePort(fd uintptr) error {
-	return nil
+	return errReusePortNotSupported
 }
@@ -0,0 +1,39 @@
+package internet
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// pmtuDiscoveryValues maps path MTU discovery modes to values of
+// IP_MTU_DISCOVER, which are the same as those of IPV6_MTU_DISCOVER.
+var pmtuDiscoveryValues = map[SocketConfig_PMTUDiscovery]int{
+	SocketConfig_Do:   unix.IP_PMTUDISC_DO,
+	SocketConfig_Dont: unix.IP_PMTUDISC_DONT,
+	SocketConfig_Want: unix.IP_PMTUDISC_WANT,
+}
+
+// applyPMTUDiscovery sets the path MTU discovery mode of UDP sockets. IPv6
+// sockets get IP_MTU_DISCOVER as well on a best-effort basis, for IPv4
+// packets sent from dual-stack sockets.
+func applyPMTUDiscovery(network string, fd uintptr, mode SocketConfig_PMTUDiscovery) error {
+	value, found := pmtuDiscoveryValues[mode]
+	if !found || !isUDPSocket(network) {
+		return nil
+	}
+
+	family, err := unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_DOMAIN)
+	if err != nil {
+		return newError("failed to get socket family").Base(err)
+	}
+	if family == unix.AF_INET6 {
+		if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MTU_DISCOVER, value); err != nil {
+			return newError("failed to set IPV6_MTU_DISCOVER=", value).Base(err)
+		}
+		unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, value)
+		return nil
+	}
+	if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, value); err != nil {
+		return newError("failed to set IP_MTU_DISCOVER=", value).Base(err)
+	}
+	return nil
+}
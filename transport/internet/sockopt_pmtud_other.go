@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package internet
+
+import "sync"
+
+var pmtuDiscoveryNotSupported sync.Once
+
+// applyPMTUDiscovery logs once that path MTU discovery mode is ignored, as
+// it is only supported on Linux.
+func applyPMTUDiscovery(network string, fd uintptr, mode SocketConfig_PMTUDiscovery) error {
+	if mode != SocketConfig_Default && isUDPSocket(network) {
+		pmtuDiscoveryNotSupported.Do(func() {
+			newError("path MTU discovery mode is only supported on Linux, ignoring ", mode).AtInfo().WriteToLog()
+		})
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package internet
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// setTOS sets the TOS field of IPv4 packets, or the traffic class of IPv6
+// packets on IPv6 sockets. As dual-stack IPv6 sockets may also send IPv4
+// packets, IP_TOS is attempted on them too.
+func setTOS(fd uintptr, tos uint32) error {
+	if tos == 0 {
+		return nil
+	}
+	if tos > 0xff {
+		return newError("invalid TOS value: ", tos)
+	}
+
+	sa, err := unix.Getsockname(int(fd))
+	if err != nil {
+		return newError("failed to get socket address").Base(err)
+	}
+	if _, ok := sa.(*unix.SockaddrInet6); !ok {
+		if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TOS, int(tos)); err != nil {
+			return newError("failed to set IP_TOS=", tos).Base(err)
+		}
+		return nil
+	}
+
+	if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_TCLASS, int(tos)); err != nil {
+		return newError("failed to set IPV6_TCLASS=", tos).Base(err)
+	}
+	// Fails on IPv6-only sockets on some systems, which never send IPv4 packets.
+	_ = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TOS, int(tos))
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package internet
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// setV6Only sets IPV6_V6ONLY of IPv6 sockets. It must be called before bind.
+func setV6Only(network string, fd uintptr, state SocketOptionState) error {
+	if !isIPv6Socket(network) {
+		return nil
+	}
+	switch state {
+	case SocketOptionState_Enable:
+		if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_V6ONLY, 1); err != nil {
+			return newError("failed to set IPV6_V6ONLY=1").Base(err)
+		}
+	case SocketOptionState_Disable:
+		if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_V6ONLY, 0); err != nil {
+			return newError("failed to set IPV6_V6ONLY=0").Base(err)
+		}
+	}
+	return nil
+}
@@ -3,7 +3,9 @@ package internet
 import "syscall"
 
 const (
-	TCP_FASTOPEN = 15 // nolint: golint,stylecheck
+	TCP_FASTOPEN  = 15 // nolint: golint,stylecheck
+	TCP_KEEPIDLE  = 3  // nolint: golint,stylecheck
+	TCP_KEEPINTVL = 17 // nolint: golint,stylecheck
 )
 
 func setTFO(fd syscall.Handle, settings SocketConfig_TCPFastOpenState) error {
@@ -20,27 +22,105 @@ func setTFO(fd syscall.Handle, settings SocketConfig_TCPFastOpenState) error {
 	return nil
 }
 
+// setV6Only sets IPV6_V6ONLY of IPv6 sockets. It must be called before bind.
+func setV6Only(network string, fd syscall.Handle, state SocketOptionState) error {
+	if !isIPv6Socket(network) {
+		return nil
+	}
+	switch state {
+	case SocketOptionState_Enable:
+		if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_V6ONLY, 1); err != nil {
+			return newError("failed to set IPV6_V6ONLY=1").Base(err)
+		}
+	case SocketOptionState_Disable:
+		if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_V6ONLY, 0); err != nil {
+			return newError("failed to set IPV6_V6ONLY=0").Base(err)
+		}
+	}
+	return nil
+}
+
+func setSocketBufferSizes(fd syscall.Handle, config *SocketConfig) error {
+	if config.RxBufSize > 0 {
+		if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_RCVBUF, int(config.RxBufSize)); err != nil {
+			return newError("failed to set SO_RCVBUF").Base(err)
+		}
+	}
+	if config.TxBufSize > 0 {
+		if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_SNDBUF, int(config.TxBufSize)); err != nil {
+			return newError("failed to set SO_SNDBUF").Base(err)
+		}
+	}
+	return nil
+}
+
+func setTCPKeepAlive(fd syscall.Handle, config *SocketConfig) error {
+	if config.TcpKeepAliveInterval < 0 || config.TcpKeepAliveIdle < 0 {
+		if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 0); err != nil {
+			return newError("failed to set SO_KEEPALIVE=0").Base(err)
+		}
+		return nil
+	}
+	if config.TcpKeepAliveInterval == 0 && config.TcpKeepAliveIdle == 0 {
+		return nil
+	}
+
+	if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 1); err != nil {
+		return newError("failed to set SO_KEEPALIVE=1").Base(err)
+	}
+	if config.TcpKeepAliveInterval > 0 {
+		if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, TCP_KEEPINTVL, int(config.TcpKeepAliveInterval)); err != nil {
+			return newError("failed to set TCP_KEEPINTVL").Base(err)
+		}
+	}
+	if config.TcpKeepAliveIdle > 0 {
+		if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, TCP_KEEPIDLE, int(config.TcpKeepAliveIdle)); err != nil {
+			return newError("failed to set TCP_KEEPIDLE").Base(err)
+		}
+	}
+	return nil
+}
+
 func applyOutboundSocketOptions(network string, address string, fd uintptr, config *SocketConfig) error {
+	if err := setSocketBufferSizes(syscall.Handle(fd), config); err != nil {
+		return err
+	}
+	warnBindToDeviceNotSupported(config)
+
 	if isTCPSocket(network) {
 		if err := setTFO(syscall.Handle(fd), config.Tfo); err != nil {
 			return err
 		}
+		if err := setTCPKeepAlive(syscall.Handle(fd), config); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig) error {
+	if err := setSocketBufferSizes(syscall.Handle(fd), config); err != nil {
+		return err
+	}
+	warnBindToDeviceNotSupported(config)
+	if err := setV6Only(network, syscall.Handle(fd), config.V6Only); err != nil {
+		return err
+	}
+
 	if isTCPSocket(network) {
 		if err := setTFO(syscall.Handle(fd), config.Tfo); err != nil {
 			return err
 		}
+		if err := setTCPKeepAlive(syscall.Handle(fd), config); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func bindAddr(fd uintptr, ip []byte, port uint32) error {
+func bindAddr(fd uintptr, ip []byte, port uint32, zone uint32) error {
 	return nil
 }
 
@@ -49,5 +129,5 @@ func setReuseAddr(fd uintptr) error {
 }
 
 func setReusePort(fd uintptr) error {
-	return nil
+	return errReusePortNotSupported
 }
@@ -0,0 +1,123 @@
+package internet
+
+import (
+	"crypto/rand"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+// sourceAddressCounter rotates source addresses of outbound connections.
+var sourceAddressCounter uint32
+
+func hasSourceAddress(sockopt *SocketConfig) bool {
+	return sockopt != nil && (len(sockopt.SourceAddress) > 0 || len(sockopt.SourceSubnet) > 0)
+}
+
+// pickSourceAddress returns the source address to dial dest from, among the
+// source addresses or in the source subnet of sockopt. It returns nil if none
+// is configured, or none matches the address family of dest.
+func pickSourceAddress(sockopt *SocketConfig, dest net.Destination) net.Address {
+	if !hasSourceAddress(sockopt) {
+		return nil
+	}
+
+	family := 0
+	if dest.Address.Family().IsIP() {
+		family = len(dest.Address.IP())
+	}
+
+	if len(sockopt.SourceSubnet) > 0 {
+		if family != 0 && family != len(sockopt.SourceSubnet) {
+			return nil
+		}
+		return net.IPAddress(randomIPInSubnet(sockopt.SourceSubnet, sockopt.SourceSubnetPrefix))
+	}
+
+	candidates := sockopt.SourceAddress
+	if family != 0 {
+		candidates = make([][]byte, 0, len(sockopt.SourceAddress))
+		for _, ip := range sockopt.SourceAddress {
+			if len(ip) == family {
+				candidates = append(candidates, ip)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+	}
+	i := atomic.AddUint32(&sourceAddressCounter, 1) - 1
+	return net.IPAddress(candidates[i%uint32(len(candidates))])
+}
+
+// randomIPInSubnet returns an address in the subnet with random host bits.
+func randomIPInSubnet(subnet []byte, prefix uint32) net.IP {
+	ip := make(net.IP, len(subnet))
+	common.Must2(rand.Read(ip))
+	for i := range ip {
+		switch {
+		case uint32(i*8+8) <= prefix:
+			ip[i] = subnet[i]
+		case uint32(i*8) < prefix:
+			mask := byte(0xff << (8 - (prefix - uint32(i*8))))
+			ip[i] = subnet[i]&mask | ip[i]&^mask
+		}
+	}
+	return ip
+}
+
+// validateSourceAddresses checks that the source addresses of sockopt can be
+// bound to, i.e. they are local to the host.
+func validateSourceAddresses(sockopt *SocketConfig) error {
+	if !hasSourceAddress(sockopt) {
+		return nil
+	}
+
+	ips := make([]net.IP, 0, len(sockopt.SourceAddress)+1)
+	if len(sockopt.SourceSubnet) > 0 {
+		if len(sockopt.SourceSubnet) != net.IPv4len && len(sockopt.SourceSubnet) != net.IPv6len {
+			return newError("invalid source subnet: ", net.IP(sockopt.SourceSubnet))
+		}
+		if sockopt.SourceSubnetPrefix > uint32(len(sockopt.SourceSubnet)*8) {
+			return newError("invalid prefix length of source subnet: ", sockopt.SourceSubnetPrefix)
+		}
+		ips = append(ips, randomIPInSubnet(sockopt.SourceSubnet, sockopt.SourceSubnetPrefix))
+	}
+	for _, ip := range sockopt.SourceAddress {
+		if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+			return newError("invalid source address: ", net.IP(ip))
+		}
+		ips = append(ips, ip)
+	}
+
+	for _, ip := range ips {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: ip, Zone: linkLocalZone(sockopt, ip)})
+		if err != nil {
+			return newError("source address ", ip, " is not local").Base(err)
+		}
+		conn.Close()
+	}
+	return nil
+}
+
+// linkLocalZone returns the zone of ip, if it is an IPv6 link-local address
+// and the bind interface of sockopt is set.
+func linkLocalZone(sockopt *SocketConfig, ip net.IP) string {
+	if sockopt == nil || sockopt.BindInterfaceIndex == 0 || ip.To4() != nil || !ip.IsLinkLocalUnicast() {
+		return ""
+	}
+	return strconv.FormatUint(uint64(sockopt.BindInterfaceIndex), 10)
+}
+
+// validateBindInterface checks that the bind interface of sockopt exists.
+func validateBindInterface(sockopt *SocketConfig) error {
+	if sockopt == nil || sockopt.BindInterfaceIndex == 0 {
+		return nil
+	}
+	if _, err := net.InterfaceByIndex(int(sockopt.BindInterfaceIndex)); err != nil {
+		return newError("bind interface ", sockopt.BindInterfaceIndex, " not found").Base(err)
+	}
+	return nil
+}
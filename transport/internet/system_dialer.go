@@ -19,7 +19,7 @@ type DefaultSystemDialer struct {
 	controllers []controller
 }
 
-func resolveSrcAddr(network net.Network, src net.Address) net.Addr {
+func resolveSrcAddr(network net.Network, src net.Address, sockopt *SocketConfig) net.Addr {
 	if src == nil || src == net.AnyIP {
 		return nil
 	}
@@ -28,12 +28,14 @@ func resolveSrcAddr(network net.Network, src net.Address) net.Addr {
 		return &net.TCPAddr{
 			IP:   src.IP(),
 			Port: 0,
+			Zone: linkLocalZone(sockopt, src.IP()),
 		}
 	}
 
 	return &net.UDPAddr{
 		IP:   src.IP(),
 		Port: 0,
+		Zone: linkLocalZone(sockopt, src.IP()),
 	}
 }
 
@@ -42,8 +44,55 @@ func hasBindAddr(sockopt *SocketConfig) bool {
 }
 
 func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest net.Destination, sockopt *SocketConfig) (net.Conn, error) {
+	// Resolved addresses of a domain destination, if it has to be resolved
+	// before dialing.
+	var ips []net.IP
+	resolver := resolverTag(ctx, sockopt)
+
+	if sockopt != nil && sockopt.DialAddressFamily != DialAddressFamily_AsIs {
+		if dest.Address.Family().IsIP() {
+			if len(filterAddressFamily([]net.IP{dest.Address.IP()}, sockopt.DialAddressFamily)) == 0 {
+				return nil, newError("address ", dest.Address, " doesn't match dial address family ", sockopt.DialAddressFamily)
+			}
+		} else {
+			resolved, err := resolveDomain(ctx, dest.Address.Domain(), resolver)
+			if err != nil {
+				return nil, newError("failed to resolve ", dest.Address).Base(err)
+			}
+			ips = filterAddressFamily(resolved, sockopt.DialAddressFamily)
+			if len(ips) == 0 {
+				return nil, newError("no address of dial address family ", sockopt.DialAddressFamily, " found for ", dest.Address)
+			}
+			if dest.Network != net.Network_TCP {
+				dest.Address = net.IPAddress(ips[0])
+				ips = nil
+			}
+		}
+	}
+
+	if len(ips) == 0 && len(resolver) > 0 && dest.Address.Family().IsDomain() {
+		resolved, err := resolveDomain(ctx, dest.Address.Domain(), resolver)
+		if err != nil {
+			return nil, newError("failed to resolve ", dest.Address, " with resolver ", resolver).Base(err)
+		}
+		if len(resolved) == 0 {
+			return nil, newError("no address found for ", dest.Address, " with resolver ", resolver)
+		}
+		if dest.Network == net.Network_TCP {
+			ips = resolved
+		} else {
+			dest.Address = net.IPAddress(resolved[0])
+		}
+	}
+
+	if src == nil || src == net.AnyIP {
+		if addr := pickSourceAddress(sockopt, dest); addr != nil {
+			src = addr
+		}
+	}
+
 	if dest.Network == net.Network_UDP && !hasBindAddr(sockopt) {
-		srcAddr := resolveSrcAddr(net.Network_UDP, src)
+		srcAddr := resolveSrcAddr(net.Network_UDP, src, sockopt)
 		if srcAddr == nil {
 			srcAddr = &net.UDPAddr{
 				IP:   []byte{0, 0, 0, 0},
@@ -66,7 +115,14 @@ func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest ne
 
 	dialer := &net.Dialer{
 		Timeout:   time.Second * 16,
-		LocalAddr: resolveSrcAddr(dest.Network, src),
+		LocalAddr: resolveSrcAddr(dest.Network, src, sockopt),
+	}
+	if timeout := session.DialTimeoutFromContext(ctx); timeout > 0 {
+		dialer.Timeout = timeout
+	}
+
+	if hasTCPKeepAliveConfig(sockopt) {
+		dialer.KeepAlive = -1
 	}
 
 	if sockopt != nil || len(d.controllers) > 0 {
@@ -76,8 +132,14 @@ func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest ne
 					if err := applyOutboundSocketOptions(network, address, fd, sockopt); err != nil {
 						newError("failed to apply socket options").Base(err).WriteToLog(session.ExportIDToError(ctx))
 					}
+					if err := applyPMTUDiscovery(network, fd, sockopt.PmtuDiscovery); err != nil {
+						newError("failed to set path MTU discovery mode").Base(err).WriteToLog(session.ExportIDToError(ctx))
+					}
+					if err := setTCPMaxSeg(network, fd, sockopt.TcpMaxSeg); err != nil {
+						newError("failed to set maximum segment size").Base(err).WriteToLog(session.ExportIDToError(ctx))
+					}
 					if dest.Network == net.Network_UDP && hasBindAddr(sockopt) {
-						if err := bindAddr(fd, sockopt.BindAddress, sockopt.BindPort); err != nil {
+						if err := bindAddr(fd, sockopt.BindAddress, sockopt.BindPort, sockopt.BindInterfaceIndex); err != nil {
 							newError("failed to bind source address to ", sockopt.BindAddress).Base(err).WriteToLog(session.ExportIDToError(ctx))
 						}
 					}
@@ -92,7 +154,53 @@ func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest ne
 		}
 	}
 
-	return dialer.DialContext(ctx, dest.Network.SystemString(), dest.NetAddr())
+	if len(ips) == 0 && sockopt != nil && sockopt.HappyEyeballs > 0 && dest.Network == net.Network_TCP && dest.Address.Family().IsDomain() {
+		var resolved []net.IP
+		var err error
+		if sockopt.HappyEyeballsResolutionDelay > 0 {
+			domain := dest.Address.Domain()
+			delay := time.Duration(sockopt.HappyEyeballsResolutionDelay) * time.Millisecond
+			resolved, err = resolveHappyEyeballs(ctx, func(ctx context.Context, network string) ([]net.IP, error) {
+				return lookupIPNetwork(ctx, network, domain)
+			}, sockopt.HappyEyeballsPreferIpv4, delay)
+		} else {
+			resolved, err = resolveDomain(ctx, dest.Address.Domain(), resolver)
+		}
+		if err != nil {
+			return nil, newError("failed to resolve ", dest.Address).Base(err)
+		}
+		ips = resolved
+	}
+
+	var conn net.Conn
+	var err error
+	if len(ips) > 0 {
+		// Addresses may be resolved for the resolver tag, which may be that
+		// of the routing rule without sockopt, or for DialAddressFamily,
+		// without happy eyeballs enabled.
+		var delay time.Duration
+		if sockopt != nil {
+			delay = time.Duration(sockopt.HappyEyeballs) * time.Millisecond
+		}
+		conn, err = dialIPs(ctx, ips, delay, func(ctx context.Context, ip net.IP) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", net.TCPDestination(net.IPAddress(ip), dest.Port).NetAddr())
+		})
+	} else {
+		conn, err = dialer.DialContext(ctx, dest.Network.SystemString(), dest.NetAddr())
+	}
+	if err != nil {
+		return nil, err
+	}
+	applyTCPNoDelay(conn, sockopt)
+
+	if sockopt != nil && sockopt.SendProxyProtocol > 0 && dest.Network == net.Network_TCP {
+		if err := writeProxyProtocolHeader(ctx, conn, sockopt.SendProxyProtocol); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
 }
 
 type packetConnWrapper struct {
@@ -4,8 +4,7 @@ import (
 	"context"
 	"runtime"
 	"syscall"
-
-	"github.com/pires/go-proxyproto"
+	"time"
 
 	"github.com/v2fly/v2ray-core/v4/common/net"
 	"github.com/v2fly/v2ray-core/v4/common/session"
@@ -21,14 +20,23 @@ type DefaultListener struct {
 
 func getControlFunc(ctx context.Context, sockopt *SocketConfig, controllers []controller) func(network, address string, c syscall.RawConn) error {
 	return func(network, address string, c syscall.RawConn) error {
-		return c.Control(func(fd uintptr) {
+		var reusePortErr error
+		err := c.Control(func(fd uintptr) {
 			if sockopt != nil {
 				if err := applyInboundSocketOptions(network, fd, sockopt); err != nil {
 					newError("failed to apply socket options to incoming connection").Base(err).WriteToLog(session.ExportIDToError(ctx))
 				}
+				if err := applyPMTUDiscovery(network, fd, sockopt.PmtuDiscovery); err != nil {
+					newError("failed to set path MTU discovery mode").Base(err).WriteToLog(session.ExportIDToError(ctx))
+				}
 			}
 
-			setReusePort(fd) // nolint: staticcheck
+			// SO_REUSEPORT is set on a best-effort basis, unless it is
+			// explicitly required.
+			if err := setReusePort(fd); err != nil && sockopt != nil && sockopt.ReusePort {
+				reusePortErr = err
+				return
+			}
 
 			for _, controller := range controllers {
 				if err := controller(network, address, fd); err != nil {
@@ -36,6 +44,10 @@ func getControlFunc(ctx context.Context, sockopt *SocketConfig, controllers []co
 				}
 			}
 		})
+		if err != nil {
+			return err
+		}
+		return reusePortErr
 	}
 }
 
@@ -49,6 +61,9 @@ func (dl *DefaultListener) Listen(ctx context.Context, addr net.Addr, sockopt *S
 		network = addr.Network()
 		address = addr.String()
 		lc.Control = getControlFunc(ctx, sockopt, dl.controllers)
+		if hasTCPKeepAliveConfig(sockopt) {
+			lc.KeepAlive = -1
+		}
 	case *net.UnixAddr:
 		lc.Control = nil
 		network = addr.Network()
@@ -75,9 +90,20 @@ func (dl *DefaultListener) Listen(ctx context.Context, addr net.Addr, sockopt *S
 	}
 
 	l, err = lc.Listen(ctx, network, address)
-	if sockopt != nil && sockopt.AcceptProxyProtocol {
-		policyFunc := func(upstream net.Addr) (proxyproto.Policy, error) { return proxyproto.REQUIRE, nil }
-		l = &proxyproto.Listener{Listener: l, Policy: policyFunc}
+	if err == nil && sockopt != nil && sockopt.Backlog > 0 {
+		if err := setBacklog(l, int(sockopt.Backlog)); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	if err == nil && sockopt != nil && sockopt.TcpNoDelay != SocketOptionState_Default {
+		l = &noDelayListener{Listener: l, sockopt: sockopt}
+	}
+	if err == nil && sockopt != nil && sockopt.IdleTimeout > 0 {
+		l = &idleTimeoutListener{Listener: l, timeout: time.Duration(sockopt.IdleTimeout) * time.Second}
+	}
+	if err == nil && sockopt != nil && sockopt.AcceptProxyProtocol {
+		l = newProxyProtocolListener(l, sockopt.AllowMissingProxyProtocol)
 	}
 	return l, err
 }
@@ -68,6 +68,14 @@ func (h *Hub) Close() error {
 	return nil
 }
 
+// NewBatchWriter creates a writer that batches datagrams to dest with UDP segmentation offload.
+func (h *Hub) NewBatchWriter(dest net.Destination) *internet.DatagramBatchWriter {
+	return internet.NewDatagramBatchWriter(h.conn, &net.UDPAddr{
+		IP:   dest.Address.IP(),
+		Port: int(dest.Port),
+	})
+}
+
 func (h *Hub) WriteTo(payload []byte, dest net.Destination) (int, error) {
 	return h.conn.WriteToUDP(payload, &net.UDPAddr{
 		IP:   dest.Address.IP(),
@@ -0,0 +1,131 @@
+package internet
+
+import (
+	"sync"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+const (
+	// Maximum number of segments the kernel accepts in one send with UDP_SEGMENT.
+	maxGSOSegments = 64
+	// Maximum size of the buffer sent in one system call, bounded by the size
+	// limit of a UDP datagram.
+	maxGSOBufferSize = 65000
+)
+
+// DatagramBatchWriter writes datagrams to a fixed destination. Consecutive
+// datagrams of the same size are buffered and sent in one system call with UDP
+// generic segmentation offload (UDP_SEGMENT) when Flush is called. A datagram
+// smaller than the previous ones ends a batch. If UDP_SEGMENT is not
+// supported, datagrams are sent one by one.
+type DatagramBatchWriter struct {
+	access      sync.Mutex
+	conn        *net.UDPConn
+	dest        *net.UDPAddr
+	gso         bool
+	buffer      []byte
+	segmentSize int
+	count       int
+}
+
+// NewDatagramBatchWriter creates a new DatagramBatchWriter that writes to dest through conn.
+func NewDatagramBatchWriter(conn *net.UDPConn, dest *net.UDPAddr) *DatagramBatchWriter {
+	w := &DatagramBatchWriter{
+		conn: conn,
+		dest: dest,
+		gso:  gsoSupported,
+	}
+	if w.gso {
+		w.buffer = make([]byte, 0, maxGSOBufferSize)
+	}
+	return w
+}
+
+// NewDatagramBatchWriterFromConn creates a new DatagramBatchWriter for a UDP
+// connection returned by DialSystem. It returns nil if conn isn't such a connection.
+func NewDatagramBatchWriterFromConn(conn net.Conn) *DatagramBatchWriter {
+	wrapper, ok := conn.(*packetConnWrapper)
+	if !ok {
+		return nil
+	}
+	udpConn, ok := wrapper.conn.(*net.UDPConn)
+	if !ok {
+		return nil
+	}
+	return NewDatagramBatchWriter(udpConn, wrapper.dest.(*net.UDPAddr))
+}
+
+// Write implements io.Writer. Each call writes one datagram, which may be
+// buffered until Flush is called.
+func (w *DatagramBatchWriter) Write(b []byte) (int, error) {
+	w.access.Lock()
+	defer w.access.Unlock()
+
+	if !w.gso || len(b) > maxGSOBufferSize {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+		return w.conn.WriteToUDP(b, w.dest)
+	}
+
+	if w.count > 0 && (len(b) > w.segmentSize || w.count >= maxGSOSegments || len(w.buffer)+len(b) > maxGSOBufferSize) {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+	}
+	if w.count == 0 {
+		w.segmentSize = len(b)
+	}
+	w.buffer = append(w.buffer, b...)
+	w.count++
+
+	if len(b) < w.segmentSize {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush sends all buffered datagrams.
+func (w *DatagramBatchWriter) Flush() error {
+	w.access.Lock()
+	defer w.access.Unlock()
+
+	return w.flush()
+}
+
+func (w *DatagramBatchWriter) flush() error {
+	if w.count == 0 {
+		return nil
+	}
+	defer func() {
+		w.buffer = w.buffer[:0]
+		w.count = 0
+	}()
+
+	if w.count == 1 {
+		_, err := w.conn.WriteToUDP(w.buffer, w.dest)
+		return err
+	}
+
+	err := writeSegments(w.conn, w.buffer, w.segmentSize, w.dest)
+	if err == nil || !isGSOUnsupported(err) {
+		return err
+	}
+
+	newError("UDP segmentation offload is not supported, falling back to sending datagrams one by one").Base(err).AtWarning().WriteToLog()
+	w.gso = false
+	for b := w.buffer; len(b) > 0; {
+		n := w.segmentSize
+		if n > len(b) {
+			n = len(b)
+		}
+		if _, err := w.conn.WriteToUDP(b[:n], w.dest); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
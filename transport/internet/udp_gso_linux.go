@@ -0,0 +1,35 @@
+package internet
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+// UDP_SEGMENT is the socket option for UDP generic segmentation offload.
+const UDP_SEGMENT = 103 // nolint: golint,stylecheck
+
+const gsoSupported = true
+
+// writeSegments sends b as datagrams of segmentSize bytes, except the last one
+// which may be shorter, in one system call.
+func writeSegments(conn *net.UDPConn, b []byte, segmentSize int, dest *net.UDPAddr) error {
+	oob := make([]byte, unix.CmsgSpace(2))
+	h := (*unix.Cmsghdr)(unsafe.Pointer(&oob[0]))
+	h.Level = unix.IPPROTO_UDP
+	h.Type = UDP_SEGMENT
+	h.SetLen(unix.CmsgLen(2))
+	*(*uint16)(unsafe.Pointer(&oob[unix.CmsgLen(0)])) = uint16(segmentSize)
+
+	_, _, err := conn.WriteMsgUDP(b, oob, dest)
+	return err
+}
+
+// isGSOUnsupported returns whether err indicates that UDP_SEGMENT is not
+// supported by the kernel or the network device.
+func isGSOUnsupported(err error) bool {
+	return errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EIO) || errors.Is(err, unix.ENOPROTOOPT)
+}
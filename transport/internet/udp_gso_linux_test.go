@@ -0,0 +1,95 @@
+package internet_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v4/common"
+	"github.com/v2fly/v2ray-core/v4/common/net"
+	. "github.com/v2fly/v2ray-core/v4/transport/internet"
+)
+
+func TestDatagramBatchWriter(t *testing.T) {
+	receiver, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.LocalHostIP.IP()})
+	common.Must(err)
+	defer receiver.Close()
+
+	sender, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.LocalHostIP.IP()})
+	common.Must(err)
+	defer sender.Close()
+
+	writer := NewDatagramBatchWriter(sender, receiver.LocalAddr().(*net.UDPAddr))
+
+	var datagrams [][]byte
+	for i := 0; i < 10; i++ {
+		datagrams = append(datagrams, bytes.Repeat([]byte{byte(i)}, 1000))
+	}
+	datagrams = append(datagrams, []byte("short"), bytes.Repeat([]byte{'l'}, 1200))
+	for _, d := range datagrams {
+		common.Must2(writer.Write(d))
+	}
+	common.Must(writer.Flush())
+
+	common.Must(receiver.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	b := make([]byte, 2048)
+	for i, d := range datagrams {
+		n, err := receiver.Read(b)
+		common.Must(err)
+		if !bytes.Equal(b[:n], d) {
+			t.Fatal("unexpected datagram #", i, " of size ", n, ", want size ", len(d))
+		}
+	}
+}
+
+func benchmarkUDPWrite(b *testing.B, write func(sender *net.UDPConn, dest *net.UDPAddr, payload []byte) error) {
+	receiver, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.LocalHostIP.IP()})
+	common.Must(err)
+	defer receiver.Close()
+	go func() {
+		buffer := make([]byte, 2048)
+		for {
+			if _, err := receiver.Read(buffer); err != nil {
+				return
+			}
+		}
+	}()
+
+	sender, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.LocalHostIP.IP()})
+	common.Must(err)
+	defer sender.Close()
+
+	dest := receiver.LocalAddr().(*net.UDPAddr)
+	payload := make([]byte, 1350)
+	b.SetBytes(int64(len(payload)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		common.Must(write(sender, dest, payload))
+	}
+}
+
+func BenchmarkUDPWrite(b *testing.B) {
+	benchmarkUDPWrite(b, func(sender *net.UDPConn, dest *net.UDPAddr, payload []byte) error {
+		_, err := sender.WriteToUDP(payload, dest)
+		return err
+	})
+}
+
+func BenchmarkDatagramBatchWriter(b *testing.B) {
+	var writer *DatagramBatchWriter
+	var count int
+	benchmarkUDPWrite(b, func(sender *net.UDPConn, dest *net.UDPAddr, payload []byte) error {
+		if writer == nil {
+			writer = NewDatagramBatchWriter(sender, dest)
+		}
+		if _, err := writer.Write(payload); err != nil {
+			return err
+		}
+		// Flush as often as mKCP does for a full sending window.
+		count++
+		if count%32 == 0 {
+			return writer.Flush()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package internet
+
+import (
+	"github.com/v2fly/v2ray-core/v4/common/net"
+)
+
+const gsoSupported = false
+
+func writeSegments(conn *net.UDPConn, b []byte, segmentSize int, dest *net.UDPAddr) error {
+	return newError("UDP segmentation offload is only supported on Linux")
+}
+
+func isGSOUnsupported(err error) bool {
+	return true
+}